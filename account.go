@@ -0,0 +1,70 @@
+package strato
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// ChangeAccountPassword changes the password of the Strato customer account.
+// On success the client and all copies made of it continue to use the new password
+// for re-authentication.
+func (c *StratoClient) ChangeAccountPassword(oldPassword, newPassword string) error {
+	if oldPassword == "" || newPassword == "" {
+		return errors.New("old and new password must not be empty")
+	}
+	if oldPassword == newPassword {
+		return errors.New("new password must differ from the old password")
+	}
+	if proceed, err := c.checkWrite("change account password"); !proceed {
+		return err
+	}
+
+	form := url.Values{}
+	form.Set("old_passwd", oldPassword)
+	form.Set("new_passwd", newPassword)
+	form.Set("new_passwd_repeat", newPassword)
+	form.Set("action_change_password", "1")
+	err := c.retryExpired(func() error { return c.postPasswordForm(form) })
+	c.auditForm(form, err)
+	if err != nil {
+		return err
+	}
+	c.setPassword(newPassword)
+	return nil
+}
+
+// postPasswordForm submits the password form of the account settings
+func (c *StratoClient) postPasswordForm(form url.Values) error {
+	resp, err := c.postForm(c.region.AccountNode, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusFound { // 302
+		// The portal sends requests of expired sessions to the login page, other
+		// redirects indicate a successful update
+		if redirectedToLogin(resp) {
+			return ErrSessionExpired
+		}
+		return nil
+	} else if resp.StatusCode == http.StatusOK { // 200
+		// The settings page is shown again with an error message
+		var updateErr *UpdateError
+		if err := formError(resp); err == nil {
+			return nil
+		} else if errors.As(err, &updateErr) && updateErr.Reason != "" {
+			return errors.New("password change failed: " + updateErr.Reason)
+		}
+		return errors.New("password change failed")
+	}
+	return errors.New("unexpected response status: " + resp.Status)
+}
+
+// redirectedToLogin reports whether resp redirects to a page without session, which
+// is where the portal sends requests of expired sessions
+func redirectedToLogin(resp *http.Response) bool {
+	location, err := resp.Location()
+	return err == nil && location.Query().Get("sessionID") == ""
+}
@@ -0,0 +1,29 @@
+package strato
+
+import "testing"
+
+// TestChangeAccountPassword makes sure the password is changed after the session
+// expired, and that copies of the client log in with the new password afterwards
+func TestChangeAccountPassword(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal)
+	copied := client.ForDomain("example.net")
+
+	client.setSessionID("EXPIRED")
+	if err := client.ChangeAccountPassword(testPassword, "new-secret"); err != nil {
+		t.Fatal(err)
+	}
+	if got := portal.loginCount(); got != 2 {
+		t.Errorf("got %d logins, want a second one for the expired session", got)
+	}
+
+	copied.setSessionID("EXPIRED")
+	if _, err := copied.GetDNSConfiguration(); err != nil {
+		t.Fatalf("copy of the client failed to log in again: %v", err)
+	}
+	portal.mu.Lock()
+	defer portal.mu.Unlock()
+	if got := portal.logins[len(portal.logins)-1].Get(RegionDE.PasswordField); got != "new-secret" {
+		t.Errorf("copy of the client logged in with %q, want the new password", got)
+	}
+}
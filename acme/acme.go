@@ -0,0 +1,230 @@
+// Package acme obtains certificates from an ACME (RFC 8555) certificate authority
+// such as Let's Encrypt with golang.org/x/crypto/acme. Challenges are answered with
+// DNS-01, so wildcard names can be covered; StratoSolver publishes the challenge
+// records at Strato. Accounts and certificates use ECDSA P-256 keys.
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/acme"
+)
+
+// Directory URLs of Let's Encrypt
+const (
+	LetsEncrypt        = acme.LetsEncryptURL
+	LetsEncryptStaging = "https://acme-staging-v02.api.letsencrypt.org/directory"
+)
+
+// Client talks to the ACME server at DirectoryURL with the account of Key
+type Client struct {
+	// DirectoryURL defaults to LetsEncrypt
+	DirectoryURL string
+	// Key is the account key
+	Key        *ecdsa.PrivateKey
+	HTTPClient *http.Client
+
+	client     *acme.Client
+	registered bool
+}
+
+// Challenge is a DNS-01 challenge: a TXT record with Value at the fully qualified Name
+type Challenge struct {
+	Name  string
+	Value string
+}
+
+// Solver publishes and removes the records of DNS-01 challenges. Present returns
+// once the records are visible to the CA.
+type Solver interface {
+	Present(ctx context.Context, challenges []Challenge) error
+	CleanUp(ctx context.Context, challenges []Challenge) error
+}
+
+// acmeClient returns the client of x/crypto/acme, created on first use
+func (c *Client) acmeClient() *acme.Client {
+	if c.client == nil {
+		directory := c.DirectoryURL
+		if directory == "" {
+			directory = LetsEncrypt
+		}
+		c.client = &acme.Client{Key: c.Key, DirectoryURL: directory, HTTPClient: c.HTTPClient}
+	}
+	return c.client
+}
+
+// Register creates the account of the key, or looks it up if it exists, agreeing to
+// the terms of service of the CA. email may be empty.
+func (c *Client) Register(ctx context.Context, email string) error {
+	account := &acme.Account{}
+	if email != "" {
+		account.Contact = []string{"mailto:" + email}
+	}
+	// The client learns the account URL in both cases
+	_, err := c.acmeClient().Register(ctx, account, acme.AcceptTOS)
+	if err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return err
+	}
+	c.registered = true
+	return nil
+}
+
+// Obtain orders a certificate for names, answering the challenges with solver. It
+// returns the PEM encoded certificate chain and the PEM encoded private key generated
+// for it. Register must have been called before.
+func (c *Client) Obtain(ctx context.Context, solver Solver, names []string) ([]byte, []byte, error) {
+	if !c.registered {
+		return nil, nil, errors.New("acme: account not registered")
+	}
+	if len(names) == 0 {
+		return nil, nil, errors.New("acme: no names to obtain a certificate for")
+	}
+	client := c.acmeClient()
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(names...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	challenges, pending, err := c.challenges(ctx, order.AuthzURLs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(challenges) > 0 {
+		// Clean up even if Present failed half way or ctx ended, the records would
+		// linger otherwise
+		defer solver.CleanUp(context.WithoutCancel(ctx), challenges)
+		if err := solver.Present(ctx, challenges); err != nil {
+			return nil, nil, err
+		}
+		for _, challenge := range pending {
+			if _, err := client.Accept(ctx, challenge); err != nil {
+				return nil, nil, err
+			}
+		}
+		for _, url := range order.AuthzURLs {
+			if _, err := client.WaitAuthorization(ctx, url); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: names[0]},
+		DNSNames: names,
+	}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order, err = client.WaitOrder(ctx, order.URI); err != nil {
+		return nil, nil, err
+	}
+	var chain [][]byte
+	if order.Status == acme.StatusValid {
+		chain, err = client.FetchCert(ctx, order.CertURL, true)
+	} else {
+		chain, _, err = client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var certPEM []byte
+	for _, der := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return certPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// challenges returns the DNS-01 challenges of the pending authorizations with the
+// records that answer them
+func (c *Client) challenges(ctx context.Context, authorizations []string) ([]Challenge, []*acme.Challenge, error) {
+	var challenges []Challenge
+	var pending []*acme.Challenge
+	client := c.acmeClient()
+	for _, url := range authorizations {
+		authz, err := client.GetAuthorization(ctx, url)
+		if err != nil {
+			return nil, nil, err
+		}
+		if authz.Status == acme.StatusValid {
+			continue
+		}
+		found := false
+		for _, challenge := range authz.Challenges {
+			if challenge.Type != "dns-01" {
+				continue
+			}
+			value, err := client.DNS01ChallengeRecord(challenge.Token)
+			if err != nil {
+				return nil, nil, err
+			}
+			challenges = append(challenges, Challenge{
+				Name:  "_acme-challenge." + strings.TrimPrefix(authz.Identifier.Value, "*."),
+				Value: value,
+			})
+			pending = append(pending, challenge)
+			found = true
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("acme: no dns-01 challenge offered for %s", authz.Identifier.Value)
+		}
+	}
+	return challenges, pending, nil
+}
+
+// LoadOrCreateKey reads the PEM encoded ECDSA P-256 key at path, or generates one
+// and writes it there if the file does not exist
+func LoadOrCreateKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return key, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+	}
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s contains no PEM data", path)
+	}
+	var parsed interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok || key.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("%s is not an ECDSA P-256 key", path)
+	}
+	return key, nil
+}
@@ -0,0 +1,92 @@
+package acme
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// StratoSolver answers DNS-01 challenges with TXT records in the zone of Domain,
+// which must be the domain Client manages. All records of an order are published
+// with one change of the zone.
+type StratoSolver struct {
+	Client *strato.StratoClient
+	Domain string
+	// Checker decides when the records are visible, by default once every
+	// authoritative nameserver answers with them
+	Checker strato.Checker
+	// Timeout bounds the wait for the records, ten minutes by default
+	Timeout time.Duration
+}
+
+// Present adds the challenge records and waits until Checker sees all of them
+func (s *StratoSolver) Present(ctx context.Context, challenges []Challenge) error {
+	records, err := s.records(challenges)
+	if err != nil {
+		return err
+	}
+	err = s.Client.WithContext(ctx).UpdateZone(func(zone *strato.Zone) (bool, error) {
+		changed := false
+		for _, record := range records {
+			changed = zone.Add(record) || changed
+		}
+		return changed, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish challenge records: %w", err)
+	}
+	return s.wait(ctx, records)
+}
+
+// CleanUp removes the challenge records
+func (s *StratoSolver) CleanUp(ctx context.Context, challenges []Challenge) error {
+	records, err := s.records(challenges)
+	if err != nil {
+		return err
+	}
+	return s.Client.WithContext(ctx).UpdateZone(func(zone *strato.Zone) (bool, error) {
+		changed := false
+		for _, record := range records {
+			changed = zone.Remove(record) || changed
+		}
+		return changed, nil
+	})
+}
+
+// records converts challenges into records of the zone of Domain
+func (s *StratoSolver) records(challenges []Challenge) ([]strato.DNSRecord, error) {
+	domain := strings.TrimSuffix(strings.ToLower(s.Domain), ".")
+	records := make([]strato.DNSRecord, 0, len(challenges))
+	for _, challenge := range challenges {
+		name := strings.TrimSuffix(strings.ToLower(challenge.Name), ".")
+		prefix := strings.TrimSuffix(name, "."+domain)
+		if prefix == name {
+			return nil, fmt.Errorf("%s is not in the zone of %s", challenge.Name, s.Domain)
+		}
+		records = append(records, strato.DNSRecord{Type: "TXT", Prefix: prefix, Value: challenge.Value})
+	}
+	return records, nil
+}
+
+// wait returns once Checker sees every record
+func (s *StratoSolver) wait(ctx context.Context, records []strato.DNSRecord) error {
+	checker := s.Checker
+	if checker == nil {
+		checker = strato.AuthoritativeChecker{}
+	}
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for _, record := range records {
+		if err := checker.Wait(ctx, s.Domain, record); err != nil {
+			return fmt.Errorf("challenge record: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+// Package acmedns serves the HTTP API of acme-dns (https://github.com/joohoi/acme-dns)
+// but writes the challenge records into a domain at Strato, so clients that already
+// speak acme-dns, such as certbot-dns-acmedns, lego and Traefik, can delegate their
+// DNS-01 challenges to it.
+//
+// A client registers once and gets a subdomain below the served domain, e.g.
+// 6f2d...a1.acme.example.com. The CNAME _acme-challenge.<name> pointing there is
+// set up once per name; afterwards the client posts each challenge to /update:
+//
+//	curl -X POST http://localhost:8080/register
+//	curl -X POST -H "X-Api-User: $USER" -H "X-Api-Key: $KEY" \
+//		--data '{"subdomain": "'$SUBDOMAIN'", "txt": "'$VALUE'"}' http://localhost:8080/update
+//
+// Like acme-dns, the two latest values of a subdomain are kept, so certificates
+// covering a name and its wildcard can be validated together.
+//
+// Anyone who can reach /register can create an account, up to DefaultMaxAccounts.
+// Use WithRegistrationFrom to only accept registrations from trusted networks, or
+// WithoutRegistration once all clients are set up.
+package acmedns
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// Account is a registration of an acme-dns client
+type Account struct {
+	Username string `json:"username"`
+	// PasswordHash is the hex encoded SHA-256 of the password
+	PasswordHash string `json:"password_hash"`
+	Subdomain    string `json:"subdomain"`
+	// AllowFrom limits updates to clients from these networks, if not empty
+	AllowFrom []string `json:"allowfrom,omitempty"`
+	// Values are the latest TXT values, the newest last
+	Values []string `json:"values,omitempty"`
+}
+
+// DefaultMaxAccounts is the number of accounts registrations are accepted for
+// unless WithMaxAccounts sets another limit
+const DefaultMaxAccounts = 100
+
+// Server is an http.Handler serving /register, /update and /health
+type Server struct {
+	client *strato.StratoClient
+	domain string
+	prefix string
+	path   string
+	mux    *http.ServeMux
+
+	noRegistration bool
+	registerFrom   []string
+	maxAccounts    int
+
+	mu       sync.Mutex
+	accounts map[string]*Account
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithoutRegistration does not serve /register, like disable_registration of
+// acme-dns. Only the accounts already in the accounts file can update records.
+func WithoutRegistration() Option {
+	return func(s *Server) {
+		s.noRegistration = true
+	}
+}
+
+// WithRegistrationFrom only accepts registrations from clients in the networks
+// given in CIDR notation
+func WithRegistrationFrom(networks []string) Option {
+	return func(s *Server) {
+		s.registerFrom = networks
+	}
+}
+
+// WithMaxAccounts refuses registrations once there are n accounts, or never if n
+// is 0
+func WithMaxAccounts(n int) Option {
+	return func(s *Server) {
+		s.maxAccounts = n
+	}
+}
+
+// New returns a server writing records to the zone of client, which manages domain.
+// Subdomains are created below prefix, e.g. "acme", or directly below domain if
+// prefix is empty. Accounts are kept in the JSON file at path.
+func New(client *strato.StratoClient, domain, prefix, path string, opts ...Option) (*Server, error) {
+	s := &Server{
+		client:      client,
+		domain:      strings.TrimSuffix(strings.ToLower(domain), "."),
+		prefix:      strings.Trim(strings.ToLower(prefix), "."),
+		path:        path,
+		mux:         http.NewServeMux(),
+		maxAccounts: DefaultMaxAccounts,
+		accounts:    map[string]*Account{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	for _, cidr := range s.registerFrom {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			return nil, fmt.Errorf("invalid registration network: %w", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		var accounts []*Account
+		if err := json.Unmarshal(data, &accounts); err != nil {
+			return nil, fmt.Errorf("invalid accounts file %s: %w", path, err)
+		}
+		for _, account := range accounts {
+			s.accounts[account.Username] = account
+		}
+	}
+	if !s.noRegistration {
+		s.mux.HandleFunc("POST /register", s.register)
+	}
+	s.mux.HandleFunc("POST /update", s.update)
+	s.mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {})
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// FullDomain returns the name the CNAME of a client has to point to
+func (s *Server) FullDomain(subdomain string) string {
+	return s.recordPrefix(subdomain) + "." + s.domain
+}
+
+func (s *Server) recordPrefix(subdomain string) string {
+	if s.prefix == "" {
+		return subdomain
+	}
+	return subdomain + "." + s.prefix
+}
+
+func (s *Server) register(w http.ResponseWriter, r *http.Request) {
+	if !allowed(s.registerFrom, r.RemoteAddr) {
+		writeError(w, http.StatusUnauthorized, "forbidden")
+		return
+	}
+	var request struct {
+		AllowFrom []string `json:"allowfrom"`
+	}
+	// The body is optional
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, "malformed_json_payload")
+			return
+		}
+	}
+	for _, cidr := range request.AllowFrom {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_allowfrom_cidr")
+			return
+		}
+	}
+	password := randomString(30)
+	account := &Account{
+		Username:     newUUID(),
+		PasswordHash: hashPassword(password),
+		Subdomain:    newUUID(),
+		AllowFrom:    request.AllowFrom,
+	}
+	s.mu.Lock()
+	if s.maxAccounts > 0 && len(s.accounts) >= s.maxAccounts {
+		s.mu.Unlock()
+		slog.Warn("Refused acme-dns registration, the account limit is reached", "accounts", s.maxAccounts)
+		writeError(w, http.StatusForbidden, "too_many_accounts")
+		return
+	}
+	s.accounts[account.Username] = account
+	err := s.save()
+	s.mu.Unlock()
+	if err != nil {
+		slog.Error("Failed to save acme-dns accounts", "path", s.path, "error", err)
+		writeError(w, http.StatusInternalServerError, "db_error")
+		return
+	}
+	slog.Info("Registered acme-dns account", "subdomain", account.Subdomain)
+	allowFrom := account.AllowFrom
+	if allowFrom == nil {
+		allowFrom = []string{}
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"username":   account.Username,
+		"password":   password,
+		"fulldomain": s.FullDomain(account.Subdomain),
+		"subdomain":  account.Subdomain,
+		"allowfrom":  allowFrom,
+	})
+}
+
+// validTXT matches the base64url encoded SHA-256 digests DNS-01 challenges use
+var validTXT = regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+
+func (s *Server) update(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Subdomain string `json:"subdomain"`
+		TXT       string `json:"txt"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "malformed_json_payload")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.accounts[r.Header.Get("X-Api-User")]
+	if !ok || subtle.ConstantTimeCompare([]byte(account.PasswordHash), []byte(hashPassword(r.Header.Get("X-Api-Key")))) != 1 {
+		writeError(w, http.StatusUnauthorized, "forbidden")
+		return
+	}
+	if !allowed(account.AllowFrom, r.RemoteAddr) {
+		writeError(w, http.StatusUnauthorized, "forbidden")
+		return
+	}
+	if request.Subdomain != account.Subdomain {
+		writeError(w, http.StatusUnauthorized, "forbidden")
+		return
+	}
+	if !validTXT.MatchString(request.TXT) {
+		writeError(w, http.StatusBadRequest, "bad_txt")
+		return
+	}
+	values := append(append([]string(nil), account.Values...), request.TXT)
+	if len(values) > 2 {
+		values = values[len(values)-2:]
+	}
+	if err := s.publish(r, account.Subdomain, values); err != nil {
+		slog.Error("Failed to update acme-dns record", "subdomain", account.Subdomain, "error", err)
+		writeError(w, http.StatusInternalServerError, "db_error")
+		return
+	}
+	account.Values = values
+	if err := s.save(); err != nil {
+		slog.Warn("Failed to save acme-dns accounts", "path", s.path, "error", err)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"txt": request.TXT})
+}
+
+// publish makes values the only TXT records of subdomain
+func (s *Server) publish(r *http.Request, subdomain string, values []string) error {
+	prefix := s.recordPrefix(subdomain)
+	keep := map[string]bool{}
+	for _, value := range values {
+		keep[value] = true
+	}
+	return s.client.WithContext(r.Context()).UpdateZone(func(zone *strato.Zone) (bool, error) {
+		removed := zone.RemoveMatching(func(record strato.DNSRecord) bool {
+			return record.Type == "TXT" && record.Prefix == prefix && !keep[record.Value]
+		})
+		added := false
+		for _, value := range values {
+			added = zone.Add(strato.DNSRecord{Type: "TXT", Prefix: prefix, Value: value}) || added
+		}
+		return added || len(removed) > 0, nil
+	})
+}
+
+// allowed reports whether remoteAddr is in one of the networks of allowFrom
+func allowed(allowFrom []string, remoteAddr string) bool {
+	if len(allowFrom) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	for _, cidr := range allowFrom {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil && prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// save writes the accounts to the file at path; the caller holds mu
+func (s *Server) save() error {
+	accounts := make([]*Account, 0, len(s.accounts))
+	for _, account := range s.accounts {
+		accounts = append(accounts, account)
+	}
+	data, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	return strato.WriteFileAtomic(s.path, data, 0o600)
+}
+
+func hashPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString returns n random bytes encoded as URL safe base64
+func randomString(n int) string {
+	data := make([]byte, n)
+	rand.Read(data)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// newUUID returns a random UUID as acme-dns uses for user names and subdomains
+func newUUID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Debug("Failed to write response", "error", err)
+	}
+}
+
+// writeError answers with an error in the format of acme-dns
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,209 @@
+package acmedns
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/internal/stratotest"
+)
+
+// challenge returns a TXT value in the format of DNS-01 challenges
+func challenge(c byte) string {
+	return strings.Repeat(string(c), 43)
+}
+
+// registration is the answer to /register
+type registration struct {
+	Username   string   `json:"username"`
+	Password   string   `json:"password"`
+	FullDomain string   `json:"fulldomain"`
+	Subdomain  string   `json:"subdomain"`
+	AllowFrom  []string `json:"allowfrom"`
+}
+
+// newTestServer returns a server for the acme prefix of example.com on a test portal
+func newTestServer(t *testing.T, opts ...Option) (*Server, *stratotest.Portal) {
+	t.Helper()
+	portal := stratotest.NewPortal(t)
+	s, err := New(portal.Client(t), "example.com", "acme", filepath.Join(t.TempDir(), "accounts.json"), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, portal
+}
+
+// request sends body from remoteAddr with the credentials of account, if not nil
+func request(s *Server, method, target, body, remoteAddr string, account *registration) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.RemoteAddr = remoteAddr
+	if account != nil {
+		r.Header.Set("X-Api-User", account.Username)
+		r.Header.Set("X-Api-Key", account.Password)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func register(t *testing.T, s *Server, body string) registration {
+	t.Helper()
+	w := request(s, http.MethodPost, "/register", body, "192.0.2.1:1234", nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+	}
+	var account registration
+	if err := json.Unmarshal(w.Body.Bytes(), &account); err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
+
+// update posts value for the subdomain of account
+func update(s *Server, account registration, subdomain, value, remoteAddr string) *httptest.ResponseRecorder {
+	body := `{"subdomain": "` + subdomain + `", "txt": "` + value + `"}`
+	return request(s, http.MethodPost, "/update", body, remoteAddr, &account)
+}
+
+// values returns the TXT values of subdomain in the zone of example.com
+func values(portal *stratotest.Portal, subdomain string) []string {
+	var values []string
+	for _, record := range portal.Config("example.com").Records {
+		if record.Type == "TXT" && record.Prefix == subdomain+".acme" {
+			values = append(values, record.Value)
+		}
+	}
+	return values
+}
+
+func TestRegister(t *testing.T) {
+	s, _ := newTestServer(t)
+	account := register(t, s, "")
+	if account.Username == "" || account.Password == "" || account.Subdomain == "" {
+		t.Fatalf("got incomplete registration %+v", account)
+	}
+	if account.FullDomain != account.Subdomain+".acme.example.com" {
+		t.Errorf("got full domain %s, want %s.acme.example.com", account.FullDomain, account.Subdomain)
+	}
+	if account.AllowFrom == nil || len(account.AllowFrom) != 0 {
+		t.Errorf("got allowfrom %v, want an empty list", account.AllowFrom)
+	}
+
+	if w := request(s, http.MethodPost, "/register", `{"allowfrom": ["not a network"]}`, "192.0.2.1:1234", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("got status %d for an invalid network, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := request(s, http.MethodPost, "/register", `{`, "192.0.2.1:1234", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("got status %d for malformed JSON, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestRegistrationLimits(t *testing.T) {
+	s, _ := newTestServer(t, WithRegistrationFrom([]string{"192.0.2.0/24"}), WithMaxAccounts(1))
+	if w := request(s, http.MethodPost, "/register", "", "198.51.100.1:1234", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("got status %d for a registration from outside, want %d", w.Code, http.StatusUnauthorized)
+	}
+	register(t, s, "")
+	if w := request(s, http.MethodPost, "/register", "", "192.0.2.1:1234", nil); w.Code != http.StatusForbidden {
+		t.Errorf("got status %d beyond the account limit, want %d", w.Code, http.StatusForbidden)
+	}
+
+	s, _ = newTestServer(t, WithoutRegistration())
+	if w := request(s, http.MethodPost, "/register", "", "192.0.2.1:1234", nil); w.Code == http.StatusCreated {
+		t.Error("registered an account with registration disabled")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	s, portal := newTestServer(t)
+	account := register(t, s, "")
+	other := register(t, s, "")
+
+	wrongKey := account
+	wrongKey.Password = "wrong"
+	tests := []struct {
+		name      string
+		account   registration
+		subdomain string
+		value     string
+		status    int
+	}{
+		{"wrong key", wrongKey, account.Subdomain, challenge('a'), http.StatusUnauthorized},
+		{"unknown user", registration{Username: "mallory", Password: account.Password}, account.Subdomain, challenge('a'), http.StatusUnauthorized},
+		{"subdomain of another account", other, account.Subdomain, challenge('a'), http.StatusUnauthorized},
+		{"invalid value", account, account.Subdomain, "short", http.StatusBadRequest},
+		{"valid", account, account.Subdomain, challenge('a'), http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if w := update(s, test.account, test.subdomain, test.value, "192.0.2.1:1234"); w.Code != test.status {
+				t.Errorf("got status %d, want %d: %s", w.Code, test.status, w.Body)
+			}
+		})
+	}
+	if got := values(portal, account.Subdomain); len(got) != 1 || got[0] != challenge('a') {
+		t.Errorf("got values %v, want only the valid update", got)
+	}
+}
+
+func TestUpdateKeepsTwoValues(t *testing.T) {
+	s, portal := newTestServer(t)
+	account := register(t, s, "")
+	for _, c := range []byte{'a', 'b', 'c'} {
+		if w := update(s, account, account.Subdomain, challenge(c), "192.0.2.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+		}
+	}
+	got := values(portal, account.Subdomain)
+	if len(got) != 2 || got[0] == challenge('a') || got[1] == challenge('a') {
+		t.Errorf("got values %v, want the latest two", got)
+	}
+	// The records of the domain outside the subdomain are kept
+	if !strato.NewZone(portal.Config("example.com")).Contains(strato.DNSRecord{Type: "CNAME", Prefix: "www", Value: "example.com."}) {
+		t.Errorf("records outside the subdomain changed: %v", portal.Config("example.com").Records)
+	}
+}
+
+func TestUpdateAllowFrom(t *testing.T) {
+	s, portal := newTestServer(t)
+	account := register(t, s, `{"allowfrom": ["192.0.2.0/24", "2001:db8::/32"]}`)
+	tests := []struct {
+		remoteAddr string
+		status     int
+	}{
+		{"198.51.100.1:1234", http.StatusUnauthorized},
+		{"[2001:db9::1]:1234", http.StatusUnauthorized},
+		{"192.0.2.1:1234", http.StatusOK},
+		{"[::ffff:192.0.2.2]:1234", http.StatusOK},
+		{"[2001:db8::1]:1234", http.StatusOK},
+	}
+	for _, test := range tests {
+		if w := update(s, account, account.Subdomain, challenge('a'), test.remoteAddr); w.Code != test.status {
+			t.Errorf("update from %s: got status %d, want %d", test.remoteAddr, w.Code, test.status)
+		}
+	}
+	if got := values(portal, account.Subdomain); len(got) != 1 {
+		t.Errorf("got values %v, want the allowed update", got)
+	}
+}
+
+func TestAccountsPersist(t *testing.T) {
+	portal := stratotest.NewPortal(t)
+	path := filepath.Join(t.TempDir(), "accounts.json")
+	s, err := New(portal.Client(t), "example.com", "acme", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := register(t, s, "")
+
+	s, err = New(portal.Client(t), "example.com", "acme", path, WithoutRegistration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := update(s, account, account.Subdomain, challenge('a'), "192.0.2.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("got status %d for an account of the accounts file, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+}
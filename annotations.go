@@ -0,0 +1,179 @@
+package strato
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Annotation is local metadata about a DNS record, which Strato cannot store itself
+type Annotation struct {
+	Owner   string    `json:"owner,omitempty"`
+	Purpose string    `json:"purpose,omitempty"`
+	Created time.Time `json:"created"`
+	// Protected records are only changed or removed by clients created with WithForce
+	Protected bool `json:"protected,omitempty"`
+}
+
+// AnnotationStore keeps annotations of DNS records per domain
+type AnnotationStore interface {
+	// List returns the annotations of all records of a domain
+	List(domain string) (map[DNSRecord]Annotation, error)
+	Set(domain string, record DNSRecord, annotation Annotation) error
+	Delete(domain string, record DNSRecord) error
+}
+
+// annotatedRecord is the serialized form of one entry of a FileAnnotationStore
+type annotatedRecord struct {
+	Record     DNSRecord  `json:"record"`
+	Annotation Annotation `json:"annotation"`
+}
+
+// FileAnnotationStore is an AnnotationStore backed by a JSON file
+type FileAnnotationStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileAnnotationStore returns a store that keeps annotations in the JSON file at path.
+// The file is created on first write.
+func NewFileAnnotationStore(path string) *FileAnnotationStore {
+	return &FileAnnotationStore{path: path}
+}
+
+// load reads the whole file, a missing file is an empty store
+func (s *FileAnnotationStore) load() (map[string][]annotatedRecord, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string][]annotatedRecord{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	domains := map[string][]annotatedRecord{}
+	if err := json.Unmarshal(data, &domains); err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+// save replaces the file atomically
+func (s *FileAnnotationStore) save(domains map[string][]annotatedRecord) error {
+	data, err := json.MarshalIndent(domains, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	return WriteFileAtomic(s.path, data, 0o600)
+}
+
+func (s *FileAnnotationStore) List(domain string) (map[DNSRecord]Annotation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	domains, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	annotations := map[DNSRecord]Annotation{}
+	for _, entry := range domains[domain] {
+		annotations[entry.Record] = entry.Annotation
+	}
+	return annotations, nil
+}
+
+func (s *FileAnnotationStore) Set(domain string, record DNSRecord, annotation Annotation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	domains, err := s.load()
+	if err != nil {
+		return err
+	}
+	entries := domains[domain]
+	for i := range entries {
+		if entries[i].Record == record {
+			entries[i].Annotation = annotation
+			return s.save(domains)
+		}
+	}
+	domains[domain] = append(entries, annotatedRecord{Record: record, Annotation: annotation})
+	return s.save(domains)
+}
+
+func (s *FileAnnotationStore) Delete(domain string, record DNSRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	domains, err := s.load()
+	if err != nil {
+		return err
+	}
+	entries := domains[domain]
+	for i := range entries {
+		if entries[i].Record == record {
+			domains[domain] = append(entries[:i], entries[i+1:]...)
+			return s.save(domains)
+		}
+	}
+	return nil
+}
+
+// Annotations returns the annotations of the records of the domain, or nil if no store is configured
+func (c *StratoClient) Annotations() (map[DNSRecord]Annotation, error) {
+	if c.annotations == nil {
+		return nil, nil
+	}
+	return c.annotations.List(c.domain)
+}
+
+// Annotate sets the purpose of a record of the domain, keeping owner and creation time
+func (c *StratoClient) Annotate(record DNSRecord, purpose string) error {
+	if c.annotations == nil {
+		return errors.New("no annotation store configured")
+	}
+	annotations, err := c.annotations.List(c.domain)
+	if err != nil {
+		return err
+	}
+	annotation, found := annotations[record]
+	if !found {
+		annotation = Annotation{Owner: c.annotationOwner, Created: time.Now().UTC()}
+	}
+	annotation.Purpose = purpose
+	return c.annotations.Set(c.domain, record, annotation)
+}
+
+// Protect marks a record of the domain as protected, or clears the mark
+func (c *StratoClient) Protect(record DNSRecord, protected bool) error {
+	if c.annotations == nil {
+		return errors.New("no annotation store configured")
+	}
+	annotations, err := c.annotations.List(c.domain)
+	if err != nil {
+		return err
+	}
+	annotation, found := annotations[record]
+	if !found {
+		annotation = Annotation{Owner: c.annotationOwner, Created: time.Now().UTC()}
+	}
+	annotation.Protected = protected
+	return c.annotations.Set(c.domain, record, annotation)
+}
+
+// updateAnnotations records the creation of added records and forgets removed ones
+func (c *StratoClient) updateAnnotations(diff ConfigDiff) error {
+	now := time.Now().UTC()
+	for _, record := range diff.Added {
+		if err := c.annotations.Set(c.domain, record, Annotation{Owner: c.annotationOwner, Created: now}); err != nil {
+			return err
+		}
+	}
+	for _, record := range diff.Removed {
+		if err := c.annotations.Delete(c.domain, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
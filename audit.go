@@ -0,0 +1,116 @@
+package strato
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditEntry describes one mutating operation
+type AuditEntry struct {
+	Time   time.Time  `json:"timestamp"`
+	Actor  string     `json:"actor"`
+	Domain string     `json:"domain"`
+	Diff   ConfigDiff `json:"diff"`
+	// Result is "success" or the error message of a failed operation
+	Result string `json:"result"`
+	// Revision is the commit of the configuration applied by SyncFromGit
+	Revision string `json:"revision,omitempty"`
+	// Operation names the portal form of changes outside the DNS configuration,
+	// e.g. "create_mailbox"; their Diff is empty
+	Operation string `json:"operation,omitempty"`
+}
+
+// AuditLogger records mutating operations
+type AuditLogger interface {
+	Log(entry AuditEntry) error
+}
+
+// FileAuditLog appends audit entries as JSON lines to a file
+type FileAuditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileAuditLog returns an AuditLogger appending to the file at path
+func NewFileAuditLog(path string) *FileAuditLog {
+	return &FileAuditLog{path: path}
+}
+
+func (l *FileAuditLog) Log(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// The file is opened per entry so external log rotation needs no signalling
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ForActor returns a client sharing the session of c that records actor in the
+// audit log, e.g. the user of a server request
+func (c *StratoClient) ForActor(actor string) *StratoClient {
+	clone := *c
+	clone.auditActor = actor
+	return &clone
+}
+
+// audit writes an entry for a change of the domain if an audit log is configured
+func (c *StratoClient) audit(diff ConfigDiff, err error) error {
+	if c.auditLog == nil {
+		return nil
+	}
+	entry := AuditEntry{
+		Time:     time.Now().UTC(),
+		Actor:    c.auditActor,
+		Domain:   c.domain,
+		Diff:     diff,
+		Result:   "success",
+		Revision: c.auditRevision,
+	}
+	if err != nil {
+		entry.Result = err.Error()
+	}
+	return c.auditLog.Log(entry)
+}
+
+// auditForm writes an entry for a form submitted to the portal, named after its
+// action field. The values of the form are left out, they may contain passwords.
+func (c *StratoClient) auditForm(form url.Values, err error) {
+	if c.auditLog == nil {
+		return
+	}
+	entry := AuditEntry{
+		Time:     time.Now().UTC(),
+		Actor:    c.auditActor,
+		Domain:   c.domain,
+		Result:   "success",
+		Revision: c.auditRevision,
+	}
+	if vhost := form.Get("vhost"); vhost != "" {
+		entry.Domain = vhost
+	}
+	for field := range form {
+		if operation, ok := strings.CutPrefix(field, "action_"); ok {
+			entry.Operation = operation
+		}
+	}
+	if err != nil {
+		entry.Result = err.Error()
+	}
+	if logErr := c.auditLog.Log(entry); logErr != nil {
+		logFor(LogForm).Error("Failed to write audit log", "error", logErr)
+	}
+}
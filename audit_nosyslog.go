@@ -0,0 +1,17 @@
+//go:build windows || plan9
+
+package strato
+
+import "errors"
+
+// SyslogAuditLog is not available on this platform
+type SyslogAuditLog struct{}
+
+// NewSyslogAuditLog fails as there is no syslog on this platform
+func NewSyslogAuditLog(tag string) (*SyslogAuditLog, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
+
+func (l *SyslogAuditLog) Log(entry AuditEntry) error {
+	return errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,30 @@
+//go:build !windows && !plan9
+
+package strato
+
+import (
+	"encoding/json"
+	"log/syslog"
+)
+
+// SyslogAuditLog sends audit entries as JSON to the local syslog daemon
+type SyslogAuditLog struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogAuditLog connects to the local syslog daemon using the given tag
+func NewSyslogAuditLog(tag string) (*SyslogAuditLog, error) {
+	writer, err := syslog.New(syslog.LOG_NOTICE|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogAuditLog{writer: writer}, nil
+}
+
+func (l *SyslogAuditLog) Log(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return l.writer.Notice(string(line))
+}
@@ -0,0 +1,142 @@
+package strato
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// auditEntries reads the entries of a FileAuditLog
+func auditEntries(t *testing.T, path string) []AuditEntry {
+	t.Helper()
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestFileAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log := NewFileAuditLog(path)
+	want := []AuditEntry{
+		{
+			Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Actor: "alice", Domain: "example.com",
+			Diff: ConfigDiff{Added: []DNSRecord{{Type: "TXT", Value: "v=spf1 -all"}}}, Result: "success", Revision: "abc123",
+		},
+		{Time: time.Date(2024, 5, 1, 12, 1, 0, 0, time.UTC), Domain: "example.com", Result: "failed", Operation: "create_mailbox"},
+	}
+	for _, entry := range want {
+		if err := log.Log(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := auditEntries(t, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("got entries %+v, want %+v", got, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("got mode %s, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestAuditDNSChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal, WithAuditLog(NewFileAuditLog(path), "alice"))
+	record := DNSRecord{Type: "TXT", Prefix: "_acme-challenge", Value: "token"}
+	add := func(current DNSConfig) (DNSConfig, error) {
+		current.Records = append(current.Records, record)
+		return current, nil
+	}
+
+	portal.failWrites = 1
+	if _, err := client.UpdateDNSConfiguration(add); err == nil {
+		t.Fatal("got no error for a failed write")
+	}
+	if _, err := client.UpdateDNSConfiguration(add); err != nil {
+		t.Fatal(err)
+	}
+	entries := auditEntries(t, path)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Actor != "alice" || entry.Domain != "example.com" || entry.Operation != "" {
+			t.Errorf("entry %d: got %+v, want a DNS change of example.com by alice", i, entry)
+		}
+		if want := []DNSRecord{record}; !reflect.DeepEqual(entry.Diff.Added, want) {
+			t.Errorf("entry %d: got added records %v, want %v", i, entry.Diff.Added, want)
+		}
+	}
+	if entries[0].Result == "success" {
+		t.Error("the failed write was logged as success")
+	}
+	if entries[1].Result != "success" {
+		t.Errorf("got result %q for the successful write, want success", entries[1].Result)
+	}
+}
+
+func TestAuditForms(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal, WithAuditLog(NewFileAuditLog(path), "alice"))
+	dryRun := newTestClient(t, portal, WithAuditLog(NewFileAuditLog(path), "alice"), WithReadOnly(true))
+
+	if err := client.SetHostAddresses("www.example.com", HostAddresses{IPv4: []string{"192.0.2.1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.ChangeAccountPassword(testPassword, "new-secret"); err != nil {
+		t.Fatal(err)
+	}
+	// Dry runs change nothing and are not logged
+	if err := dryRun.SetHostAddresses("www.example.com", HostAddresses{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ domain, operation string }{
+		{"www.example.com", "change_ip_settings"},
+		{"example.com", "change_password"},
+	}
+	entries := auditEntries(t, path)
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.Domain != want[i].domain || entry.Operation != want[i].operation || entry.Actor != "alice" || entry.Result != "success" {
+			t.Errorf("entry %d: got %+v, want a successful %s of %s by alice", i, entry, want[i].operation, want[i].domain)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "new-secret") {
+		t.Error("the audit log contains the new password")
+	}
+}
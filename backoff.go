@@ -0,0 +1,140 @@
+package strato
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxLoginCooldown caps the waiting time after repeated failed logins
+const maxLoginCooldown = 24 * time.Hour
+
+// loginState records the failed logins of one identifier
+type loginState struct {
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"lastFailure"`
+}
+
+// LoginBackoffError is returned instead of logging in while the cool-down after
+// failed logins has not passed
+type LoginBackoffError struct {
+	Failures int
+	RetryAt  time.Time
+}
+
+func (e *LoginBackoffError) Error() string {
+	return fmt.Sprintf("%s: %d failed logins, not retrying before %s",
+		ErrLoginBackoff, e.Failures, e.RetryAt.Format(time.RFC3339))
+}
+
+func (e *LoginBackoffError) Unwrap() error {
+	return ErrLoginBackoff
+}
+
+// loginBackoff keeps failed logins per identifier in a state file, so that the
+// cool-down survives restarts of the process
+type loginBackoff struct {
+	path     string
+	cooldown time.Duration
+}
+
+func (b *loginBackoff) load() (map[string]loginState, error) {
+	states := map[string]loginState{}
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return states, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("invalid login state file %s: %w", b.path, err)
+	}
+	return states, nil
+}
+
+func (b *loginBackoff) save(states map[string]loginState) error {
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(b.path, data, 0o600)
+}
+
+// update calls fn with the states in the file while holding the lock of the file
+// and saves them if fn reports a change, so processes sharing the file do not lose
+// each other's failures. The lock is taken on a file next to the state file, which
+// is replaced on every save.
+func (b *loginBackoff) update(fn func(map[string]loginState) bool) error {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(b.path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := lockFile(file); err != nil {
+		return err
+	}
+	defer unlockFile(file)
+
+	states, err := b.load()
+	if err != nil {
+		return err
+	}
+	if !fn(states) {
+		return nil
+	}
+	return b.save(states)
+}
+
+// check returns a *LoginBackoffError if identifier must not log in yet
+func (b *loginBackoff) check(identifier string) error {
+	states, err := b.load()
+	if err != nil {
+		return err
+	}
+	state, ok := states[identifier]
+	if !ok || state.Failures == 0 {
+		return nil
+	}
+	cooldown := b.cooldown
+	for i := 1; i < state.Failures && cooldown < maxLoginCooldown; i++ {
+		cooldown *= 2
+	}
+	if cooldown > maxLoginCooldown {
+		cooldown = maxLoginCooldown
+	}
+	if retryAt := state.LastFailure.Add(cooldown); time.Now().Before(retryAt) {
+		return &LoginBackoffError{Failures: state.Failures, RetryAt: retryAt}
+	}
+	return nil
+}
+
+// record stores the outcome of a login. Only rejected credentials count as
+// failures, network errors leave the state untouched.
+func (b *loginBackoff) record(identifier string, loginErr error) error {
+	rejected := errors.Is(loginErr, ErrAuthenticationFailed) ||
+		errors.Is(loginErr, ErrAccountLocked) ||
+		errors.Is(loginErr, ErrPasswordExpired)
+	if loginErr != nil && !rejected {
+		return nil
+	}
+	return b.update(func(states map[string]loginState) bool {
+		if loginErr == nil {
+			if _, ok := states[identifier]; !ok {
+				return false
+			}
+			delete(states, identifier)
+			return true
+		}
+		state := states[identifier]
+		state.Failures++
+		state.LastFailure = time.Now().UTC()
+		states[identifier] = state
+		return true
+	})
+}
@@ -0,0 +1,66 @@
+package strato
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoginBackoff(t *testing.T) {
+	backoff := &loginBackoff{path: filepath.Join(t.TempDir(), "state", "logins.json"), cooldown: time.Minute}
+	if err := backoff.check(testIdentifier); err != nil {
+		t.Fatalf("got %v without failed logins, want nil", err)
+	}
+	if err := backoff.record(testIdentifier, errors.New("network down")); err != nil {
+		t.Fatal(err)
+	}
+	if err := backoff.check(testIdentifier); err != nil {
+		t.Errorf("got %v after a network error, want nil", err)
+	}
+
+	if err := backoff.record(testIdentifier, ErrAuthenticationFailed); err != nil {
+		t.Fatal(err)
+	}
+	var backoffErr *LoginBackoffError
+	if err := backoff.check(testIdentifier); !errors.As(err, &backoffErr) || backoffErr.Failures != 1 {
+		t.Fatalf("got %v after a rejected login, want a backoff after 1 failure", err)
+	}
+	if err := backoff.check("other"); err != nil {
+		t.Errorf("got %v for another identifier, want nil", err)
+	}
+
+	if err := backoff.record(testIdentifier, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := backoff.check(testIdentifier); err != nil {
+		t.Errorf("got %v after a successful login, want nil", err)
+	}
+}
+
+func TestLoginBackoffConcurrentFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logins.json")
+	const failures = 20
+	var wg sync.WaitGroup
+	for range failures {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Every failure goes through a backoff of its own, like separate processes
+			backoff := &loginBackoff{path: path, cooldown: time.Minute}
+			if err := backoff.record(testIdentifier, ErrAuthenticationFailed); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	states, err := (&loginBackoff{path: path}).load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := states[testIdentifier].Failures; got != failures {
+		t.Errorf("got %d failures, want %d", got, failures)
+	}
+}
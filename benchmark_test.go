@@ -0,0 +1,146 @@
+package strato
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// benchmarkSizes are the numbers of records of the benchmarked zones
+var benchmarkSizes = []int{10, 100, 500}
+
+// largeConfig returns a configuration with n records of mixed types
+func largeConfig(n int) DNSConfig {
+	config := DNSConfig{DMARCType: "strato", SPFType: "none"}
+	for i := 0; i < n; i++ {
+		var record DNSRecord
+		switch i % 4 {
+		case 0:
+			record = DNSRecord{Type: "TXT", Prefix: fmt.Sprintf("_acme-challenge.host%d", i), Value: strings.Repeat("x", 43)}
+		case 1:
+			record = DNSRecord{Type: "CNAME", Prefix: fmt.Sprintf("host%d", i), Value: "example.com."}
+		case 2:
+			record = DNSRecord{Type: "TXT", Prefix: fmt.Sprintf("sel%d._domainkey", i), Value: "v=DKIM1; k=rsa; p=" + strings.Repeat("A", 392)}
+		case 3:
+			record = DNSRecord{Type: "MX", Prefix: fmt.Sprintf("sub%d", i), Value: fmt.Sprintf("%d mx.example.net.", i%50)}
+		}
+		config.Records = append(config.Records, record)
+	}
+	return config
+}
+
+// txtFormPage renders config like the TXT record form of the portal. Mail settings
+// that are empty are left out, like on the forms of packages without them.
+func txtFormPage(config DNSConfig) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<!DOCTYPE html><html lang="de"><head><meta charset="utf-8"><title>TXT- und CNAME-Records verwalten</title></head><body><main id="content">`)
+	b.WriteString(`<form id="jss_txt_record_form" method="post" action="/apps/CustomerService">`)
+	for _, setting := range []struct{ name, value string }{{"dmarc_type", config.DMARCType}, {"spf_type", config.SPFType}} {
+		if setting.value == "" {
+			continue
+		}
+		b.WriteString(`<fieldset class="mail-settings">`)
+		for _, value := range []string{"strato", "none"} {
+			checked := ""
+			if value == setting.value {
+				checked = " checked"
+			}
+			fmt.Fprintf(&b, `<label><input type="radio" name="%s" value="%s"%s> %s</label>`, setting.name, value, checked, value)
+		}
+		b.WriteString(`</fieldset>`)
+	}
+	b.WriteString(`<div id="jss_txt_container" data-max-records="1000">`)
+	for _, record := range config.Records {
+		b.WriteString(`<div class="txt-record-tmpl row"><select name="type">`)
+		for _, recordType := range []string{"TXT", "CNAME", "MX"} {
+			selected := ""
+			if recordType == record.Type {
+				selected = " selected"
+			}
+			fmt.Fprintf(&b, `<option value="%s"%s>%s</option>`, recordType, selected, recordType)
+		}
+		fmt.Fprintf(&b, `</select><input type="text" name="prefix" value="%s"><textarea name="value">%s</textarea></div>`,
+			html.EscapeString(record.Prefix), html.EscapeString(record.Value))
+	}
+	b.WriteString(`</div><input type="submit" name="action_change_txt_records" value="Einstellung übernehmen"></form></main></body></html>`)
+	return b.Bytes()
+}
+
+func BenchmarkParseDNSConfiguration(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		page := txtFormPage(largeConfig(n))
+		b.Run(fmt.Sprintf("records=%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(page)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := parseDNSConfiguration(bytes.NewReader(page), false); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDiffConfigs(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		oldConfig := largeConfig(n)
+		// Every tenth record changes its value
+		newConfig := largeConfig(n)
+		newConfig.Records = append([]DNSRecord(nil), newConfig.Records...)
+		for i := 0; i < n; i += 10 {
+			newConfig.Records[i].Value += "-changed"
+		}
+		b.Run(fmt.Sprintf("records=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if diff := DiffConfigs(oldConfig, newConfig); diff.Empty() {
+					b.Fatal("no difference found")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkNewZone(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		config := largeConfig(n)
+		b.Run(fmt.Sprintf("records=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				NewZone(config)
+			}
+		})
+	}
+}
+
+func BenchmarkWriteSnapshot(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		snapshot := Snapshot{Domain: "example.com", Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Config: largeConfig(n)}
+		b.Run(fmt.Sprintf("records=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := WriteSnapshot(io.Discard, snapshot); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWriteRecordsCSV(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		records := largeConfig(n).Records
+		b.Run(fmt.Sprintf("records=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := WriteRecordsCSV(io.Discard, records); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
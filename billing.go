@@ -0,0 +1,77 @@
+package strato
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// accountCID is the cID of pages that belong to the account rather than a package
+const accountCID = "0"
+
+// Invoice is a billing document of the account
+type Invoice struct {
+	ID   string    `json:"id"`
+	Date time.Time `json:"date"`
+	// Amount is the total as displayed by the portal, including currency
+	Amount string `json:"amount"`
+}
+
+// ListInvoices retrieves the invoices of the account dated between from and to (inclusive).
+// A zero from or to leaves that end of the range open.
+func (c *StratoClient) ListInvoices(from, to time.Time) ([]Invoice, error) {
+	doc, err := c.fetchPackagePage(accountCID, c.region.InvoiceNode)
+	if err != nil {
+		return nil, err
+	}
+	table := htmlquery.FindOne(doc, "//table[@id='jss_invoice_table']")
+	if table == nil {
+		return nil, errors.New("failed to find invoice table")
+	}
+	var invoices []Invoice
+	for _, row := range htmlquery.Find(table, ".//tr[@data-invoice-id]") {
+		date, err := parsePortalDate(textOf(row, ".//td[contains(@class, 'date')]"))
+		if err != nil {
+			return nil, err
+		}
+		if (!from.IsZero() && date.Before(from)) || (!to.IsZero() && date.After(to)) {
+			continue
+		}
+		invoices = append(invoices, Invoice{
+			ID:     htmlquery.SelectAttr(row, "data-invoice-id"),
+			Date:   date,
+			Amount: textOf(row, ".//td[contains(@class, 'amount')]"),
+		})
+	}
+	return invoices, nil
+}
+
+// DownloadInvoice writes the PDF document of an invoice to w
+func (c *StratoClient) DownloadInvoice(id string, w io.Writer) error {
+	if id == "" {
+		return errors.New("invoice id must not be empty")
+	}
+	req, err := c.newRequest("GET", c.portalURL(accountCID, c.region.InvoiceNode, "action_download_invoice", "invoice_id="+url.QueryEscape(id)), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.session.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("unexpected response status: " + resp.Status)
+	}
+	// The portal answers unknown invoices with an HTML page instead of a PDF
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/pdf") {
+		return errors.New("invoice " + id + " not available, got " + contentType)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
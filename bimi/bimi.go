@@ -0,0 +1,149 @@
+// Package bimi creates and validates BIMI records, which point mail clients to the
+// brand logo of a domain.
+package bimi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// DefaultSelector is used by mail clients unless a message names another selector
+const DefaultSelector = "default"
+
+// Record is a BIMI assertion record
+type Record struct {
+	// Selector defaults to DefaultSelector
+	Selector string
+	// Logo is the HTTPS URL of the SVG Tiny PS logo
+	Logo string
+	// Authority is the HTTPS URL of the Verified Mark Certificate (PEM); optional,
+	// but most mailbox providers only show logos with one
+	Authority string
+}
+
+// Prefix returns the record name below the domain
+func (r Record) Prefix() string {
+	selector := r.Selector
+	if selector == "" {
+		selector = DefaultSelector
+	}
+	return selector + "._bimi"
+}
+
+// String formats the record as TXT value
+func (r Record) String() string {
+	value := "v=BIMI1; l=" + r.Logo
+	if r.Authority != "" {
+		value += "; a=" + r.Authority
+	}
+	return value
+}
+
+// Validate checks the URLs of the record without fetching them
+func (r Record) Validate() error {
+	if strings.ContainsAny(r.Selector, " ;.") {
+		return fmt.Errorf("invalid bimi selector: %q", r.Selector)
+	}
+	if r.Logo == "" {
+		return errors.New("bimi record needs a logo URL")
+	}
+	if err := validateURL("logo", r.Logo, ".svg"); err != nil {
+		return err
+	}
+	if r.Authority != "" {
+		if err := validateURL("vmc", r.Authority, ".pem"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateURL(kind, rawURL, extension string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid %s URL: %w", kind, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%s URL must use https: %s", kind, rawURL)
+	}
+	if !strings.EqualFold(path.Ext(u.Path), extension) {
+		return fmt.Errorf("%s URL must point to a %s file: %s", kind, extension, rawURL)
+	}
+	if strings.ContainsAny(rawURL, ";,") {
+		return fmt.Errorf("%s URL must not contain ';' or ',': %s", kind, rawURL)
+	}
+	return nil
+}
+
+// Verify fetches the logo and certificate and checks that they are an SVG Tiny PS
+// image and a PEM certificate
+func (r Record) Verify(ctx context.Context, client *http.Client) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	logo, err := fetch(ctx, client, r.Logo)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(logo, "<svg") {
+		return fmt.Errorf("logo is not an SVG image: %s", r.Logo)
+	}
+	if !strings.Contains(logo, "tiny-ps") {
+		return fmt.Errorf("logo does not use the SVG Tiny PS profile: %s", r.Logo)
+	}
+	if r.Authority != "" {
+		vmc, err := fetch(ctx, client, r.Authority)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(vmc, "-----BEGIN CERTIFICATE-----") {
+			return fmt.Errorf("vmc is not a PEM certificate: %s", r.Authority)
+		}
+	}
+	return nil
+}
+
+// fetch returns up to 1 MiB of the body at rawURL
+func fetch(ctx context.Context, client *http.Client, rawURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch %s: %s", rawURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return string(body), err
+}
+
+// Apply validates the URLs of record and publishes it as the only TXT record of its
+// selector. The logo and certificate are not fetched, call Verify for that first.
+// It returns false without writing if the selector already has exactly this record.
+func Apply(ctx context.Context, client *strato.StratoClient, record Record) (bool, error) {
+	if err := record.Validate(); err != nil {
+		return false, err
+	}
+	desired := strato.DNSRecord{Type: "TXT", Prefix: record.Prefix(), Value: record.String()}
+	changed := false
+	err := client.WithContext(ctx).UpdateZone(func(zone *strato.Zone) (bool, error) {
+		changed = zone.Replace(desired, nil)
+		return changed, nil
+	})
+	return changed, err
+}
@@ -0,0 +1,130 @@
+package bimi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/internal/stratotest"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		record Record
+		prefix string
+		want   string
+	}{
+		{Record{Logo: "https://example.com/logo.svg"}, "default._bimi", "v=BIMI1; l=https://example.com/logo.svg"},
+		{
+			Record{Selector: "brand", Logo: "https://example.com/logo.svg", Authority: "https://example.com/vmc.pem"},
+			"brand._bimi",
+			"v=BIMI1; l=https://example.com/logo.svg; a=https://example.com/vmc.pem",
+		},
+	}
+	for _, test := range tests {
+		if got := test.record.Prefix(); got != test.prefix {
+			t.Errorf("got prefix %s, want %s", got, test.prefix)
+		}
+		if got := test.record.String(); got != test.want {
+			t.Errorf("got %s\nwant %s", got, test.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		record Record
+		valid  bool
+	}{
+		{"logo", Record{Logo: "https://example.com/logo.svg"}, true},
+		{"logo and certificate", Record{Logo: "https://example.com/logo.SVG", Authority: "https://example.com/vmc.pem"}, true},
+		{"missing logo", Record{Authority: "https://example.com/vmc.pem"}, false},
+		{"http logo", Record{Logo: "http://example.com/logo.svg"}, false},
+		{"png logo", Record{Logo: "https://example.com/logo.png"}, false},
+		{"logo with semicolon", Record{Logo: "https://example.com/a;b.svg"}, false},
+		{"certificate with comma", Record{Logo: "https://example.com/logo.svg", Authority: "https://example.com/a,b.pem"}, false},
+		{"der certificate", Record{Logo: "https://example.com/logo.svg", Authority: "https://example.com/vmc.der"}, false},
+		{"selector with dot", Record{Selector: "a.b", Logo: "https://example.com/logo.svg"}, false},
+	}
+	for _, test := range tests {
+		if err := test.record.Validate(); (err == nil) != test.valid {
+			t.Errorf("%s: Validate() = %v, want valid %t", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	pages := map[string]string{
+		"/logo.svg":       `<svg xmlns="http://www.w3.org/2000/svg" version="1.2" baseProfile="tiny-ps"></svg>`,
+		"/plain.svg":      `<svg xmlns="http://www.w3.org/2000/svg"></svg>`,
+		"/image.svg":      `PNG`,
+		"/vmc.pem":        "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
+		"/not-a-cert.pem": "-----BEGIN PUBLIC KEY-----\n",
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		logo, authority string
+		valid           bool
+	}{
+		{"/logo.svg", "", true},
+		{"/logo.svg", "/vmc.pem", true},
+		{"/plain.svg", "", false},
+		{"/image.svg", "", false},
+		{"/missing.svg", "", false},
+		{"/logo.svg", "/not-a-cert.pem", false},
+		{"/logo.svg", "/missing.pem", false},
+	}
+	for _, test := range tests {
+		record := Record{Logo: server.URL + test.logo}
+		if test.authority != "" {
+			record.Authority = server.URL + test.authority
+		}
+		if err := record.Verify(context.Background(), server.Client()); (err == nil) != test.valid {
+			t.Errorf("Verify(%s, %s) = %v, want valid %t", test.logo, test.authority, err, test.valid)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	portal := stratotest.NewPortal(t)
+	portal.SetConfig("example.com", strato.DNSConfig{Records: []strato.DNSRecord{
+		{Type: "TXT", Prefix: "default._bimi", Value: "v=BIMI1; l=https://example.com/old.svg"},
+		{Type: "TXT", Prefix: "brand._bimi", Value: "v=BIMI1; l=https://example.com/brand.svg"},
+	}})
+	client := portal.Client(t)
+	record := Record{Logo: "https://example.com/logo.svg"}
+
+	if _, err := Apply(context.Background(), client, Record{Logo: "http://example.com/logo.svg"}); err == nil {
+		t.Error("applied an invalid record")
+	}
+	changed, err := Apply(context.Background(), client, record)
+	if err != nil || !changed {
+		t.Fatalf("Apply = %t, %v, want a change", changed, err)
+	}
+	zone := strato.NewZone(portal.Config("example.com"))
+	if got := zone.ByPrefix("default._bimi"); len(got) != 1 || got[0].Value != record.String() {
+		t.Errorf("got records %v for the default selector, want only %s", got, record)
+	}
+	if got := zone.ByPrefix("brand._bimi"); len(got) != 1 {
+		t.Errorf("got records %v for another selector, want it kept", got)
+	}
+
+	if changed, err := Apply(context.Background(), client, record); err != nil || changed {
+		t.Errorf("repeated Apply = %t, %v, want no change", changed, err)
+	}
+	if portal.Writes() != 1 {
+		t.Errorf("got %d writes, want 1", portal.Writes())
+	}
+}
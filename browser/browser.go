@@ -0,0 +1,131 @@
+// Package browser logs in to the Strato portal with a headless Chrome or Chromium
+// controlled through chromedp. It is a fallback for when Strato gates the login behind
+// JavaScript checks that the form login of the client cannot pass:
+//
+//	client, err := strato.NewStratoClient("", identifier, password, order, domain,
+//		strato.WithDriver(browser.Driver{}))
+//
+// The package is a module of its own, so only programs using it depend on chromedp.
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+	"github.com/fl0eb/go-strato"
+)
+
+// Driver is a strato.LoginDriver that fills in the login form in a browser and hands
+// the session of the browser over to the client
+type Driver struct {
+	// ExecPath of the browser, found in the usual locations if empty
+	ExecPath string
+	// Headful shows the browser window, e.g. to watch a login that fails
+	Headful bool
+	// Region names the fields of the login form, RegionDE if unset
+	Region strato.Region
+	// Timeout bounds the whole login, two minutes by default
+	Timeout time.Duration
+}
+
+// Login opens the login page at api, submits the credentials and waits until the
+// portal redirects to a page with a session ID
+func (d Driver) Login(ctx context.Context, api, identifier, password string) (strato.PortalSession, error) {
+	region := d.Region
+	if region.IdentifierField == "" {
+		region = strato.RegionDE
+	}
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	opts := append([]chromedp.ExecAllocatorOption(nil), chromedp.DefaultExecAllocatorOptions[:]...)
+	if d.ExecPath != "" {
+		opts = append(opts, chromedp.ExecPath(d.ExecPath))
+	}
+	if d.Headful {
+		opts = append(opts, chromedp.Flag("headless", false))
+	}
+	allocator, cancel := chromedp.NewExecAllocator(ctx, opts...)
+	defer cancel()
+	browser, cancel := chromedp.NewContext(allocator)
+	defer cancel()
+
+	identifierField := `input[name="` + region.IdentifierField + `"]`
+	passwordField := `input[name="` + region.PasswordField + `"]`
+	var sessionID string
+	var cookies []*network.Cookie
+	err := chromedp.Run(browser,
+		chromedp.Navigate(api),
+		chromedp.WaitVisible(identifierField, chromedp.ByQuery),
+		chromedp.SendKeys(identifierField, identifier, chromedp.ByQuery),
+		// Enter submits the form like its login button would
+		chromedp.SendKeys(passwordField, password+kb.Enter, chromedp.ByQuery),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			sessionID, err = waitForSession(ctx)
+			return err
+		}),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			cookies, err = network.GetCookies().WithURLs([]string{api}).Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return strato.PortalSession{}, fmt.Errorf("browser login failed: %w", err)
+	}
+	session := strato.PortalSession{ID: sessionID}
+	for _, cookie := range cookies {
+		session.Cookies = append(session.Cookies, httpCookie(cookie))
+	}
+	return session, nil
+}
+
+// waitForSession polls the location of the browser until it carries a session ID
+func waitForSession(ctx context.Context) (string, error) {
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		var location string
+		if err := chromedp.Location(&location).Do(ctx); err != nil {
+			return "", err
+		}
+		if parsed, err := url.Parse(location); err == nil {
+			if id := parsed.Query().Get("sessionID"); id != "" {
+				return id, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return "", errors.New("portal did not open a session, check the credentials")
+		case <-ticker.C:
+		}
+	}
+}
+
+// httpCookie converts a cookie of the browser for the cookie jar of the client
+func httpCookie(cookie *network.Cookie) *http.Cookie {
+	converted := &http.Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Domain:   cookie.Domain,
+		Path:     cookie.Path,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HTTPOnly,
+	}
+	if !cookie.Session && cookie.Expires > 0 {
+		converted.Expires = time.Unix(int64(cookie.Expires), 0)
+	}
+	return converted
+}
@@ -0,0 +1,64 @@
+package strato
+
+import (
+	"sync"
+	"time"
+)
+
+// GetOption modifies a single GetDNSConfiguration call
+type GetOption func(*getOptions)
+
+type getOptions struct {
+	forceRefresh bool
+}
+
+// ForceRefresh bypasses the read cache and fetches the configuration from the portal
+func ForceRefresh() GetOption {
+	return func(o *getOptions) {
+		o.forceRefresh = true
+	}
+}
+
+// configCache holds the last fetched DNS configuration of every domain for a limited
+// time. It is shared by the clients returned by ForDomain, ForPackage and WithContext,
+// so a write through one of them invalidates the copy the others would read.
+type configCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	config  DNSConfig
+	fetched time.Time
+}
+
+func newConfigCache(ttl time.Duration) *configCache {
+	return &configCache{ttl: ttl, entries: map[string]cacheEntry{}}
+}
+
+// get returns a copy of the cached configuration of domain if it has not expired
+func (cc *configCache) get(domain string) (DNSConfig, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	entry, ok := cc.entries[domain]
+	if !ok || time.Since(entry.fetched) > cc.ttl {
+		return DNSConfig{}, false
+	}
+	config := entry.config
+	config.Records = append([]DNSRecord(nil), entry.config.Records...)
+	return config, true
+}
+
+func (cc *configCache) set(domain string, config DNSConfig) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	config.Records = append([]DNSRecord(nil), config.Records...)
+	cc.entries[domain] = cacheEntry{config: config, fetched: time.Now()}
+}
+
+func (cc *configCache) invalidate(domain string) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	delete(cc.entries, domain)
+}
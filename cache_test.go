@@ -0,0 +1,81 @@
+package strato
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal, WithCache(time.Hour))
+	reads := func() int {
+		portal.mu.Lock()
+		defer portal.mu.Unlock()
+		return len(portal.txtReads)
+	}
+
+	config, err := client.GetDNSConfiguration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The cache hands out copies
+	first := config.Records[0]
+	config.Records[0].Value = "changed"
+	cached, err := client.WithContext(context.Background()).GetDNSConfiguration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reads() != 1 {
+		t.Errorf("got %d reads, want 1", reads())
+	}
+	if cached.Records[0] != first {
+		t.Errorf("got record %v from the cache, want %v", cached.Records[0], first)
+	}
+
+	if _, err := client.GetDNSConfiguration(ForceRefresh()); err != nil {
+		t.Fatal(err)
+	}
+	if reads() != 2 {
+		t.Errorf("got %d reads with ForceRefresh, want 2", reads())
+	}
+
+	// Other domains have entries of their own
+	if _, err := client.ForDomain("example.net").GetDNSConfiguration(); err != nil {
+		t.Fatal(err)
+	}
+	if reads() != 3 {
+		t.Errorf("got %d reads for another domain, want 3", reads())
+	}
+
+	// A write through a derived client invalidates the entry the others read
+	record := DNSRecord{Type: "TXT", Prefix: "_acme-challenge", Value: "token"}
+	_, err = client.ForDomain("example.com").UpdateDNSConfiguration(func(current DNSConfig) (DNSConfig, error) {
+		current.Records = append(current.Records, record)
+		return current, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err = client.GetDNSConfiguration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !NewZone(config).Contains(record) {
+		t.Errorf("got records %v after the write, want %v among them", config.Records, record)
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal, WithCache(10*time.Millisecond))
+	for range 2 {
+		if _, err := client.GetDNSConfiguration(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if len(portal.txtReads) != 2 {
+		t.Errorf("got %d reads, want 2", len(portal.txtReads))
+	}
+}
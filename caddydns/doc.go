@@ -0,0 +1,21 @@
+// Package caddydns is a Caddy module that lets Caddy solve ACME DNS-01 challenges
+// for domains at Strato. It registers dns.providers.strato, configured in a
+// Caddyfile as
+//
+//	tls {
+//		dns strato {
+//			identifier {env.STRATO_IDENTIFIER}
+//			password {env.STRATO_PASSWORD}
+//			order 123456789
+//			region de
+//		}
+//	}
+//
+// The package is a module of its own, so the rest of go-strato does without Caddy
+// and its dependencies. It requires go-strato by version like any other module, so
+// changes to go-strato reach it with go get github.com/fl0eb/go-strato@<commit>,
+// and a tag of go-strato comes before the caddydns/vX.Y.Z that needs it. Build Caddy
+// with it using xcaddy:
+//
+//	xcaddy build --with github.com/fl0eb/go-strato/caddydns
+package caddydns
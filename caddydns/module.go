@@ -0,0 +1,76 @@
+package caddydns
+
+import (
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+)
+
+func init() {
+	caddy.RegisterModule(&Provider{})
+}
+
+// CaddyModule returns the module information of dns.providers.strato
+func (*Provider) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "dns.providers.strato",
+		New: func() caddy.Module { return new(Provider) },
+	}
+}
+
+// Provision replaces placeholders such as {env.STRATO_PASSWORD} in the settings
+func (p *Provider) Provision(ctx caddy.Context) error {
+	repl := caddy.NewReplacer()
+	p.Identifier = repl.ReplaceAll(p.Identifier, "")
+	p.Password = repl.ReplaceAll(p.Password, "")
+	p.Order = repl.ReplaceAll(p.Order, "")
+	p.Region = repl.ReplaceAll(p.Region, "")
+	return nil
+}
+
+// UnmarshalCaddyfile reads the settings of the provider:
+//
+//	strato {
+//		identifier <identifier>
+//		password <password>
+//		order <order number>
+//		region <de|nl|se|uk>
+//	}
+func (p *Provider) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
+	for d.Next() {
+		if d.NextArg() {
+			return d.ArgErr()
+		}
+		for nesting := d.Nesting(); d.NextBlock(nesting); {
+			var target *string
+			switch d.Val() {
+			case "identifier":
+				target = &p.Identifier
+			case "password":
+				target = &p.Password
+			case "order":
+				target = &p.Order
+			case "region":
+				target = &p.Region
+			default:
+				return d.Errf("unrecognized subdirective '%s'", d.Val())
+			}
+			if !d.NextArg() {
+				return d.ArgErr()
+			}
+			*target = d.Val()
+			if d.NextArg() {
+				return d.ArgErr()
+			}
+		}
+	}
+	if p.Identifier == "" || p.Password == "" {
+		return d.Err("identifier and password are required")
+	}
+	return nil
+}
+
+// Interface guards
+var (
+	_ caddyfile.Unmarshaler = (*Provider)(nil)
+	_ caddy.Provisioner     = (*Provider)(nil)
+)
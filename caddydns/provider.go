@@ -0,0 +1,200 @@
+package caddydns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/migrate"
+	"github.com/libdns/libdns"
+)
+
+// Provider implements the libdns interfaces on top of the Strato portal. Strato does
+// not let customers set TTLs, so they are ignored when records are written.
+type Provider struct {
+	Identifier string `json:"identifier,omitempty"`
+	Password   string `json:"password,omitempty"`
+	// Order is the package order number, found from the zone if empty
+	Order string `json:"order,omitempty"`
+	// Region is the portal variant: de, nl, se or uk
+	Region string `json:"region,omitempty"`
+
+	mu      sync.Mutex
+	clients map[string]*strato.StratoClient
+}
+
+// client returns the logged in client of zone, creating it on first use
+func (p *Provider) client(zone string) (*strato.StratoClient, error) {
+	zone = strings.TrimSuffix(strings.ToLower(zone), ".")
+	if client, ok := p.clients[zone]; ok {
+		return client, nil
+	}
+	regionName := p.Region
+	if regionName == "" {
+		regionName = "de"
+	}
+	region, err := strato.RegionByName(regionName)
+	if err != nil {
+		return nil, err
+	}
+	client, err := strato.NewStratoClient("", p.Identifier, p.Password, p.Order, zone, strato.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	if p.clients == nil {
+		p.clients = map[string]*strato.StratoClient{}
+	}
+	p.clients[zone] = client
+	return client, nil
+}
+
+// GetRecords lists all records of zone
+func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	client, err := p.client(zone)
+	if err != nil {
+		return nil, err
+	}
+	config, err := client.GetDNSConfiguration()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]libdns.Record, 0, len(config.Records))
+	for _, record := range config.Records {
+		records = append(records, fromStrato(record))
+	}
+	return records, nil
+}
+
+// AppendRecords adds records to zone and returns those that were missing
+func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return p.change(zone, func(z *strato.Zone) ([]libdns.Record, error) {
+		var added []libdns.Record
+		for _, record := range records {
+			converted, err := toStrato(record)
+			if err != nil {
+				return nil, err
+			}
+			if z.Add(converted) {
+				added = append(added, record)
+			}
+		}
+		return added, nil
+	})
+}
+
+// SetRecords makes records the only records of their name and type in zone
+func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return p.change(zone, func(z *strato.Zone) ([]libdns.Record, error) {
+		converted := make([]strato.DNSRecord, 0, len(records))
+		for _, record := range records {
+			c, err := toStrato(record)
+			if err != nil {
+				return nil, err
+			}
+			converted = append(converted, c)
+		}
+		for _, record := range converted {
+			z.RemoveMatching(func(existing strato.DNSRecord) bool {
+				return existing.Type == record.Type && existing.Prefix == record.Prefix
+			})
+		}
+		for _, record := range converted {
+			z.Add(record)
+		}
+		return records, nil
+	})
+}
+
+// DeleteRecords removes records from zone and returns those that were present
+func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return p.change(zone, func(z *strato.Zone) ([]libdns.Record, error) {
+		var deleted []libdns.Record
+		for _, record := range records {
+			converted, err := toStrato(record)
+			if err != nil {
+				return nil, err
+			}
+			if z.Remove(converted) {
+				deleted = append(deleted, record)
+			}
+		}
+		return deleted, nil
+	})
+}
+
+// change applies modify to the current zone and saves it if records were changed
+func (p *Provider) change(zone string, modify func(*strato.Zone) ([]libdns.Record, error)) ([]libdns.Record, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	client, err := p.client(zone)
+	if err != nil {
+		return nil, err
+	}
+	var changed []libdns.Record
+	err = client.UpdateZone(func(z *strato.Zone) (bool, error) {
+		before := z.Config()
+		var err error
+		if changed, err = modify(z); err != nil {
+			return false, err
+		}
+		return !strato.DiffConfigs(before, z.Config()).Empty(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// fromStrato converts a Strato record into the typed record of libdns, with the
+// trailing dot libdns expects of host names
+func fromStrato(record strato.DNSRecord) libdns.Record {
+	rr := libdns.RR{Type: record.Type, Name: record.Prefix, Data: record.Value}
+	if rr.Name == "" {
+		rr.Name = "@"
+	}
+	switch record.Type {
+	case "CNAME", "MX", "SRV":
+		if !strings.HasSuffix(rr.Data, ".") {
+			rr.Data += "."
+		}
+	}
+	if parsed, err := rr.Parse(); err == nil {
+		return parsed
+	}
+	return rr
+}
+
+// toStrato converts a libdns record, whose Name is relative to the zone
+func toStrato(record libdns.Record) (strato.DNSRecord, error) {
+	rr := record.RR()
+	converted := strato.DNSRecord{
+		Type:   strings.ToUpper(rr.Type),
+		Prefix: strings.TrimSuffix(rr.Name, "."),
+		Value:  rr.Data,
+	}
+	if converted.Prefix == "@" {
+		converted.Prefix = ""
+	}
+	switch converted.Type {
+	case "CNAME", "MX", "SRV":
+		converted.Value = strings.TrimSuffix(rr.Data, ".")
+	}
+	for _, supported := range migrate.SupportedTypes {
+		if converted.Type == supported {
+			return converted, nil
+		}
+	}
+	return strato.DNSRecord{}, fmt.Errorf("record type %s is not supported by Strato", rr.Type)
+}
+
+// Interface guards
+var (
+	_ libdns.RecordGetter   = (*Provider)(nil)
+	_ libdns.RecordAppender = (*Provider)(nil)
+	_ libdns.RecordSetter   = (*Provider)(nil)
+	_ libdns.RecordDeleter  = (*Provider)(nil)
+)
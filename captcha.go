@@ -0,0 +1,84 @@
+package strato
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// CaptchaChallenge is a CAPTCHA presented by the login page
+type CaptchaChallenge struct {
+	// ImageURL is the absolute URL of the challenge image
+	ImageURL string
+	// Image holds the challenge image, if it could be downloaded
+	Image []byte
+	// Field is the name of the form field the answer is submitted in
+	Field string
+}
+
+// SolverFunc answers a CAPTCHA challenge, e.g. by prompting the user or asking an
+// external solving service
+type SolverFunc func(ctx context.Context, challenge CaptchaChallenge) (string, error)
+
+// CaptchaError is returned when the login page asks for a CAPTCHA that was not solved
+type CaptchaError struct {
+	Challenge CaptchaChallenge
+}
+
+func (e *CaptchaError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrCaptchaRequired, e.Challenge.ImageURL)
+}
+
+func (e *CaptchaError) Unwrap() error {
+	return ErrCaptchaRequired
+}
+
+// findCaptcha looks for a CAPTCHA on the login page
+func findCaptcha(page []byte, base string) (CaptchaChallenge, bool) {
+	doc, err := htmlquery.Parse(bytes.NewReader(page))
+	if err != nil {
+		return CaptchaChallenge{}, false
+	}
+	input := htmlquery.FindOne(doc, "//input[contains(@name, 'captcha')]")
+	if input == nil {
+		return CaptchaChallenge{}, false
+	}
+	challenge := CaptchaChallenge{Field: htmlquery.SelectAttr(input, "name")}
+	src := attrOf(doc, "//img[contains(@src, 'captcha') or contains(@id, 'captcha') or contains(@class, 'captcha')]", "src")
+	if baseURL, err := url.Parse(base); err == nil && src != "" {
+		if imageURL, err := baseURL.Parse(src); err == nil {
+			challenge.ImageURL = imageURL.String()
+		}
+	}
+	return challenge, true
+}
+
+// solveCaptcha asks the configured solver for the answer to challenge and submits
+// the login form again with it
+func (c *StratoClient) solveCaptcha(challenge CaptchaChallenge, form url.Values) error {
+	if challenge.ImageURL != "" {
+		// The challenge is bound to the session, so the image is fetched with its cookies
+		image, err := c.fetchRaw(challenge.ImageURL)
+		if err != nil {
+			return fmt.Errorf("failed to fetch CAPTCHA image: %w", err)
+		}
+		challenge.Image = image
+	}
+	logFor(LogAuth).Info("Login requires a CAPTCHA, asking solver")
+	answer, err := c.captchaSolver(c.requestContext(), challenge)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCaptchaRequired, err)
+	}
+	answered := maps.Clone(form)
+	answered.Set(challenge.Field, answer)
+	resp, err := c.postLogin(answered)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return c.loginResult(resp, form, false)
+}
@@ -0,0 +1,98 @@
+package strato
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// Certificate is an SSL certificate provisioned by Strato
+type Certificate struct {
+	Issuer string    `json:"issuer"`
+	Expiry time.Time `json:"expiry"`
+	// Names lists the domain names covered by the certificate
+	Names []string `json:"names"`
+}
+
+// ExpiresWithin reports whether the certificate expires within d from now
+func (cert Certificate) ExpiresWithin(d time.Duration) bool {
+	return time.Until(cert.Expiry) < d
+}
+
+// portalDateFormats are the date layouts used by the portal variants
+var portalDateFormats = []string{"02.01.2006", "2006-01-02", "02-01-2006", "02/01/2006"}
+
+// parsePortalDate parses a date as displayed by the portal
+func parsePortalDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range portalDateFormats {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unrecognized date: " + value)
+}
+
+// GetCertificates retrieves the SSL certificates Strato has provisioned for domain
+func (c *StratoClient) GetCertificates(domain string) ([]Certificate, error) {
+	doc, err := c.fetchPage(c.region.SSLNode, "vhost="+domain)
+	if err != nil {
+		return nil, err
+	}
+	table := htmlquery.FindOne(doc, "//table[@id='jss_ssl_table']")
+	if table == nil {
+		return nil, errors.New("failed to find certificate table")
+	}
+	var certificates []Certificate
+	for _, row := range htmlquery.Find(table, ".//tr[@data-certificate]") {
+		expiry, err := parsePortalDate(textOf(row, ".//td[contains(@class, 'expiry')]"))
+		if err != nil {
+			return nil, err
+		}
+		certificate := Certificate{
+			Issuer: textOf(row, ".//td[contains(@class, 'issuer')]"),
+			Expiry: expiry,
+		}
+		for _, name := range strings.Split(textOf(row, ".//td[contains(@class, 'names')]"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				certificate.Names = append(certificate.Names, name)
+			}
+		}
+		certificates = append(certificates, certificate)
+	}
+	return certificates, nil
+}
+
+// InstallCertificate uploads an externally obtained certificate with its private key and
+// intermediate chain to the SSL manager of domain. The key pair and the covered names are
+// checked locally before anything is submitted.
+func (c *StratoClient) InstallCertificate(domain string, certPEM, keyPEM, chainPEM []byte) error {
+	pair, err := tls.X509KeyPair(append(append([]byte{}, certPEM...), chainPEM...), keyPEM)
+	if err != nil {
+		return fmt.Errorf("invalid certificate or key: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return err
+	}
+	if err := leaf.VerifyHostname(domain); err != nil {
+		return err
+	}
+	if time.Now().After(leaf.NotAfter) {
+		return errors.New("certificate expired on " + leaf.NotAfter.Format(time.DateOnly))
+	}
+
+	form := url.Values{}
+	form.Set("vhost", domain)
+	form.Set("certificate", string(certPEM))
+	form.Set("private_key", string(keyPEM))
+	form.Set("intermediate", string(chainPEM))
+	form.Set("action_install_certificate", "1")
+	return c.submitForm(c.region.SSLNode, form)
+}
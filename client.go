@@ -2,80 +2,174 @@ package strato
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"strings"
 
 	"github.com/antchfx/htmlquery"
-	"k8s.io/klog/v2"
+	"golang.org/x/net/html"
 )
 
 type DNSConfig struct {
-	DMARCType string
-	SPFType   string
-	Records   []DNSRecord
+	// DMARCType and SPFType are empty for packages whose TXT form has no mail settings
+	DMARCType string      `json:"dmarcType"`
+	SPFType   string      `json:"spfType"`
+	Records   []DNSRecord `json:"records"`
+	// Warnings lists the parts of the page that could not be read in lenient mode
+	Warnings []string `json:"-"`
+	// MaxRecords is the number of records the tariff allows, 0 if the page does not tell
+	MaxRecords int `json:"-"`
 }
 
 type DNSRecord struct {
-	Type   string
-	Prefix string
-	Value  string
+	Type   string `json:"type"`
+	Prefix string `json:"prefix"`
+	Value  string `json:"value"`
 }
 
 type StratoClient struct {
 	api        string
 	identifier string
-	password   string
 	order      string
 	domain     string
 	cID        string
+	region     Region
 	session    *http.Client
-	sessionID  string
+	auth       *authState
+	// transport and limiter are set by options and put together with the other
+	// layers of session.Transport once all options are applied
+	transport http.RoundTripper
+	limiter   limiter
+
+	verifyAfterWrite bool
+	stateDir         string
+	rawOrder         bool
+	annotations      AnnotationStore
+	annotationOwner  string
+	cache            *configCache
+	auditLog         AuditLogger
+	auditActor       string
+	auditRevision    string
+	streamingParser  bool
+	lenient          bool
+	backoff          *loginBackoff
+	driver           LoginDriver
+	captchaSolver    SolverFunc
+	sessionStore     CredentialStore
+	writeLock        Locker
+	readOnly         bool
+	dryRun           bool
+	maxResponseSize  int64
+	status           *StatusTracker
+	history          HistoryRecorder
+	protected        []RecordFilter
+	force            bool
+	recordQuota      int
+	language         Language
+	resolvers        []string
+	ctx              context.Context
 }
 
-// NewStratoClient initializes and returns a new StratoClient instance
-func NewStratoClient(api, identifier, password, order, domain string) (*StratoClient, error) {
+// NewStratoClient initializes and returns a new StratoClient instance.
+// If api is empty, the portal URL of the selected region is used.
+func NewStratoClient(api, identifier, password, order, domain string, opts ...Option) (*StratoClient, error) {
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		return nil, err
 	}
 
 	client := &StratoClient{
-		api:        api,
-		identifier: identifier,
-		password:   password,
-		order:      order,
-		domain:     domain,
+		api:             api,
+		identifier:      identifier,
+		order:           order,
+		domain:          domain,
+		region:          RegionDE,
+		auth:            &authState{password: password},
+		maxResponseSize: DefaultMaxResponseSize,
 		session: &http.Client{
-			Jar: jar,
+			Jar:     jar,
+			Timeout: DefaultRequestTimeout,
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				// Prevent following redirects
 				return http.ErrUseLastResponse
 			},
 		},
 	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	if client.api == "" {
+		client.api = client.region.API
+	}
+	transport := client.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	transport = &loggingTransport{next: &contextTransport{next: transport}}
+	if client.limiter != nil {
+		transport = &rateLimitedTransport{next: transport, limiter: client.limiter}
+	}
+	client.session.Transport = &limitedTransport{next: transport, limit: client.maxResponseSize}
 
-	// Authenticate during initialization
-	if err := client.authenticate(); err != nil {
-		return nil, err
+	// Authenticate during initialization, unless a stored session is still valid
+	if !client.restoreSession() {
+		if err := client.login(); err != nil {
+			return nil, err
+		}
 	}
 
-	// Find cID
-	if err := client.populatePackageID(); err != nil {
-		return nil, err
+	// Find cID, unless it was given with WithPackageID
+	if client.cID == "" {
+		if client.order == "" {
+			if client.order, err = client.ResolveOrderForDomain(domain); err != nil {
+				return nil, err
+			}
+		}
+		if err := client.populatePackageID(); err != nil {
+			return nil, err
+		}
 	}
 	return client, nil
 }
 
+// login authenticates, respecting the cool-down after failed logins set up with WithLoginBackoff
+func (c *StratoClient) login() error {
+	if c.backoff == nil {
+		if err := c.authenticate(); err != nil {
+			return err
+		}
+		c.saveSession()
+		return nil
+	}
+	identifier := normalizeIdentifier(c.identifier)
+	if err := c.backoff.check(identifier); err != nil {
+		return err
+	}
+	err := c.authenticate()
+	if recordErr := c.backoff.record(identifier, err); recordErr != nil {
+		logFor(LogAuth).Error("Failed to record login attempt", "error", recordErr)
+	}
+	if err == nil {
+		c.saveSession()
+	}
+	return err
+}
+
 // authenticate sends credentials to a webform and stores session cookies
 func (c *StratoClient) authenticate() error {
+	if c.driver != nil {
+		return c.driverLogin()
+	}
 	// We need to establish a session first.
 	// This is done by sending a GET request to the login page.
 	// The server will respond with a Set-Cookie header containing the session ID.
 	// We need to store this cookie in the cookie jar for subsequent requests.
-	req, err := http.NewRequest("GET", c.api, nil)
+	req, err := c.newRequest("GET", c.api, nil)
 	if err != nil {
 		return err
 	}
@@ -88,32 +182,43 @@ func (c *StratoClient) authenticate() error {
 	cookies := resp.Header.Values("Set-Cookie")
 	for _, cookie := range cookies {
 		if strings.Contains(cookie, "ksb_session") {
-			klog.V(6).Infof("ksb id Cookie: %s", cookie)
+			logFor(LogAuth).Log(context.Background(), LevelTrace, "Received session cookie", "cookie", cookie)
 			break
 		}
 	}
 
 	// Now we can send the login form data to the server.
-	form := []string{}
-	form = append(form, "identifier="+c.identifier)
-	form = append(form, "passwd="+c.password)
-	form = append(form, "action_customer_login.x=Login")
-	queryString := strings.Join(form, "&")
+	logFor(LogAuth).Debug("Logging in")
+	form := url.Values{}
+	form.Set(c.region.IdentifierField, normalizeIdentifier(c.identifier))
+	form.Set(c.region.PasswordField, c.password())
+	action, label, _ := strings.Cut(c.region.LoginAction, "=")
+	form.Set(action, label)
 
-	req, err = http.NewRequest("POST", c.api, bytes.NewBufferString(queryString))
+	resp, err = c.postLogin(form)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
+	return c.loginResult(resp, form, true)
+}
+
+// postLogin submits the login form
+func (c *StratoClient) postLogin(form url.Values) (*http.Response, error) {
+	req, err := c.newRequest("POST", c.api, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
 	// Set the Content-Type header to application/x-www-form-urlencoded
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	// Send the request
-	resp, err = c.session.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	return c.session.Do(req)
+}
 
+// loginResult evaluates the answer to the login form. If the portal asks for a
+// CAPTCHA and solve is set, the solver configured with WithCaptchaSolver is asked.
+func (c *StratoClient) loginResult(resp *http.Response, form url.Values, solve bool) error {
 	if resp.StatusCode == http.StatusFound { // 302
 		// Strato uses a 302 redirect for successful login
 		// The user is redirected to the dashboard page
@@ -122,84 +227,203 @@ func (c *StratoClient) authenticate() error {
 		if err != nil {
 			return err
 		}
-		c.sessionID = parsedURL.Query().Get("sessionID")
-		if c.sessionID == "" {
+		sessionID := parsedURL.Query().Get("sessionID")
+		if sessionID == "" {
 			return errors.New("sessionID not found in redirect URL")
 		}
-		klog.V(6).Infof("Session ID: %s", c.sessionID)
+		c.setSessionID(sessionID)
+		logFor(LogAuth).Log(context.Background(), LevelTrace, "Logged in", "sessionID", sessionID)
 		return nil
 	} else if resp.StatusCode == http.StatusOK { // 200
 		// If the status code is 200, it means the login failed
 		// and the user is presented with the same login page again
-		return errors.New("authentication failed")
+		page, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if challenge, ok := findCaptcha(page, c.api); ok {
+			if !solve || c.captchaSolver == nil {
+				return &CaptchaError{Challenge: challenge}
+			}
+			return c.solveCaptcha(challenge, form)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(page))
+		return loginError(resp)
 	}
 	return errors.New("unexpected response status: " + resp.Status)
 }
 
+// loginError extracts the reason for a failed login from the returned login page
+func loginError(resp *http.Response) error {
+	message := errorBanner(resp)
+	if message == "" {
+		return ErrAuthenticationFailed
+	}
+	return fmt.Errorf("%w: %s", classifyLoginError(message), message)
+}
+
+// errorBanner returns the text of the error message shown on a portal page, if any
+func errorBanner(resp *http.Response) string {
+	doc, err := htmlquery.Parse(resp.Body)
+	if err != nil {
+		return ""
+	}
+	if message, saved := banner(doc); !saved {
+		return message
+	}
+	return ""
+}
+
+// PackageID returns the cID of the package the client works on
+func (c *StratoClient) PackageID() string {
+	return c.cID
+}
+
+// ForPackage returns a client sharing the session of c that works on the package
+// with the given cID, e.g. for accounts where the domain is not in the first contract
+func (c *StratoClient) ForPackage(cID string) *StratoClient {
+	clone := *c
+	clone.cID = cID
+	return &clone
+}
+
 func (c *StratoClient) populatePackageID() error {
+	cID, err := c.packageID(c.order)
+	if err != nil {
+		return err
+	}
+	c.cID = cID
+	return nil
+}
+
+// ResolveOrderForDomain returns the order number of the package on the entry page
+// that contains domain. Subdomains such as www.example.de are not listed there, so
+// the parent domains are tried in turn if no package lists domain itself.
+func (c *StratoClient) ResolveOrderForDomain(domain string) (string, error) {
+	doc, err := c.fetchPackagePage("0", c.region.EntryNode)
+	if err != nil {
+		return "", err
+	}
+	pkgNodes := htmlquery.Find(doc, "//*[@data-pkg-name-order]")
+	for name := strings.TrimSuffix(domain, "."); strings.Contains(name, "."); name = name[strings.Index(name, ".")+1:] {
+		for _, pkgNode := range pkgNodes {
+			if packageContains(pkgNode, name) {
+				return htmlquery.SelectAttr(pkgNode, "data-pkg-name-order"), nil
+			}
+		}
+	}
+	return "", errors.New("failed to find a package containing " + domain)
+}
+
+// packageContains reports whether the package node of the entry page lists domain
+func packageContains(pkgNode *html.Node, domain string) bool {
+	if htmlquery.FindOne(pkgNode, ".//*[@data-domain="+xpathLiteral(domain)+"]") != nil {
+		return true
+	}
+	for _, word := range strings.Fields(htmlquery.InnerText(pkgNode)) {
+		if strings.EqualFold(word, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// packageID looks up the cID of the package with the given order number on the entry page
+func (c *StratoClient) packageID(order string) (string, error) {
 	getURL := c.api +
-		"?sessionID=" + c.sessionID +
+		"?sessionID=" + c.sessionID() +
 		"&cID=0" +
-		"&node=kds_CustomerEntryPage"
+		"&node=" + c.region.EntryNode
 
 	// Create a new HTTP request
-	req, err := http.NewRequest("GET", getURL, nil)
+	req, err := c.newRequest("GET", getURL, nil)
 	if err != nil {
-		return nil
+		return "", err
 	}
 	// Send the request
 	resp, err := c.session.Do(req)
 	if err != nil {
-		return nil
+		return "", err
 	}
 	doc, err := htmlquery.Parse(resp.Body)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer resp.Body.Close()
 	// Find a table row with the order name first
-	pkgNode := htmlquery.FindOne(doc, "//tr[@data-pkg-name-order='"+c.order+"']")
+	pkgNode := htmlquery.FindOne(doc, "//tr[@data-pkg-name-order="+xpathLiteral(order)+"]")
 	// Find a div with the order name
 	if pkgNode == nil {
-		pkgNode = htmlquery.FindOne(doc, "//div[@data-pkg-name-order='"+c.order+"']")
+		pkgNode = htmlquery.FindOne(doc, "//div[@data-pkg-name-order="+xpathLiteral(order)+"]")
 	}
 	if pkgNode == nil {
-		return errors.New("failed to find order")
+		return "", errors.New("failed to find order")
 	}
 	linkNode := htmlquery.FindOne(pkgNode, ".//a")
 	if linkNode == nil {
-		return errors.New("failed to find link")
+		return "", errors.New("failed to find link")
 	}
 	link := htmlquery.SelectAttr(linkNode, "href")
 	if link == "" {
-		return errors.New("failed to find link value")
+		return "", errors.New("failed to find link value")
 	}
 	// Extract the cID from the link
 	parts := strings.Split(link, "&")
 	for _, part := range parts {
 		if strings.HasPrefix(part, "cID=") {
-			cID := strings.TrimPrefix(part, "cID=")
-			c.cID = cID
-			break
+			if cID := strings.TrimPrefix(part, "cID="); cID != "" {
+				return cID, nil
+			}
+		}
+	}
+	return "", errors.New("failed to find cID in link")
+}
+
+// GetDNSConfiguration retrieves the DNS configuration of the domain.
+// With WithCache, a cached copy may be returned unless ForceRefresh is passed.
+func (c *StratoClient) GetDNSConfiguration(opts ...GetOption) (DNSConfig, error) {
+	if c.cache == nil {
+		return c.fetchDNSConfiguration()
+	}
+	options := getOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if !options.forceRefresh {
+		if config, ok := c.cache.get(c.domain); ok {
+			logFor(LogScrape).Debug("Using cached DNS configuration")
+			return config, nil
 		}
 	}
-	if c.cID == "" {
-		return errors.New("failed to find cID in link")
+	config, err := c.fetchDNSConfiguration()
+	if err != nil {
+		return DNSConfig{}, err
 	}
-	return nil
+	c.cache.set(c.domain, config)
+	return config, nil
 }
 
-// getDNSRecords retrieves DNS records from the website
-func (c *StratoClient) GetDNSConfiguration() (DNSConfig, error) {
+// fetchDNSConfiguration retrieves DNS records from the website, logging in again if
+// the session expired
+func (c *StratoClient) fetchDNSConfiguration() (DNSConfig, error) {
+	var config DNSConfig
+	err := c.retryExpired(func() (err error) {
+		config, err = c.fetchDNSConfigurationOnce()
+		return err
+	})
+	return config, err
+}
+
+func (c *StratoClient) fetchDNSConfigurationOnce() (DNSConfig, error) {
 	getURL := c.api +
-		"?sessionID=" + c.sessionID +
+		"?sessionID=" + c.sessionID() +
 		"&cID=" + c.cID +
-		"&node=ManageDomains" +
+		"&node=" + c.region.ManageDomainsNode +
 		"&action_show_txt_records" +
 		"&vhost=" + c.domain
 
 	// Create a new HTTP request
-	req, err := http.NewRequest("GET", getURL, nil)
+	req, err := c.newRequest("GET", getURL, nil)
 	if err != nil {
 		return DNSConfig{}, err
 	}
@@ -208,85 +432,233 @@ func (c *StratoClient) GetDNSConfiguration() (DNSConfig, error) {
 	if err != nil {
 		return DNSConfig{}, err
 	}
-	if resp.StatusCode != http.StatusOK {
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusFound {
+		return DNSConfig{}, ErrSessionExpired
+	} else if resp.StatusCode != http.StatusOK {
 		return DNSConfig{}, errors.New("failed to fetch TXT records")
 	}
 
-	doc, err := htmlquery.Parse(resp.Body)
+	var config DNSConfig
+	if c.streamingParser {
+		config, err = parseDNSConfigurationStream(resp.Body, c.lenient)
+	} else {
+		config, err = parseDNSConfiguration(resp.Body, c.lenient)
+	}
+	if errors.Is(err, errTXTFormNotFound) {
+		return DNSConfig{}, c.vhostError(err)
+	} else if err != nil {
+		return DNSConfig{}, err
+	}
+	for _, warning := range config.Warnings {
+		logFor(LogScrape).Warn("Incomplete DNS configuration", "domain", c.domain, "warning", warning)
+	}
+	if !c.rawOrder {
+		// Strato returns records in arbitrary order
+		SortRecords(config.Records)
+	}
+	c.recordHistory(config, HistoryObserved)
+	return config, nil
+}
+
+// problem returns an error for message, or records it as a warning of config in lenient mode
+func problem(config *DNSConfig, lenient bool, message string) error {
+	if !lenient {
+		return fmt.Errorf("%w: %s", ErrUnexpectedPage, message)
+	}
+	config.Warnings = append(config.Warnings, message)
+	return nil
+}
+
+// parseDNSConfiguration extracts the configuration from the TXT record page
+func parseDNSConfiguration(r io.Reader, lenient bool) (DNSConfig, error) {
+	doc, err := htmlquery.Parse(r)
 	if err != nil {
 		return DNSConfig{}, err
 	}
-	defer resp.Body.Close()
 
 	config := DNSConfig{}
 
 	form := htmlquery.FindOne(doc, "//form[@id='jss_txt_record_form']")
 	if form == nil {
-		return DNSConfig{}, errors.New("failed to find form element")
+		return DNSConfig{}, errTXTFormNotFound
 	}
 
-	dmarcNode := htmlquery.FindOne(form, "//input[@name='dmarc_type' and @checked]")
-	if dmarcNode == nil {
-		return DNSConfig{}, errors.New("failed to find dmarc_type element")
-	}
-	dmarcType := htmlquery.SelectAttr(dmarcNode, "value")
-	if dmarcType == "" {
-		return DNSConfig{}, errors.New("failed to find dmarc_type value")
+	if htmlquery.FindOne(form, "//input[@name='dmarc_type']") == nil {
+		logFor(LogScrape).Debug("TXT form has no DMARC settings")
+	} else if dmarcNode := htmlquery.FindOne(form, "//input[@name='dmarc_type' and @checked]"); dmarcNode == nil {
+		if err := problem(&config, lenient, "failed to find dmarc_type element"); err != nil {
+			return DNSConfig{}, err
+		}
+	} else if config.DMARCType = htmlquery.SelectAttr(dmarcNode, "value"); config.DMARCType == "" {
+		if err := problem(&config, lenient, "failed to find dmarc_type value"); err != nil {
+			return DNSConfig{}, err
+		}
 	}
-	config.DMARCType = dmarcType
 
-	spfNode := htmlquery.FindOne(form, "//input[@name='spf_type' and @checked]")
-	if spfNode == nil {
-		return DNSConfig{}, errors.New("failed to find spf_type element")
-	}
-	spfType := htmlquery.SelectAttr(spfNode, "value")
-	if spfType == "" {
-		return DNSConfig{}, errors.New("failed to find spf_type value")
+	if htmlquery.FindOne(form, "//input[@name='spf_type']") == nil {
+		logFor(LogScrape).Debug("TXT form has no SPF settings")
+	} else if spfNode := htmlquery.FindOne(form, "//input[@name='spf_type' and @checked]"); spfNode == nil {
+		if err := problem(&config, lenient, "failed to find spf_type element"); err != nil {
+			return DNSConfig{}, err
+		}
+	} else if config.SPFType = htmlquery.SelectAttr(spfNode, "value"); config.SPFType == "" {
+		if err := problem(&config, lenient, "failed to find spf_type value"); err != nil {
+			return DNSConfig{}, err
+		}
 	}
-	config.SPFType = spfType
+
+	config.MaxRecords = maxRecords(attrOf(form, "//div[@id='jss_txt_container']", "data-max-records"))
 
 	var records []DNSRecord
 	recordNodes := htmlquery.Find(form, "//div[@id='jss_txt_container']/div[contains(@class, 'txt-record-tmpl')]")
-	for _, recordNode := range recordNodes {
+	for i, recordNode := range recordNodes {
 		recordTypeNode := htmlquery.FindOne(recordNode, ".//select[@name='type']/option[@selected]")
-		recordPrefixNode := htmlquery.FindOne(recordNode, ".//input[@name='prefix']")
 		recordValueNode := htmlquery.FindOne(recordNode, ".//textarea[@name='value']")
 
 		if recordTypeNode != nil && recordValueNode != nil {
 			record := DNSRecord{
 				Type:   htmlquery.SelectAttr(recordTypeNode, "value"),
-				Prefix: htmlquery.SelectAttr(recordPrefixNode, "value"),
+				Prefix: attrOf(recordNode, ".//input[@name='prefix']", "value"),
 				Value:  htmlquery.InnerText(recordValueNode),
 			}
 			records = append(records, record)
+		} else if lenient {
+			config.Warnings = append(config.Warnings, fmt.Sprintf("skipped record %d without type or value", i+1))
 		}
 	}
 	config.Records = records
 	return config, nil
 }
 
+// SetDNSConfiguration replaces the DNS configuration of the domain. To change the
+// current configuration, use UpdateZone, which keeps the write lock from the read on.
 func (c *StratoClient) SetDNSConfiguration(config DNSConfig) error {
+	if proceed, err := c.checkWrite("set DNS configuration"); !proceed {
+		return err
+	}
+	unlock, err := c.lockWrites()
+	if err != nil {
+		return err
+	}
+	return errors.Join(c.writeDNSConfiguration(config), unlock())
+}
+
+// UpdateDNSConfiguration fetches the configuration, passes it to update and writes
+// the configuration update returns if it differs. The write lock of WithWriteLock is
+// held from the read to the write, so changes other replicas make in the meantime are
+// not overwritten. It returns the changes that were written.
+func (c *StratoClient) UpdateDNSConfiguration(update func(current DNSConfig) (DNSConfig, error)) (ConfigDiff, error) {
+	unlock, err := c.lockWrites()
+	if err != nil {
+		return ConfigDiff{}, err
+	}
+	diff, err := c.updateDNSConfiguration(update)
+	return diff, errors.Join(err, unlock())
+}
+
+func (c *StratoClient) updateDNSConfiguration(update func(DNSConfig) (DNSConfig, error)) (ConfigDiff, error) {
+	current, err := c.GetDNSConfiguration(ForceRefresh())
+	if err != nil {
+		return ConfigDiff{}, err
+	}
+	desired, err := update(current)
+	if err != nil {
+		return ConfigDiff{}, err
+	}
+	diff := DiffConfigs(current, desired)
+	if diff.Empty() {
+		return diff, nil
+	}
+	if proceed, err := c.checkWrite("set DNS configuration"); !proceed {
+		return diff, err
+	}
+	return diff, c.writeDNSConfiguration(desired)
+}
+
+// writeDNSConfiguration replaces the DNS configuration while the caller holds the
+// write lock
+func (c *StratoClient) writeDNSConfiguration(config DNSConfig) error {
+	var previous Snapshot
+	// With a history, the snapshot records changes made elsewhere before this one
+	if c.stateDir != "" || c.annotations != nil || c.auditLog != nil || c.history != nil || len(c.protected) > 0 {
+		var err error
+		if previous, err = c.TakeSnapshot(); err != nil {
+			return err
+		}
+	}
+	if c.stateDir != "" {
+		path, err := SaveSnapshot(c.stateDir, previous)
+		if err != nil {
+			return err
+		}
+		logFor(LogForm).Debug("Saved previous configuration", "path", path)
+	}
+	if err := c.checkProtected(DiffConfigs(previous.Config, config)); err != nil {
+		return err
+	}
+	if err := c.checkQuota(config, previous.Config); err != nil {
+		return err
+	}
+	if c.cache != nil {
+		c.cache.invalidate(c.domain)
+	}
+	err := c.postDNSConfiguration(config)
+	if auditErr := c.audit(DiffConfigs(previous.Config, config), err); auditErr != nil {
+		logFor(LogForm).Error("Failed to write audit log", "error", auditErr)
+	}
+	if err != nil {
+		return err
+	}
+	c.recordHistory(config, HistoryApplied)
+	if c.annotations != nil {
+		if err := c.updateAnnotations(DiffConfigs(previous.Config, config)); err != nil {
+			return err
+		}
+	}
+	if !c.verifyAfterWrite {
+		return nil
+	}
+	actual, err := c.GetDNSConfiguration(ForceRefresh())
+	if err != nil {
+		return err
+	}
+	if diff := DiffConfigs(config, actual); !diff.Empty() {
+		return &VerificationError{Diff: diff}
+	}
+	return nil
+}
+
+// postDNSConfiguration submits the TXT record form
+func (c *StratoClient) postDNSConfiguration(config DNSConfig) error {
 	setURL := c.api +
-		"?sessionID=" + c.sessionID +
+		"?sessionID=" + c.sessionID() +
 		"&cID=" + c.cID +
 		"&action_change_txt_records"
 
-	form := []string{}
-	form = append(form, "sessionID="+c.sessionID)
-	form = append(form, "cID="+c.cID)
-	form = append(form, "node=ManageDomains")
-	form = append(form, "vhost="+c.domain)
-	form = append(form, "dmarc_type="+config.DMARCType)
-	form = append(form, "spf_type="+config.SPFType)
+	form := url.Values{}
+	form.Set("sessionID", c.sessionID())
+	form.Set("cID", c.cID)
+	form.Set("node", c.region.ManageDomainsNode)
+	form.Set("vhost", c.domain)
+	// Packages without mail settings have no such fields, and submitting
+	// empty values would break the settings of those that do
+	if config.DMARCType != "" {
+		form.Set("dmarc_type", config.DMARCType)
+	}
+	if config.SPFType != "" {
+		form.Set("spf_type", config.SPFType)
+	}
+	// Values keep the order of repeated fields, so the n-th type, prefix and value
+	// still belong to the same record
 	for _, record := range config.Records {
-		form = append(form, "type="+record.Type)
-		form = append(form, "prefix="+record.Prefix)
-		form = append(form, "value="+record.Value)
+		form.Add("type", record.Type)
+		form.Add("prefix", record.Prefix)
+		form.Add("value", record.Value)
 	}
-	form = append(form, "action_change_txt_records=Einstellung übernehmen")
-	queryString := strings.Join(form, "&")
+	form.Set("action_change_txt_records", c.applyLabel())
 
-	req, err := http.NewRequest("POST", setURL, bytes.NewBufferString(queryString))
+	req, err := c.newRequest("POST", setURL, strings.NewReader(form.Encode()))
 	if err != nil {
 		return err
 	}
@@ -306,7 +678,7 @@ func (c *StratoClient) SetDNSConfiguration(config DNSConfig) error {
 	} else if resp.StatusCode == http.StatusOK { // 200
 		// If the status code is 200, it means the update failed
 		// and the user is presented with the same page again
-		return errors.New("update failed")
+		return dnsUpdateError(resp.Body, len(config.Records))
 	}
 	return errors.New("unexpected response status: " + resp.Status)
 }
@@ -0,0 +1,180 @@
+package strato
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mailSettingsTests are the TXT record forms of packages with and without mail settings
+var mailSettingsTests = []struct {
+	fixture         string
+	dmarcType       string
+	spfType         string
+	hasMailSettings bool
+}{
+	{"txt_form.html", "strato", "none", true},
+	{"txt_form_no_mail.html", "", "", false},
+}
+
+func TestParseDNSConfigurationMailSettings(t *testing.T) {
+	parsers := map[string]func(*bytes.Reader, bool) (DNSConfig, error){
+		"htmlquery": func(r *bytes.Reader, lenient bool) (DNSConfig, error) { return parseDNSConfiguration(r, lenient) },
+		"stream":    func(r *bytes.Reader, lenient bool) (DNSConfig, error) { return parseDNSConfigurationStream(r, lenient) },
+	}
+	for _, test := range mailSettingsTests {
+		for name, parse := range parsers {
+			t.Run(test.fixture+"/"+name, func(t *testing.T) {
+				config, err := parse(bytes.NewReader(fixture(t, test.fixture)), false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if config.DMARCType != test.dmarcType || config.SPFType != test.spfType {
+					t.Errorf("got DMARC %q and SPF %q, want %q and %q", config.DMARCType, config.SPFType, test.dmarcType, test.spfType)
+				}
+				if !reflect.DeepEqual(config.Records, txtFormConfig.Records) {
+					t.Errorf("got records %v, want %v", config.Records, txtFormConfig.Records)
+				}
+				if len(config.Warnings) > 0 {
+					t.Errorf("got warnings %v", config.Warnings)
+				}
+			})
+		}
+	}
+}
+
+func TestSetDNSConfigurationMailSettings(t *testing.T) {
+	for _, test := range mailSettingsTests {
+		t.Run(test.fixture, func(t *testing.T) {
+			portal := newTestPortal(t, RegionDE)
+			portal.setPage(txtFormNode, test.fixture)
+			client := newTestClient(t, portal)
+
+			_, err := client.UpdateDNSConfiguration(func(current DNSConfig) (DNSConfig, error) {
+				current.Records = append(current.Records, DNSRecord{Type: "TXT", Prefix: "_acme-challenge", Value: "token"})
+				return current, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			form, _ := portal.lastTXTForm()
+			for field, want := range map[string]string{"dmarc_type": test.dmarcType, "spf_type": test.spfType} {
+				if form.Has(field) != test.hasMailSettings {
+					t.Errorf("%s submitted: %v, want %v", field, form.Has(field), test.hasMailSettings)
+				}
+				if got := form.Get(field); got != want {
+					t.Errorf("got %s %q, want %q", field, got, want)
+				}
+			}
+			if got := len(form["type"]); got != len(txtFormConfig.Records)+1 {
+				t.Errorf("got %d records, want %d", got, len(txtFormConfig.Records)+1)
+			}
+		})
+	}
+}
+
+func TestPackageIDFromEntryPage(t *testing.T) {
+	tests := []struct {
+		fixture string
+		cID     string
+	}{
+		{"entry.html", "1"},
+		// The package of the domain is not the first contract of the account
+		{"entry_contracts.html", "3"},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			portal := newTestPortal(t, RegionDE)
+			portal.setPage(RegionDE.EntryNode, test.fixture)
+			if cID := newTestClient(t, portal).PackageID(); cID != test.cID {
+				t.Errorf("got package ID %q, want %q", cID, test.cID)
+			}
+		})
+	}
+}
+
+// TestSetDNSConfigurationPackageID makes sure the TXT record form is read and posted
+// for the selected package, in the query and in the form body alike
+func TestSetDNSConfigurationPackageID(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		client func(*StratoClient) *StratoClient
+		cID    string
+	}{
+		{name: "from entry page", cID: "3"},
+		{name: "WithPackageID", opts: []Option{WithPackageID("7")}, cID: "7"},
+		{name: "ForPackage", client: func(c *StratoClient) *StratoClient { return c.ForPackage("5") }, cID: "5"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			portal := newTestPortal(t, RegionDE)
+			portal.setPage(RegionDE.EntryNode, "entry_contracts.html")
+			client := newTestClient(t, portal, test.opts...)
+			if test.client != nil {
+				client = test.client(client)
+			}
+
+			config, err := client.GetDNSConfiguration()
+			if err != nil {
+				t.Fatal(err)
+			}
+			config.Records = append(config.Records, DNSRecord{Type: "TXT", Prefix: "_acme-challenge", Value: "token"})
+			if err := client.SetDNSConfiguration(config); err != nil {
+				t.Fatal(err)
+			}
+
+			form, query := portal.lastTXTForm()
+			if got := form.Get("cID"); got != test.cID {
+				t.Errorf("posted form with cID %q, want %q", got, test.cID)
+			}
+			if got := query.Get("cID"); got != test.cID {
+				t.Errorf("posted to cID %q, want %q", got, test.cID)
+			}
+			for _, read := range portal.txtReads {
+				if got := read.Get("cID"); got != test.cID {
+					t.Errorf("read TXT records of cID %q, want %q", got, test.cID)
+				}
+			}
+		})
+	}
+}
+
+// countingTransport counts the requests it passes on to http.DefaultTransport
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// TestTransportOptionOrder makes sure WithTransport and WithRateLimit take effect in
+// either order
+func TestTransportOptionOrder(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	for _, transportFirst := range []bool{true, false} {
+		portal := newTestPortal(t, RegionDE)
+		transport := &countingTransport{}
+		opts := []Option{WithRateLimit(interval), WithTransport(transport)}
+		if transportFirst {
+			opts[0], opts[1] = opts[1], opts[0]
+		}
+		start := time.Now()
+		client := newTestClient(t, portal, opts...)
+		if _, err := client.GetDNSConfiguration(); err != nil {
+			t.Fatal(err)
+		}
+		requests := int(transport.requests.Load())
+		if requests < 3 {
+			t.Errorf("transport first: %v: transport saw %d requests", transportFirst, requests)
+		}
+		if elapsed := time.Since(start); elapsed < time.Duration(requests-1)*interval {
+			t.Errorf("transport first: %v: %d requests took %s, want them rate limited", transportFirst, requests, elapsed)
+		}
+	}
+}
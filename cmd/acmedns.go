@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/acmedns"
+	"k8s.io/klog/v2"
+)
+
+// acmeDNSOptions are the flags of the acme-dns command
+type acmeDNSOptions struct {
+	listen   string
+	domain   string
+	prefix   string
+	accounts string
+	// registerFrom are the networks allowed to register, or "none"
+	registerFrom []string
+	maxAccounts  int
+}
+
+// runACMEDNSCommand serves the acme-dns API until SIGINT or SIGTERM
+func runACMEDNSCommand(client *strato.StratoClient, o acmeDNSOptions) {
+	if o.accounts == "" {
+		fatal("--acme-dns-accounts is required for acme-dns command")
+	}
+	opts := []acmedns.Option{acmedns.WithMaxAccounts(o.maxAccounts)}
+	switch {
+	case len(o.registerFrom) == 1 && o.registerFrom[0] == "none":
+		opts = append(opts, acmedns.WithoutRegistration())
+	case len(o.registerFrom) > 0:
+		opts = append(opts, acmedns.WithRegistrationFrom(o.registerFrom))
+	}
+	api, err := acmedns.New(client, o.domain, o.prefix, o.accounts, opts...)
+	if err != nil {
+		fatalf("Failed to load acme-dns accounts: %v", err)
+	}
+	httpServer := &http.Server{
+		Addr:              o.listen,
+		Handler:           api,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+	klog.V(2).Infof("Serving acme-dns API for %s on %s", api.FullDomain("<subdomain>"), o.listen)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatalf("Failed to serve acme-dns API: %v", err)
+	}
+}
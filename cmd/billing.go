@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// runBillingCommand executes the invoices-* commands for invoices dated between since and until
+func runBillingCommand(client *strato.StratoClient, command, since, until, invoiceDir string) {
+	from := parseDate("since", since)
+	to := parseDate("until", until)
+	invoices, err := client.ListInvoices(from, to)
+	if err != nil {
+		fatalf("Failed to fetch invoices: %v", err)
+	}
+	switch command {
+	case "invoices-list":
+		rows := make([][]string, 0, len(invoices))
+		for _, invoice := range invoices {
+			rows = append(rows, []string{invoice.ID, invoice.Date.Format(time.DateOnly), invoice.Amount})
+		}
+		if err := printRows(os.Stdout, []string{"id", "date", "amount"}, rows); err != nil {
+			fatalf("Failed to print invoices: %v", err)
+		}
+	case "invoices-download":
+		if err := os.MkdirAll(invoiceDir, 0o755); err != nil {
+			fatalf("Failed to create invoice directory: %v", err)
+		}
+		for _, invoice := range invoices {
+			path := filepath.Join(invoiceDir, invoice.Date.Format(time.DateOnly)+"-"+invoice.ID+".pdf")
+			if _, err := os.Stat(path); err == nil {
+				klog.V(4).Infof("Skipping %s, already downloaded", path)
+				continue
+			}
+			downloadInvoice(client, invoice.ID, path)
+			klog.V(2).Infof("Downloaded %s", path)
+		}
+	}
+}
+
+// downloadInvoice stores an invoice at path without leaving partial files behind
+func downloadInvoice(client *strato.StratoClient, id, path string) {
+	file, err := os.Create(path + ".part")
+	if err != nil {
+		fatalf("Failed to create %s: %v", path, err)
+	}
+	if err := client.DownloadInvoice(id, file); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		fatalf("Failed to download invoice %s: %v", id, err)
+	}
+	if err := file.Close(); err != nil {
+		fatalf("Failed to write %s: %v", path, err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// parseDate parses an optional YYYY-MM-DD flag value
+func parseDate(name, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		fatalf("Invalid --%s date, use YYYY-MM-DD: %v", name, err)
+	}
+	return date
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/bimi"
+	"k8s.io/klog/v2"
+)
+
+// runBIMISetCommand checks the logo and certificate and writes the BIMI record
+func runBIMISetCommand(client *strato.StratoClient, record bimi.Record, verify bool) {
+	ctx := context.Background()
+	if verify {
+		if err := record.Verify(ctx, nil); err != nil {
+			fatalf("Failed to verify BIMI record: %v", err)
+		}
+	}
+	changed, err := bimi.Apply(ctx, client, record)
+	if err != nil {
+		fatalf("Failed to set BIMI record: %v", err)
+	}
+	if changed {
+		klog.V(2).Infof("BIMI record %s set to %s", record.Prefix(), record)
+	} else {
+		klog.V(2).Infof("BIMI record %s already %s", record.Prefix(), record)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// promptCaptcha saves the challenge image to a temporary file and reads the answer from stdin
+func promptCaptcha(_ context.Context, challenge strato.CaptchaChallenge) (string, error) {
+	if len(challenge.Image) > 0 {
+		file, err := os.CreateTemp("", "strato-captcha-*.png")
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		if _, err := file.Write(challenge.Image); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(os.Stderr, "CAPTCHA saved to %s\n", file.Name())
+	} else {
+		fmt.Fprintf(os.Stderr, "CAPTCHA: %s\n", challenge.ImageURL)
+	}
+	fmt.Fprint(os.Stderr, "Answer: ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/acme"
+	"k8s.io/klog/v2"
+)
+
+// runCertsCommand executes the certs-* commands
+func runCertsCommand(client *strato.StratoClient, command, domain string, warnBefore time.Duration, certFile, keyFile, chainFile string) {
+	switch command {
+	case "certs-list":
+		certificates, err := client.GetCertificates(domain)
+		if err != nil {
+			fatalf("Failed to fetch certificates: %v", err)
+		}
+		rows := make([][]string, 0, len(certificates))
+		for _, certificate := range certificates {
+			names := strings.Join(certificate.Names, ", ")
+			if certificate.ExpiresWithin(warnBefore) {
+				klog.Warningf("Certificate for %s expires on %s", names, certificate.Expiry.Format(time.DateOnly))
+			}
+			rows = append(rows, []string{names, certificate.Issuer, certificate.Expiry.Format(time.DateOnly)})
+		}
+		if err := printRows(os.Stdout, []string{"names", "issuer", "expiry"}, rows); err != nil {
+			fatalf("Failed to print certificates: %v", err)
+		}
+	case "certs-install":
+		if certFile == "" || keyFile == "" {
+			fatal("--cert-file and --key-file are required for certs-install command")
+		}
+		certPEM := readFile(certFile)
+		keyPEM := readFile(keyFile)
+		var chainPEM []byte
+		if chainFile != "" {
+			chainPEM = readFile(chainFile)
+		}
+		if err := client.InstallCertificate(domain, certPEM, keyPEM, chainPEM); err != nil {
+			fatalf("Failed to install certificate: %v", err)
+		}
+		klog.V(2).Infof("Certificate installed for %s", domain)
+	}
+}
+
+// certsObtainOptions are the flags of the certs-obtain command
+type certsObtainOptions struct {
+	domain     string
+	names      []string
+	email      string
+	directory  string
+	accountKey string
+	certFile   string
+	keyFile    string
+	wait       time.Duration
+	check      string
+}
+
+// runCertsObtainCommand obtains a certificate from an ACME CA, answering the DNS-01
+// challenges with records at Strato, and writes the certificate chain and its key
+func runCertsObtainCommand(client *strato.StratoClient, opts certsObtainOptions) {
+	if opts.certFile == "" || opts.keyFile == "" {
+		fatal("--cert-file and --key-file are required for certs-obtain command")
+	}
+	if len(opts.names) == 0 {
+		opts.names = []string{opts.domain}
+	}
+	if opts.accountKey == "" {
+		opts.accountKey = filepath.Join(filepath.Dir(opts.keyFile), "acme-account.key")
+	}
+	key, err := acme.LoadOrCreateKey(opts.accountKey)
+	if err != nil {
+		fatalf("Failed to load ACME account key: %v", err)
+	}
+	ca := &acme.Client{DirectoryURL: opts.directory, Key: key}
+	ctx := context.Background()
+	if err := ca.Register(ctx, opts.email); err != nil {
+		fatalf("Failed to register ACME account: %v", err)
+	}
+	checker, err := strato.ParseChecker(opts.check)
+	if err != nil {
+		fatalf("Invalid --propagation-check: %v", err)
+	}
+	solver := &acme.StratoSolver{Client: client, Domain: opts.domain, Checker: checker, Timeout: opts.wait}
+	klog.V(2).Infof("Obtaining certificate for %s", strings.Join(opts.names, ", "))
+	certPEM, keyPEM, err := ca.Obtain(ctx, solver, opts.names)
+	if err != nil {
+		fatalf("Failed to obtain certificate: %v", err)
+	}
+	// The key is written first, so a certificate is never left without it
+	if err := os.WriteFile(opts.keyFile, keyPEM, 0o600); err != nil {
+		fatalf("Failed to write key: %v", err)
+	}
+	if err := os.WriteFile(opts.certFile, certPEM, 0o644); err != nil {
+		fatalf("Failed to write certificate: %v", err)
+	}
+	klog.V(2).Infof("Certificate written to %s", opts.certFile)
+}
+
+func readFile(path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fatalf("Failed to read %s: %v", path, err)
+	}
+	return data
+}
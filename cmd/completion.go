@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// flagValues lists the values offered for flags with a fixed set of choices
+func flagValues() map[string][]string {
+	regions := make([]string, 0, len(strato.Regions))
+	for _, region := range strato.Regions {
+		regions = append(regions, region.Name)
+	}
+	return map[string][]string{
+		"command":        commands,
+		"region":         regions,
+		"output":         {"table", "wide"},
+		"traffic-format": {"csv", "json"},
+		"webhook-format": {"generic", "slack", "discord"},
+		"mail-mode":      {"strato", "external", "none"},
+		"contact-role":   {"owner", "admin"},
+		"shell":          {"bash", "zsh", "fish"},
+	}
+}
+
+// printCompletion writes the completion script for shell to w
+func printCompletion(w io.Writer, shell string) error {
+	program := filepath.Base(os.Args[0])
+	var flags []string
+	flag.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, f.Name)
+	})
+	values := flagValues()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	switch shell {
+	case "bash":
+		fmt.Fprintf(w, "_%s_complete() {\n", program)
+		fmt.Fprintln(w, `	local cur prev dir profiles i
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]#--}"
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		[[ ${COMP_WORDS[i]} == --state-dir ]] && dir="${COMP_WORDS[i+1]}"
+		[[ ${COMP_WORDS[i]} == --profiles ]] && profiles="${COMP_WORDS[i+1]}"
+	done
+	case "$prev" in`)
+		for _, name := range names {
+			fmt.Fprintf(w, "\t%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", name, strings.Join(values[name], " "))
+		}
+		fmt.Fprintf(w, "\tdomain) COMPREPLY=($(compgen -W \"$(%s --command completion-domains --state-dir \"$dir\" 2>/dev/null)\" -- \"$cur\")); return ;;\n", program)
+		fmt.Fprintf(w, "\tprofile) COMPREPLY=($(compgen -W \"$(%s --command completion-profiles ${profiles:+--profiles \"$profiles\"} 2>/dev/null)\" -- \"$cur\")); return ;;\n", program)
+		fmt.Fprintf(w, "\tesac\n\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n}\n", "--"+strings.Join(flags, " --"))
+		fmt.Fprintf(w, "complete -F _%s_complete %s\n", program, program)
+	case "zsh":
+		fmt.Fprintf(w, "#compdef %s\n_arguments \\\n", program)
+		for _, name := range flags {
+			action := ""
+			if choices, ok := values[name]; ok {
+				action = ":" + name + ":(" + strings.Join(choices, " ") + ")"
+			} else if name == "domain" {
+				action = fmt.Sprintf(":domain:{_values domain $(%s --command completion-domains --state-dir \"${opt_args[--state-dir]}\" 2>/dev/null)}", program)
+			} else if name == "profile" {
+				action = fmt.Sprintf(":profile:{_values profile $(%s --command completion-profiles ${opt_args[--profiles]:+--profiles \"${opt_args[--profiles]}\"} 2>/dev/null)}", program)
+			} else if f := flag.Lookup(name); !isBoolFlag(f) {
+				action = ":" + name + ":"
+			}
+			fmt.Fprintf(w, "\t'--%s[%s]%s' \\\n", name, zshEscape(flag.Lookup(name).Usage), action)
+		}
+		fmt.Fprintln(w)
+	case "fish":
+		for _, name := range flags {
+			f := flag.Lookup(name)
+			line := fmt.Sprintf("complete -c %s -l %s -d %q", program, name, f.Usage)
+			if choices, ok := values[name]; ok {
+				line += fmt.Sprintf(" -x -a %q", strings.Join(choices, " "))
+			} else if name == "domain" {
+				line += fmt.Sprintf(" -x -a '(%s --command completion-domains --state-dir (__fish_strato_state_dir) 2>/dev/null)'", program)
+			} else if name == "profile" {
+				line += fmt.Sprintf(" -x -a '(%s --command completion-profiles (__fish_strato_profiles) 2>/dev/null)'", program)
+			} else if !isBoolFlag(f) {
+				line += " -r"
+			}
+			fmt.Fprintln(w, line)
+		}
+		fmt.Fprintln(w, `function __fish_strato_state_dir
+	set -l tokens (commandline -opc)
+	set -l i (contains -i -- --state-dir $tokens); and echo $tokens[(math $i + 1)]
+end
+function __fish_strato_profiles
+	set -l tokens (commandline -opc)
+	set -l i (contains -i -- --profiles $tokens); and string join \n -- --profiles $tokens[(math $i + 1)]
+end`)
+	default:
+		return fmt.Errorf("unsupported shell: %s. Use bash, zsh or fish", shell)
+	}
+	return nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func zshEscape(s string) string {
+	return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+// printCachedDomains lists the domains that have snapshots in dir
+func printCachedDomains(w io.Writer, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		if i := strings.LastIndex(name, "-"); i > 0 && name != entry.Name() && !seen[name[:i]] {
+			seen[name[:i]] = true
+			fmt.Fprintln(w, name[:i])
+		}
+	}
+	return nil
+}
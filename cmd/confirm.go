@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// confirm shows diff and asks for confirmation if stdin is a terminal. It returns
+// true without asking if yes is set or the input is not interactive.
+func confirm(diff strato.ConfigDiff, yes bool) bool {
+	return confirmf(yes, "%s\nApply these changes?", diff)
+}
+
+// confirmf asks the question formatted from format and args like confirm
+func confirmf(yes bool, format string, args ...interface{}) bool {
+	if yes {
+		return true
+	}
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return true
+	}
+	fmt.Fprintf(os.Stderr, format+" [y/N] ", args...)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// errChangedMeanwhile is returned when the configuration changed while the user was
+// asked to confirm a change
+var errChangedMeanwhile = errors.New("configuration changed while waiting for confirmation, run the command again")
+
+// confirmUpdate shows the changes update makes to the current configuration and asks
+// for confirmation before the write lock is taken, so other writers are not blocked
+// while the prompt waits. The changes are only written if the configuration read
+// under the lock still matches the one that was confirmed.
+func confirmUpdate(client *strato.StratoClient, yes bool, update func(current strato.DNSConfig) (strato.DNSConfig, error)) (strato.ConfigDiff, bool, error) {
+	current, err := client.GetDNSConfiguration(strato.ForceRefresh())
+	if err != nil {
+		return strato.ConfigDiff{}, false, err
+	}
+	desired, err := update(current)
+	if err != nil {
+		return strato.ConfigDiff{}, false, err
+	}
+	diff := strato.DiffConfigs(current, desired)
+	if diff.Empty() {
+		return diff, false, nil
+	}
+	fmt.Println(diff)
+	if !confirm(diff, yes) {
+		return diff, false, nil
+	}
+	_, err = client.UpdateDNSConfiguration(func(latest strato.DNSConfig) (strato.DNSConfig, error) {
+		if !strato.DiffConfigs(current, latest).Empty() {
+			return latest, errChangedMeanwhile
+		}
+		return desired, nil
+	})
+	return diff, true, err
+}
+
+// confirmZoneUpdate is confirmUpdate for changes made to the zone. update returns
+// the changes it made.
+func confirmZoneUpdate(client *strato.StratoClient, yes bool, update func(zone *strato.Zone) strato.ConfigDiff) (strato.ConfigDiff, bool, error) {
+	current, err := client.GetDNSConfiguration(strato.ForceRefresh())
+	if err != nil {
+		return strato.ConfigDiff{}, false, err
+	}
+	diff := update(strato.NewZone(current))
+	if diff.Empty() {
+		return diff, false, nil
+	}
+	fmt.Println(diff)
+	if !confirm(diff, yes) {
+		return diff, false, nil
+	}
+	err = client.UpdateZone(func(zone *strato.Zone) (bool, error) {
+		if !strato.DiffConfigs(current, zone.Config()).Empty() {
+			return false, errChangedMeanwhile
+		}
+		return !update(zone).Empty(), nil
+	})
+	return diff, true, err
+}
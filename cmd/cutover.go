@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// cutoverOptions are the flags of the cutover command
+type cutoverOptions struct {
+	hosts    []string
+	from     []string
+	to       []string
+	rollback bool
+	wait     time.Duration
+	check    string
+	yes      bool
+}
+
+// runCutoverCommand switches --hosts from --from-ips to --to-ips, or back with
+// --rollback, and waits until the new records are visible
+func runCutoverCommand(client *strato.StratoClient, o cutoverOptions) {
+	if len(o.hosts) == 0 || len(o.from) == 0 || len(o.to) == 0 {
+		fatal("--hosts, --from-ips and --to-ips are required for cutover command")
+	}
+	cutover := strato.Cutover{Prefixes: o.hosts, From: o.from, To: o.to}
+	if o.rollback {
+		cutover = cutover.Reverse()
+	}
+	changes, err := client.PlanCutover(cutover)
+	if err != nil {
+		fatalf("Failed to plan cutover: %v", err)
+	}
+	if len(changes) > 0 {
+		var plan strings.Builder
+		for _, change := range changes {
+			fmt.Fprintln(&plan, change)
+		}
+		if !confirmf(o.yes, "%s\nApply these changes?", plan.String()) {
+			klog.V(2).Info("Aborted")
+			return
+		}
+	}
+	var checker strato.Checker
+	if o.wait > 0 {
+		if checker, err = strato.ParseChecker(o.check); err != nil {
+			fatalf("Invalid --propagation-check: %v", err)
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if o.wait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.wait)
+		defer cancel()
+	}
+	if _, err := client.Cutover(ctx, cutover, checker); err != nil {
+		if o.rollback {
+			fatalf("Failed to roll back: %v", err)
+		}
+		fatalf("Failed to cut over: %v; switch back with --rollback", err)
+	}
+	klog.V(2).Infof("Switched %d hosts to %v", len(o.hosts), cutover.To)
+}
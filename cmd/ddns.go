@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// ddnsOptions configure the ddns command
+type ddnsOptions struct {
+	hostname      string
+	password      string
+	families      string
+	hysteresis    int
+	interval      time.Duration
+	hook          string
+	webhookURL    string
+	webhookFormat string
+	lock          strato.Locker
+	status        *strato.StatusTracker
+}
+
+func runDDNSCommand(o ddnsOptions) {
+	if o.hostname == "" || o.password == "" {
+		fatal("--domain and --ddns-password or --vault-path are required for ddns command")
+	}
+	if o.hook != "" && strings.TrimSpace(o.hook) == "" {
+		fatal("--ddns-hook must not be blank")
+	}
+	families, err := strato.ParseIPFamilies(o.families)
+	if err != nil {
+		fatalf("Invalid --families: %v", err)
+	}
+	var webhook *strato.Webhook
+	if o.webhookURL != "" {
+		if webhook, err = strato.NewWebhook(o.webhookURL, strato.WebhookFormat(o.webhookFormat)); err != nil {
+			fatalf("Invalid webhook: %v", err)
+		}
+	}
+	updater := &strato.DDNSUpdater{
+		Hostname:   o.hostname,
+		Password:   o.password,
+		Families:   families,
+		Hysteresis: o.hysteresis,
+		Lock:       o.lock,
+		Status:     o.status,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = updater.Run(ctx, o.interval, func(update strato.DDNSUpdate, err error) {
+		if err != nil {
+			klog.Errorf("Failed to update %s: %v", o.hostname, err)
+			notify(webhook, strato.Notification{Domain: o.hostname, Event: "ddns update failed", Error: err.Error()})
+			return
+		}
+		if !update.Changed {
+			return
+		}
+		klog.Infof("Updated %s: %v -> %v", o.hostname, update.Old, update.New)
+		notify(webhook, strato.Notification{Time: update.Time, Domain: o.hostname, Event: "address changed", Diff: update.Diff()})
+		if o.hook != "" {
+			runHook(ctx, o.hook, update)
+		}
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fatalf("Failed to run ddns: %v", err)
+	}
+}
+
+// runHook runs the hook command line through the shell, so arguments can be quoted,
+// with the update in its environment; failures are only logged
+func runHook(ctx context.Context, hook string, update strato.DDNSUpdate) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", hook)
+	}
+	cmd.Env = append(os.Environ(), update.Environ()...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		klog.Errorf("Hook %q failed: %v", hook, err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// dkimOptions are the flags of the dkim-rotate command
+type dkimOptions struct {
+	domain   string
+	selector string
+	keyType  string
+	keyBits  int
+	keyOut   string
+	replace  bool
+	wait     time.Duration
+	check    string
+}
+
+// runDKIMRotateCommand generates a key pair, writes the private key, publishes the
+// public key and waits until the record is visible in DNS
+func runDKIMRotateCommand(client *strato.StratoClient, opts dkimOptions) {
+	if opts.selector == "" {
+		opts.selector = "s" + time.Now().Format("20060102")
+	}
+	var checker strato.Checker
+	if opts.wait > 0 {
+		var err error
+		if checker, err = strato.ParseChecker(opts.check); err != nil {
+			fatalf("Invalid --propagation-check: %v", err)
+		}
+	}
+	key, err := strato.GenerateDKIMKey(opts.selector, strato.DKIMKeyType(opts.keyType), opts.keyBits)
+	if err != nil {
+		fatalf("Failed to generate DKIM key: %v", err)
+	}
+	private, err := key.PrivateKeyPEM()
+	if err != nil {
+		fatalf("Failed to encode DKIM key: %v", err)
+	}
+	// The private key is written before publishing, so it is not lost if a later step fails
+	if opts.keyOut != "" {
+		if err := os.WriteFile(opts.keyOut, private, 0o600); err != nil {
+			fatalf("Failed to write DKIM key: %v", err)
+		}
+	} else if _, err := os.Stdout.Write(private); err != nil {
+		fatalf("Failed to write DKIM key: %v", err)
+	}
+	record, err := key.Record()
+	if err != nil {
+		fatalf("Failed to build DKIM record: %v", err)
+	}
+	if err := client.PublishDKIMKey(key, opts.replace); err != nil {
+		fatalf("Failed to publish DKIM key: %v", err)
+	}
+	klog.V(2).Infof("Published DKIM key with selector %s", opts.selector)
+	if opts.wait > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), opts.wait)
+		defer cancel()
+		if err := checker.Wait(ctx, opts.domain, record); err != nil {
+			fatalf("Failed to wait for DKIM record: %v", err)
+		}
+		klog.V(2).Infof("DKIM record %s.%s is visible in DNS", record.Prefix, opts.domain)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/dmarc"
+	"k8s.io/klog/v2"
+)
+
+// runDMARCSetCommand writes the DMARC policy given by the flags
+func runDMARCSetCommand(client *strato.StratoClient, record dmarc.Record) {
+	if record.Policy == "" {
+		fatal("--policy is required for dmarc-set command")
+	}
+	changed, err := dmarc.Apply(context.Background(), client, record)
+	if err != nil {
+		fatalf("Failed to set DMARC policy: %v", err)
+	}
+	if changed {
+		klog.V(2).Infof("DMARC policy set to %s", record)
+	} else {
+		klog.V(2).Infof("DMARC policy already %s", record)
+	}
+}
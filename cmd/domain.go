@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// runDomainCommand executes the domain-* commands
+func runDomainCommand(client *strato.StratoClient, command, domain, nameservers, glueHost, glueIPs string) {
+	switch command {
+	case "domain-authcode":
+		code, err := client.GetAuthCode(domain)
+		if err != nil {
+			fatalf("Failed to fetch auth code: %v", err)
+		}
+		fmt.Println(code)
+	case "domain-check":
+		availability, err := client.CheckDomainAvailability(domain)
+		if err != nil {
+			fatalf("Failed to check availability: %v", err)
+		}
+		status := "taken"
+		if availability.Available {
+			status = "available"
+		}
+		if err := printRows(os.Stdout, []string{"domain", "status", "price"}, [][]string{{availability.Name, status, availability.Price}}); err != nil {
+			fatalf("Failed to print availability: %v", err)
+		}
+	case "domain-ns-get":
+		current, err := client.GetNameservers(domain)
+		if err != nil {
+			fatalf("Failed to fetch nameservers: %v", err)
+		}
+		if len(current) == 0 {
+			fmt.Println("strato")
+			return
+		}
+		for _, nameserver := range current {
+			fmt.Println(nameserver)
+		}
+	case "domain-ns-set":
+		if nameservers == "" {
+			fatal("--nameservers is required for domain-ns-set command")
+		}
+		var list []string
+		if nameservers != "strato" {
+			list = splitList(nameservers)
+		}
+		if err := client.SetNameservers(domain, list); err != nil {
+			fatalf("Failed to update nameservers: %v", err)
+		}
+		klog.V(2).Infof("Nameservers of %s updated", domain)
+	case "domain-glue-list":
+		records, err := client.ListGlueRecords(domain)
+		if err != nil {
+			fatalf("Failed to fetch glue records: %v", err)
+		}
+		rows := make([][]string, 0, len(records))
+		for _, record := range records {
+			rows = append(rows, []string{record.Host, strings.Join(record.IPs, ", ")})
+		}
+		if err := printRows(os.Stdout, []string{"host", "ips"}, rows); err != nil {
+			fatalf("Failed to print glue records: %v", err)
+		}
+	case "domain-glue-set":
+		if glueHost == "" || glueIPs == "" {
+			fatal("--glue-host and --glue-ips are required for domain-glue-set command")
+		}
+		if err := client.SetGlueRecord(domain, glueHost, splitList(glueIPs)); err != nil {
+			fatalf("Failed to update glue record: %v", err)
+		}
+		klog.V(2).Infof("Glue record %s updated", glueHost)
+	}
+}
+
+// runContactCommand executes the domain-contact-* commands, contacts are exchanged as JSON
+func runContactCommand(client *strato.StratoClient, command, domain, role, contactFile string) {
+	contactRole := strato.ContactRole(role)
+	if contactRole != strato.ContactOwner && contactRole != strato.ContactAdmin {
+		fatalf("Invalid contact role: %s. Use owner or admin", role)
+	}
+	switch command {
+	case "domain-contact-get":
+		contact, err := client.GetDomainContact(domain, contactRole)
+		if err != nil {
+			fatalf("Failed to fetch contact: %v", err)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(contact); err != nil {
+			fatalf("Failed to print contact: %v", err)
+		}
+	case "domain-contact-set":
+		if contactFile == "" {
+			fatal("--contact-file is required for domain-contact-set command")
+		}
+		var contact strato.Contact
+		if err := json.Unmarshal(readFile(contactFile), &contact); err != nil {
+			fatalf("Failed to parse %s: %v", contactFile, err)
+		}
+		if err := client.UpdateDomainContact(domain, contactRole, contact); err != nil {
+			fatalf("Failed to update contact: %v", err)
+		}
+		klog.V(2).Infof("%s contact of %s updated", role, domain)
+	}
+}
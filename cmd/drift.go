@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// driftResult is the JSON form of the drift of one domain
+type driftResult struct {
+	Domain  string             `json:"domain"`
+	Drifted bool               `json:"drifted"`
+	Diff    *strato.ConfigDiff `json:"diff,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// printDriftReport writes the drift of every domain as text or JSON
+func printDriftReport(w io.Writer, report strato.SyncReport, asJSON bool) error {
+	results := make([]driftResult, 0, len(report.Results))
+	for _, result := range report.Results {
+		entry := driftResult{Domain: result.Domain}
+		if result.Err != nil {
+			entry.Error = result.Err.Error()
+		} else if !result.Diff.Empty() {
+			diff := result.Diff
+			entry.Drifted = true
+			entry.Diff = &diff
+		}
+		results = append(results, entry)
+	}
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+	for _, entry := range results {
+		switch {
+		case entry.Error != "":
+			fmt.Fprintf(w, "%s\tfailed: %s\n", entry.Domain, entry.Error)
+		case entry.Drifted:
+			fmt.Fprintf(w, "%s\tdrifted\n%s\n", entry.Domain, entry.Diff)
+		default:
+			fmt.Fprintf(w, "%s\tin sync\n", entry.Domain)
+		}
+	}
+	return nil
+}
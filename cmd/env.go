@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// envName returns the environment variable that configures the flag name,
+// e.g. STRATO_STATE_DIR for --state-dir
+func envName(name string) string {
+	return "STRATO_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// loadEnvFile adds the KEY=VALUE lines of path to the environment. Empty lines and
+// lines starting with # are skipped; values may be quoted.
+func loadEnvFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if err := os.Setenv(strings.TrimSpace(key), value); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// applyEnv sets every flag that was not given on the command line from its
+// STRATO_* environment variable, if present
+func applyEnv() error {
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		if value, ok := os.LookupEnv(envName(f.Name)); ok && !set[f.Name] && err == nil {
+			if setErr := flag.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf("%s: %w", envName(f.Name), setErr)
+			}
+		}
+	})
+	return err
+}
+
+// isFlagSet reports whether the flag name was given on the command line or in the environment
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+// readNewPassword reads the new password of the password commands from path, from
+// stdin if path is -, or from STRATO_NEW_PASSWORD if path is empty. A trailing line
+// break is removed.
+func readNewPassword(path string) (string, error) {
+	var data []byte
+	var err error
+	switch path {
+	case "":
+		return os.Getenv("STRATO_NEW_PASSWORD"), nil
+	case "-":
+		data, err = io.ReadAll(os.Stdin)
+	default:
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// Exit codes of the binary, so wrappers can react to failures programmatically
+const (
+	exitFailure     = 1
+	exitAuth        = 2
+	exitNotFound    = 3
+	exitConflict    = 4
+	exitRateLimited = 5
+	exitParse       = 6
+	// exitDrift is returned by the drift command if a domain differs from its desired
+	// configuration, and by dns-verify if DNS differs from the portal
+	exitDrift = 7
+	// exitReadOnly is returned if a change was refused because of --read-only
+	exitReadOnly = 8
+)
+
+var exitKinds = map[int]string{
+	exitFailure:     "failure",
+	exitAuth:        "auth",
+	exitNotFound:    "not_found",
+	exitConflict:    "conflict",
+	exitRateLimited: "rate_limited",
+	exitParse:       "parse",
+	exitDrift:       "drift",
+	exitReadOnly:    "read_only",
+}
+
+// exitCode classifies err
+func exitCode(err error) int {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, strato.ErrLoginBackoff):
+		return exitRateLimited
+	case errors.Is(err, strato.ErrAuthenticationFailed), errors.Is(err, strato.ErrAccountLocked),
+		errors.Is(err, strato.ErrPasswordExpired), errors.Is(err, strato.ErrSessionExpired),
+		errors.Is(err, strato.ErrCaptchaRequired):
+		return exitAuth
+	case errors.Is(err, strato.ErrDomainNotFound), errors.Is(err, strato.ErrCredentialNotFound),
+		errors.Is(err, os.ErrNotExist):
+		return exitNotFound
+	case errors.Is(err, strato.ErrVerificationFailed), errors.Is(err, strato.ErrRecordQuotaExceeded),
+		errors.Is(err, strato.ErrProtectedRecord), errors.Is(err, strato.ErrDKIMSelectorInUse):
+		return exitConflict
+	case errors.Is(err, strato.ErrReadOnly):
+		return exitReadOnly
+	case errors.Is(err, strato.ErrUnexpectedPage), errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return exitParse
+	}
+	return exitFailure
+}
+
+// fatalf prints the message as a single logfmt line to stderr and exits with the
+// code of the first error among args
+func fatalf(format string, args ...interface{}) {
+	code := exitFailure
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			code = exitCode(err)
+			break
+		}
+	}
+	klog.Flush()
+	fmt.Fprintf(os.Stderr, "level=error code=%d kind=%s msg=%s\n", code, exitKinds[code], strconv.Quote(fmt.Sprintf(format, args...)))
+	os.Exit(code)
+}
+
+// fatal is fatalf for a message without arguments
+func fatal(message string) {
+	fatalf("%s", message)
+}
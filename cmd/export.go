@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/migrate"
+	"github.com/fl0eb/go-strato/octodns"
+)
+
+// writeExport writes config as CSV records, octoDNS zone file or JSON configuration
+func writeExport(w io.Writer, config strato.DNSConfig, format string) error {
+	switch format {
+	case "csv":
+		return strato.WriteRecordsCSV(w, config.Records)
+	case "octodns":
+		data, err := octodns.Marshal(migrate.FromStrato(config.Records))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case "json":
+		records := append([]strato.DNSRecord(nil), config.Records...)
+		strato.SortRecords(records)
+		config.Records = records
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(config)
+	}
+	return fmt.Errorf("unknown format %q, use csv, octodns or json", format)
+}
+
+// readImport reads the configuration to import from a .csv file of records, an
+// octoDNS .yaml zone file or a JSON configuration. Mail settings the file does not
+// contain are taken from current. Records of an octoDNS zone that cannot be created
+// at Strato are reported and left out.
+func readImport(path string, current strato.DNSConfig) (strato.DNSConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return strato.DNSConfig{}, err
+	}
+	defer file.Close()
+	config := strato.DNSConfig{DMARCType: current.DMARCType, SPFType: current.SPFType}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		config.Records, err = strato.ReadRecordsCSV(file)
+		return config, err
+	case ".yaml", ".yml":
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return strato.DNSConfig{}, err
+		}
+		records, err := octodns.Unmarshal(data)
+		if err != nil {
+			return strato.DNSConfig{}, err
+		}
+		plan := migrate.Convert(records)
+		for _, skipped := range plan.Skipped {
+			fmt.Fprintf(os.Stderr, "skipped %s: %s\n", skipped.Record, skipped.Reason)
+		}
+		config.Records = plan.Records
+		return config, nil
+	}
+	var imported strato.DNSConfig
+	if err := json.NewDecoder(file).Decode(&imported); err != nil {
+		return strato.DNSConfig{}, err
+	}
+	config.Records = imported.Records
+	if imported.DMARCType != "" {
+		config.DMARCType = imported.DMARCType
+	}
+	if imported.SPFType != "" {
+		config.SPFType = imported.SPFType
+	}
+	return config, nil
+}
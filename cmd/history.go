@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/history"
+	"k8s.io/klog/v2"
+)
+
+// historyLine is the JSON form of a history entry, with the change to the entry before
+type historyLine struct {
+	Time    time.Time          `json:"time"`
+	Source  string             `json:"source"`
+	Actor   string             `json:"actor,omitempty"`
+	Records int                `json:"records"`
+	Diff    *strato.ConfigDiff `json:"diff,omitempty"`
+}
+
+// printHistory writes the entries of domain since the given day with what changed
+// from one entry to the next
+func printHistory(w io.Writer, store *history.Store, domain string, since time.Time, asJSON bool) error {
+	entries, err := store.Entries(domain, time.Time{})
+	if err != nil {
+		return err
+	}
+	var lines []historyLine
+	for i, entry := range entries {
+		if entry.Time.Before(since) {
+			continue
+		}
+		line := historyLine{Time: entry.Time, Source: entry.Source, Actor: entry.Actor, Records: len(entry.Config.Records)}
+		if i > 0 {
+			diff := strato.DiffConfigs(entries[i-1].Config, entry.Config)
+			line.Diff = &diff
+		}
+		lines = append(lines, line)
+	}
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(lines)
+	}
+	for _, line := range lines {
+		by := line.Source
+		if line.Actor != "" {
+			by += " by " + line.Actor
+		}
+		change := "first entry"
+		if line.Diff != nil {
+			change = fmt.Sprintf("+%d -%d", len(line.Diff.Added), len(line.Diff.Removed))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d records\t%s\n", line.Time.Local().Format(time.RFC3339), by, line.Records, change)
+	}
+	return nil
+}
+
+// runHistoryDiffCommand prints the changes between the configuration recorded at
+// since and the current one
+func runHistoryDiffCommand(client *strato.StratoClient, store *history.Store, domain string, since time.Time) {
+	if since.IsZero() {
+		fatal("--since is required for history-diff command")
+	}
+	past, err := store.At(domain, since)
+	if err != nil {
+		fatalf("Failed to find past configuration: %v", err)
+	}
+	current, err := client.GetDNSConfiguration(strato.ForceRefresh())
+	if err != nil {
+		fatalf("Failed to fetch current configuration: %v", err)
+	}
+	diff := strato.DiffConfigs(past.Config, current)
+	if diff.Empty() {
+		klog.V(2).Infof("No changes since %s", past.Time.Format(time.RFC3339))
+		return
+	}
+	fmt.Println(diff)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// hostingOptions holds the flags of the hosting commands
+type hostingOptions struct {
+	username    string
+	database    string
+	newPassword string
+	cronID      string
+	schedule    string
+	script      string
+	domain      string
+	phpVersion  string
+	webroot     string
+}
+
+// runHostingCommand executes the commands managing the webspace of the package
+func runHostingCommand(client *strato.StratoClient, command string, opts hostingOptions) {
+	switch command {
+	case "ftp-list":
+		accounts, err := client.ListFTPAccounts()
+		if err != nil {
+			fatalf("Failed to fetch FTP accounts: %v", err)
+		}
+		rows := make([][]string, 0, len(accounts))
+		for _, account := range accounts {
+			protocol := "ftp"
+			if account.SFTP {
+				protocol = "sftp"
+			}
+			rows = append(rows, []string{account.Username, protocol, account.Directory})
+		}
+		if err := printRows(os.Stdout, []string{"username", "protocol", "directory"}, rows); err != nil {
+			fatalf("Failed to print FTP accounts: %v", err)
+		}
+	case "ftp-reset-password":
+		if opts.username == "" || opts.newPassword == "" {
+			fatal("--username and --new-password-file or STRATO_NEW_PASSWORD are required for ftp-reset-password command")
+		}
+		if err := client.ResetFTPPassword(opts.username, opts.newPassword); err != nil {
+			fatalf("Failed to reset FTP password: %v", err)
+		}
+		klog.V(2).Infof("Password of FTP user %s reset", opts.username)
+	case "db-list":
+		databases, err := client.ListDatabases()
+		if err != nil {
+			fatalf("Failed to fetch databases: %v", err)
+		}
+		rows := make([][]string, 0, len(databases))
+		for _, database := range databases {
+			rows = append(rows, []string{database.Name, database.User, database.Host, database.Size})
+		}
+		if err := printRows(os.Stdout, []string{"name", "user", "host", "size"}, rows); err != nil {
+			fatalf("Failed to print databases: %v", err)
+		}
+	case "db-create":
+		if opts.newPassword == "" {
+			fatal("--new-password-file or STRATO_NEW_PASSWORD is required for db-create command")
+		}
+		database, err := client.CreateDatabase(opts.newPassword)
+		if err != nil {
+			fatalf("Failed to create database: %v", err)
+		}
+		if err := printRows(os.Stdout, []string{"name", "user", "host"}, [][]string{{database.Name, database.User, database.Host}}); err != nil {
+			fatalf("Failed to print database: %v", err)
+		}
+	case "db-reset-password":
+		if opts.database == "" || opts.newPassword == "" {
+			fatal("--database and --new-password-file or STRATO_NEW_PASSWORD are required for db-reset-password command")
+		}
+		if err := client.ResetDatabasePassword(opts.database, opts.newPassword); err != nil {
+			fatalf("Failed to reset database password: %v", err)
+		}
+		klog.V(2).Infof("Password of database %s reset", opts.database)
+	case "cron-list":
+		jobs, err := client.ListCronJobs()
+		if err != nil {
+			fatalf("Failed to fetch cron jobs: %v", err)
+		}
+		rows := make([][]string, 0, len(jobs))
+		for _, job := range jobs {
+			rows = append(rows, []string{job.ID, job.Schedule, job.Script})
+		}
+		if err := printRows(os.Stdout, []string{"id", "schedule", "script"}, rows); err != nil {
+			fatalf("Failed to print cron jobs: %v", err)
+		}
+	case "cron-create":
+		job := strato.CronJob{Schedule: opts.schedule, Script: opts.script}
+		if err := client.CreateCronJob(job); err != nil {
+			fatalf("Failed to create cron job: %v", err)
+		}
+		klog.V(2).Infof("Cron job for %s created", opts.script)
+	case "cron-delete":
+		if opts.cronID == "" {
+			fatal("--cron-id is required for cron-delete command")
+		}
+		if err := client.DeleteCronJob(opts.cronID); err != nil {
+			fatalf("Failed to delete cron job: %v", err)
+		}
+		klog.V(2).Infof("Cron job %s deleted", opts.cronID)
+	case "webspace-get":
+		settings, err := client.GetWebspaceSettings(opts.domain)
+		if err != nil {
+			fatalf("Failed to fetch webspace settings: %v", err)
+		}
+		rows := [][]string{{opts.domain, settings.PHPVersion, settings.Webroot, strings.Join(settings.AvailablePHPVersions, ", ")}}
+		if err := printRows(os.Stdout, []string{"domain", "php", "webroot", "available php"}, rows); err != nil {
+			fatalf("Failed to print webspace settings: %v", err)
+		}
+	case "webspace-set":
+		if opts.phpVersion == "" && opts.webroot == "" {
+			fatal("--php-version or --webroot is required for webspace-set command")
+		}
+		settings := strato.WebspaceSettings{PHPVersion: opts.phpVersion, Webroot: opts.webroot}
+		if err := client.SetWebspaceSettings(opts.domain, settings); err != nil {
+			fatalf("Failed to update webspace settings: %v", err)
+		}
+		klog.V(2).Infof("Webspace settings of %s updated", opts.domain)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// newLocker returns the write lock selected by --lock-file or --lock-lease, or nil
+func newLocker(lockFile, lockLease string) strato.Locker {
+	switch {
+	case lockFile != "" && lockLease != "":
+		fatal("--lock-file and --lock-lease are mutually exclusive")
+	case lockFile != "":
+		return strato.FileLock{Path: lockFile}
+	case lockLease != "":
+		identity, err := os.Hostname()
+		if err != nil {
+			fatalf("Failed to determine lease identity: %v", err)
+		}
+		lock, err := strato.NewInClusterLeaseLock(lockLease, identity, time.Minute)
+		if err != nil {
+			fatalf("Failed to set up lease lock: %v", err)
+		}
+		return lock
+	}
+	return nil
+}
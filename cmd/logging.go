@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// klogHandler writes library log records through klog, so that -v keeps controlling
+// the verbosity of the binary. Levels map to klog as follows: Info is V(2),
+// Debug is V(4) and Trace is V(6).
+type klogHandler struct {
+	attrs  []slog.Attr
+	prefix string
+}
+
+func klogVerbosity(level slog.Level) klog.Level {
+	switch {
+	case level <= strato.LevelTrace:
+		return 6
+	case level <= slog.LevelDebug:
+		return 4
+	default:
+		return 2
+	}
+}
+
+func (h *klogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if level >= slog.LevelWarn {
+		return true
+	}
+	return klog.V(klogVerbosity(level)).Enabled()
+}
+
+func (h *klogHandler) Handle(_ context.Context, record slog.Record) error {
+	var b strings.Builder
+	b.WriteString(record.Message)
+	write := func(attr slog.Attr) bool {
+		fmt.Fprintf(&b, " %s=%v", attr.Key, attr.Value)
+		return true
+	}
+	for _, attr := range h.attrs {
+		write(attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		attr.Key = h.prefix + attr.Key
+		return write(attr)
+	})
+	// Enabled already applied the verbosity, or a component level set with
+	// --log-levels overrode it, so records are written unconditionally
+	switch {
+	case record.Level >= slog.LevelError:
+		klog.ErrorDepth(3, b.String())
+	case record.Level >= slog.LevelWarn:
+		klog.WarningDepth(3, b.String())
+	default:
+		klog.InfoDepth(3, b.String())
+	}
+	return nil
+}
+
+func (h *klogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := &klogHandler{prefix: h.prefix, attrs: append([]slog.Attr{}, h.attrs...)}
+	for _, attr := range attrs {
+		attr.Key = h.prefix + attr.Key
+		clone.attrs = append(clone.attrs, attr)
+	}
+	return clone
+}
+
+func (h *klogHandler) WithGroup(name string) slog.Handler {
+	return &klogHandler{attrs: h.attrs, prefix: h.prefix + name + "."}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// runMailCommand executes the mail-* commands
+func runMailCommand(client *strato.StratoClient, command, address, mailPassword, forwards string) {
+	if command != "mail-list" && command != "mail-aliases" && address == "" {
+		fatalf("--address is required for %s command", command)
+	}
+	switch command {
+	case "mail-list":
+		mailboxes, err := client.ListMailboxes()
+		if err != nil {
+			fatalf("Failed to fetch mailboxes: %v", err)
+		}
+		printMailboxes(mailboxes)
+	case "mail-aliases":
+		aliases, err := client.ListAliases()
+		if err != nil {
+			fatalf("Failed to fetch aliases: %v", err)
+		}
+		printMailboxes(aliases)
+	case "mail-create":
+		if mailPassword == "" {
+			fatal("--mail-password is required for mail-create command")
+		}
+		if err := client.CreateMailbox(address, mailPassword); err != nil {
+			fatalf("Failed to create mailbox: %v", err)
+		}
+		klog.V(2).Infof("Mailbox %s created", address)
+	case "mail-delete":
+		if err := client.DeleteMailbox(address); err != nil {
+			fatalf("Failed to delete mailbox: %v", err)
+		}
+		klog.V(2).Infof("Mailbox %s deleted", address)
+	case "mail-forward":
+		targets := splitList(forwards)
+		if err := client.SetForwarding(address, targets); err != nil {
+			fatalf("Failed to set forwarding: %v", err)
+		}
+		klog.V(2).Infof("Forwarding of %s set to %v", address, targets)
+	}
+}
+
+func printMailboxes(mailboxes []strato.Mailbox) {
+	rows := make([][]string, 0, len(mailboxes))
+	for _, mailbox := range mailboxes {
+		rows = append(rows, []string{mailbox.Address, strings.Join(mailbox.Forwards, ", ")})
+	}
+	if err := printRows(os.Stdout, []string{"address", "forwards"}, rows); err != nil {
+		fatalf("Failed to print mailboxes: %v", err)
+	}
+}
+
+// runMailModeCommand switches the domain between Strato, external and no mail
+func runMailModeCommand(client *strato.StratoClient, mode, mxHosts string) {
+	if mode == "" {
+		fatal("--mail-mode is required for mail-mode command")
+	}
+	changed, err := client.EnsureMailMode(strato.MailMode(mode), splitList(mxHosts))
+	if err != nil {
+		fatalf("Failed to set mail mode: %v", err)
+	}
+	if changed {
+		klog.V(2).Infof("Mail mode set to %s", mode)
+	} else {
+		klog.V(2).Infof("Mail mode already %s", mode)
+	}
+}
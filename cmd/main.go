@@ -1,35 +1,528 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/acme"
+	"github.com/fl0eb/go-strato/acmedns"
+	"github.com/fl0eb/go-strato/bimi"
+	"github.com/fl0eb/go-strato/browser"
+	"github.com/fl0eb/go-strato/dmarc"
+	"github.com/fl0eb/go-strato/history"
 	"k8s.io/klog/v2"
 )
 
+var commands = []string{
+	"add", "remove", "rename", "replace", "protect", "unprotect", "list", "prune", "watch", "sync", "backup", "restore", "export", "import", "migrate", "undo", "change-password",
+	"mail-list", "mail-aliases", "mail-create", "mail-delete", "mail-forward", "mail-mode",
+	"certs-list", "certs-install", "certs-obtain",
+	"domain-authcode", "domain-check", "domain-ns-get", "domain-ns-set", "domain-glue-list", "domain-glue-set",
+	"domain-contact-get", "domain-contact-set", "package-info",
+	"ftp-list", "ftp-reset-password", "db-list", "db-create", "db-reset-password",
+	"cron-list", "cron-create", "cron-delete", "webspace-get", "webspace-set",
+	"storage-quota", "storage-shares", "invoices-list", "invoices-download", "traffic",
+	"drift", "dns-verify", "cutover", "status", "metrics", "dkim-rotate", "dmarc-set", "bimi-set", "verify-add", "serve", "acme-dns", "api-token", "record-fixtures", "history", "history-diff", "schedule", "schedule-list", "schedule-cancel", "schedule-run", "completion", "version", "self-update", "install-service", "ddns",
+}
+
 func main() {
 	klog.InitFlags(nil)
 
 	// Parse command-line arguments
-	api := flag.String("api", "https://www.strato.de/apps/CustomerService", "Strato API URL")
+	api := flag.String("api", "", "Strato API URL (default: portal URL of the region)")
+	regionName := flag.String("region", "de", "Strato portal variant: de, nl, se or uk")
+	language := flag.String("language", "", "Language the portal is shown in, if changed from the default of the region: de, en, nl or sv")
 	identifier := flag.String("identifier", "", "Strato identifier")
 	password := flag.String("password", "", "Strato password")
-	order := flag.String("order", "", "Package order number to update")
+	order := flag.String("order", "", "Package order number to update (default: the package containing --domain)")
 	domain := flag.String("domain", "", "(Sub-)Domain to manage")
-	command := flag.String("command", "", "Command to execute: add, remove, or list")
-	recordType := flag.String("type", "TXT", "Type of DNS record (default: TXT)")
-	recordPrefix := flag.String("prefix", "", "Prefix for the DNS record")
+	profilesFile := flag.String("profiles", "", "JSON file with the accounts of --profile, keyed by profile name")
+	profile := flag.String("profile", "", "Account of --profiles to work on; its identifier, password, order, domain and API URL apply unless given as flags")
+	command := flag.String("command", "", "Command to execute: "+strings.Join(commands, ", "))
+	recordType := flag.String("type", "TXT", "Type of DNS record (default: TXT), or the only type the rename and replace commands change if set")
+	recordPrefix := flag.String("prefix", "", "Prefix for the DNS record, or below which the acme-dns command creates subdomains")
 	recordValue := flag.String("value", "", "Value for the DNS record")
+	matchType := flag.String("match-type", "", "Only list records of this type")
+	matchPrefix := flag.String("match-prefix", "", "Only list records whose prefix matches this glob pattern")
+	replaceMatch := flag.String("match", "", "Text the replace command substitutes in the values of all records, e.g. an old server address")
+	replaceWith := flag.String("with", "", "Replacement of --match for the replace command")
+	matchValue := flag.String("match-value", "", "Only list records whose value contains this text")
+	acmeOnly := flag.Bool("acme-only", false, "Only list ACME challenge records")
+	output := flag.String("output", "table", "Output format of the list command: table or wide")
+	columns := flag.String("columns", "type,prefix,value", "Comma separated columns of the list command")
+	resolve := flag.Bool("resolve", false, "Let the list command look the records up in DNS and add the ttl and propagation columns")
+	noHeader := flag.Bool("no-header", false, "Omit the header line of the list command")
+	interval := flag.Duration("interval", time.Minute, "Polling interval of the watch, ddns, serve and schedule-run commands")
+	fromPrefix := flag.String("from-prefix", "", "Prefix the rename command moves the records of")
+	toPrefix := flag.String("to-prefix", "", "Prefix the rename command moves the records of --from-prefix to")
+	sourceProvider := flag.String("source-provider", "", "Provider the migrate command copies the records of --zone from: "+strings.Join(providerNames, ", "))
+	targetProvider := flag.String("target-provider", "", "Provider the migrate command pushes the records of --domain to: "+strings.Join(providerNames, ", "))
+	zoneName := flag.String("zone", "", "Zone of the migrate command at the other provider (default: --domain)")
+	exportFormat := flag.String("export-format", "json", "Output format of the export command: csv (type,prefix,value,ttl), octodns (zone YAML) or json")
+	backupDir := flag.String("backup-dir", ".", "Directory the backup command writes snapshots to")
+	snapshotFile := flag.String("snapshot", "", "Snapshot file the restore command applies")
+	importFile := flag.String("import-file", "", ".csv, octoDNS .yaml or JSON file the import command applies")
+	stateDir := flag.String("state-dir", "", "Directory to keep a snapshot of the configuration before every change, enables undo")
+	address := flag.String("address", "", "Email address for the mail commands")
+	mailPassword := flag.String("mail-password", "", "Mailbox password for the mail-create command")
+	forwards := flag.String("forward", "", "Comma separated forwarding targets for the mail-forward command")
+	warnBefore := flag.Duration("warn-before", 14*24*time.Hour, "Warn about certificates expiring within this duration")
+	certFile := flag.String("cert-file", "", "PEM certificate for the certs-install command, or where certs-obtain writes the certificate chain")
+	keyFile := flag.String("key-file", "", "PEM private key for the certs-install command, or where certs-obtain writes the key")
+	chainFile := flag.String("chain-file", "", "PEM intermediate chain for the certs-install command")
+	certNames := flag.String("cert-names", "", "Comma separated names of the certs-obtain command, e.g. example.com,*.example.com (default: --domain)")
+	email := flag.String("email", "", "Contact address of the ACME account of the certs-obtain command")
+	acmeDirectory := flag.String("acme-directory", acme.LetsEncrypt, "Directory URL of the ACME CA of the certs-obtain command")
+	acmeAccountKey := flag.String("acme-account-key", "", "ACME account key of the certs-obtain command, created if missing (default: acme-account.key next to --key-file)")
+	username := flag.String("username", "", "User name for the ftp-reset-password command")
+	database := flag.String("database", "", "Database name for the db-reset-password command")
+	cronID := flag.String("cron-id", "", "Cron job ID for the cron-delete command")
+	schedule := flag.String("schedule", "", "Cron expression for the cron-create command")
+	script := flag.String("script", "", "Webspace script path for the cron-create command")
+	phpVersion := flag.String("php-version", "", "PHP version for the webspace-set command")
+	webroot := flag.String("webroot", "", "Webspace directory for the webspace-set command")
+	storageOrder := flag.String("storage-order", "", "Order number of the hiDrive package for the storage commands")
+	since := flag.String("since", "", "Only include invoices or history entries dated on or after this day, or the day the history-diff command compares with (YYYY-MM-DD)")
+	until := flag.String("until", "", "Only include invoices dated on or before this day (YYYY-MM-DD)")
+	invoiceDir := flag.String("invoice-dir", ".", "Directory the invoices-download command writes PDFs to")
+	month := flag.String("month", "", "Month for the traffic command (YYYY-MM, default: current month)")
+	fixturesDir := flag.String("fixtures-dir", "fixtures", "Directory the record-fixtures command writes sanitized portal pages to")
+	trafficFormat := flag.String("traffic-format", "csv", "Output format of the traffic command: csv or json")
+	nameservers := flag.String("nameservers", "", "Comma separated nameservers for the domain-ns-set command, or strato")
+	glueHost := flag.String("glue-host", "", "Child nameserver host for the domain-glue-set command")
+	glueIPs := flag.String("glue-ips", "", "Comma separated IP addresses for the domain-glue-set command")
+	contactRole := flag.String("contact-role", "owner", "Contact for the domain-contact commands: owner or admin")
+	contactFile := flag.String("contact-file", "", "JSON file with the contact data for the domain-contact-set command")
+	mailMode := flag.String("mail-mode", "", "Mail mode for the mail-mode command: strato, external or none")
+	mxHosts := flag.String("mx", "", "Comma separated MX hosts by preference for the external mail mode")
+	selector := flag.String("selector", "", "Selector of the dkim-rotate command (default: s<YYYYMMDD>) and the bimi-set command (default: default)")
+	dkimKeyType := flag.String("key-type", "rsa", "Key type of the dkim-rotate command: rsa or ed25519")
+	dkimKeyBits := flag.Int("key-bits", 2048, "RSA key size of the dkim-rotate command")
+	dkimKeyOut := flag.String("key-out", "", "File the dkim-rotate command writes the private key to (default: stdout)")
+	dkimReplace := flag.Bool("replace-key", false, "Let the dkim-rotate command replace a different key already published under --selector")
+	propagationTimeout := flag.Duration("propagation-timeout", 10*time.Minute, "How long the dkim-rotate, certs-obtain and cutover commands wait for new records to show up in DNS (0 lets dkim-rotate and cutover skip the wait)")
+	propagationCheck := flag.String("propagation-check", "authoritative", "How dkim-rotate, certs-obtain and cutover decide that new records are visible: comma separated checks authoritative, resolvers[:quorum] and delay:<duration> run in order, | separates alternatives that race, e.g. authoritative,delay:30s|delay:1h")
+	dmarcPolicy := flag.String("policy", "", "DMARC policy of the dmarc-set command: none, quarantine or reject")
+	dmarcSubdomainPolicy := flag.String("subdomain-policy", "", "DMARC policy for subdomains (default: --policy)")
+	dmarcRUA := flag.String("rua", "", "Comma separated mailto: URIs for DMARC aggregate reports")
+	dmarcRUF := flag.String("ruf", "", "Comma separated mailto: URIs for DMARC failure reports")
+	dmarcPercent := flag.Int("pct", 0, "Percentage of failing mail the DMARC policy applies to (default: 100)")
+	dmarcADKIM := flag.String("adkim", "", "DKIM alignment of the DMARC policy: r or s")
+	dmarcASPF := flag.String("aspf", "", "SPF alignment of the DMARC policy: r or s")
+	bimiLogo := flag.String("logo", "", "HTTPS URL of the SVG Tiny PS logo for the bimi-set command")
+	bimiVMC := flag.String("vmc", "", "HTTPS URL of the Verified Mark Certificate for the bimi-set command")
+	bimiVerify := flag.Bool("verify", true, "Let the bimi-set command fetch the logo and certificate before writing the record")
+	provider := flag.String("provider", "", "Service of the verify-add command: "+strings.Join(strato.VerificationProviderNames(), ", "))
+	token := flag.String("token", "", "Verification token issued by the service for the verify-add command")
+	rawOrder := flag.Bool("raw-order", false, "Keep records in the order the portal lists them instead of sorting them")
+	annotationsFile := flag.String("annotations", "", "JSON file to keep owner, purpose, creation time and protection of records in")
+	owner := flag.String("owner", "", "Owner recorded for records added by this invocation")
+	purpose := flag.String("purpose", "", "Purpose recorded for the record added by the add command")
+	olderThan := flag.Duration("older-than", 24*time.Hour, "Minimum age of ACME challenge records removed by the prune command, also used by the metrics command")
+	pruneUnannotated := flag.Bool("prune-unannotated", false, "Let the prune command also remove unannotated records that look like ACME tokens")
+	protect := flag.String("protect", "", "Comma separated TYPE:PREFIX patterns of records no command changes or removes without --force, e.g. MX,TXT:*._domainkey")
+	recordQuota := flag.Int("record-quota", 0, "Refuse changes leaving more than this many records, for tariffs whose portal page does not state the limit")
+	force := flag.Bool("force", false, "Allow changing and removing protected records")
+	dryRun := flag.Bool("dry-run", false, "Only print the records the prune command would remove or the migrate command would create")
+	printService := flag.Bool("print-service", false, "Only print the service definition the install-service command would install")
+	skipWrites := flag.Bool("skip-writes", false, "With --read-only, log the changes of any command and skip them instead of failing")
+	syncFile := flag.String("sync-file", "", "JSON file mapping domains to their desired configuration for the sync and drift commands, rendered as Go template first")
+	desiredFile := flag.String("desired", "", "Zone file <domain>.yaml in the format of --git-url, or a directory of them, with the desired configuration for the drift command")
+	gitURL := flag.String("git-url", "", "Let the sync command reconcile every --interval from the <domain>.yaml files of this Git repository")
+	gitBranch := flag.String("git-branch", "", "Branch of --git-url (default: the default branch)")
+	gitPath := flag.String("git-path", "", "Directory of the zone files within --git-url")
+	gitDir := flag.String("git-dir", filepath.Join(os.TempDir(), "go-strato-git"), "Local working copy of --git-url")
+	gitEnv := flag.String("git-env", "", "Comma-separated environment variables the zone files of --git-url may read with env (default: none)")
+	concurrency := flag.Int("concurrency", 4, "Number of domains the sync command reconciles at the same time")
+	rateLimit := flag.Duration("rate-limit", 0, "Minimum time between two requests to the portal (default: no limit)")
+	cacheTTL := flag.Duration("cache-ttl", 0, "Serve repeated reads of a domain from memory for this long, e.g. for the serve command (default: off)")
+	listen := flag.String("listen", "localhost:8080", "Address the serve and acme-dns commands listen on")
+	acmeDNSAccounts := flag.String("acme-dns-accounts", "", "JSON file the acme-dns command keeps the registered accounts in")
+	acmeDNSRegisterFrom := flag.String("acme-dns-register-from", "", "Comma separated networks the acme-dns command accepts registrations from, or none to disable registration (default: any)")
+	acmeDNSMaxAccounts := flag.Int("acme-dns-max-accounts", acmedns.DefaultMaxAccounts, "Number of accounts after which the acme-dns command refuses registrations, 0 for no limit")
+	apiTokens := flag.String("api-tokens", "", "JSON file with the API tokens, scopes and domains the serve command accepts")
+	apiTokensVaultPath := flag.String("api-tokens-vault-path", "", "Read the API tokens of the serve command from the tokens key of this Vault KV v2 secret")
+	watchDomains := flag.String("watch-domains", "", "Comma separated domains the serve command polls every --interval to stream their changes on /v1/events")
+	requireApproval := flag.Bool("require-approval", false, "Let the serve command queue changes until another user approves them")
+	trustedProxy := flag.Bool("trusted-proxy", false, "Let the serve command take the user from the X-Remote-User header set by an authenticating reverse proxy, unless it uses --api-tokens")
+	readOnly := flag.Bool("read-only", false, "Refuse all changes, or with --skip-writes only log them")
+	lockFile := flag.String("lock-file", "", "Hold a lock on this file while writing, so only one process on the host writes at a time")
+	lockLease := flag.String("lock-lease", "", "Hold this Kubernetes Lease while writing, so only one replica in the cluster writes at a time")
+	textfile := flag.String("textfile", "", "File the metrics command writes to for the node_exporter textfile collector, e.g. /var/lib/node_exporter/strato.prom (default: stdout)")
+	statusFile := flag.String("status-file", "", "File the watch, sync, ddns and serve commands keep the sync status of every domain in, read by the status and metrics commands")
+	rateLimitFile := flag.String("rate-limit-file", "", "Share --rate-limit with all processes using this file, e.g. replicas on the same host")
+	newPasswordFile := flag.String("new-password-file", "", "File with the new password for the change-password, db-create and *-reset-password commands, - for stdin (default: STRATO_NEW_PASSWORD)")
+	vaultPath := flag.String("vault-path", "", "Read identifier and password, or the ddns_password of the ddns command, from this Vault KV v2 secret (uses VAULT_ADDR and VAULT_TOKEN)")
+	vaultMount := flag.String("vault-mount", "secret", "Mount path of the Vault KV engine")
+	credentialStore := flag.String("credential-store", "", "Keep the password and the portal session in a credential store: keyring")
+	cutoverHosts := flag.String("hosts", "", "Comma separated prefixes of the hosts the cutover command switches, @ for the domain itself")
+	cutoverFrom := flag.String("from-ips", "", "Comma separated addresses the hosts point to before the cutover")
+	cutoverTo := flag.String("to-ips", "", "Comma separated addresses the hosts point to after the cutover")
+	rollback := flag.Bool("rollback", false, "Switch the hosts of the cutover command back from --to-ips to --from-ips")
+	scheduleFile := flag.String("schedule-file", "", "File of scheduled changes, applied when due by the schedule-run, watch and serve commands")
+	scheduleAt := flag.String("at", "", "Local time the schedule command applies the change at (YYYY-MM-DDTHH:MM)")
+	scheduleApply := flag.String("apply", "", "Configuration file the schedule command changes the records to: .csv, octoDNS .yaml or JSON")
+	scheduleID := flag.String("schedule-id", "", "ID of the change the schedule-cancel command cancels")
+	historyFile := flag.String("history-file", "", "Record every distinct configuration observed or applied in this SQLite database, read by the history and history-diff commands")
+	auditLog := flag.String("audit-log", "", "Append every DNS change to this JSON lines file, or send it to syslog with \"syslog\"")
+	actor := flag.String("actor", os.Getenv("USER"), "Actor recorded in the audit log")
+	webhookURL := flag.String("webhook", "", "URL the watch, sync and ddns commands post changes and failures to")
+	webhookFormat := flag.String("webhook-format", "generic", "Payload format of the webhook: generic, slack or discord")
+	pprofAddr := flag.String("pprof", "", "Serve pprof endpoints on this address, e.g. localhost:6060")
+	lenient := flag.Bool("lenient", false, "Work with partial DNS configurations instead of failing if parts of the page are missing")
+	streamingParser := flag.Bool("streaming-parser", false, "Extract records with the low-memory tokenizer instead of the DOM parser")
+	captchaPrompt := flag.Bool("captcha-prompt", false, "Ask on the terminal for the answer when the login page shows a CAPTCHA")
+	loginCommand := flag.String("login-command", "", "Log in by running this command, e.g. a headless browser script printing the session as JSON")
+	loginBrowser := flag.Bool("login-browser", false, "Log in with a headless Chrome or Chromium, for when the portal blocks the form login")
+	loginState := flag.String("login-state", "", "File to track failed logins in; further logins are refused during a cool-down")
+	loginCooldown := flag.Duration("login-cooldown", 15*time.Minute, "Cool-down after a failed login, doubled with every further failure")
+	families := flag.String("families", "ipv4,ipv6", "Address families the ddns command publishes: ipv4, ipv6 or both")
+	ddnsPassword := flag.String("ddns-password", "", "DynDNS password of the domain for the ddns command (default: ddns_password of --vault-path)")
+	hysteresis := flag.Int("hysteresis", 3, "Number of consecutive detections before the ddns command publishes a changed or lost address")
+	ddnsHook := flag.String("ddns-hook", "", "Command line the ddns command runs through the shell after changing records, with STRATO_DOMAIN, STRATO_OLD_IPV4, STRATO_NEW_IPV4, STRATO_OLD_IPV6 and STRATO_NEW_IPV6 set")
+	envFile := flag.String("env-file", "", "Read flags not given on the command line from STRATO_* variables in this file, e.g. STRATO_PASSWORD")
+	serviceName := flag.String("service-name", "go-strato", "Name of the service created by the install-service command")
+	serviceCommand := flag.String("service-command", "ddns", "Command the service installed by install-service runs, e.g. ddns or watch")
+	serviceArgs := flag.String("service-args", "", "Additional flags for the service installed by install-service")
+	jsonOutput := flag.Bool("json", false, "Print the output of the version, drift, dns-verify, status, history and schedule-list commands as JSON")
+	shell := flag.String("shell", "bash", "Shell of the completion command: bash, zsh or fish")
+	yes := flag.Bool("yes", false, "Apply remove, rename, replace, restore, import, migrate, undo, schedule, cutover and sync without asking for confirmation")
+	keepAlive := flag.Duration("keep-alive", 0, "Ping the portal at this interval during the watch and serve commands to keep the session alive (default: off)")
+	timeout := flag.Duration("timeout", strato.DefaultRequestTimeout, "Timeout of every request to the portal")
+	maxResponseSize := flag.Int64("max-response-size", strato.DefaultMaxResponseSize, "Largest response body in bytes read from the portal")
+	logLevels := flag.String("log-levels", "", "Per-component log levels of the library, e.g. auth=debug,http=trace (components: auth, scrape, form, http)")
 	flag.Parse()
 
-	if *identifier == "" || *password == "" || *order == "" || *domain == "" || *command == "" {
-		klog.Fatal("All flags --identifier, --password, --order, --domain, and --command are required")
+	// Flags not given on the command line may come from the environment
+	if *envFile != "" {
+		if err := loadEnvFile(*envFile); err != nil {
+			fatalf("Failed to read env file: %v", err)
+		}
+	}
+	if err := applyEnv(); err != nil {
+		fatalf("Invalid environment variable: %v", err)
+	}
+
+	strato.SetLogger(slog.New(&klogHandler{}))
+	if *pprofAddr != "" {
+		startProfiling(*pprofAddr)
+	}
+
+	var statusTracker *strato.StatusTracker
+	if *statusFile != "" && *command != "status" {
+		var err error
+		if statusTracker, err = newStatusTracker(*statusFile); err != nil {
+			fatalf("Failed to read sync status: %v", err)
+		}
+	}
+	var scheduled *strato.Schedule
+	if *scheduleFile != "" {
+		scheduled = strato.NewSchedule(*scheduleFile)
+	}
+	var historyStore *history.Store
+	if *historyFile != "" {
+		var err error
+		if historyStore, err = history.Open(*historyFile); err != nil {
+			fatalf("Failed to read history: %v", err)
+		}
+		defer historyStore.Close()
+	}
+
+	// Completion works offline, before any credentials are needed
+	switch *command {
+	case "completion":
+		if err := printCompletion(os.Stdout, *shell); err != nil {
+			fatalf("Failed to generate completion: %v", err)
+		}
+		return
+	case "api-token":
+		if err := printAPIToken(os.Stdout); err != nil {
+			fatalf("Failed to generate API token: %v", err)
+		}
+		return
+	case "completion-domains":
+		if err := printCachedDomains(os.Stdout, *stateDir); err != nil {
+			fatalf("Failed to list domains: %v", err)
+		}
+		return
+	case "completion-profiles":
+		if err := printProfiles(os.Stdout, *profilesFile); err != nil {
+			fatalf("Failed to list profiles: %v", err)
+		}
+		return
+	case "version":
+		if err := printVersion(os.Stdout, *jsonOutput); err != nil {
+			fatalf("Failed to print version: %v", err)
+		}
+		return
+	case "history":
+		if historyStore == nil {
+			fatal("--history-file is required for history command")
+		}
+		if err := printHistory(os.Stdout, historyStore, *domain, parseDate("since", *since), *jsonOutput); err != nil {
+			fatalf("Failed to print history: %v", err)
+		}
+		return
+	case "schedule-list", "schedule-cancel":
+		if scheduled == nil {
+			fatalf("--schedule-file is required for %s command", *command)
+		}
+		if *command == "schedule-list" {
+			if err := printSchedule(os.Stdout, scheduled, *domain, *jsonOutput); err != nil {
+				fatalf("Failed to print schedule: %v", err)
+			}
+			return
+		}
+		if *scheduleID == "" {
+			fatal("--schedule-id is required for schedule-cancel command")
+		}
+		if err := scheduled.Cancel(*scheduleID); err != nil {
+			fatalf("Failed to cancel scheduled change: %v", err)
+		}
+		return
+	case "status":
+		if *statusFile == "" {
+			fatal("--status-file is required for status command")
+		}
+		statuses, err := strato.LoadSyncStatus(*statusFile)
+		if err != nil {
+			fatalf("Failed to read sync status: %v", err)
+		}
+		failing, err := printStatus(os.Stdout, statuses, *jsonOutput)
+		if err != nil {
+			fatalf("Failed to print sync status: %v", err)
+		}
+		if failing > 0 {
+			fatalf("%d of %d domains are failing", failing, len(statuses))
+		}
+		return
+	case "ddns":
+		// DynDNS has its own credentials and needs no portal login
+		if *ddnsPassword == "" && *vaultPath != "" {
+			var err error
+			if *ddnsPassword, err = openVault(*vaultMount, *vaultPath).DDNSPassword(context.Background()); err != nil {
+				fatalf("Failed to read DynDNS password from Vault: %v", err)
+			}
+		}
+		runDDNSCommand(ddnsOptions{
+			hostname:      *domain,
+			password:      *ddnsPassword,
+			families:      *families,
+			hysteresis:    *hysteresis,
+			interval:      *interval,
+			hook:          *ddnsHook,
+			webhookURL:    *webhookURL,
+			webhookFormat: *webhookFormat,
+			lock:          newLocker(*lockFile, *lockLease),
+			status:        statusTracker,
+		})
+		return
+	case "install-service":
+		err := installService(serviceOptions{
+			name:    *serviceName,
+			command: *serviceCommand,
+			domain:  *domain,
+			envFile: *envFile,
+			args:    strings.Fields(*serviceArgs),
+			dryRun:  *printService,
+		})
+		if err != nil {
+			fatalf("Failed to install service: %v", err)
+		}
+		return
+	case "self-update":
+		release, err := selfUpdate()
+		if err != nil {
+			fatalf("Failed to update: %v", err)
+		}
+		fmt.Printf("Running %s\n", release)
+		return
+	}
+	if err := strato.ParseLogLevels(*logLevels); err != nil {
+		fatalf("Invalid --log-levels: %v", err)
+	}
+
+	if *profile != "" {
+		if *profilesFile == "" {
+			fatal("--profile requires --profiles")
+		}
+		accountFlags := map[string]*string{"api": api, "identifier": identifier, "password": password, "order": order, "domain": domain}
+		if err := applyProfile(*profilesFile, *profile, accountFlags); err != nil {
+			fatalf("Failed to read profile: %v", err)
+		}
+	}
+	if *vaultPath != "" {
+		credentials, err := openVault(*vaultMount, *vaultPath).Credentials(context.Background())
+		if err != nil {
+			fatalf("Failed to read credentials from Vault: %v", err)
+		}
+		*identifier = credentials.Identifier
+		*password = credentials.Password
+	}
+
+	var store strato.CredentialStore
+	switch *credentialStore {
+	case "":
+	case "keyring":
+		var err error
+		store, err = strato.NewKeyringStore()
+		if err != nil {
+			fatalf("Failed to open keyring: %v", err)
+		}
+	default:
+		fatalf("Invalid credential store: %s. Use keyring", *credentialStore)
+	}
+	// A given password is remembered so it can be omitted next time, but only once a
+	// login with it succeeded, so a mistyped one does not replace the stored one
+	var rememberPassword bool
+	if store != nil && *identifier != "" {
+		storedPassword, err := store.Get(*identifier)
+		if *password != "" {
+			rememberPassword = err != nil || storedPassword != *password
+		} else if err != nil {
+			fatalf("Failed to read password from credential store: %v", err)
+		} else {
+			*password = storedPassword
+		}
+	}
+
+	// New passwords are never taken from the command line, where they would show up
+	// in the process list and the shell history
+	var newPassword string
+	switch *command {
+	case "change-password", "db-create", "ftp-reset-password", "db-reset-password":
+		var err error
+		if newPassword, err = readNewPassword(*newPasswordFile); err != nil {
+			fatalf("Failed to read new password: %v", err)
+		}
+	}
+
+	if *identifier == "" || *password == "" || *domain == "" || *command == "" {
+		fatal("All flags --identifier, --password, --domain, and --command are required")
+	}
+
+	region, err := strato.RegionByName(*regionName)
+	if err != nil {
+		fatalf("Invalid region: %v", err)
+	}
+
+	opts := []strato.Option{
+		strato.WithRegion(region),
+		strato.WithVerifyAfterWrite(),
+		strato.WithRequestTimeout(*timeout),
+		strato.WithMaxResponseSize(*maxResponseSize),
+	}
+	if *language != "" {
+		portalLanguage, err := strato.LanguageByName(*language)
+		if err != nil {
+			fatalf("Invalid language: %v", err)
+		}
+		opts = append(opts, strato.WithLanguage(portalLanguage))
+	}
+	if *skipWrites && !*readOnly {
+		fatal("--skip-writes requires --read-only")
+	}
+	if *readOnly {
+		opts = append(opts, strato.WithReadOnly(*skipWrites))
+	}
+	if locker := newLocker(*lockFile, *lockLease); locker != nil {
+		opts = append(opts, strato.WithWriteLock(locker))
+	}
+	if *rateLimitFile != "" && *rateLimit <= 0 {
+		fatal("--rate-limit-file requires --rate-limit")
+	}
+	if *rateLimit > 0 && *rateLimitFile != "" {
+		opts = append(opts, strato.WithSharedRateLimit(*rateLimitFile, *rateLimit))
+	} else if *rateLimit > 0 {
+		opts = append(opts, strato.WithRateLimit(*rateLimit))
+	}
+	if *cacheTTL > 0 {
+		opts = append(opts, strato.WithCache(*cacheTTL))
+	}
+	if *captchaPrompt {
+		opts = append(opts, strato.WithCaptchaSolver(promptCaptcha))
+	}
+	if *loginCommand != "" {
+		opts = append(opts, strato.WithDriver(strato.CommandDriver{Command: strings.Fields(*loginCommand)}))
+	} else if *loginBrowser {
+		opts = append(opts, strato.WithDriver(browser.Driver{Region: region}))
+	}
+	if *loginState != "" {
+		opts = append(opts, strato.WithLoginBackoff(*loginState, *loginCooldown))
+	}
+	// A stored session would skip the login that checks a new password
+	if store != nil && !rememberPassword {
+		opts = append(opts, strato.WithSessionStore(store))
+	}
+	if *stateDir != "" {
+		opts = append(opts, strato.WithStateDir(*stateDir))
+	}
+	if statusTracker != nil {
+		opts = append(opts, strato.WithStatusTracker(statusTracker))
+	}
+	if historyStore != nil {
+		opts = append(opts, strato.WithHistory(historyStore))
+	}
+	if *rawOrder {
+		opts = append(opts, strato.WithRawOrder())
+	}
+	if *streamingParser {
+		opts = append(opts, strato.WithStreamingParser())
+	}
+	if *lenient {
+		opts = append(opts, strato.WithLenientParsing())
+	}
+	if *annotationsFile != "" {
+		opts = append(opts, strato.WithAnnotations(strato.NewFileAnnotationStore(*annotationsFile), *owner))
+	}
+	if *protect != "" {
+		opts = append(opts, strato.WithProtection(parseProtection(splitList(*protect))...))
+	}
+	if *force {
+		opts = append(opts, strato.WithForce())
+	}
+	if *recordQuota > 0 {
+		opts = append(opts, strato.WithRecordQuota(*recordQuota))
+	}
+	switch *auditLog {
+	case "":
+	case "syslog":
+		logger, err := strato.NewSyslogAuditLog("go-strato")
+		if err != nil {
+			fatalf("Failed to connect to syslog: %v", err)
+		}
+		opts = append(opts, strato.WithAuditLog(logger, *actor))
+	default:
+		opts = append(opts, strato.WithAuditLog(strato.NewFileAuditLog(*auditLog), *actor))
+	}
+
+	var webhook *strato.Webhook
+	if *webhookURL != "" {
+		if webhook, err = strato.NewWebhook(*webhookURL, strato.WebhookFormat(*webhookFormat)); err != nil {
+			fatalf("Invalid webhook: %v", err)
+		}
 	}
 
 	// Initialize the Strato client
-	client, err := strato.NewStratoClient(*api, *identifier, *password, *order, *domain)
+	client, err := strato.NewStratoClient(*api, *identifier, *password, *order, *domain, opts...)
 	if err != nil {
-		klog.Fatalf("Failed to create Strato client: %v", err)
+		fatalf("Failed to create Strato client: %v", err)
+	}
+	if rememberPassword {
+		if err := store.Set(*identifier, *password); err != nil {
+			fatalf("Failed to store password: %v", err)
+		}
 	}
 
 	// Execute command
@@ -37,105 +530,574 @@ func main() {
 	case "list":
 		config, err := client.GetDNSConfiguration()
 		if err != nil {
-			klog.Fatalf("Failed to fetch DNS records: %v", err)
+			fatalf("Failed to fetch DNS records: %v", err)
+		}
+		config.Records = strato.FilterRecords(config.Records, strato.RecordFilter{
+			Type:     *matchType,
+			Prefix:   *matchPrefix,
+			Value:    *matchValue,
+			ACMEOnly: *acmeOnly,
+		})
+		if *output != "table" && *output != "wide" {
+			fatalf("Invalid output format: %s. Use table or wide", *output)
+		}
+		selectedColumns, err := parseColumns(*columns)
+		if err != nil {
+			fatalf("Invalid columns: %v", err)
+		}
+		klog.V(2).Info("DMARC Type:", config.DMARCType)
+		klog.V(2).Info("SPF Type:", config.SPFType)
+		annotations, err := client.Annotations()
+		if err != nil {
+			fatalf("Failed to read annotations: %v", err)
+		}
+		var resolved map[strato.DNSRecord]*strato.EffectiveRecord
+		if *resolve {
+			resolved = resolveRecords(client, config.Records)
+			if !isFlagSet("columns") {
+				selectedColumns = append(selectedColumns, "ttl", "propagation")
+			}
+		}
+		if err := printTable(os.Stdout, config.Records, annotations, resolved, selectedColumns, !*noHeader, *output == "wide"); err != nil {
+			fatalf("Failed to print records: %v", err)
 		}
-		printConfig(config)
 		return
 
 	case "add":
 		if *recordType == "" {
-			klog.Fatal("--type is required for add command")
+			fatal("--type is required for add command")
 		}
 		if *recordPrefix == "" {
-			klog.Fatal("--prefix is required for add command")
+			fatal("--prefix is required for add command")
 		}
 		if *recordValue == "" {
-			klog.Fatal("--value is required for add command")
+			fatal("--value is required for add command")
 		}
 		providedRecord := strato.DNSRecord{
 			Type:   *recordType,
 			Prefix: *recordPrefix,
 			Value:  *recordValue,
 		}
-		config, err := client.GetDNSConfiguration()
+		added := false
+		err := client.UpdateZone(func(zone *strato.Zone) (bool, error) {
+			klog.V(2).Info("DNS configuration before update:")
+			printConfig(zone.Config())
+			added = zone.Add(providedRecord)
+			return added, nil
+		})
 		if err != nil {
-			klog.Fatalf("Failed to fetch initial configuration: %v", err)
-			return
+			fatalf("Failed to add new record: %v", err)
 		}
-		klog.V(2).Info("DNS configuration before update:")
-		printConfig(config)
-
-		if contains(config.Records, providedRecord) {
-			klog.V(2).Infof("Record already exists: Type: '%s', Prefix: '%s', Value: '%s'", providedRecord.Type, providedRecord.Prefix, providedRecord.Value)
+		if !added {
+			klog.V(2).Infof("Record already exists: %s", providedRecord)
 			return
 		}
-
-		config.Records = append(config.Records, providedRecord)
-		if err := client.SetDNSConfiguration(config); err != nil {
-			klog.Fatalf("Failed to update DNS records: %v", err)
-		}
-		config, err = client.GetDNSConfiguration()
-		if err != nil {
-			klog.Fatalf("Failed to fetch updated configuration: %v", err)
-		}
-		printConfig(config)
-		if !contains(config.Records, providedRecord) {
-			klog.Fatalf("Failed to add new record")
-			return
+		if *purpose != "" && *annotationsFile != "" {
+			if err := client.Annotate(providedRecord, *purpose); err != nil {
+				fatalf("Failed to annotate record: %v", err)
+			}
 		}
 		klog.V(2).Info("New record added successfully")
 		return
 	case "remove":
 		if *recordType == "" {
-			klog.Fatal("--type is required for add command")
+			fatal("--type is required for add command")
 		}
 		if *recordPrefix == "" {
-			klog.Fatal("--prefix is required for add command")
+			fatal("--prefix is required for add command")
 		}
 		if *recordValue == "" {
-			klog.Fatal("--value is required for add command")
+			fatal("--value is required for add command")
 		}
 		providedRecord := strato.DNSRecord{
 			Type:   *recordType,
 			Prefix: *recordPrefix,
 			Value:  *recordValue,
 		}
+		diff, confirmed, err := confirmZoneUpdate(client, *yes, func(zone *strato.Zone) strato.ConfigDiff {
+			if zone.Remove(providedRecord) {
+				return strato.ConfigDiff{Removed: []strato.DNSRecord{providedRecord}}
+			}
+			return strato.ConfigDiff{}
+		})
+		switch {
+		case err != nil:
+			fatalf("Failed to remove record: %v", err)
+		case diff.Empty():
+			klog.V(2).Infof("Record not found: %s", providedRecord)
+			return
+		case !confirmed:
+			klog.V(2).Info("Aborted")
+			return
+		}
+		klog.V(2).Info("Record successfully removed")
+		return
+	case "rename":
+		if !isFlagSet("from-prefix") || !isFlagSet("to-prefix") {
+			fatal("--from-prefix and --to-prefix are required for rename command")
+		}
+		onlyType := ""
+		if isFlagSet("type") {
+			onlyType = *recordType
+		}
+		diff, confirmed, err := confirmZoneUpdate(client, *yes, func(zone *strato.Zone) strato.ConfigDiff {
+			return zone.RenamePrefix(*fromPrefix, *toPrefix, onlyType)
+		})
+		switch {
+		case err != nil:
+			fatalf("Failed to rename records: %v", err)
+		case diff.Empty():
+			klog.V(2).Infof("No records named %q", *fromPrefix)
+			return
+		case !confirmed:
+			klog.V(2).Info("Aborted")
+			return
+		}
+		klog.V(2).Infof("Renamed %d records", len(diff.Removed))
+		return
+	case "protect", "unprotect":
+		if *annotationsFile == "" {
+			fatalf("--annotations is required for %s command", *command)
+		}
+		runProtectCommand(client, *command, strato.DNSRecord{Type: *recordType, Prefix: *recordPrefix, Value: *recordValue})
+		return
+	case "replace":
+		if *replaceMatch == "" || !isFlagSet("with") {
+			fatal("--match and --with are required for replace command")
+		}
+		onlyType := ""
+		if isFlagSet("type") {
+			onlyType = *recordType
+		}
+		diff, confirmed, err := confirmZoneUpdate(client, *yes, func(zone *strato.Zone) strato.ConfigDiff {
+			return zone.ReplaceValues(*replaceMatch, *replaceWith, onlyType)
+		})
+		switch {
+		case err != nil:
+			fatalf("Failed to replace values: %v", err)
+		case diff.Empty():
+			klog.V(2).Infof("No values contain %q", *replaceMatch)
+			return
+		case !confirmed:
+			klog.V(2).Info("Aborted")
+			return
+		}
+		klog.V(2).Infof("Replaced values of %d records", len(diff.Removed))
+		return
+	case "prune":
+		pruned, err := client.PruneChallengeRecords(strato.PruneOptions{
+			OlderThan:          *olderThan,
+			IncludeUnannotated: *pruneUnannotated,
+			DryRun:             *dryRun,
+		})
+		if err != nil {
+			fatalf("Failed to prune challenge records: %v", err)
+		}
+		for _, record := range pruned {
+			fmt.Println("-", record)
+		}
+		klog.V(2).Infof("Pruned %d challenge records", len(pruned))
+		return
+	case "sync":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if *gitURL != "" {
+			source := strato.GitSource{URL: *gitURL, Branch: *gitBranch, Path: *gitPath, Dir: *gitDir, Env: splitList(*gitEnv)}
+			err := client.SyncFromGit(ctx, source, *interval, *concurrency, func(revision string, report strato.SyncReport, err error) {
+				if err != nil {
+					klog.Errorf("Failed to load %s: %v", *gitURL, err)
+					return
+				}
+				klog.V(2).Infof("Synchronized revision %s", revision)
+				printSyncReport(report, webhook)
+			})
+			if err != nil && !errors.Is(err, context.Canceled) {
+				fatalf("Failed to synchronize from Git: %v", err)
+			}
+			return
+		}
+		if *syncFile == "" {
+			fatal("--sync-file or --git-url is required for sync command")
+		}
+		desired := loadSyncFile(ctx, *syncFile)
+		if !*yes {
+			desired = confirmSync(ctx, client, desired, *concurrency)
+			if len(desired) == 0 {
+				return
+			}
+		}
+		report := client.SyncAll(ctx, desired, *concurrency)
+		printSyncReport(report, webhook)
+		if err := report.Err(); err != nil {
+			fatalf("Failed to synchronize %d of %d domains", len(report.Failed()), len(report.Results))
+		}
+		return
+	case "drift":
+		if (*syncFile == "") == (*desiredFile == "") {
+			fatal("either --desired or --sync-file is required for drift command")
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		var desired map[string]strato.DNSConfig
+		if *desiredFile != "" {
+			var err error
+			if desired, err = strato.LoadZoneFiles(*desiredFile, strato.NewTemplateData(ctx, nil)); err != nil {
+				fatalf("Failed to load %s: %v", *desiredFile, err)
+			}
+		} else {
+			desired = loadSyncFile(ctx, *syncFile)
+		}
+		report := client.CheckDrift(ctx, desired, *concurrency)
+		if err := printDriftReport(os.Stdout, report, *jsonOutput); err != nil {
+			fatalf("Failed to print drift report: %v", err)
+		}
+		if err := report.Err(); err != nil {
+			fatalf("Failed to check %d of %d domains", len(report.Failed()), len(report.Results))
+		}
+		if len(report.Drifted()) > 0 {
+			klog.Flush()
+			os.Exit(exitDrift)
+		}
+		return
+	case "metrics":
 		config, err := client.GetDNSConfiguration()
 		if err != nil {
-			klog.Fatalf("Failed to fetch initial configuration: %v", err)
+			fatalf("Failed to fetch DNS records: %v", err)
 		}
-		klog.V(2).Info("DNS configuration before update:")
-		printConfig(config)
-
-		var updatedRecords []strato.DNSRecord
-		for _, record := range config.Records {
-			if record.Type != providedRecord.Type || record.Prefix != providedRecord.Prefix || record.Value != providedRecord.Value {
-				updatedRecords = append(updatedRecords, record)
+		annotations, err := client.Annotations()
+		if err != nil {
+			fatalf("Failed to read annotations: %v", err)
+		}
+		metrics := strato.Metrics{Domain: *domain, Config: config, Annotations: annotations, ChallengeTTL: *olderThan}
+		if statusTracker != nil {
+			metrics.Statuses = statusTracker.Statuses()
+		}
+		if *textfile == "" {
+			_, err = metrics.WriteTo(os.Stdout)
+		} else {
+			err = strato.WriteTextfile(*textfile, metrics)
+		}
+		if err != nil {
+			fatalf("Failed to write metrics: %v", err)
+		}
+		return
+	case "dns-verify":
+		report, err := client.VerifyPublished()
+		if err != nil {
+			fatalf("Failed to verify published records: %v", err)
+		}
+		if err := printPublishReport(os.Stdout, report, *jsonOutput); err != nil {
+			fatalf("Failed to print verification report: %v", err)
+		}
+		if mismatched := report.Mismatched(); len(mismatched) > 0 {
+			klog.Errorf("%d records differ between the portal and %s", len(mismatched), strings.Join(report.Nameservers, ", "))
+			klog.Flush()
+			os.Exit(exitDrift)
+		}
+		return
+	case "watch":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		startKeepAlive(ctx, client, *keepAlive)
+		if scheduled != nil {
+			go runScheduler(ctx, client, scheduled, *interval, webhook)
+		}
+		err := client.Watch(ctx, *interval, func(event strato.WatchEvent) {
+			timestamp := event.Time.Format(time.RFC3339)
+			if event.Err != nil {
+				klog.Errorf("Failed to fetch DNS configuration: %v", event.Err)
+				notify(webhook, strato.Notification{Time: event.Time, Domain: *domain, Event: "fetch failed", Error: event.Err.Error()})
+				return
 			}
+			for _, line := range strings.Split(event.Diff.String(), "\n") {
+				fmt.Println(timestamp, line)
+			}
+			notify(webhook, strato.Notification{Time: event.Time, Domain: *domain, Event: "records changed", Diff: event.Diff})
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			fatalf("Failed to watch DNS configuration: %v", err)
+		}
+		return
+	case "backup":
+		snapshot, err := client.TakeSnapshot()
+		if err != nil {
+			fatalf("Failed to fetch DNS configuration: %v", err)
 		}
-		if len(updatedRecords) == len(config.Records) {
-			klog.V(2).Infof("Record not found: Type: '%s', Prefix: '%s', Value: '%s'", providedRecord.Type, providedRecord.Prefix, providedRecord.Value)
+		path, err := strato.SaveSnapshot(*backupDir, snapshot)
+		if err != nil {
+			fatalf("Failed to write snapshot: %v", err)
+		}
+		fmt.Println(path)
+		return
+	case "restore":
+		if *snapshotFile == "" {
+			fatal("--snapshot is required for restore command")
+		}
+		snapshot, err := strato.LoadSnapshot(*snapshotFile)
+		if err != nil {
+			fatalf("Failed to read snapshot: %v", err)
+		}
+		if snapshot.Domain != *domain {
+			fatalf("Snapshot belongs to %s, not %s", snapshot.Domain, *domain)
+		}
+		diff, confirmed, err := confirmUpdate(client, *yes, func(strato.DNSConfig) (strato.DNSConfig, error) {
+			return snapshot.Config, nil
+		})
+		switch {
+		case err != nil:
+			fatalf("Failed to restore snapshot: %v", err)
+		case diff.Empty():
+			klog.V(2).Info("Configuration already matches the snapshot")
+			return
+		case !confirmed:
+			klog.V(2).Info("Aborted")
 			return
 		}
-		config.Records = updatedRecords
-
-		if err := client.SetDNSConfiguration(config); err != nil {
-			klog.Fatalf("Failed to update DNS configuration: %v", err)
+		klog.V(2).Infof("Restored snapshot from %s", snapshot.Time.Format(time.RFC3339))
+		return
+	case "migrate":
+		if *zoneName == "" {
+			*zoneName = *domain
 		}
-		config, err = client.GetDNSConfiguration()
+		runMigrateCommand(client, migrateOptions{from: *sourceProvider, to: *targetProvider, zone: *zoneName, dryRun: *dryRun, yes: *yes})
+		return
+	case "export":
+		config, err := client.GetDNSConfiguration()
 		if err != nil {
-			klog.Fatalf("Failed to fetch DNS configuration: %v", err)
+			fatalf("Failed to fetch DNS configuration: %v", err)
 		}
-		klog.V(2).Info("DNS configuration after update:")
-		printConfig(config)
-		if contains(config.Records, providedRecord) {
-			klog.Fatalf("Failed to remove record")
+		if err := writeExport(os.Stdout, config, *exportFormat); err != nil {
+			fatalf("Failed to export records: %v", err)
+		}
+		return
+	case "import":
+		if *importFile == "" {
+			fatal("--import-file is required for import command")
+		}
+		var desired strato.DNSConfig
+		diff, confirmed, err := confirmUpdate(client, *yes, func(current strato.DNSConfig) (strato.DNSConfig, error) {
+			var err error
+			if desired, err = readImport(*importFile, current); err != nil {
+				return current, fmt.Errorf("failed to read %s: %w", *importFile, err)
+			}
+			return desired, nil
+		})
+		switch {
+		case err != nil:
+			fatalf("Failed to import records: %v", err)
+		case diff.Empty():
+			klog.V(2).Info("Configuration already matches the import")
+			return
+		case !confirmed:
+			klog.V(2).Info("Aborted")
 			return
 		}
-		klog.V(2).Info("Record successfully removed")
+		klog.V(2).Infof("Imported %d records from %s", len(desired.Records), *importFile)
+		return
+	case "undo":
+		if *stateDir == "" {
+			fatal("--state-dir is required for undo command")
+		}
+		previous, _, err := strato.LatestSnapshot(*stateDir, *domain)
+		if err != nil {
+			fatalf("Failed to find previous configuration: %v", err)
+		}
+		current, err := client.GetDNSConfiguration()
+		if err != nil {
+			fatalf("Failed to fetch current configuration: %v", err)
+		}
+		if !confirm(strato.DiffConfigs(current, previous.Config), *yes) {
+			klog.V(2).Info("Aborted")
+			return
+		}
+		snapshot, err := client.Undo()
+		if err != nil {
+			fatalf("Failed to undo last change: %v", err)
+		}
+		klog.V(2).Infof("Restored configuration from %s", snapshot.Time.Format(time.RFC3339))
+		return
+	case "mail-list", "mail-aliases", "mail-create", "mail-delete", "mail-forward":
+		runMailCommand(client, *command, *address, *mailPassword, *forwards)
+		return
+	case "certs-list", "certs-install":
+		runCertsCommand(client, *command, *domain, *warnBefore, *certFile, *keyFile, *chainFile)
+		return
+	case "certs-obtain":
+		runCertsObtainCommand(client, certsObtainOptions{
+			domain:     *domain,
+			names:      splitList(*certNames),
+			email:      *email,
+			directory:  *acmeDirectory,
+			accountKey: *acmeAccountKey,
+			certFile:   *certFile,
+			keyFile:    *keyFile,
+			wait:       *propagationTimeout,
+			check:      *propagationCheck,
+		})
+		return
+	case "domain-authcode", "domain-check", "domain-ns-get", "domain-ns-set", "domain-glue-list", "domain-glue-set":
+		runDomainCommand(client, *command, *domain, *nameservers, *glueHost, *glueIPs)
+		return
+	case "domain-contact-get", "domain-contact-set":
+		runContactCommand(client, *command, *domain, *contactRole, *contactFile)
+		return
+	case "package-info":
+		info, err := client.GetPackageInfo()
+		if err != nil {
+			fatalf("Failed to fetch package information: %v", err)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(info); err != nil {
+			fatalf("Failed to print package information: %v", err)
+		}
+		return
+	case "ftp-list", "ftp-reset-password", "db-list", "db-create", "db-reset-password",
+		"cron-list", "cron-create", "cron-delete", "webspace-get", "webspace-set":
+		runHostingCommand(client, *command, hostingOptions{
+			username:    *username,
+			database:    *database,
+			newPassword: newPassword,
+			cronID:      *cronID,
+			schedule:    *schedule,
+			script:      *script,
+			domain:      *domain,
+			phpVersion:  *phpVersion,
+			webroot:     *webroot,
+		})
+		return
+	case "storage-quota", "storage-shares":
+		runStorageCommand(client, *command, *storageOrder)
+		return
+	case "cutover":
+		runCutoverCommand(client, cutoverOptions{
+			hosts:    splitList(*cutoverHosts),
+			from:     splitList(*cutoverFrom),
+			to:       splitList(*cutoverTo),
+			rollback: *rollback,
+			wait:     *propagationTimeout,
+			check:    *propagationCheck,
+			yes:      *yes,
+		})
+		return
+	case "schedule", "schedule-run":
+		if scheduled == nil {
+			fatalf("--schedule-file is required for %s command", *command)
+		}
+		if *command == "schedule" {
+			runScheduleCommand(client, scheduleOptions{schedule: scheduled, domain: *domain, at: *scheduleAt, apply: *scheduleApply, yes: *yes})
+			return
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		runScheduler(ctx, client, scheduled, *interval, webhook)
+		return
+	case "history-diff":
+		if historyStore == nil {
+			fatal("--history-file is required for history-diff command")
+		}
+		runHistoryDiffCommand(client, historyStore, *domain, parseDate("since", *since))
+		return
+	case "invoices-list", "invoices-download":
+		runBillingCommand(client, *command, *since, *until, *invoiceDir)
+		return
+	case "traffic":
+		runTrafficCommand(client, *domain, *month, *trafficFormat)
+		return
+	case "record-fixtures":
+		paths, err := client.RecordFixtures(*fixturesDir)
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+		if err != nil {
+			fatalf("Failed to record fixtures: %v", err)
+		}
+		return
+	case "mail-mode":
+		runMailModeCommand(client, *mailMode, *mxHosts)
+		return
+	case "dmarc-set":
+		runDMARCSetCommand(client, dmarc.Record{
+			Policy:          dmarc.Policy(*dmarcPolicy),
+			SubdomainPolicy: dmarc.Policy(*dmarcSubdomainPolicy),
+			RUA:             splitList(*dmarcRUA),
+			RUF:             splitList(*dmarcRUF),
+			Percent:         *dmarcPercent,
+			ADKIM:           dmarc.Alignment(*dmarcADKIM),
+			ASPF:            dmarc.Alignment(*dmarcASPF),
+		})
+		return
+	case "serve":
+		runServeCommand(client, serveOptions{
+			listen:          *listen,
+			requireApproval: *requireApproval,
+			trustedProxy:    *trustedProxy,
+			tokensFile:      *apiTokens,
+			tokensVaultPath: *apiTokensVaultPath,
+			vaultMount:      *vaultMount,
+			watchDomains:    splitList(*watchDomains),
+			interval:        *interval,
+			status:          statusTracker,
+			schedule:        scheduled,
+			webhook:         webhook,
+			keepAlive:       *keepAlive,
+		})
+		return
+	case "acme-dns":
+		runACMEDNSCommand(client, acmeDNSOptions{
+			listen:       *listen,
+			domain:       *domain,
+			prefix:       *recordPrefix,
+			accounts:     *acmeDNSAccounts,
+			registerFrom: splitList(*acmeDNSRegisterFrom),
+			maxAccounts:  *acmeDNSMaxAccounts,
+		})
+		return
+	case "verify-add":
+		if *provider == "" || *token == "" {
+			fatal("--provider and --token are required for verify-add command")
+		}
+		added, err := client.AddVerificationRecord(*provider, *token)
+		if err != nil {
+			fatalf("Failed to add verification record: %v", err)
+		}
+		if added {
+			klog.V(2).Infof("Verification record for %s added", *provider)
+		} else {
+			klog.V(2).Infof("Verification record for %s already exists", *provider)
+		}
+		return
+	case "bimi-set":
+		runBIMISetCommand(client, bimi.Record{Selector: *selector, Logo: *bimiLogo, Authority: *bimiVMC}, *bimiVerify)
+		return
+	case "dkim-rotate":
+		runDKIMRotateCommand(client, dkimOptions{
+			domain:   *domain,
+			selector: *selector,
+			keyType:  *dkimKeyType,
+			keyBits:  *dkimKeyBits,
+			keyOut:   *dkimKeyOut,
+			replace:  *dkimReplace,
+			wait:     *propagationTimeout,
+			check:    *propagationCheck,
+		})
+		return
+	case "change-password":
+		if newPassword == "" {
+			fatal("--new-password-file or STRATO_NEW_PASSWORD is required for change-password command")
+		}
+		if err := client.ChangeAccountPassword(*password, newPassword); err != nil {
+			fatalf("Failed to change account password: %v", err)
+		}
+		if store != nil {
+			if err := store.Set(*identifier, newPassword); err != nil {
+				fatalf("Password changed but failed to update credential store: %v", err)
+			}
+		}
+		klog.V(2).Info("Account password changed successfully")
 		return
 	default:
-		klog.Fatalf("Invalid command: %s. Use add, remove, or list", *command)
+		fatalf("Invalid command: %s. Use %s", *command, strings.Join(commands, ", "))
 	}
 	defer klog.Flush()
 }
@@ -145,15 +1107,103 @@ func printConfig(config strato.DNSConfig) {
 	klog.V(2).Info("SPF Type:", config.SPFType)
 	klog.V(2).Info("DNS records:")
 	for _, record := range config.Records {
-		klog.V(2).Infof("Type: '%s', Prefix: '%s', Value: '%s'", record.Type, record.Prefix, record.Value)
+		klog.V(2).Info(record)
+	}
+}
+
+// notify posts n to webhook if one is configured; failures are only logged
+func notify(webhook *strato.Webhook, n strato.Notification) {
+	if webhook == nil {
+		return
+	}
+	if n.Time.IsZero() {
+		n.Time = time.Now()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := webhook.Notify(ctx, n); err != nil {
+		klog.Errorf("Failed to notify webhook: %v", err)
+	}
+}
+
+// startKeepAlive keeps the session of client alive in the background until ctx is
+// cancelled, unless interval is zero
+func startKeepAlive(ctx context.Context, client *strato.StratoClient, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		if err := client.KeepAlive(ctx, interval); err != nil && !errors.Is(err, context.Canceled) {
+			klog.Errorf("Keep-alive stopped: %v", err)
+		}
+	}()
+}
+
+// loadSyncFile renders and parses the desired configuration of a sync file
+func loadSyncFile(ctx context.Context, path string) map[string]strato.DNSConfig {
+	rendered, err := strato.RenderTemplate(path, readFile(path), strato.NewTemplateData(ctx, nil))
+	if err != nil {
+		fatalf("Failed to render %s: %v", path, err)
+	}
+	var desired map[string]strato.DNSConfig
+	if err := json.Unmarshal(rendered, &desired); err != nil {
+		fatalf("Failed to parse %s: %v", path, err)
+	}
+	return desired
+}
+
+// confirmSync shows the changes that synchronizing desired would make and asks for
+// confirmation. It returns the desired configuration of the domains to change, or
+// nil if nothing differs or the changes were not confirmed.
+func confirmSync(ctx context.Context, client *strato.StratoClient, desired map[string]strato.DNSConfig, concurrency int) map[string]strato.DNSConfig {
+	report := client.CheckDrift(ctx, desired, concurrency)
+	if err := report.Err(); err != nil {
+		fatalf("Failed to compare %d of %d domains: %v", len(report.Failed()), len(report.Results), err)
+	}
+	drifted := report.Drifted()
+	if len(drifted) == 0 {
+		klog.V(2).Info("All domains are in sync")
+		return nil
+	}
+	changes := make(map[string]strato.DNSConfig, len(drifted))
+	var text strings.Builder
+	for _, result := range drifted {
+		changes[result.Domain] = desired[result.Domain]
+		fmt.Fprintf(&text, "%s\n%s\n", result.Domain, result.Diff)
+	}
+	if !confirmf(false, "%sApply these changes to %d domains?", text.String(), len(drifted)) {
+		klog.V(2).Info("Aborted")
+		return nil
+	}
+	return changes
+}
+
+// printSyncReport prints the status of every domain of a sync and notifies the webhook
+// of changes and failures
+func printSyncReport(report strato.SyncReport, webhook *strato.Webhook) {
+	for _, result := range report.Results {
+		status := "unchanged"
+		if result.Err != nil {
+			status = "failed: " + result.Err.Error()
+		} else if result.Changed {
+			status = "changed"
+		}
+		fmt.Printf("%s\t%s\n", result.Domain, status)
+		if result.Err != nil {
+			notify(webhook, strato.Notification{Domain: result.Domain, Event: "sync failed", Diff: result.Diff, Error: result.Err.Error()})
+		} else if result.Changed {
+			notify(webhook, strato.Notification{Domain: result.Domain, Event: "records changed", Diff: result.Diff})
+		}
 	}
 }
 
-func contains(records []strato.DNSRecord, record strato.DNSRecord) bool {
-	for _, entry := range records {
-		if entry.Type == record.Type && entry.Prefix == record.Prefix && entry.Value == record.Value {
-			return true
+// splitList splits a comma separated flag value, ignoring empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
-	return false
+	return items
 }
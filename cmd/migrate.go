@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/migrate"
+	"k8s.io/klog/v2"
+)
+
+// migrateOptions are the flags of the migrate command
+type migrateOptions struct {
+	from   string
+	to     string
+	zone   string
+	dryRun bool
+	yes    bool
+}
+
+// runMigrateCommand copies the records of a zone at another provider to the domain of
+// client, or with --target-provider the records of the domain to the other provider
+func runMigrateCommand(client *strato.StratoClient, o migrateOptions) {
+	if (o.from == "") == (o.to == "") {
+		fatal("either --source-provider or --target-provider is required for migrate command")
+	}
+	if o.to != "" {
+		runMigrateExport(client, o)
+		return
+	}
+	provider, err := newProvider(o.from)
+	if err != nil {
+		fatalf("Failed to configure %s: %v", o.from, err)
+	}
+	records, err := provider.Records(context.Background(), o.zone)
+	if err != nil {
+		fatalf("Failed to read %s from %s: %v", o.zone, o.from, err)
+	}
+	plan := migrate.Convert(records)
+	for _, skipped := range plan.Skipped {
+		fmt.Fprintf(os.Stderr, "skipped %s: %s\n", skipped.Record, skipped.Reason)
+	}
+	klog.V(2).Infof("%d of %d records of %s can be created at Strato", len(plan.Records), len(records), o.zone)
+	if o.dryRun {
+		for _, record := range plan.Records {
+			fmt.Println("+", record)
+		}
+		return
+	}
+	if !o.yes {
+		current, err := client.GetDNSConfiguration()
+		if err != nil {
+			fatalf("Failed to fetch current configuration: %v", err)
+		}
+		zone := strato.NewZone(current)
+		for _, record := range plan.Records {
+			zone.Add(record)
+		}
+		diff := strato.DiffConfigs(current, zone.Config())
+		if diff.Empty() {
+			klog.V(2).Info("All records already exist")
+			return
+		}
+		if !confirm(diff, false) {
+			klog.V(2).Info("Aborted")
+			return
+		}
+	}
+	diff, err := migrate.Import(client, plan)
+	if err != nil {
+		fatalf("Failed to create records: %v", err)
+	}
+	fmt.Println(diff)
+}
+
+// runMigrateExport pushes the records of the domain of client to the zone at --target-provider
+func runMigrateExport(client *strato.StratoClient, o migrateOptions) {
+	writer, err := newProvider(o.to)
+	if err != nil {
+		fatalf("Failed to configure %s: %v", o.to, err)
+	}
+	config, err := client.GetDNSConfiguration()
+	if err != nil {
+		fatalf("Failed to fetch DNS configuration: %v", err)
+	}
+	ctx := context.Background()
+	planned, err := migrate.PlanExport(ctx, config, writer, o.zone)
+	if err != nil {
+		fatalf("Failed to read %s from %s: %v", o.zone, o.to, err)
+	}
+	if len(planned) == 0 {
+		klog.V(2).Infof("%s at %s already matches", o.zone, o.to)
+		return
+	}
+	for _, record := range planned {
+		fmt.Println("=", record)
+	}
+	if o.dryRun || !confirmf(o.yes, "Replace these record sets at %s?", o.to) {
+		return
+	}
+	written, err := migrate.Export(ctx, config, writer, o.zone)
+	if err != nil {
+		fatalf("Failed to write records to %s: %v", o.to, err)
+	}
+	klog.V(2).Infof("Wrote %d records to %s", len(written), o.to)
+}
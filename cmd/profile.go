@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// loadProfiles returns a manager with the accounts of the profiles file at path
+func loadProfiles(path string) (*strato.AccountManager, error) {
+	manager := strato.NewAccountManager(0)
+	if path == "" {
+		return manager, nil
+	}
+	return manager, manager.LoadProfiles(path)
+}
+
+// applyProfile sets the account flags that were not given from the account of
+// profile in the profiles file at path
+func applyProfile(path, profile string, flags map[string]*string) error {
+	manager, err := loadProfiles(path)
+	if err != nil {
+		return err
+	}
+	account, ok := manager.Account(profile)
+	if !ok {
+		return fmt.Errorf("unknown profile %s, the profiles file has %v", profile, manager.Profiles())
+	}
+	for name, value := range map[string]string{
+		"api":        account.API,
+		"identifier": account.Identifier,
+		"password":   account.Password,
+		"order":      account.Order,
+		"domain":     account.Domain,
+	} {
+		if value != "" && !isFlagSet(name) {
+			*flags[name] = value
+		}
+	}
+	return nil
+}
+
+// printProfiles lists the profiles of the profiles file at path
+func printProfiles(w io.Writer, path string) error {
+	manager, err := loadProfiles(path)
+	if err != nil {
+		return err
+	}
+	for _, profile := range manager.Profiles() {
+		fmt.Fprintln(w, profile)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"net/http"
+	_ "net/http/pprof"
+
+	"k8s.io/klog/v2"
+)
+
+// startProfiling serves the pprof endpoints below /debug/pprof/ on addr, so the
+// long-running watch and sync commands can be profiled while they work
+func startProfiling(addr string) {
+	go func() {
+		klog.V(2).Infof("Serving pprof on http://%s/debug/pprof/", addr)
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			klog.Errorf("Failed to serve pprof: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// parseProtection turns TYPE:PREFIX patterns into record filters. Either part may be
+// empty, the prefix is a glob, e.g. MX, TXT:*._domainkey or :www.
+func parseProtection(patterns []string) []strato.RecordFilter {
+	filters := make([]strato.RecordFilter, 0, len(patterns))
+	for _, pattern := range patterns {
+		recordType, prefix, _ := strings.Cut(pattern, ":")
+		if recordType == "" && prefix == "" {
+			fatalf("Invalid --protect pattern %q, use TYPE:PREFIX", pattern)
+		}
+		filters = append(filters, strato.RecordFilter{Type: recordType, Prefix: prefix})
+	}
+	return filters
+}
+
+// runProtectCommand sets or clears the protection of the record given by --type,
+// --prefix and --value
+func runProtectCommand(client *strato.StratoClient, command string, record strato.DNSRecord) {
+	if record.Value == "" {
+		fatalf("--type, --prefix and --value are required for %s command", command)
+	}
+	config, err := client.GetDNSConfiguration()
+	if err != nil {
+		fatalf("Failed to fetch DNS configuration: %v", err)
+	}
+	if !strato.ContainsRecord(config.Records, record) {
+		fatalf("Record not found: %s", record)
+	}
+	if err := client.Protect(record, command == "protect"); err != nil {
+		fatalf("Failed to %s record: %v", command, err)
+	}
+	klog.V(2).Infof("Record %sed: %s", command, record)
+}
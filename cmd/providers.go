@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fl0eb/go-strato/migrate"
+	"github.com/libdns/cloudflare"
+	"github.com/libdns/hetzner"
+	"github.com/libdns/route53"
+)
+
+// providerNames lists the names accepted by newProvider
+var providerNames = []string{"cloudflare", "hetzner", "route53"}
+
+// newProvider creates the libdns provider called name with the credentials of
+// its usual environment variables: CLOUDFLARE_API_TOKEN, HETZNER_DNS_API_TOKEN, or
+// for route53 the default credential chain of the AWS SDK, e.g. AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and optionally AWS_SESSION_TOKEN
+func newProvider(name string) (*migrate.LibDNS, error) {
+	switch strings.ToLower(name) {
+	case "cloudflare":
+		token := os.Getenv("CLOUDFLARE_API_TOKEN")
+		if token == "" {
+			return nil, errors.New("CLOUDFLARE_API_TOKEN must be set")
+		}
+		return &migrate.LibDNS{Name: "cloudflare", Provider: &cloudflare.Provider{APIToken: token}}, nil
+	case "hetzner":
+		token := os.Getenv("HETZNER_DNS_API_TOKEN")
+		if token == "" {
+			return nil, errors.New("HETZNER_DNS_API_TOKEN must be set")
+		}
+		return &migrate.LibDNS{Name: "hetzner", Provider: hetzner.New(token)}, nil
+	case "route53":
+		return &migrate.LibDNS{Name: "route53", Provider: &route53.Provider{}}, nil
+	}
+	return nil, fmt.Errorf("unknown provider %q, use one of %s", name, strings.Join(providerNames, ", "))
+}
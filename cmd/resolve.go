@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// resolveRecords looks every record up in DNS for list --resolve. Records that cannot
+// be resolved are left out and show empty columns.
+func resolveRecords(client *strato.StratoClient, records []strato.DNSRecord) map[strato.DNSRecord]*strato.EffectiveRecord {
+	resolved := make(map[strato.DNSRecord]*strato.EffectiveRecord, len(records))
+	for _, record := range records {
+		effective, err := client.ResolveEffective(record)
+		if err != nil {
+			klog.Warningf("Failed to resolve %s record %s: %v", record.Type, record.Prefix, err)
+			continue
+		}
+		resolved[record] = &effective
+	}
+	return resolved
+}
+
+// printPublishReport writes the result of dns-verify as a table or as JSON
+func printPublishReport(w io.Writer, report strato.PublishReport, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	rows := make([][]string, 0, len(report.Results))
+	for _, result := range report.Results {
+		detail := strings.Join(result.Servers, ",")
+		if result.Error != "" {
+			detail = result.Error
+		}
+		rows = append(rows, []string{result.Status, result.Record.Type, result.Record.Prefix, result.Record.Value, detail})
+	}
+	return printRows(w, []string{"status", "type", "prefix", "value", "servers"}, rows)
+}
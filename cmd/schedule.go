@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// scheduleOptions are the flags of the schedule commands
+type scheduleOptions struct {
+	schedule *strato.Schedule
+	domain   string
+	at       string
+	apply    string
+	yes      bool
+}
+
+// runScheduleCommand stores the change from the current configuration to --apply as
+// due at --at
+func runScheduleCommand(client *strato.StratoClient, o scheduleOptions) {
+	if o.at == "" || o.apply == "" {
+		fatal("--at and --apply are required for schedule command")
+	}
+	at := parseTime("at", o.at)
+	if at.Before(time.Now()) {
+		fatalf("--at %s is in the past", o.at)
+	}
+	current, err := client.GetDNSConfiguration(strato.ForceRefresh())
+	if err != nil {
+		fatalf("Failed to fetch current configuration: %v", err)
+	}
+	desired, err := readImport(o.apply, current)
+	if err != nil {
+		fatalf("Failed to read %s: %v", o.apply, err)
+	}
+	change := strato.ScheduleChange(o.domain, at, current, desired)
+	if change.Diff().Empty() {
+		klog.V(2).Info("Configuration already matches the file")
+		return
+	}
+	change.Source = o.apply
+	fmt.Println(change.Diff())
+	if !confirm(change.Diff(), o.yes) {
+		klog.V(2).Info("Aborted")
+		return
+	}
+	change, err = o.schedule.Add(change)
+	if err != nil {
+		fatalf("Failed to schedule change: %v", err)
+	}
+	fmt.Printf("Scheduled change %s for %s\n", change.ID, change.At.Local().Format(time.RFC3339))
+}
+
+// printSchedule writes the scheduled changes of domain, or of all domains if domain is empty
+func printSchedule(w io.Writer, schedule *strato.Schedule, domain string, asJSON bool) error {
+	changes, err := schedule.Changes()
+	if err != nil {
+		return err
+	}
+	var selected []strato.ScheduledChange
+	for _, change := range changes {
+		if domain == "" || change.Domain == domain {
+			selected = append(selected, change)
+		}
+	}
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(selected)
+	}
+	for _, change := range selected {
+		status := change.Status
+		if change.Error != "" {
+			status += ": " + change.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t+%d -%d\t%s\n", change.ID, change.At.Local().Format(time.RFC3339), change.Domain,
+			len(change.Add), len(change.Remove), status)
+	}
+	return nil
+}
+
+// runScheduler applies scheduled changes as they become due until ctx is cancelled
+func runScheduler(ctx context.Context, client *strato.StratoClient, schedule *strato.Schedule, interval time.Duration, webhook *strato.Webhook) {
+	klog.V(2).Infof("Applying scheduled changes every %s", interval)
+	err := client.RunSchedule(ctx, schedule, interval, func(change strato.ScheduledChange) {
+		if change.Status == strato.ScheduleFailed {
+			klog.Errorf("Failed to apply scheduled change %s to %s: %s", change.ID, change.Domain, change.Error)
+			notify(webhook, strato.Notification{Domain: change.Domain, Event: "scheduled change failed", Diff: change.Diff(), Error: change.Error})
+			return
+		}
+		klog.V(2).Infof("Applied scheduled change %s to %s", change.ID, change.Domain)
+		notify(webhook, strato.Notification{Domain: change.Domain, Event: "scheduled change applied", Diff: change.Diff()})
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		klog.Errorf("Stopped applying scheduled changes: %v", err)
+	}
+}
+
+// parseTime parses the value of the flag name as local time, with or without seconds,
+// or as RFC 3339 with a zone offset
+func parseTime(name, value string) time.Time {
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02T15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t
+		}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		fatalf("Invalid --%s time, use YYYY-MM-DDTHH:MM: %v", name, err)
+	}
+	return t
+}
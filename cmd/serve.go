@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/server"
+	"k8s.io/klog/v2"
+)
+
+// serveOptions are the flags of the serve command
+type serveOptions struct {
+	listen          string
+	requireApproval bool
+	trustedProxy    bool
+	tokensFile      string
+	tokensVaultPath string
+	vaultMount      string
+	watchDomains    []string
+	interval        time.Duration
+	status          *strato.StatusTracker
+	schedule        *strato.Schedule
+	webhook         *strato.Webhook
+	keepAlive       time.Duration
+}
+
+// runServeCommand serves the REST API until SIGINT or SIGTERM
+func runServeCommand(client *strato.StratoClient, o serveOptions) {
+	var opts []server.Option
+	if o.requireApproval {
+		opts = append(opts, server.WithApproval())
+	}
+	tokens := loadTokens(o)
+	if tokens != nil {
+		opts = append(opts, server.WithTokens(tokens))
+	} else {
+		klog.Warning("Serving without API tokens, only expose the server to trusted clients")
+	}
+	if o.trustedProxy && tokens != nil {
+		// The user of a request is the token it was sent with, no header can claim another
+		klog.Warning("Ignoring --trusted-proxy, requests with API tokens act as the user of their token")
+	} else if o.trustedProxy {
+		opts = append(opts, server.WithTrustedProxy())
+	} else if o.requireApproval && tokens == nil {
+		// Without a trusted user name anyone could approve their own proposals
+		fatal("--require-approval needs --api-tokens or --trusted-proxy")
+	}
+	if o.status != nil {
+		opts = append(opts, server.WithStatus(o.status))
+	}
+	api := server.New(client, opts...)
+	httpServer := &http.Server{
+		Addr:              o.listen,
+		Handler:           api,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if len(o.watchDomains) > 0 {
+		go func() {
+			if err := api.Watch(ctx, o.watchDomains, o.interval); err != nil {
+				klog.Errorf("Stopped watching domains: %v", err)
+			}
+		}()
+	}
+	startKeepAlive(ctx, client, o.keepAlive)
+	if o.schedule != nil {
+		go runScheduler(ctx, client, o.schedule, o.interval, o.webhook)
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+	klog.V(2).Infof("Serving API on %s", o.listen)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatalf("Failed to serve API: %v", err)
+	}
+}
+
+// loadTokens reads the API tokens from --api-tokens or the "tokens" key of the Vault
+// secret at --api-tokens-vault-path; nil means the server runs without tokens
+func loadTokens(o serveOptions) []server.Token {
+	var tokens []server.Token
+	var err error
+	switch {
+	case o.tokensFile != "":
+		tokens, err = server.LoadTokens(o.tokensFile)
+	case o.tokensVaultPath != "":
+		var provider *strato.VaultProvider
+		if provider, err = strato.NewVaultProviderFromEnv(o.vaultMount, o.tokensVaultPath); err != nil {
+			break
+		}
+		var secret map[string]string
+		if secret, err = provider.Secret(context.Background()); err == nil {
+			tokens, err = server.ParseTokens([]byte(secret["tokens"]))
+		}
+	default:
+		return nil
+	}
+	if err != nil {
+		fatalf("Failed to load API tokens: %v", err)
+	}
+	if tokens == nil {
+		tokens = []server.Token{}
+	}
+	return tokens
+}
+
+// printAPIToken generates a token secret and prints it with the hash for the tokens file
+func printAPIToken(w io.Writer) error {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(secret)
+	_, err := fmt.Fprintf(w, "token:  %s\nsha256: %s\n", token, server.HashToken(token))
+	return err
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// serviceOptions describe the background service installed by the install-service command
+type serviceOptions struct {
+	name    string
+	command string
+	domain  string
+	envFile string
+	args    []string
+	dryRun  bool
+}
+
+// serviceArgs returns the command line the service runs the binary with
+func (o serviceOptions) serviceArgs() ([]string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	envFile, err := filepath.Abs(o.envFile)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{executable, "--env-file", envFile, "--command", o.command, "--domain", o.domain}
+	return append(args, o.args...), nil
+}
+
+// installService writes and registers a systemd unit, launchd agent or Windows task that
+// keeps the binary running. Credentials are read from the env file at start.
+func installService(o serviceOptions) error {
+	if o.envFile == "" {
+		return fmt.Errorf("--env-file is required for install-service command")
+	}
+	args, err := o.serviceArgs()
+	if err != nil {
+		return err
+	}
+	switch runtime.GOOS {
+	case "linux":
+		path := filepath.Join("/etc/systemd/system", o.name+".service")
+		unit := fmt.Sprintf(`[Unit]
+Description=go-strato %s for %s
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+ExecStart=%s
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=multi-user.target
+`, o.command, o.domain, strings.Join(quoteAll(args, systemdQuote), " "))
+		return writeService(o, path, unit, "systemctl daemon-reload && systemctl enable --now "+o.name)
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		label := "com.github.fl0eb.go-strato." + o.name
+		path := filepath.Join(home, "Library", "LaunchAgents", label+".plist")
+		var arguments strings.Builder
+		for _, arg := range args {
+			fmt.Fprintf(&arguments, "\t\t<string>%s</string>\n", xmlEscape(arg))
+		}
+		plist := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+%s	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+</dict>
+</plist>
+`, label, arguments.String())
+		return writeService(o, path, plist, "launchctl load -w "+path)
+	case "windows":
+		// A scheduled task started with the system runs the binary without a service wrapper
+		taskArgs := []string{"/Create", "/F", "/SC", "ONSTART", "/RU", "SYSTEM", "/TN", o.name,
+			"/TR", strings.Join(quoteAll(args, windowsQuote), " ")}
+		if o.dryRun {
+			fmt.Println("schtasks " + strings.Join(quoteAll(taskArgs, windowsQuote), " "))
+			return nil
+		}
+		cmd := exec.Command("schtasks", taskArgs...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		return cmd.Run()
+	}
+	return fmt.Errorf("install-service is not supported on %s", runtime.GOOS)
+}
+
+// writeService writes content to path, or prints it with dryRun (--print-service), and
+// tells how to activate it
+func writeService(o serviceOptions, path, content, activate string) error {
+	if o.dryRun {
+		fmt.Printf("# %s\n%s", path, content)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\nActivate it with: %s\n", path, activate)
+	return nil
+}
+
+func quoteAll(args []string, quote func(string) string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quote(arg)
+	}
+	return quoted
+}
+
+func systemdQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$%") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$", "%", "%%").Replace(arg) + `"`
+}
+
+func windowsQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"") {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// runTrafficCommand prints the traffic statistics of a month as CSV or JSON
+func runTrafficCommand(client *strato.StratoClient, domain, month, format string) {
+	selected := time.Now()
+	if month != "" {
+		var err error
+		selected, err = time.Parse("2006-01", month)
+		if err != nil {
+			fatalf("Invalid --month, use YYYY-MM: %v", err)
+		}
+	}
+	stats, err := client.GetTrafficStats(domain, selected)
+	if err != nil {
+		fatalf("Failed to fetch traffic statistics: %v", err)
+	}
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(stats)
+	case "csv":
+		writer := csv.NewWriter(os.Stdout)
+		writer.Write([]string{"date", "bytes", "requests"})
+		for _, day := range stats.Days {
+			writer.Write([]string{day.Date.Format(time.DateOnly), strconv.FormatInt(day.Bytes, 10), strconv.FormatInt(day.Requests, 10)})
+		}
+		writer.Flush()
+		err = writer.Error()
+	default:
+		fatalf("Invalid traffic format: %s. Use csv or json", format)
+	}
+	if err != nil {
+		fatalf("Failed to print traffic statistics: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// newStatusTracker creates the tracker of --status-file and publishes its statuses as
+// the expvar sync_status, served on /debug/vars next to the pprof endpoints
+func newStatusTracker(path string) (*strato.StatusTracker, error) {
+	tracker, err := strato.NewStatusTracker(path)
+	if err != nil {
+		return nil, err
+	}
+	expvar.Publish("sync_status", expvar.Func(func() interface{} { return tracker.Statuses() }))
+	return tracker, nil
+}
+
+// printStatus writes the statuses as a table or as JSON and returns how many domains are failing
+func printStatus(w io.Writer, statuses []strato.SyncStatus, asJSON bool) (int, error) {
+	failing := 0
+	for _, status := range statuses {
+		if status.Failing() {
+			failing++
+		}
+	}
+	if asJSON {
+		if statuses == nil {
+			statuses = []strato.SyncStatus{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return failing, encoder.Encode(statuses)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DOMAIN\tLAST SUCCESS\tFAILURES\tNEXT RETRY\tLAST ERROR")
+	for _, status := range statuses {
+		lastError := ""
+		if status.Failing() {
+			lastError = status.LastError
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", status.Domain, formatStatusTime(status.LastSuccess),
+			status.ConsecutiveFailures, formatStatusTime(status.NextRetry), lastError)
+	}
+	return failing, tw.Flush()
+}
+
+// formatStatusTime formats t in local time, or "-" if it is not set
+func formatStatusTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
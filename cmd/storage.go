@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// runStorageCommand executes the storage-* commands against the hiDrive package storageOrder
+func runStorageCommand(client *strato.StratoClient, command, storageOrder string) {
+	if storageOrder == "" {
+		fatalf("--storage-order is required for %s command", command)
+	}
+	storage, err := client.Storage(storageOrder)
+	if err != nil {
+		fatalf("Failed to open storage package: %v", err)
+	}
+	switch command {
+	case "storage-quota":
+		quota, err := storage.Quota()
+		if err != nil {
+			fatalf("Failed to fetch storage quota: %v", err)
+		}
+		if err := printRows(os.Stdout, []string{"used", "total"}, [][]string{{quota.Used, quota.Total}}); err != nil {
+			fatalf("Failed to print storage quota: %v", err)
+		}
+	case "storage-shares":
+		links, err := storage.ListShareLinks()
+		if err != nil {
+			fatalf("Failed to fetch share links: %v", err)
+		}
+		rows := make([][]string, 0, len(links))
+		for _, link := range links {
+			expiry := "never"
+			if !link.Expiry.IsZero() {
+				expiry = link.Expiry.Format(time.DateOnly)
+			}
+			rows = append(rows, []string{link.Path, link.URL, expiry})
+		}
+		if err := printRows(os.Stdout, []string{"path", "url", "expiry"}, rows); err != nil {
+			fatalf("Failed to print share links: %v", err)
+		}
+	}
+}
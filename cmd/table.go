@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// maxValueWidth is the width long values (e.g. DKIM keys) are cut to in table output
+const maxValueWidth = 60
+
+// recordRow is a record with the data shown next to it by the list command
+type recordRow struct {
+	record     strato.DNSRecord
+	annotation strato.Annotation
+	// effective is only set with --resolve
+	effective *strato.EffectiveRecord
+}
+
+var recordColumns = map[string]func(recordRow) string{
+	"type":    func(r recordRow) string { return r.record.Type },
+	"prefix":  func(r recordRow) string { return r.record.Prefix },
+	"value":   func(r recordRow) string { return r.record.Value },
+	"owner":   func(r recordRow) string { return r.annotation.Owner },
+	"purpose": func(r recordRow) string { return r.annotation.Purpose },
+	"created": func(r recordRow) string {
+		if r.annotation.Created.IsZero() {
+			return ""
+		}
+		return r.annotation.Created.Format(time.RFC3339)
+	},
+	"ttl": func(r recordRow) string {
+		if r.effective == nil || r.effective.TTL == 0 {
+			return ""
+		}
+		return r.effective.TTL.String()
+	},
+	"propagation": func(r recordRow) string {
+		if r.effective == nil {
+			return ""
+		}
+		return r.effective.Propagation()
+	},
+}
+
+// parseColumns validates a comma separated column list
+func parseColumns(list string) ([]string, error) {
+	var columns []string
+	for _, column := range strings.Split(list, ",") {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if _, ok := recordColumns[column]; !ok {
+			return nil, fmt.Errorf("unknown column %q, use type, prefix, value, owner, purpose, created, ttl or propagation", column)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// printTable writes the records with their annotations and, for --resolve, their
+// state in DNS as aligned columns. Unless wide is set, long values are shortened.
+func printTable(w io.Writer, records []strato.DNSRecord, annotations map[strato.DNSRecord]strato.Annotation, resolved map[strato.DNSRecord]*strato.EffectiveRecord, columns []string, header, wide bool) error {
+	rows := make([][]string, 0, len(records))
+	for _, record := range records {
+		fields := make([]string, len(columns))
+		for i, column := range columns {
+			field := recordColumns[column](recordRow{record: record, annotation: annotations[record], effective: resolved[record]})
+			if runes := []rune(field); !wide && len(runes) > maxValueWidth {
+				field = string(runes[:maxValueWidth-3]) + "..."
+			}
+			fields[i] = field
+		}
+		rows = append(rows, fields)
+	}
+	if !header {
+		columns = nil
+	}
+	return printRows(w, columns, rows)
+}
+
+// printRows writes rows as aligned columns below an optional header
+func printRows(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if header != nil {
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"k8s.io/klog/v2"
+)
+
+// openVault returns the provider of the Vault secret at path and keeps its token
+// alive for the lifetime of the process, for long-running commands like watch and ddns
+func openVault(mount, path string) *strato.VaultProvider {
+	provider, err := strato.NewVaultProviderFromEnv(mount, path)
+	if err != nil {
+		fatalf("Failed to configure Vault: %v", err)
+	}
+	go func() {
+		if err := provider.RenewToken(context.Background(), time.Hour); err != nil {
+			klog.Errorf("Failed to renew Vault token: %v", err)
+		}
+	}()
+	return provider
+}
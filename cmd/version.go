@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+	"k8s.io/klog/v2"
+)
+
+// Set at build time with -ldflags "-X main.version=... -X main.commit=... -X main.date=..."
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+// releasesURL is the GitHub API endpoint of the latest release
+const releasesURL = "https://api.github.com/repos/fl0eb/go-strato/releases/latest"
+
+// versionInfo describes the running binary
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+func buildInfo() versionInfo {
+	info := versionInfo{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	// Binaries built with go install carry the VCS information themselves
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+func printVersion(w io.Writer, asJSON bool) error {
+	info := buildInfo()
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+	_, err := fmt.Fprintf(w, "%s (commit %s, built %s, %s, %s)\n", info.Version, info.Commit, info.Date, info.GoVersion, info.Platform)
+	return err
+}
+
+// selfUpdate replaces the running binary with the asset of the latest release for
+// this platform, after checking it against the checksums file of the release. The
+// assets are published by the release configuration in .goreleaser.yaml. Builds
+// without a release version are not replaced, and neither are newer ones.
+func selfUpdate() (string, error) {
+	current := buildInfo().Version
+	if !semver.IsValid(current) {
+		return "", fmt.Errorf("%s build has no release version to update from, install a release instead", current)
+	}
+	client := &http.Client{Timeout: 5 * time.Minute}
+	var release struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	body, err := download(client, releasesURL)
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(body, &release); err != nil {
+		return "", err
+	}
+	if !semver.IsValid(release.TagName) {
+		return "", fmt.Errorf("latest release has an invalid version %q", release.TagName)
+	}
+	switch semver.Compare(release.TagName, current) {
+	case 0:
+		return current, nil
+	case -1:
+		klog.Infof("%s is newer than the latest release %s, not downgrading", current, release.TagName)
+		return current, nil
+	}
+
+	assetName := fmt.Sprintf("strato_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		assetName += ".exe"
+	}
+	var assetURL, checksumsURL string
+	for _, asset := range release.Assets {
+		switch asset.Name {
+		case assetName:
+			assetURL = asset.URL
+		case "checksums.txt":
+			checksumsURL = asset.URL
+		}
+	}
+	if assetURL == "" || checksumsURL == "" {
+		return "", fmt.Errorf("release %s has no %s or checksums.txt", release.TagName, assetName)
+	}
+
+	checksums, err := download(client, checksumsURL)
+	if err != nil {
+		return "", err
+	}
+	expected := ""
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 2 && fields[1] == assetName {
+			expected = fields[0]
+		}
+	}
+	if expected == "" {
+		return "", fmt.Errorf("checksums.txt lists no checksum for %s", assetName)
+	}
+	binary, err := download(client, assetURL)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(binary)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s", assetName, expected, actual)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return "", err
+	}
+	tmp := executable + ".new"
+	if err := os.WriteFile(tmp, binary, 0o755); err != nil {
+		return "", err
+	}
+	// Windows cannot replace a running executable, but can rename it
+	old := executable + ".old"
+	os.Remove(old)
+	if err := os.Rename(executable, old); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, executable); err != nil {
+		os.Rename(old, executable)
+		return "", err
+	}
+	if runtime.GOOS != "windows" {
+		os.Remove(old)
+	}
+	return release.TagName, nil
+}
+
+// download returns the body of url
+func download(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("unexpected response status for " + url + ": " + resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,139 @@
+package strato
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// ContactRole selects one of the contacts of a domain
+type ContactRole string
+
+const (
+	// ContactOwner is the registrant of the domain
+	ContactOwner ContactRole = "owner"
+	// ContactAdmin is the administrative contact (admin-c)
+	ContactAdmin ContactRole = "admin"
+)
+
+// Contact holds the WHOIS contact data of a domain
+type Contact struct {
+	Name         string `json:"name"`
+	Organization string `json:"organization,omitempty"`
+	Street       string `json:"street"`
+	PostalCode   string `json:"postalCode"`
+	City         string `json:"city"`
+	Country      string `json:"country"`
+	Email        string `json:"email"`
+	Phone        string `json:"phone"`
+}
+
+// contactFields maps the form field suffixes to the Contact fields
+func contactFields(contact *Contact) map[string]*string {
+	return map[string]*string{
+		"name":         &contact.Name,
+		"organization": &contact.Organization,
+		"street":       &contact.Street,
+		"zip":          &contact.PostalCode,
+		"city":         &contact.City,
+		"country":      &contact.Country,
+		"email":        &contact.Email,
+		"phone":        &contact.Phone,
+	}
+}
+
+// GetDomainContact retrieves the contact data of a domain for the given role
+func (c *StratoClient) GetDomainContact(domain string, role ContactRole) (Contact, error) {
+	doc, err := c.fetchPage(c.region.ManageDomainsNode, "vhost="+domain, "action_show_contacts", "role="+string(role))
+	if err != nil {
+		return Contact{}, err
+	}
+	form := htmlquery.FindOne(doc, "//form[@id='jss_contact_form']")
+	if form == nil {
+		return Contact{}, errors.New("failed to find contact form")
+	}
+	contact := Contact{}
+	for field, value := range contactFields(&contact) {
+		*value = attrOf(form, ".//input[@name="+xpathLiteral(string(role)+"_"+field)+"]", "value")
+	}
+	if contact.Name == "" && contact.Organization == "" {
+		return Contact{}, errors.New("failed to find contact name")
+	}
+	return contact, nil
+}
+
+// UpdateDomainContact replaces the contact data of a domain for the given role.
+// Strato asks to confirm owner changes on a second page, this confirmation is submitted automatically.
+func (c *StratoClient) UpdateDomainContact(domain string, role ContactRole, contact Contact) error {
+	if contact.Name == "" || contact.Email == "" || contact.Country == "" {
+		return errors.New("contact name, email and country are required")
+	}
+	form := url.Values{}
+	form.Set("vhost", domain)
+	form.Set("role", string(role))
+	for field, value := range contactFields(&contact) {
+		form.Set(string(role)+"_"+field, *value)
+	}
+	form.Set("action_change_contact", "1")
+	if proceed, err := c.checkWrite("change domain contact"); !proceed {
+		return err
+	}
+	err := c.changeDomainContact(domain, form)
+	c.auditForm(form, err)
+	return err
+}
+
+// changeDomainContact submits the contact form and, if the portal asks for it, the
+// confirmation of the change
+func (c *StratoClient) changeDomainContact(domain string, form url.Values) error {
+	resp, err := c.postForm(c.region.ManageDomainsNode, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusFound { // 302
+		return nil
+	} else if resp.StatusCode != http.StatusOK { // 200
+		return errors.New("unexpected response status: " + resp.Status)
+	}
+	doc, err := htmlquery.Parse(resp.Body)
+	if err != nil {
+		return err
+	}
+	confirmation := htmlquery.FindOne(doc, "//form[@id='jss_contact_confirm_form']")
+	if confirmation == nil {
+		return contactUpdateError(doc)
+	}
+	return c.confirmContactChange(domain, confirmation)
+}
+
+// confirmContactChange submits the confirmation page of a contact change
+func (c *StratoClient) confirmContactChange(domain string, confirmation *html.Node) error {
+	token := attrOf(confirmation, ".//input[@name='confirm_token']", "value")
+	if token == "" {
+		return errors.New("failed to find confirmation token")
+	}
+	form := url.Values{}
+	form.Set("vhost", domain)
+	form.Set("confirm_token", token)
+	form.Set("confirm", "1")
+	form.Set("action_confirm_contact_change", "1")
+	return c.sendForm(c.region.ManageDomainsNode, form)
+}
+
+// contactUpdateError builds the error for a rejected contact change from the returned
+// page, or returns nil if the page confirms the change without asking
+func contactUpdateError(doc *html.Node) error {
+	message, saved := banner(doc)
+	if saved {
+		return nil
+	}
+	if message != "" {
+		return errors.New("contact update failed: " + message)
+	}
+	return errors.New("contact update failed")
+}
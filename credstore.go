@@ -0,0 +1,83 @@
+package strato
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// CredentialService is the service name secrets are filed under in credential stores
+const CredentialService = "go-strato"
+
+// ErrCredentialNotFound is returned when a credential store holds no secret for an account
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// CredentialStore stores secrets such as the account password outside of config files
+type CredentialStore interface {
+	Get(account string) (string, error)
+	Set(account, secret string) error
+	Delete(account string) error
+}
+
+// NewKeyringStore returns a CredentialStore backed by the keychain of the operating system:
+// the kernel key retention service (keyctl) on Linux, the Keychain on macOS and the
+// Credential Manager on Windows.
+func NewKeyringStore() (CredentialStore, error) {
+	return newKeyringStore()
+}
+
+// sessionAccount is the account the session of the client is stored under
+func (c *StratoClient) sessionAccount() string {
+	return "session:" + normalizeIdentifier(c.identifier)
+}
+
+// restoreSession adopts the session kept in the session store and reports whether
+// the portal still accepts it
+func (c *StratoClient) restoreSession() bool {
+	if c.sessionStore == nil {
+		return false
+	}
+	blob, err := c.sessionStore.Get(c.sessionAccount())
+	if err != nil {
+		return false
+	}
+	var session PortalSession
+	apiURL, err := url.Parse(c.api)
+	if err != nil || json.Unmarshal([]byte(blob), &session) != nil || session.ID == "" {
+		return false
+	}
+	c.session.Jar.SetCookies(apiURL, session.Cookies)
+	c.setSessionID(session.ID)
+	if err := c.Ping(); err != nil {
+		logFor(LogAuth).Debug("Stored session is no longer valid", "error", err)
+		c.setSessionID("")
+		return false
+	}
+	logFor(LogAuth).Debug("Reusing stored session")
+	return true
+}
+
+// saveSession keeps the current session in the session store. Failures only cost
+// a login next time, so they are logged.
+func (c *StratoClient) saveSession() {
+	if c.sessionStore == nil {
+		return
+	}
+	apiURL, err := url.Parse(c.api)
+	if err != nil {
+		return
+	}
+	session := PortalSession{ID: c.sessionID()}
+	// The jar only returns names and values, which is all the portal needs
+	for _, cookie := range c.session.Jar.Cookies(apiURL) {
+		session.Cookies = append(session.Cookies, &http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	blob, err := json.Marshal(session)
+	if err == nil {
+		err = c.sessionStore.Set(c.sessionAccount(), string(blob))
+	}
+	if err != nil {
+		logFor(LogAuth).Warn("Failed to store session", "error", err)
+	}
+}
@@ -0,0 +1,58 @@
+package strato
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// memoryStore is a CredentialStore in memory
+type memoryStore map[string]string
+
+func (m memoryStore) Get(account string) (string, error) {
+	secret, ok := m[account]
+	if !ok {
+		return "", ErrCredentialNotFound
+	}
+	return secret, nil
+}
+
+func (m memoryStore) Set(account, secret string) error {
+	m[account] = secret
+	return nil
+}
+
+func (m memoryStore) Delete(account string) error {
+	delete(m, account)
+	return nil
+}
+
+func TestSessionStore(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	store := memoryStore{}
+	newTestClient(t, portal, WithSessionStore(store))
+	var session PortalSession
+	if err := json.Unmarshal([]byte(store["session:"+testIdentifier]), &session); err != nil || session.ID != testSessionID {
+		t.Fatalf("got stored session %q, want one with ID %s", store["session:"+testIdentifier], testSessionID)
+	}
+
+	// The next client reuses the stored session
+	client := newTestClient(t, portal, WithSessionStore(store))
+	if len(portal.logins) != 1 {
+		t.Errorf("got %d logins, want the stored session to be reused", len(portal.logins))
+	}
+	if _, err := client.GetDNSConfiguration(); err != nil {
+		t.Fatal(err)
+	}
+
+	// An expired session is replaced by a new login
+	session.ID = "EXPIRED"
+	blob, _ := json.Marshal(session)
+	store["session:"+testIdentifier] = string(blob)
+	newTestClient(t, portal, WithSessionStore(store))
+	if len(portal.logins) != 2 {
+		t.Errorf("got %d logins, want a login for the expired session", len(portal.logins))
+	}
+	if err := json.Unmarshal([]byte(store["session:"+testIdentifier]), &session); err != nil || session.ID != testSessionID {
+		t.Errorf("got stored session %q after the login, want the new one", store["session:"+testIdentifier])
+	}
+}
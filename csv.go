@@ -0,0 +1,102 @@
+package strato
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvColumns are the columns of record CSV files. Strato does not let customers set
+// TTLs; the ttl column only exists for exchange with other tools and stays empty.
+var csvColumns = []string{"type", "prefix", "value", "ttl"}
+
+// WriteRecordsCSV writes records as CSV with a type,prefix,value,ttl header, in
+// canonical order so exports of the same configuration are byte-identical
+func WriteRecordsCSV(w io.Writer, records []DNSRecord) error {
+	records = append([]DNSRecord(nil), records...)
+	SortRecords(records)
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := writer.Write([]string{record.Type, record.Prefix, record.Value, ""}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ReadRecordsCSV reads records written by WriteRecordsCSV or edited in a spreadsheet.
+// Columns are matched by their header, so they may be reordered, and files saved
+// with semicolons as separator are accepted. Values of the ttl column are ignored.
+func ReadRecordsCSV(r io.Reader) ([]DNSRecord, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// Spreadsheets often prepend a byte order mark
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	if header, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("CSV has no header")
+	} else if err != nil {
+		return nil, err
+	}
+	index := map[string]int{}
+	for i, column := range header {
+		index[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range csvColumns[:3] {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("CSV lacks the %s column", column)
+		}
+	}
+	field := func(row []string, column string) string {
+		if i, ok := index[column]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var records []DNSRecord
+	ignoredTTLs := 0
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		record := DNSRecord{
+			Type:   strings.ToUpper(field(row, "type")),
+			Prefix: field(row, "prefix"),
+			Value:  field(row, "value"),
+		}
+		if record == (DNSRecord{}) {
+			continue
+		}
+		if record.Type == "" || record.Value == "" {
+			return nil, fmt.Errorf("line %d: type and value must not be empty", line)
+		}
+		if field(row, "ttl") != "" {
+			ignoredTTLs++
+		}
+		records = append(records, record)
+	}
+	if ignoredTTLs > 0 {
+		logFor(LogForm).Warn("Ignoring TTLs, Strato does not allow setting them", "records", ignoredTTLs)
+	}
+	return records, nil
+}
@@ -0,0 +1,229 @@
+package strato
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Cutover moves a set of hosts from the addresses of the old servers to those of the
+// new ones, e.g. for a blue/green server migration. The addresses are the A and AAAA
+// records of the hosts, which Strato keeps in the domain settings of every host.
+type Cutover struct {
+	// Prefixes of the hosts, "" or "@" for the domain itself
+	Prefixes []string
+	// From are the addresses the hosts point to now
+	From []string
+	// To are the addresses the hosts point to afterwards
+	To []string
+}
+
+// AddressChange is the switch of a single host by a cutover
+type AddressChange struct {
+	// Prefix of the host, "" for the domain itself
+	Prefix string        `json:"prefix"`
+	From   HostAddresses `json:"from"`
+	To     HostAddresses `json:"to"`
+}
+
+func (a AddressChange) String() string {
+	prefix := a.Prefix
+	if prefix == "" {
+		prefix = "@"
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", prefix, a.From, a.To)
+}
+
+// Reverse returns the cutover switching the hosts back to the old addresses
+func (c Cutover) Reverse() Cutover {
+	return Cutover{Prefixes: c.Prefixes, From: c.To, To: c.From}
+}
+
+// prefixes returns the prefixes of the hosts with "@" replaced by ""
+func (c Cutover) prefixes() []string {
+	prefixes := make([]string, len(c.Prefixes))
+	for i, prefix := range c.Prefixes {
+		if prefix != "@" {
+			prefixes[i] = prefix
+		}
+	}
+	return prefixes
+}
+
+// Plan returns the changes the cutover makes to the hosts, given the addresses they
+// point to now keyed by prefix. Every host keeps its address families: an IPv4-only
+// host only gets the new IPv4 addresses. Hosts already pointing to new addresses only
+// are left out, so a cutover can be repeated.
+func (c Cutover) Plan(current map[string]HostAddresses) ([]AddressChange, error) {
+	if len(c.Prefixes) == 0 || len(c.From) == 0 || len(c.To) == 0 {
+		return nil, errors.New("cutover needs hosts, old and new addresses")
+	}
+	from, err := parseAddresses(c.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseAddresses(c.To)
+	if err != nil {
+		return nil, err
+	}
+	var changes []AddressChange
+	for _, prefix := range c.prefixes() {
+		addresses := current[prefix]
+		if addresses.Empty() {
+			return nil, fmt.Errorf("host %q points to the Strato webspace, not to addresses", prefix)
+		}
+		next, switched := HostAddresses{}, false
+		for _, family := range []struct {
+			current, from, to []string
+			next              *[]string
+		}{
+			{addresses.IPv4, from.IPv4, to.IPv4, &next.IPv4},
+			{addresses.IPv6, from.IPv6, to.IPv6, &next.IPv6},
+		} {
+			kept := withoutAddresses(family.current, family.from)
+			if len(kept) == len(family.current) {
+				*family.next = family.current
+				continue
+			}
+			switched = true
+			*family.next = append(kept, withoutAddresses(family.to, kept)...)
+		}
+		if !switched {
+			if pointsTo(addresses, to) {
+				continue
+			}
+			return nil, fmt.Errorf("host %q points to none of %s", prefix, strings.Join(c.From, ", "))
+		}
+		if len(next.IPv4) == len(withoutAddresses(next.IPv4, to.IPv4)) && len(next.IPv6) == len(withoutAddresses(next.IPv6, to.IPv6)) {
+			return nil, fmt.Errorf("none of %s has the address family of host %q", strings.Join(c.To, ", "), prefix)
+		}
+		changes = append(changes, AddressChange{Prefix: prefix, From: addresses, To: next})
+	}
+	return changes, nil
+}
+
+// parseAddresses sorts addresses into IPv4 and IPv6 addresses in canonical form
+func parseAddresses(addresses []string) (HostAddresses, error) {
+	var parsed HostAddresses
+	for _, address := range addresses {
+		addr, err := netip.ParseAddr(strings.TrimSpace(address))
+		if err != nil {
+			return HostAddresses{}, fmt.Errorf("invalid address %q", address)
+		}
+		if addr = addr.Unmap(); addr.Is4() {
+			parsed.IPv4 = append(parsed.IPv4, addr.String())
+		} else {
+			parsed.IPv6 = append(parsed.IPv6, addr.String())
+		}
+	}
+	return parsed, nil
+}
+
+// withoutAddresses returns the addresses that are not in remove
+func withoutAddresses(addresses, remove []string) []string {
+	var kept []string
+	for _, address := range addresses {
+		if !containsAddress(remove, address) {
+			kept = append(kept, address)
+		}
+	}
+	return kept
+}
+
+// containsAddress reports whether addresses has address, comparing canonical forms
+func containsAddress(addresses []string, address string) bool {
+	addr, err := netip.ParseAddr(address)
+	for _, other := range addresses {
+		if other == address {
+			return true
+		}
+		if otherAddr, otherErr := netip.ParseAddr(other); err == nil && otherErr == nil && otherAddr.Unmap() == addr.Unmap() {
+			return true
+		}
+	}
+	return false
+}
+
+// pointsTo reports whether all addresses of a host are among those in to
+func pointsTo(addresses, to HostAddresses) bool {
+	return len(withoutAddresses(addresses.IPv4, to.IPv4)) == 0 && len(withoutAddresses(addresses.IPv6, to.IPv6)) == 0
+}
+
+// host returns the name of the host with prefix in the domain of the client
+func (c *StratoClient) host(prefix string) string {
+	if prefix == "" {
+		return c.domain
+	}
+	return prefix + "." + c.domain
+}
+
+// PlanCutover reads the addresses of the hosts of cutover and returns the changes
+// Cutover would make to them
+func (c *StratoClient) PlanCutover(cutover Cutover) ([]AddressChange, error) {
+	current := map[string]HostAddresses{}
+	for _, prefix := range cutover.prefixes() {
+		addresses, err := c.GetHostAddresses(c.host(prefix))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read addresses of %s: %w", c.host(prefix), err)
+		}
+		current[prefix] = addresses
+	}
+	return cutover.Plan(current)
+}
+
+// Cutover switches the hosts of cutover to the new addresses and, unless checker is
+// nil, waits until checker sees every new address. The portal saves the addresses
+// of every host separately, so the hosts are switched one after another; a failed
+// cutover is repeated or rolled back with Cutover(ctx, cutover.Reverse(), nil), as
+// hosts already switched are left alone.
+func (c *StratoClient) Cutover(ctx context.Context, cutover Cutover, checker Checker) ([]AddressChange, error) {
+	c = c.WithContext(ctx)
+	unlock, err := c.lockWrites()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := c.cutover(cutover)
+	if err = errors.Join(err, unlock()); err != nil || checker == nil || c.dryRun {
+		return changes, err
+	}
+	for _, change := range changes {
+		for _, record := range change.records() {
+			if err := checker.Wait(ctx, c.domain, record); err != nil {
+				return changes, fmt.Errorf("cutover applied but not verified: %w", err)
+			}
+		}
+	}
+	return changes, nil
+}
+
+// cutover plans and applies cutover while the caller holds the write lock. It
+// returns the changes that were applied.
+func (c *StratoClient) cutover(cutover Cutover) ([]AddressChange, error) {
+	changes, err := c.PlanCutover(cutover)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		logFor(LogForm).Info("Hosts already point to the new addresses", "domain", c.domain)
+	}
+	for i, change := range changes {
+		if err := c.SetHostAddresses(c.host(change.Prefix), change.To); err != nil {
+			return changes[:i], fmt.Errorf("failed to switch %s: %w", c.host(change.Prefix), err)
+		}
+	}
+	return changes, nil
+}
+
+// records returns the A and AAAA records the host has after the change
+func (a AddressChange) records() []DNSRecord {
+	var records []DNSRecord
+	for _, address := range a.To.IPv4 {
+		records = append(records, DNSRecord{Type: "A", Prefix: a.Prefix, Value: address})
+	}
+	for _, address := range a.To.IPv6 {
+		records = append(records, DNSRecord{Type: "AAAA", Prefix: a.Prefix, Value: address})
+	}
+	return records
+}
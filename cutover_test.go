@@ -0,0 +1,145 @@
+package strato
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// blueGreen switches www and the domain itself from the blue to the green servers
+var blueGreen = Cutover{
+	Prefixes: []string{"www", "@"},
+	From:     []string{"192.0.2.1", "2001:db8::1"},
+	To:       []string{"198.51.100.1", "2001:db8::2"},
+}
+
+func TestCutoverPlan(t *testing.T) {
+	blue := HostAddresses{IPv4: []string{"192.0.2.1"}, IPv6: []string{"2001:db8::1"}}
+	green := HostAddresses{IPv4: []string{"198.51.100.1"}, IPv6: []string{"2001:db8::2"}}
+	tests := []struct {
+		name    string
+		current map[string]HostAddresses
+		changes []AddressChange
+		err     bool
+	}{
+		{
+			name:    "dual stack",
+			current: map[string]HostAddresses{"www": blue, "": blue},
+			changes: []AddressChange{{Prefix: "www", From: blue, To: green}, {Prefix: "", From: blue, To: green}},
+		},
+		{
+			name: "keeps address families",
+			current: map[string]HostAddresses{
+				"www": {IPv4: []string{"192.0.2.1"}},
+				"":    {IPv6: []string{"2001:db8::1"}},
+			},
+			changes: []AddressChange{
+				{Prefix: "www", From: HostAddresses{IPv4: []string{"192.0.2.1"}}, To: HostAddresses{IPv4: []string{"198.51.100.1"}}},
+				{Prefix: "", From: HostAddresses{IPv6: []string{"2001:db8::1"}}, To: HostAddresses{IPv6: []string{"2001:db8::2"}}},
+			},
+		},
+		{
+			name: "keeps other addresses",
+			current: map[string]HostAddresses{
+				"www": {IPv4: []string{"192.0.2.1", "203.0.113.9"}},
+				"":    green,
+			},
+			changes: []AddressChange{
+				{Prefix: "www", From: HostAddresses{IPv4: []string{"192.0.2.1", "203.0.113.9"}}, To: HostAddresses{IPv4: []string{"203.0.113.9", "198.51.100.1"}}},
+			},
+		},
+		{
+			name:    "already switched",
+			current: map[string]HostAddresses{"www": green, "": green},
+		},
+		{
+			name:    "host on other addresses",
+			current: map[string]HostAddresses{"www": {IPv4: []string{"203.0.113.9"}}, "": blue},
+			err:     true,
+		},
+		{
+			name:    "host on the Strato webspace",
+			current: map[string]HostAddresses{"www": blue},
+			err:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes, err := blueGreen.Plan(test.current)
+			if (err != nil) != test.err {
+				t.Fatalf("got error %v, want error: %v", err, test.err)
+			}
+			if !reflect.DeepEqual(changes, test.changes) {
+				t.Errorf("got %v, want %v", changes, test.changes)
+			}
+		})
+	}
+}
+
+func TestCutoverPlanInvalid(t *testing.T) {
+	current := map[string]HostAddresses{"www": {IPv4: []string{"192.0.2.1"}}}
+	for name, cutover := range map[string]Cutover{
+		"no hosts":             {From: []string{"192.0.2.1"}, To: []string{"198.51.100.1"}},
+		"no new addresses":     {Prefixes: []string{"www"}, From: []string{"192.0.2.1"}},
+		"invalid address":      {Prefixes: []string{"www"}, From: []string{"192.0.2.1"}, To: []string{"green"}},
+		"other address family": {Prefixes: []string{"www"}, From: []string{"192.0.2.1"}, To: []string{"2001:db8::2"}},
+	} {
+		if changes, err := cutover.Plan(current); err == nil {
+			t.Errorf("%s: got %v, want an error", name, changes)
+		}
+	}
+}
+
+func TestCutoverReverse(t *testing.T) {
+	reverse := blueGreen.Reverse()
+	if !reflect.DeepEqual(reverse.From, blueGreen.To) || !reflect.DeepEqual(reverse.To, blueGreen.From) || !reflect.DeepEqual(reverse.Prefixes, blueGreen.Prefixes) {
+		t.Errorf("got %+v, want the addresses of %+v swapped", reverse, blueGreen)
+	}
+	if !reflect.DeepEqual(reverse.Reverse(), blueGreen) {
+		t.Errorf("reversing twice got %+v, want %+v", reverse.Reverse(), blueGreen)
+	}
+}
+
+// TestCutoverRepeated switches the hosts in the address settings of the portal,
+// repeats the cutover without changes and rolls it back
+func TestCutoverRepeated(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	blue := HostAddresses{IPv4: []string{"192.0.2.1"}, IPv6: []string{"2001:db8::1"}}
+	portal.addresses["www.example.com"] = blue
+	portal.addresses["example.com"] = blue
+	client := newTestClient(t, portal)
+
+	changes, err := client.Cutover(context.Background(), blueGreen, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	green := HostAddresses{IPv4: []string{"198.51.100.1"}, IPv6: []string{"2001:db8::2"}}
+	if len(changes) != 2 || len(portal.addressForms) != 2 {
+		t.Fatalf("got changes %v and %d submitted forms, want 2", changes, len(portal.addressForms))
+	}
+	for _, host := range []string{"www.example.com", "example.com"} {
+		if got := portal.addresses[host]; !reflect.DeepEqual(got, green) {
+			t.Errorf("%s points to %v, want %v", host, got, green)
+		}
+	}
+	if len(portal.txtForms) > 0 {
+		t.Error("cutover submitted the record form")
+	}
+
+	changes, err = client.Cutover(context.Background(), blueGreen, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 || len(portal.addressForms) != 2 {
+		t.Errorf("repeated cutover made changes %v", changes)
+	}
+
+	if _, err := client.Cutover(context.Background(), blueGreen.Reverse(), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, host := range []string{"www.example.com", "example.com"} {
+		if got := portal.addresses[host]; !reflect.DeepEqual(got, blue) {
+			t.Errorf("%s points to %v after the rollback, want %v", host, got, blue)
+		}
+	}
+}
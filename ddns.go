@@ -0,0 +1,339 @@
+package strato
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDynDNSURL is the update endpoint of the Strato DynDNS service
+const DefaultDynDNSURL = "https://dyndns.strato.com/nic/update"
+
+// IPFamily selects IPv4 (A records) or IPv6 (AAAA records)
+type IPFamily string
+
+const (
+	IPv4 IPFamily = "ipv4"
+	IPv6 IPFamily = "ipv6"
+)
+
+// ParseIPFamilies parses a comma separated list of families, e.g. "ipv4,ipv6"
+func ParseIPFamilies(list string) ([]IPFamily, error) {
+	var families []IPFamily
+	for _, name := range strings.Split(list, ",") {
+		switch family := IPFamily(strings.ToLower(strings.TrimSpace(name))); family {
+		case IPv4, IPv6:
+			families = append(families, family)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown IP family: %s", name)
+		}
+	}
+	if len(families) == 0 {
+		return nil, errors.New("no IP family selected")
+	}
+	return families, nil
+}
+
+// matches reports whether addr belongs to the family
+func (f IPFamily) matches(addr netip.Addr) bool {
+	if f == IPv4 {
+		return addr.Is4() || addr.Is4In6()
+	}
+	return addr.Is6() && !addr.Is4In6()
+}
+
+// IPDetector finds the public address of one family
+type IPDetector interface {
+	Detect(ctx context.Context) (netip.Addr, error)
+}
+
+// HTTPDetector asks a web service that answers with the address of the caller as plain text
+type HTTPDetector struct {
+	URL    string
+	Client *http.Client
+}
+
+func (d HTTPDetector) Detect(ctx context.Context) (netip.Addr, error) {
+	client := d.Client
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", d.URL, nil)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return netip.Addr{}, errors.New("unexpected response status: " + resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return netip.ParseAddr(strings.TrimSpace(string(body)))
+}
+
+// DefaultDetectors returns detectors for both families using ipify.org
+func DefaultDetectors() map[IPFamily]IPDetector {
+	return map[IPFamily]IPDetector{
+		IPv4: HTTPDetector{URL: "https://api.ipify.org"},
+		IPv6: HTTPDetector{URL: "https://api6.ipify.org"},
+	}
+}
+
+// DDNSUpdate is the outcome of one round of DDNSUpdater
+type DDNSUpdate struct {
+	Time     time.Time
+	Hostname string
+	// Old and New hold the published address per family; a missing family has no record
+	Old, New map[IPFamily]netip.Addr
+	Changed  bool
+}
+
+// Diff describes the update as removed and added A and AAAA records
+func (u DDNSUpdate) Diff() ConfigDiff {
+	var diff ConfigDiff
+	for _, family := range []IPFamily{IPv4, IPv6} {
+		recordType := "A"
+		if family == IPv6 {
+			recordType = "AAAA"
+		}
+		old, hadOld := u.Old[family]
+		addr, hasNew := u.New[family]
+		if hadOld && hasNew && old == addr {
+			continue
+		}
+		if hadOld {
+			diff.Removed = append(diff.Removed, DNSRecord{Type: recordType, Value: old.String()})
+		}
+		if hasNew {
+			diff.Added = append(diff.Added, DNSRecord{Type: recordType, Value: addr.String()})
+		}
+	}
+	return diff
+}
+
+// Environ returns the update as STRATO_DOMAIN, STRATO_OLD_IPV4, STRATO_NEW_IPV4,
+// STRATO_OLD_IPV6 and STRATO_NEW_IPV6 environment variables for hook programs.
+// Variables of families without an address are empty.
+func (u DDNSUpdate) Environ() []string {
+	env := []string{"STRATO_DOMAIN=" + u.Hostname}
+	for _, family := range []IPFamily{IPv4, IPv6} {
+		name := strings.ToUpper(string(family))
+		var old, addr string
+		if a, ok := u.Old[family]; ok {
+			old = a.String()
+		}
+		if a, ok := u.New[family]; ok {
+			addr = a.String()
+		}
+		env = append(env, "STRATO_OLD_"+name+"="+old, "STRATO_NEW_"+name+"="+addr)
+	}
+	return env
+}
+
+// DDNSUpdater keeps the A and AAAA records of a hostname pointed at the public
+// addresses of this host through the Strato DynDNS service. Each family is detected
+// on its own; a family whose address cannot be detected anymore (e.g. the ISP dropped
+// IPv6) has its record removed. A new address, or the loss of one, is only published
+// after it was seen Hysteresis times in a row, so short outages do not flap records.
+type DDNSUpdater struct {
+	Hostname string
+	// Password is the DynDNS password of the domain, set in the Strato portal
+	Password   string
+	URL        string
+	Families   []IPFamily
+	Detectors  map[IPFamily]IPDetector
+	Hysteresis int
+	Client     *http.Client
+	// Lock, if set, is held while addresses are published
+	Lock Locker
+	// Status, if set, records the outcome of every round of Run under Hostname
+	Status *StatusTracker
+
+	mu        sync.Mutex
+	published map[IPFamily]netip.Addr
+	started   bool
+	pending   map[IPFamily]pendingAddr
+}
+
+// pendingAddr is an observed address (invalid for none) and how often it was seen in a row
+type pendingAddr struct {
+	addr  netip.Addr
+	count int
+}
+
+// observe applies the hysteresis to the detected addresses and returns the addresses
+// to publish and the addresses still pending afterwards. It leaves the state of u
+// alone, so a round whose addresses fail to publish does not count.
+func (u *DDNSUpdater) observe(detected map[IPFamily]netip.Addr) (map[IPFamily]netip.Addr, map[IPFamily]pendingAddr) {
+	next := map[IPFamily]netip.Addr{}
+	for family, addr := range u.published {
+		next[family] = addr
+	}
+	stillPending := map[IPFamily]pendingAddr{}
+	for _, family := range u.Families {
+		addr := detected[family]
+		if addr == u.published[family] {
+			continue
+		}
+		pending := u.pending[family]
+		if pending.addr == addr {
+			pending.count++
+		} else {
+			pending = pendingAddr{addr: addr, count: 1}
+		}
+		// The first round publishes right away, as nothing is known yet
+		if !u.started || pending.count >= u.Hysteresis {
+			if addr.IsValid() {
+				next[family] = addr
+			} else {
+				delete(next, family)
+			}
+		} else {
+			stillPending[family] = pending
+		}
+	}
+	return next, stillPending
+}
+
+// Update detects the current addresses and publishes them if they changed
+func (u *DDNSUpdater) Update(ctx context.Context) (DDNSUpdate, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	detectors := u.Detectors
+	if detectors == nil {
+		detectors = DefaultDetectors()
+	}
+	detected := map[IPFamily]netip.Addr{}
+	for _, family := range u.Families {
+		detector, ok := detectors[family]
+		if !ok {
+			return DDNSUpdate{}, fmt.Errorf("no detector for %s", family)
+		}
+		addr, err := detector.Detect(ctx)
+		if err == nil && !family.matches(addr) {
+			err = fmt.Errorf("detector returned %s", addr)
+		}
+		if err != nil {
+			logFor(LogHTTP).Debug("Failed to detect public address", "family", family, "error", err)
+			continue
+		}
+		detected[family] = addr.Unmap()
+	}
+
+	update := DDNSUpdate{Time: time.Now(), Hostname: u.Hostname, Old: u.published}
+	next, pending := u.observe(detected)
+	update.New = next
+	if len(next) == 0 {
+		// Sending no address at all would not remove the records, only fail
+		return update, errors.New("no public address detected")
+	}
+	if !sameAddrs(u.published, next) {
+		if u.Lock != nil {
+			unlock, err := u.Lock.Lock(ctx)
+			if err != nil {
+				return update, fmt.Errorf("failed to acquire write lock: %w", err)
+			}
+			defer unlock()
+		}
+		// On failure the next round publishes again right away
+		if err := u.publish(ctx, next); err != nil {
+			return update, err
+		}
+		u.published = next
+		update.Changed = true
+	}
+	u.pending, u.started = pending, true
+	return update, nil
+}
+
+// Run calls Update every interval until ctx is cancelled and passes the outcome to callback
+func (u *DDNSUpdater) Run(ctx context.Context, interval time.Duration, callback func(DDNSUpdate, error)) error {
+	if interval <= 0 {
+		return errors.New("ddns interval must be positive")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		update, err := u.Update(ctx)
+		if u.Status != nil {
+			u.Status.Record(u.Hostname, err, time.Now().Add(interval))
+		}
+		callback(update, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// publish sends the addresses to the DynDNS service, replacing the records of the hostname
+func (u *DDNSUpdater) publish(ctx context.Context, addrs map[IPFamily]netip.Addr) error {
+	var ips []string
+	for _, family := range []IPFamily{IPv4, IPv6} {
+		if addr, ok := addrs[family]; ok {
+			ips = append(ips, addr.String())
+		}
+	}
+	endpoint := u.URL
+	if endpoint == "" {
+		endpoint = DefaultDynDNSURL
+	}
+	query := url.Values{"hostname": {u.Hostname}, "myip": {strings.Join(ips, ",")}}
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(u.Hostname, u.Password)
+	client := u.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return err
+	}
+	// The dyndns2 protocol answers "good <ip>" or "nochg <ip>" on success
+	answer := strings.TrimSpace(string(body))
+	switch code, _, _ := strings.Cut(answer, " "); code {
+	case "good", "nochg":
+		logFor(LogForm).Info("Published public addresses", "hostname", u.Hostname, "addresses", strings.Join(ips, ","))
+		return nil
+	case "badauth":
+		return fmt.Errorf("%w: DynDNS rejected the credentials of %s", ErrAuthenticationFailed, u.Hostname)
+	case "abuse":
+		return fmt.Errorf("DynDNS blocked updates of %s after too many requests", u.Hostname)
+	}
+	return fmt.Errorf("DynDNS update failed: %s", answer)
+}
+
+func sameAddrs(a, b map[IPFamily]netip.Addr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for family, addr := range a {
+		if b[family] != addr {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,260 @@
+package strato
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// addrs builds the addresses per family from "ipv4"/"ipv6" and address pairs
+func addrs(pairs ...string) map[IPFamily]netip.Addr {
+	m := map[IPFamily]netip.Addr{}
+	for i := 0; i < len(pairs); i += 2 {
+		m[IPFamily(pairs[i])] = netip.MustParseAddr(pairs[i+1])
+	}
+	return m
+}
+
+func TestDDNSObserve(t *testing.T) {
+	none := netip.Addr{}
+	v4, v4b := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2")
+	tests := []struct {
+		name        string
+		published   map[IPFamily]netip.Addr
+		started     bool
+		pending     map[IPFamily]pendingAddr
+		detected    map[IPFamily]netip.Addr
+		next        map[IPFamily]netip.Addr
+		nextPending map[IPFamily]pendingAddr
+	}{
+		{
+			name:        "first round",
+			detected:    addrs("ipv4", "192.0.2.1", "ipv6", "2001:db8::1"),
+			next:        addrs("ipv4", "192.0.2.1", "ipv6", "2001:db8::1"),
+			nextPending: map[IPFamily]pendingAddr{},
+		},
+		{
+			name:        "first round without IPv6",
+			detected:    addrs("ipv4", "192.0.2.1"),
+			next:        addrs("ipv4", "192.0.2.1"),
+			nextPending: map[IPFamily]pendingAddr{},
+		},
+		{
+			name:        "unchanged",
+			published:   addrs("ipv4", "192.0.2.1"),
+			started:     true,
+			detected:    addrs("ipv4", "192.0.2.1"),
+			next:        addrs("ipv4", "192.0.2.1"),
+			nextPending: map[IPFamily]pendingAddr{},
+		},
+		{
+			name:        "new address seen once",
+			published:   addrs("ipv4", "192.0.2.1"),
+			started:     true,
+			detected:    addrs("ipv4", "192.0.2.2"),
+			next:        addrs("ipv4", "192.0.2.1"),
+			nextPending: map[IPFamily]pendingAddr{IPv4: {addr: v4b, count: 1}},
+		},
+		{
+			name:        "new address seen often enough",
+			published:   addrs("ipv4", "192.0.2.1"),
+			started:     true,
+			pending:     map[IPFamily]pendingAddr{IPv4: {addr: v4b, count: 2}},
+			detected:    addrs("ipv4", "192.0.2.2"),
+			next:        addrs("ipv4", "192.0.2.2"),
+			nextPending: map[IPFamily]pendingAddr{},
+		},
+		{
+			name:        "flapping restarts the count",
+			published:   addrs("ipv4", "192.0.2.1"),
+			started:     true,
+			pending:     map[IPFamily]pendingAddr{IPv4: {addr: netip.MustParseAddr("192.0.2.3"), count: 2}},
+			detected:    addrs("ipv4", "192.0.2.2"),
+			next:        addrs("ipv4", "192.0.2.1"),
+			nextPending: map[IPFamily]pendingAddr{IPv4: {addr: v4b, count: 1}},
+		},
+		{
+			name:        "back to the published address",
+			published:   addrs("ipv4", "192.0.2.1"),
+			started:     true,
+			pending:     map[IPFamily]pendingAddr{IPv4: {addr: v4b, count: 2}},
+			detected:    addrs("ipv4", "192.0.2.1"),
+			next:        addrs("ipv4", "192.0.2.1"),
+			nextPending: map[IPFamily]pendingAddr{},
+		},
+		{
+			name:        "IPv6 lost once",
+			published:   addrs("ipv4", "192.0.2.1", "ipv6", "2001:db8::1"),
+			started:     true,
+			detected:    addrs("ipv4", "192.0.2.1"),
+			next:        addrs("ipv4", "192.0.2.1", "ipv6", "2001:db8::1"),
+			nextPending: map[IPFamily]pendingAddr{IPv6: {addr: none, count: 1}},
+		},
+		{
+			name:        "IPv6 lost for good",
+			published:   addrs("ipv4", "192.0.2.1", "ipv6", "2001:db8::1"),
+			started:     true,
+			pending:     map[IPFamily]pendingAddr{IPv6: {addr: none, count: 2}},
+			detected:    addrs("ipv4", "192.0.2.1"),
+			next:        addrs("ipv4", "192.0.2.1"),
+			nextPending: map[IPFamily]pendingAddr{},
+		},
+		{
+			name:        "IPv4 back after an outage",
+			published:   addrs("ipv6", "2001:db8::1"),
+			started:     true,
+			pending:     map[IPFamily]pendingAddr{IPv4: {addr: v4, count: 2}},
+			detected:    addrs("ipv4", "192.0.2.1", "ipv6", "2001:db8::1"),
+			next:        addrs("ipv4", "192.0.2.1", "ipv6", "2001:db8::1"),
+			nextPending: map[IPFamily]pendingAddr{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := &DDNSUpdater{Families: []IPFamily{IPv4, IPv6}, Hysteresis: 3, published: test.published, started: test.started, pending: test.pending}
+			next, pending := u.observe(test.detected)
+			if !reflect.DeepEqual(next, test.next) {
+				t.Errorf("got addresses %v, want %v", next, test.next)
+			}
+			if !reflect.DeepEqual(pending, test.nextPending) {
+				t.Errorf("got pending %v, want %v", pending, test.nextPending)
+			}
+			if !reflect.DeepEqual(u.published, test.published) || !reflect.DeepEqual(u.pending, test.pending) {
+				t.Error("observe changed the state of the updater")
+			}
+		})
+	}
+}
+
+// detectorFunc detects the address it returns
+type detectorFunc func() (netip.Addr, error)
+
+func (f detectorFunc) Detect(ctx context.Context) (netip.Addr, error) {
+	return f()
+}
+
+// testDynDNS is a dyndns2 endpoint that answers with answer and keeps the
+// published addresses
+type testDynDNS struct {
+	*httptest.Server
+	mu        sync.Mutex
+	answer    string
+	published []string
+}
+
+func newTestDynDNS(t *testing.T) *testDynDNS {
+	d := &testDynDNS{answer: "good"}
+	d.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if user, password, _ := r.BasicAuth(); user != "home.example.com" || password != "secret" {
+			w.Write([]byte("badauth"))
+			return
+		}
+		if d.answer == "good" {
+			d.published = append(d.published, r.URL.Query().Get("myip"))
+		}
+		w.Write([]byte(d.answer + " " + r.URL.Query().Get("myip")))
+	}))
+	t.Cleanup(d.Close)
+	return d
+}
+
+func TestDDNSUpdate(t *testing.T) {
+	dyndns := newTestDynDNS(t)
+	// detected holds the address each detector finds, none for an empty string
+	detected := map[IPFamily]string{}
+	detector := func(family IPFamily) IPDetector {
+		return detectorFunc(func() (netip.Addr, error) {
+			if detected[family] == "" {
+				return netip.Addr{}, errors.New("no route")
+			}
+			return netip.ParseAddr(detected[family])
+		})
+	}
+	u := &DDNSUpdater{
+		Hostname:   "home.example.com",
+		Password:   "secret",
+		URL:        dyndns.URL,
+		Families:   []IPFamily{IPv4, IPv6},
+		Detectors:  map[IPFamily]IPDetector{IPv4: detector(IPv4), IPv6: detector(IPv6)},
+		Hysteresis: 2,
+	}
+	steps := []struct {
+		name      string
+		ipv4      string
+		ipv6      string
+		answer    string
+		changed   bool
+		err       bool
+		published string
+	}{
+		{name: "first round", ipv4: "192.0.2.1", ipv6: "2001:db8::1", changed: true, published: "192.0.2.1,2001:db8::1"},
+		{name: "unchanged", ipv4: "192.0.2.1", ipv6: "2001:db8::1"},
+		{name: "flap", ipv4: "192.0.2.2", ipv6: "2001:db8::1"},
+		{name: "flap back", ipv4: "192.0.2.1", ipv6: "2001:db8::1"},
+		{name: "new address once", ipv4: "192.0.2.2", ipv6: "2001:db8::1"},
+		{name: "new address twice", ipv4: "192.0.2.2", ipv6: "2001:db8::1", changed: true, published: "192.0.2.2,2001:db8::1"},
+		{name: "IPv6 lost once", ipv4: "192.0.2.2"},
+		{name: "IPv6 lost twice", ipv4: "192.0.2.2", changed: true, published: "192.0.2.2"},
+		{name: "IPv4 mapped", ipv4: "::ffff:192.0.2.2"},
+		{name: "publish fails once", ipv4: "192.0.2.3", answer: "dnserr"},
+		{name: "publish fails", ipv4: "192.0.2.3", answer: "dnserr", err: true},
+		{name: "publish retried", ipv4: "192.0.2.3", changed: true, published: "192.0.2.3"},
+		{name: "all lost once"},
+		// Sending no address would not remove the records
+		{name: "all lost twice", err: true},
+	}
+	for _, step := range steps {
+		detected[IPv4], detected[IPv6] = step.ipv4, step.ipv6
+		dyndns.mu.Lock()
+		dyndns.answer = "good"
+		if step.answer != "" {
+			dyndns.answer = step.answer
+		}
+		before := len(dyndns.published)
+		dyndns.mu.Unlock()
+
+		update, err := u.Update(context.Background())
+		if (err != nil) != step.err {
+			t.Fatalf("%s: got error %v, want error: %v", step.name, err, step.err)
+		}
+		if update.Changed != step.changed {
+			t.Errorf("%s: got changed %v, want %v", step.name, update.Changed, step.changed)
+		}
+		dyndns.mu.Lock()
+		published := dyndns.published[before:]
+		dyndns.mu.Unlock()
+		switch {
+		case step.published == "" && len(published) > 0:
+			t.Errorf("%s: published %v", step.name, published)
+		case step.published != "" && (len(published) != 1 || published[0] != step.published):
+			t.Errorf("%s: published %v, want %s", step.name, published, step.published)
+		}
+	}
+}
+
+func TestDDNSUpdateBadAuth(t *testing.T) {
+	dyndns := newTestDynDNS(t)
+	u := &DDNSUpdater{
+		Hostname: "home.example.com",
+		Password: "wrong",
+		URL:      dyndns.URL,
+		Families: []IPFamily{IPv4},
+		Detectors: map[IPFamily]IPDetector{IPv4: detectorFunc(func() (netip.Addr, error) {
+			return netip.MustParseAddr("192.0.2.1"), nil
+		})},
+		Hysteresis: 2,
+	}
+	if _, err := u.Update(context.Background()); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("got %v, want %v", err, ErrAuthenticationFailed)
+	}
+	if _, err := u.Update(context.Background()); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("second round got %v, want the failed publish to be repeated", err)
+	}
+}
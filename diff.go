@@ -0,0 +1,90 @@
+package strato
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigDiff describes the differences between two DNS configurations
+type ConfigDiff struct {
+	// DMARCType and SPFType hold "old -> new" when the setting differs
+	DMARCType string
+	SPFType   string
+	// Added holds records only present in the new configuration
+	Added []DNSRecord
+	// Removed holds records only present in the old configuration
+	Removed []DNSRecord
+}
+
+// Empty reports whether both configurations are equal
+func (d ConfigDiff) Empty() bool {
+	return d.DMARCType == "" && d.SPFType == "" && len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+func (d ConfigDiff) String() string {
+	var lines []string
+	if d.DMARCType != "" {
+		lines = append(lines, "~ dmarc_type "+d.DMARCType)
+	}
+	if d.SPFType != "" {
+		lines = append(lines, "~ spf_type "+d.SPFType)
+	}
+	for _, record := range d.Added {
+		lines = append(lines, "+ "+record.String())
+	}
+	for _, record := range d.Removed {
+		lines = append(lines, "- "+record.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// String formats the record the same way the CLI prints it
+func (r DNSRecord) String() string {
+	return fmt.Sprintf("Type: '%s', Prefix: '%s', Value: '%s'", r.Type, r.Prefix, r.Value)
+}
+
+// ContainsRecord reports whether records contains an entry equal to record
+func ContainsRecord(records []DNSRecord, record DNSRecord) bool {
+	for _, entry := range records {
+		if entry == record {
+			return true
+		}
+	}
+	return false
+}
+
+// KeepMailSettings returns desired with the DMARC and SPF settings of current where
+// desired leaves them empty. The portal keeps its settings when the form omits them,
+// so an empty setting means unchanged rather than a difference to current.
+func KeepMailSettings(current, desired DNSConfig) DNSConfig {
+	if desired.DMARCType == "" {
+		desired.DMARCType = current.DMARCType
+	}
+	if desired.SPFType == "" {
+		desired.SPFType = current.SPFType
+	}
+	return desired
+}
+
+// DiffConfigs computes the changes needed to turn oldConfig into newConfig.
+// Records are compared as sets, their order does not matter.
+func DiffConfigs(oldConfig, newConfig DNSConfig) ConfigDiff {
+	diff := ConfigDiff{}
+	if oldConfig.DMARCType != newConfig.DMARCType {
+		diff.DMARCType = oldConfig.DMARCType + " -> " + newConfig.DMARCType
+	}
+	if oldConfig.SPFType != newConfig.SPFType {
+		diff.SPFType = oldConfig.SPFType + " -> " + newConfig.SPFType
+	}
+	for _, record := range newConfig.Records {
+		if !ContainsRecord(oldConfig.Records, record) && !ContainsRecord(diff.Added, record) {
+			diff.Added = append(diff.Added, record)
+		}
+	}
+	for _, record := range oldConfig.Records {
+		if !ContainsRecord(newConfig.Records, record) && !ContainsRecord(diff.Removed, record) {
+			diff.Removed = append(diff.Removed, record)
+		}
+	}
+	return diff
+}
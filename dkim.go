@@ -0,0 +1,114 @@
+package strato
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DKIMKeyType is the signing algorithm of a DKIM key
+type DKIMKeyType string
+
+const (
+	DKIMKeyRSA     DKIMKeyType = "rsa"
+	DKIMKeyEd25519 DKIMKeyType = "ed25519"
+)
+
+// DKIMKey is a generated DKIM key pair with the selector it is published under
+type DKIMKey struct {
+	Selector string
+	Type     DKIMKeyType
+	Private  crypto.Signer
+}
+
+// GenerateDKIMKey creates a key pair for selector. bits is the RSA key size and
+// ignored for Ed25519; RSA keys below 1024 bits are rejected by receivers.
+func GenerateDKIMKey(selector string, keyType DKIMKeyType, bits int) (DKIMKey, error) {
+	if selector == "" || strings.ContainsAny(selector, " ;") {
+		return DKIMKey{}, fmt.Errorf("invalid dkim selector: %q", selector)
+	}
+	key := DKIMKey{Selector: selector, Type: keyType}
+	switch keyType {
+	case DKIMKeyRSA:
+		if bits < 1024 {
+			return DKIMKey{}, fmt.Errorf("rsa dkim keys need at least 1024 bits, got %d", bits)
+		}
+		private, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return DKIMKey{}, err
+		}
+		key.Private = private
+	case DKIMKeyEd25519:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return DKIMKey{}, err
+		}
+		key.Private = private
+	default:
+		return DKIMKey{}, errors.New("unknown dkim key type: " + string(keyType))
+	}
+	return key, nil
+}
+
+// Record returns the TXT record publishing the public key below the domain of the client
+func (k DKIMKey) Record() (DNSRecord, error) {
+	var public []byte
+	switch key := k.Private.Public().(type) {
+	case ed25519.PublicKey:
+		// RFC 8463 publishes the raw key instead of a SubjectPublicKeyInfo
+		public = key
+	default:
+		var err error
+		if public, err = x509.MarshalPKIXPublicKey(key); err != nil {
+			return DNSRecord{}, err
+		}
+	}
+	return DNSRecord{
+		Type:   "TXT",
+		Prefix: k.Selector + "._domainkey",
+		Value:  "v=DKIM1; k=" + string(k.Type) + "; p=" + base64.StdEncoding.EncodeToString(public),
+	}, nil
+}
+
+// PrivateKeyPEM encodes the private key as PKCS #8 for the MTA
+func (k DKIMKey) PrivateKeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.Private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ErrDKIMSelectorInUse is returned by PublishDKIMKey when another key is published
+// under the selector
+var ErrDKIMSelectorInUse = errors.New("dkim selector already in use")
+
+// PublishDKIMKey writes the public key record of key. Keys of other selectors are
+// kept, so mail signed with the previous key still verifies while the MTA switches
+// over. The MTA may still sign with a different key of the same selector, so that
+// one is only replaced if replace is set; otherwise ErrDKIMSelectorInUse is returned.
+func (c *StratoClient) PublishDKIMKey(key DKIMKey, replace bool) error {
+	record, err := key.Record()
+	if err != nil {
+		return err
+	}
+	return c.UpdateZone(func(zone *Zone) (bool, error) {
+		for _, existing := range zone.ByPrefix(record.Prefix) {
+			if existing.Type == record.Type && existing != record && !replace {
+				return false, fmt.Errorf("%w: %s", ErrDKIMSelectorInUse, key.Selector)
+			}
+		}
+		if !zone.Replace(record, nil) {
+			return false, nil
+		}
+		logFor(LogForm).Debug("Publishing DKIM key", "domain", c.domain, "selector", key.Selector)
+		return true, nil
+	})
+}
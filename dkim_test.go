@@ -0,0 +1,38 @@
+package strato
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestPublishDKIMKeySelectorInUse makes sure the key of a selector the MTA may still
+// sign with is only replaced on request
+func TestPublishDKIMKeySelectorInUse(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal)
+	// txt_form.html publishes a key under the selector mail
+	key, err := GenerateDKIMKey("mail", DKIMKeyEd25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := key.Record()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.PublishDKIMKey(key, false); !errors.Is(err, ErrDKIMSelectorInUse) {
+		t.Fatalf("got %v, want %v", err, ErrDKIMSelectorInUse)
+	}
+	if len(portal.txtForms) != 0 {
+		t.Error("submitted the TXT record form although the selector is in use")
+	}
+
+	if err := client.PublishDKIMKey(key, true); err != nil {
+		t.Fatal(err)
+	}
+	form, _ := portal.lastTXTForm()
+	config := submittedConfig(form)
+	if keys := newZone(*config, false).ByPrefix(record.Prefix); len(keys) != 1 || keys[0] != record {
+		t.Errorf("got %v under the selector, want only %v", keys, record)
+	}
+}
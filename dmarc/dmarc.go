@@ -0,0 +1,151 @@
+// Package dmarc composes and validates DMARC policy records (RFC 7489).
+package dmarc
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// Prefix is the record name below the domain the policy is published under
+const Prefix = "_dmarc"
+
+// Policy is the handling requested for mail failing the DMARC check
+type Policy string
+
+const (
+	None       Policy = "none"
+	Quarantine Policy = "quarantine"
+	Reject     Policy = "reject"
+)
+
+// Alignment is the identifier alignment mode of DKIM or SPF
+type Alignment string
+
+const (
+	Relaxed Alignment = "r"
+	Strict  Alignment = "s"
+)
+
+// Record is a DMARC policy
+type Record struct {
+	Policy Policy
+	// SubdomainPolicy defaults to Policy when empty
+	SubdomainPolicy Policy
+	// RUA and RUF are the mailto: URIs aggregate and failure reports are sent to.
+	// Addresses outside the domain have to authorize the reports with a record of
+	// their own.
+	RUA []string
+	RUF []string
+	// Percent is the share of failing mail the policy applies to; 0 leaves it at 100
+	Percent int
+	ADKIM   Alignment
+	ASPF    Alignment
+}
+
+// String formats the record as TXT value
+func (r Record) String() string {
+	tags := []string{"v=DMARC1", "p=" + string(r.Policy)}
+	if r.SubdomainPolicy != "" {
+		tags = append(tags, "sp="+string(r.SubdomainPolicy))
+	}
+	if r.Percent != 0 {
+		tags = append(tags, "pct="+strconv.Itoa(r.Percent))
+	}
+	if len(r.RUA) > 0 {
+		tags = append(tags, "rua="+strings.Join(r.RUA, ","))
+	}
+	if len(r.RUF) > 0 {
+		tags = append(tags, "ruf="+strings.Join(r.RUF, ","))
+	}
+	if r.ADKIM != "" {
+		tags = append(tags, "adkim="+string(r.ADKIM))
+	}
+	if r.ASPF != "" {
+		tags = append(tags, "aspf="+string(r.ASPF))
+	}
+	return strings.Join(tags, "; ")
+}
+
+// Validate checks the tags of the record
+func (r Record) Validate() error {
+	if err := validatePolicy("p", r.Policy); err != nil {
+		return err
+	}
+	if r.SubdomainPolicy != "" {
+		if err := validatePolicy("sp", r.SubdomainPolicy); err != nil {
+			return err
+		}
+	}
+	if r.Percent < 0 || r.Percent > 100 {
+		return fmt.Errorf("pct must be between 0 and 100, got %d", r.Percent)
+	}
+	for _, uri := range append(append([]string{}, r.RUA...), r.RUF...) {
+		if err := validateURI(uri); err != nil {
+			return err
+		}
+	}
+	for tag, alignment := range map[string]Alignment{"adkim": r.ADKIM, "aspf": r.ASPF} {
+		if alignment != "" && alignment != Relaxed && alignment != Strict {
+			return fmt.Errorf("invalid %s: %q", tag, alignment)
+		}
+	}
+	return nil
+}
+
+func validatePolicy(tag string, policy Policy) error {
+	switch policy {
+	case None, Quarantine, Reject:
+		return nil
+	}
+	return fmt.Errorf("invalid %s: %q", tag, policy)
+}
+
+// validSize matches the size limit of a report URI, a number with an optional unit
+var validSize = regexp.MustCompile(`^[0-9]+[kmgt]?$`)
+
+// validateURI accepts mailto: URIs with an optional size limit, e.g. mailto:dmarc@example.de!10m
+func validateURI(uri string) error {
+	address, ok := strings.CutPrefix(uri, "mailto:")
+	if !ok {
+		return fmt.Errorf("report address must be a mailto: URI: %s", uri)
+	}
+	address, size, limited := strings.Cut(address, "!")
+	if limited && !validSize.MatchString(size) {
+		return fmt.Errorf("invalid report size limit: %s", uri)
+	}
+	if strings.ContainsAny(address, ",;") {
+		return fmt.Errorf("invalid report address: %s", uri)
+	}
+	if _, err := mail.ParseAddress(address); err != nil {
+		return fmt.Errorf("invalid report address %s: %w", uri, err)
+	}
+	return nil
+}
+
+// Apply validates record and publishes it as the DMARC policy of the domain of the
+// client. An earlier v=DMARC1 record at _dmarc is replaced, other TXT records there
+// are kept. The DMARC record Strato manages itself is switched off, as receivers
+// ignore a domain with two policies. The result is false if the policy was in place.
+func Apply(ctx context.Context, client *strato.StratoClient, record Record) (bool, error) {
+	if err := record.Validate(); err != nil {
+		return false, err
+	}
+	changed := false
+	err := client.WithContext(ctx).UpdateZone(func(zone *strato.Zone) (bool, error) {
+		changed = zone.Replace(strato.DNSRecord{Type: "TXT", Prefix: Prefix, Value: record.String()}, func(existing strato.DNSRecord) bool {
+			return strings.HasPrefix(existing.Value, "v=DMARC1")
+		})
+		if zone.DMARCType != "" && zone.DMARCType != strato.DMARCTypeNone {
+			zone.DMARCType = strato.DMARCTypeNone
+			changed = true
+		}
+		return changed, nil
+	})
+	return changed, err
+}
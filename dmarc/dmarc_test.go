@@ -0,0 +1,115 @@
+package dmarc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/internal/stratotest"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		record Record
+		want   string
+	}{
+		{Record{Policy: None}, "v=DMARC1; p=none"},
+		{
+			Record{
+				Policy:          Reject,
+				SubdomainPolicy: Quarantine,
+				RUA:             []string{"mailto:dmarc@example.com", "mailto:reports@example.net!10m"},
+				RUF:             []string{"mailto:forensic@example.com"},
+				Percent:         50,
+				ADKIM:           Strict,
+				ASPF:            Relaxed,
+			},
+			"v=DMARC1; p=reject; sp=quarantine; pct=50; rua=mailto:dmarc@example.com,mailto:reports@example.net!10m; " +
+				"ruf=mailto:forensic@example.com; adkim=s; aspf=r",
+		},
+	}
+	for _, test := range tests {
+		if got := test.record.String(); got != test.want {
+			t.Errorf("got %s\nwant %s", got, test.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		record Record
+		valid  bool
+	}{
+		{"minimal", Record{Policy: None}, true},
+		{"missing policy", Record{}, false},
+		{"unknown policy", Record{Policy: "block"}, false},
+		{"unknown subdomain policy", Record{Policy: Reject, SubdomainPolicy: "block"}, false},
+		{"percent", Record{Policy: Quarantine, Percent: 100}, true},
+		{"negative percent", Record{Policy: Quarantine, Percent: -1}, false},
+		{"percent above 100", Record{Policy: Quarantine, Percent: 101}, false},
+		{"alignment", Record{Policy: Reject, ADKIM: Strict, ASPF: Relaxed}, true},
+		{"unknown alignment", Record{Policy: Reject, ASPF: "x"}, false},
+		{"report address", Record{Policy: None, RUA: []string{"mailto:a@b"}}, true},
+		{"size limit", Record{Policy: None, RUA: []string{"mailto:a@b!10m"}}, true},
+		{"size limit without unit", Record{Policy: None, RUF: []string{"mailto:a@b!1024"}}, true},
+		{"invalid size limit", Record{Policy: None, RUA: []string{"mailto:a@b!ten"}}, false},
+		{"empty size limit", Record{Policy: None, RUA: []string{"mailto:a@b!"}}, false},
+		{"comma", Record{Policy: None, RUA: []string{"mailto:a@b,mailto:c@d"}}, false},
+		{"semicolon", Record{Policy: None, RUA: []string{"mailto:a@b;p=none"}}, false},
+		{"not mailto", Record{Policy: None, RUA: []string{"https://example.com/reports"}}, false},
+		{"invalid address", Record{Policy: None, RUF: []string{"mailto:not an address"}}, false},
+	}
+	for _, test := range tests {
+		if err := test.record.Validate(); (err == nil) != test.valid {
+			t.Errorf("%s: Validate() = %v, want valid %t", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	portal := stratotest.NewPortal(t)
+	portal.SetConfig("example.com", strato.DNSConfig{DMARCType: strato.DMARCTypeStrato, Records: []strato.DNSRecord{
+		{Type: "TXT", Prefix: Prefix, Value: "v=DMARC1; p=none"},
+		{Type: "TXT", Prefix: Prefix, Value: "verification=abc"},
+		{Type: "TXT", Prefix: "_dmarc.sub", Value: "v=DMARC1; p=none"},
+	}})
+	client := portal.Client(t)
+	record := Record{Policy: Reject, RUA: []string{"mailto:dmarc@example.com"}}
+
+	if _, err := Apply(context.Background(), client, Record{Policy: "block"}); err == nil {
+		t.Error("applied an invalid record")
+	}
+	if portal.Writes() != 0 {
+		t.Fatal("an invalid record was written")
+	}
+
+	changed, err := Apply(context.Background(), client, record)
+	if err != nil || !changed {
+		t.Fatalf("Apply = %t, %v, want a change", changed, err)
+	}
+	config := portal.Config("example.com")
+	if config.DMARCType != strato.DMARCTypeNone {
+		t.Errorf("got dmarc type %q, want %q", config.DMARCType, strato.DMARCTypeNone)
+	}
+	want := []strato.DNSRecord{
+		{Type: "TXT", Prefix: Prefix, Value: "verification=abc"},
+		{Type: "TXT", Prefix: "_dmarc.sub", Value: "v=DMARC1; p=none"},
+		{Type: "TXT", Prefix: Prefix, Value: "v=DMARC1; p=reject; rua=mailto:dmarc@example.com"},
+	}
+	if len(config.Records) != len(want) {
+		t.Fatalf("got records %v, want %v", config.Records, want)
+	}
+	for _, record := range want {
+		if !strato.NewZone(config).Contains(record) {
+			t.Errorf("record %v is missing in %v", record, config.Records)
+		}
+	}
+
+	if changed, err := Apply(context.Background(), client, record); err != nil || changed {
+		t.Errorf("repeated Apply = %t, %v, want no change", changed, err)
+	}
+	if portal.Writes() != 1 {
+		t.Errorf("got %d writes, want 1", portal.Writes())
+	}
+}
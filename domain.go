@@ -0,0 +1,247 @@
+package strato
+
+import (
+	"errors"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// DomainAvailability is the result of a domain search
+type DomainAvailability struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	// Price is the price as displayed by the portal, including currency and billing period
+	Price string `json:"price,omitempty"`
+}
+
+// GetAuthCode requests the transfer auth code of a domain registered in the package
+func (c *StratoClient) GetAuthCode(domain string) (string, error) {
+	doc, err := c.fetchPage(c.region.ManageDomainsNode, "vhost="+url.QueryEscape(domain), "action_show_authcode")
+	if err != nil {
+		return "", err
+	}
+	code := textOf(doc, "//*[@id='jss_authcode']")
+	if code == "" {
+		code = attrOf(doc, "//input[@name='authcode']", "value")
+	}
+	if code == "" {
+		return "", errors.New("failed to find auth code for " + domain)
+	}
+	return code, nil
+}
+
+// CheckDomainAvailability looks up whether a domain can be registered, using the domain search of the portal
+func (c *StratoClient) CheckDomainAvailability(name string) (DomainAvailability, error) {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	if !strings.Contains(name, ".") {
+		return DomainAvailability{}, errors.New("domain name must include a TLD: " + name)
+	}
+	doc, err := c.fetchPage(c.region.DomainSearchNode, "domain="+url.QueryEscape(name), "action_check_domain")
+	if err != nil {
+		return DomainAvailability{}, err
+	}
+	resultNode := htmlquery.FindOne(doc, "//*[@data-domain="+xpathLiteral(name)+"]")
+	if resultNode == nil {
+		return DomainAvailability{}, errors.New("failed to find search result for " + name)
+	}
+	available := htmlquery.SelectAttr(resultNode, "data-available")
+	return DomainAvailability{
+		Name:      name,
+		Available: available == "true" || available == "1",
+		Price:     textOf(resultNode, ".//*[contains(@class, 'price')]"),
+	}, nil
+}
+
+// GetNameservers retrieves the external nameservers a domain is delegated to.
+// An empty result means the domain uses the Strato nameservers.
+func (c *StratoClient) GetNameservers(domain string) ([]string, error) {
+	doc, err := c.fetchPage(c.region.ManageDomainsNode, "vhost="+url.QueryEscape(domain), "action_show_nameservers")
+	if err != nil {
+		return nil, err
+	}
+	form := htmlquery.FindOne(doc, "//form[@id='jss_ns_form']")
+	if form == nil {
+		return nil, errors.New("failed to find nameserver form")
+	}
+	if attrOf(form, ".//input[@name='ns_type' and @checked]", "value") != "external" {
+		return nil, nil
+	}
+	var nameservers []string
+	for _, input := range htmlquery.Find(form, ".//input[starts-with(@name, 'ns') and @type='text']") {
+		if value := strings.TrimSpace(htmlquery.SelectAttr(input, "value")); value != "" {
+			nameservers = append(nameservers, value)
+		}
+	}
+	return nameservers, nil
+}
+
+// SetNameservers delegates a domain to external nameservers. At least two distinct
+// nameservers are required; passing none switches the domain back to Strato DNS.
+func (c *StratoClient) SetNameservers(domain string, nameservers []string) error {
+	form := url.Values{}
+	form.Set("vhost", domain)
+	if len(nameservers) == 0 {
+		form.Set("ns_type", "strato")
+	} else {
+		if err := validateNameservers(nameservers); err != nil {
+			return err
+		}
+		form.Set("ns_type", "external")
+		for i, nameserver := range nameservers {
+			form.Set("ns"+strconv.Itoa(i+1), nameserver)
+		}
+	}
+	form.Set("action_change_nameservers", "1")
+	return c.submitForm(c.region.ManageDomainsNode, form)
+}
+
+// validateNameservers checks that at least two distinct host names are given
+func validateNameservers(nameservers []string) error {
+	if len(nameservers) < 2 {
+		return errors.New("at least two nameservers are required")
+	}
+	seen := map[string]bool{}
+	for _, nameserver := range nameservers {
+		name := strings.ToLower(strings.TrimSuffix(nameserver, "."))
+		if !strings.Contains(name, ".") || strings.ContainsAny(name, " /:@") {
+			return errors.New("invalid nameserver: " + nameserver)
+		}
+		if seen[name] {
+			return errors.New("duplicate nameserver: " + nameserver)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// GlueRecord is a child nameserver registered for a domain
+type GlueRecord struct {
+	// Host is the nameserver name below the domain, e.g. ns1.example.de
+	Host string   `json:"host"`
+	IPs  []string `json:"ips"`
+}
+
+// ListGlueRecords retrieves the child nameservers registered for a domain
+func (c *StratoClient) ListGlueRecords(domain string) ([]GlueRecord, error) {
+	doc, err := c.fetchPage(c.region.ManageDomainsNode, "vhost="+url.QueryEscape(domain), "action_show_glue_records")
+	if err != nil {
+		return nil, err
+	}
+	table := htmlquery.FindOne(doc, "//table[@id='jss_glue_table']")
+	if table == nil {
+		return nil, errors.New("failed to find glue record table")
+	}
+	var records []GlueRecord
+	for _, row := range htmlquery.Find(table, ".//tr[@data-glue-host]") {
+		record := GlueRecord{Host: htmlquery.SelectAttr(row, "data-glue-host")}
+		for _, ipNode := range htmlquery.Find(row, ".//*[contains(@class, 'ip')]") {
+			record.IPs = append(record.IPs, strings.TrimSpace(htmlquery.InnerText(ipNode)))
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// SetGlueRecord creates or updates the child nameserver host of a domain with the given addresses
+func (c *StratoClient) SetGlueRecord(domain, host string, ips []string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if !strings.HasSuffix(host, "."+strings.ToLower(domain)) {
+		return errors.New("glue record host " + host + " must be below " + domain)
+	}
+	if len(ips) == 0 {
+		return errors.New("glue record needs at least one IP address")
+	}
+	form := url.Values{}
+	form.Set("vhost", domain)
+	form.Set("glue_host", host)
+	for _, ip := range ips {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return err
+		}
+		form.Add("glue_ip", addr.String())
+	}
+	form.Set("action_change_glue_record", "1")
+	return c.submitForm(c.region.ManageDomainsNode, form)
+}
+
+// HostAddresses are the A and AAAA records of a domain or subdomain. Strato sets them
+// in the domain settings of the portal rather than in the record form; a host without
+// addresses points to the Strato webspace.
+type HostAddresses struct {
+	IPv4 []string `json:"ipv4,omitempty"`
+	IPv6 []string `json:"ipv6,omitempty"`
+}
+
+// Empty reports whether the host points to the Strato webspace
+func (a HostAddresses) Empty() bool {
+	return len(a.IPv4) == 0 && len(a.IPv6) == 0
+}
+
+func (a HostAddresses) String() string {
+	if a.Empty() {
+		return "Strato webspace"
+	}
+	return strings.Join(append(append([]string(nil), a.IPv4...), a.IPv6...), ", ")
+}
+
+// GetHostAddresses retrieves the addresses a domain or subdomain of the package points to
+func (c *StratoClient) GetHostAddresses(host string) (HostAddresses, error) {
+	doc, err := c.fetchPage(c.region.ManageDomainsNode, "vhost="+host, "action_show_ip_settings")
+	if err != nil {
+		return HostAddresses{}, err
+	}
+	form := htmlquery.FindOne(doc, "//form[@id='jss_ip_form']")
+	if form == nil {
+		return HostAddresses{}, errors.New("failed to find address settings of " + host)
+	}
+	var addresses HostAddresses
+	if attrOf(form, ".//input[@name='ip_type' and @checked]", "value") != "external" {
+		return addresses, nil
+	}
+	for _, field := range []struct {
+		name string
+		list *[]string
+	}{{"ipv4", &addresses.IPv4}, {"ipv6", &addresses.IPv6}} {
+		for _, input := range htmlquery.Find(form, ".//input[@name='"+field.name+"']") {
+			if value := strings.TrimSpace(htmlquery.SelectAttr(input, "value")); value != "" {
+				*field.list = append(*field.list, value)
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// SetHostAddresses points a domain or subdomain of the package to the given addresses.
+// Empty addresses switch the host back to the Strato webspace.
+func (c *StratoClient) SetHostAddresses(host string, addresses HostAddresses) error {
+	form := url.Values{}
+	form.Set("vhost", host)
+	if addresses.Empty() {
+		form.Set("ip_type", "strato")
+	} else {
+		form.Set("ip_type", "external")
+		for _, field := range []struct {
+			name string
+			list []string
+			is4  bool
+		}{{"ipv4", addresses.IPv4, true}, {"ipv6", addresses.IPv6, false}} {
+			for _, ip := range field.list {
+				addr, err := netip.ParseAddr(ip)
+				if err != nil {
+					return err
+				}
+				if addr = addr.Unmap(); addr.Is4() != field.is4 {
+					return errors.New("address " + ip + " is not an " + field.name + " address")
+				}
+				form.Add(field.name, addr.String())
+			}
+		}
+	}
+	form.Set("action_change_ip_settings", "1")
+	return c.submitForm(c.region.ManageDomainsNode, form)
+}
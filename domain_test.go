@@ -0,0 +1,193 @@
+package strato
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetAuthCode(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	portal.setPage("action_show_authcode", "authcode.html")
+	client := newTestClient(t, portal)
+
+	code, err := client.GetAuthCode("bücher.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "Xk7#pQ2!vR9m" {
+		t.Errorf("got auth code %q, want Xk7#pQ2!vR9m", code)
+	}
+	if got := portal.lastRead().Get("vhost"); got != "bücher.example" {
+		t.Errorf("got vhost %q, want bücher.example", got)
+	}
+
+	// A page without the code, e.g. for a domain that cannot be transferred
+	portal.setPage("action_show_authcode", "txt_form.html")
+	if _, err := client.GetAuthCode("example.com"); err == nil {
+		t.Error("got no error for a page without auth code")
+	}
+}
+
+func TestCheckDomainAvailability(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	portal.setPage(RegionDE.DomainSearchNode, "domain_check.html")
+	client := newTestClient(t, portal)
+
+	tests := []struct {
+		name string
+		want DomainAvailability
+	}{
+		{" Example-Shop.DE. ", DomainAvailability{Name: "example-shop.de", Available: true, Price: "1,00 € / Monat"}},
+		{"example.com", DomainAvailability{Name: "example.com"}},
+	}
+	for _, test := range tests {
+		got, err := client.CheckDomainAvailability(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("CheckDomainAvailability(%q) = %+v, want %+v", test.name, got, test.want)
+		}
+		if query := portal.lastRead(); query.Get("domain") != test.want.Name || !query.Has("action_check_domain") {
+			t.Errorf("got query %v, want a search for %s", query, test.want.Name)
+		}
+	}
+
+	// The page only lists results for the searched domain
+	if _, err := client.CheckDomainAvailability("example.org"); err == nil {
+		t.Error("got no error for a domain missing in the results")
+	}
+	if _, err := client.CheckDomainAvailability("example"); err == nil {
+		t.Error("got no error for a name without TLD")
+	}
+}
+
+func TestGetNameservers(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	portal.setPage("action_show_nameservers", "nameservers.html")
+	client := newTestClient(t, portal)
+
+	nameservers, err := client.GetNameservers("bücher.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ns1.example.net", "ns2.example.net"}; !reflect.DeepEqual(nameservers, want) {
+		t.Errorf("got nameservers %v, want %v", nameservers, want)
+	}
+	if got := portal.lastRead().Get("vhost"); got != "bücher.example" {
+		t.Errorf("got vhost %q, want bücher.example", got)
+	}
+
+	// A domain using the Strato nameservers has no external ones
+	portal.setPage("action_show_nameservers", "nameservers_strato.html")
+	nameservers, err = client.GetNameservers("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nameservers != nil {
+		t.Errorf("got nameservers %v for Strato DNS, want none", nameservers)
+	}
+
+	portal.setPage("action_show_nameservers", "txt_form.html")
+	if _, err := client.GetNameservers("example.com"); err == nil {
+		t.Error("got no error for a page without nameserver form")
+	}
+}
+
+func TestSetNameservers(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal)
+
+	if err := client.SetNameservers("example.com", []string{"ns1.example.net", "ns2.example.net"}); err != nil {
+		t.Fatal(err)
+	}
+	form := portal.lastForm()
+	if form.Get("vhost") != "example.com" || form.Get("ns_type") != "external" || !form.Has("action_change_nameservers") {
+		t.Errorf("got form %v, want external nameservers for example.com", form)
+	}
+	if form.Get("ns1") != "ns1.example.net" || form.Get("ns2") != "ns2.example.net" || form.Has("ns3") {
+		t.Errorf("got nameservers %q and %q, want ns1 and ns2.example.net", form.Get("ns1"), form.Get("ns2"))
+	}
+
+	if err := client.SetNameservers("example.com", nil); err != nil {
+		t.Fatal(err)
+	}
+	if form := portal.lastForm(); form.Get("ns_type") != "strato" || form.Has("ns1") {
+		t.Errorf("got form %v, want Strato nameservers", form)
+	}
+
+	invalid := [][]string{
+		{"ns1.example.net"},
+		{"ns1.example.net", "NS1.example.net."},
+		{"ns1.example.net", "ns2"},
+		{"ns1.example.net", "http://ns2.example.net"},
+	}
+	for _, nameservers := range invalid {
+		if err := client.SetNameservers("example.com", nameservers); err == nil {
+			t.Errorf("SetNameservers(%v) succeeded", nameservers)
+		}
+	}
+	if len(portal.forms) != 2 {
+		t.Errorf("got %d forms, invalid calls submitted some", len(portal.forms))
+	}
+}
+
+func TestListGlueRecords(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	portal.setPage("action_show_glue_records", "glue_records.html")
+	client := newTestClient(t, portal)
+
+	records, err := client.ListGlueRecords("bücher.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GlueRecord{
+		{Host: "ns1.example.com", IPs: []string{"192.0.2.53", "2001:db8::53"}},
+		{Host: "ns2.example.com", IPs: []string{"198.51.100.53"}},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got glue records %+v, want %+v", records, want)
+	}
+	if got := portal.lastRead().Get("vhost"); got != "bücher.example" {
+		t.Errorf("got vhost %q, want bücher.example", got)
+	}
+
+	portal.setPage("action_show_glue_records", "txt_form.html")
+	if _, err := client.ListGlueRecords("example.com"); err == nil {
+		t.Error("got no error for a page without glue record table")
+	}
+}
+
+func TestSetGlueRecord(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal)
+
+	if err := client.SetGlueRecord("example.com", "NS1.example.com.", []string{"192.0.2.53", "2001:0db8::0053"}); err != nil {
+		t.Fatal(err)
+	}
+	form := portal.lastForm()
+	if form.Get("vhost") != "example.com" || form.Get("glue_host") != "ns1.example.com" || !form.Has("action_change_glue_record") {
+		t.Errorf("got form %v, want glue record ns1.example.com of example.com", form)
+	}
+	if want := []string{"192.0.2.53", "2001:db8::53"}; !reflect.DeepEqual(form["glue_ip"], want) {
+		t.Errorf("got addresses %v, want %v", form["glue_ip"], want)
+	}
+
+	invalid := []struct {
+		host string
+		ips  []string
+	}{
+		{"ns1.example.net", []string{"192.0.2.53"}},
+		{"example.com", []string{"192.0.2.53"}},
+		{"ns1.example.com", nil},
+		{"ns1.example.com", []string{"ns.example.net"}},
+	}
+	for _, test := range invalid {
+		if err := client.SetGlueRecord("example.com", test.host, test.ips); err == nil {
+			t.Errorf("SetGlueRecord(%s, %v) succeeded", test.host, test.ips)
+		}
+	}
+	if len(portal.forms) != 1 {
+		t.Errorf("got %d forms, invalid calls submitted some", len(portal.forms))
+	}
+}
@@ -0,0 +1,75 @@
+package strato
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+)
+
+// PortalSession is an authenticated session of the portal
+type PortalSession struct {
+	ID      string         `json:"sessionID"`
+	Cookies []*http.Cookie `json:"cookies"`
+}
+
+// LoginDriver logs in to the portal in place of the built-in HTTP form login,
+// e.g. with a headless browser when Strato gates the login behind JavaScript checks
+type LoginDriver interface {
+	Login(ctx context.Context, api, identifier, password string) (PortalSession, error)
+}
+
+// CommandDriver logs in by running an external program, such as a headless browser
+// script. The program gets the portal URL and credentials in the environment variables
+// STRATO_API, STRATO_IDENTIFIER and STRATO_PASSWORD and must print the resulting
+// PortalSession as JSON on stdout.
+type CommandDriver struct {
+	Command []string
+}
+
+func (d CommandDriver) Login(ctx context.Context, api, identifier, password string) (PortalSession, error) {
+	if len(d.Command) == 0 {
+		return PortalSession{}, errors.New("login command is empty")
+	}
+	cmd := exec.CommandContext(ctx, d.Command[0], d.Command[1:]...)
+	cmd.Env = append(os.Environ(),
+		"STRATO_API="+api,
+		"STRATO_IDENTIFIER="+identifier,
+		"STRATO_PASSWORD="+password,
+	)
+	cmd.Stderr = os.Stderr
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return PortalSession{}, fmt.Errorf("login command failed: %w", err)
+	}
+	var session PortalSession
+	if err := json.Unmarshal(stdout.Bytes(), &session); err != nil {
+		return PortalSession{}, fmt.Errorf("invalid output of login command: %w", err)
+	}
+	return session, nil
+}
+
+// driverLogin authenticates with the configured LoginDriver and adopts its session
+func (c *StratoClient) driverLogin() error {
+	session, err := c.driver.Login(c.requestContext(), c.api, normalizeIdentifier(c.identifier), c.password())
+	if err != nil {
+		return err
+	}
+	if session.ID == "" {
+		return errors.New("login driver returned no session ID")
+	}
+	apiURL, err := url.Parse(c.api)
+	if err != nil {
+		return err
+	}
+	c.session.Jar.SetCookies(apiURL, session.Cookies)
+	c.setSessionID(session.ID)
+	logFor(LogAuth).Debug("Logged in with login driver", "driver", fmt.Sprintf("%T", c.driver))
+	return nil
+}
@@ -0,0 +1,139 @@
+package strato
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrAuthenticationFailed is returned when Strato rejects the credentials
+	ErrAuthenticationFailed = errors.New("authentication failed")
+	// ErrAccountLocked is returned when Strato reports the account as locked
+	ErrAccountLocked = errors.New("account locked")
+	// ErrPasswordExpired is returned when Strato requires a password change before login
+	ErrPasswordExpired = errors.New("password expired")
+	// ErrSessionExpired is returned when the portal no longer accepts the session
+	ErrSessionExpired = errors.New("session expired")
+	// ErrLoginBackoff is returned when a login is refused because earlier attempts failed
+	ErrLoginBackoff = errors.New("login backoff")
+	// ErrCaptchaRequired is returned when the login page asks for a CAPTCHA
+	ErrCaptchaRequired = errors.New("captcha required")
+	// ErrUnexpectedPage is returned when a portal page lacks the elements the parsers rely on
+	ErrUnexpectedPage = errors.New("unexpected page")
+)
+
+// ErrVerificationFailed is returned when the configuration read back after a write differs from the submitted one
+var ErrVerificationFailed = errors.New("verification failed")
+
+// VerificationError carries the difference between the submitted and the actual configuration
+type VerificationError struct {
+	// Diff holds the changes from the submitted to the actual configuration
+	Diff ConfigDiff
+}
+
+func (e *VerificationError) Error() string {
+	return ErrVerificationFailed.Error() + ":\n" + e.Diff.String()
+}
+
+func (e *VerificationError) Unwrap() error {
+	return ErrVerificationFailed
+}
+
+// ErrResponseTooLarge is returned when a response body exceeds the limit set with WithMaxResponseSize
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// ErrDomainNotFound is returned when the domain is not a vhost of the package
+var ErrDomainNotFound = errors.New("domain not found")
+
+// ErrReadOnly is returned by mutating calls of a client created with WithReadOnly
+var ErrReadOnly = errors.New("client is read-only")
+
+// ErrUpdateFailed is returned when the portal shows a form again instead of saving it
+var ErrUpdateFailed = errors.New("update failed")
+
+// UpdateError carries the reasons the portal gave for rejecting a form
+type UpdateError struct {
+	// Reason is the text of the error banner, empty if the page had none
+	Reason string
+	// Fields are the messages shown next to single records
+	Fields []FieldError
+}
+
+// FieldError is a message the portal shows next to a record of the TXT record form
+type FieldError struct {
+	// Index is the position of the record in the submitted form, starting at 1
+	Index   int
+	Record  DNSRecord
+	Message string
+}
+
+func (e *UpdateError) Error() string {
+	parts := make([]string, 0, len(e.Fields)+1)
+	if e.Reason != "" {
+		parts = append(parts, e.Reason)
+	}
+	for _, field := range e.Fields {
+		parts = append(parts, fmt.Sprintf("record %d (%s): %s", field.Index, field.Record, field.Message))
+	}
+	if len(parts) == 0 {
+		return ErrUpdateFailed.Error()
+	}
+	return ErrUpdateFailed.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *UpdateError) Unwrap() error {
+	return ErrUpdateFailed
+}
+
+// ErrRecordQuotaExceeded is returned when a configuration has more records than the tariff allows
+var ErrRecordQuotaExceeded = errors.New("record quota exceeded")
+
+// RecordQuotaError carries the record limit of the tariff
+type RecordQuotaError struct {
+	Limit   int
+	Records int
+}
+
+func (e *RecordQuotaError) Error() string {
+	return fmt.Sprintf("%s: %d records, the tariff allows %d", ErrRecordQuotaExceeded, e.Records, e.Limit)
+}
+
+func (e *RecordQuotaError) Unwrap() error {
+	return ErrRecordQuotaExceeded
+}
+
+// ErrProtectedRecord is returned when a change would modify or remove a protected record
+var ErrProtectedRecord = errors.New("record is protected")
+
+// ProtectedRecordError names the protected records a change would have touched
+type ProtectedRecordError struct {
+	Records []DNSRecord
+}
+
+func (e *ProtectedRecordError) Error() string {
+	lines := make([]string, 0, len(e.Records))
+	for _, record := range e.Records {
+		lines = append(lines, record.String())
+	}
+	return ErrProtectedRecord.Error() + ":\n" + strings.Join(lines, "\n")
+}
+
+func (e *ProtectedRecordError) Unwrap() error {
+	return ErrProtectedRecord
+}
+
+// DomainNotFoundError names the vhosts the package does have
+type DomainNotFoundError struct {
+	Domain    string
+	Available []string
+}
+
+func (e *DomainNotFoundError) Error() string {
+	return fmt.Sprintf("%s: %s is not part of the package (available: %s)",
+		ErrDomainNotFound, e.Domain, strings.Join(e.Available, ", "))
+}
+
+func (e *DomainNotFoundError) Unwrap() error {
+	return ErrDomainNotFound
+}
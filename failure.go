@@ -0,0 +1,73 @@
+package strato
+
+import (
+	"io"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// errorClasses selects elements showing an error message
+const errorClasses = "contains(@class, 'error') or contains(@class, 'alert') or contains(@class, 'invalid-feedback')"
+
+// dnsUpdateError builds the error for a rejected TXT record form from the page the
+// portal showed again: the banner, the messages next to single records, or the
+// record limit if the submission of records exceeded it. It returns nil if the page
+// confirms the change after all.
+func dnsUpdateError(r io.Reader, records int) error {
+	doc, err := htmlquery.Parse(r)
+	if err != nil {
+		return &UpdateError{}
+	}
+	reason, saved := banner(doc)
+	if saved {
+		logFor(LogForm).Debug("TXT record form shown again with a confirmation", "message", reason)
+		return nil
+	}
+	limit := maxRecords(attrOf(doc, "//div[@id='jss_txt_container']", "data-max-records"))
+	if limit == 0 {
+		limit = quotaLimit(reason, pageLanguage(doc))
+	}
+	if limit > 0 && records > limit {
+		return &RecordQuotaError{Limit: limit, Records: records}
+	}
+	updateErr := &UpdateError{Reason: reason}
+	recordNodes := htmlquery.Find(doc, "//div[@id='jss_txt_container']/div[contains(@class, 'txt-record-tmpl')]")
+	for i, recordNode := range recordNodes {
+		message := fieldMessage(recordNode)
+		if message == "" {
+			continue
+		}
+		updateErr.Fields = append(updateErr.Fields, FieldError{
+			Index: i + 1,
+			Record: DNSRecord{
+				Type:   attrOf(recordNode, ".//select[@name='type']/option[@selected]", "value"),
+				Prefix: attrOf(recordNode, ".//input[@name='prefix']", "value"),
+				Value:  textOf(recordNode, ".//textarea[@name='value']"),
+			},
+			Message: message,
+		})
+	}
+	return updateErr
+}
+
+// fieldMessage returns the error messages shown inside a record of the form
+func fieldMessage(recordNode *html.Node) string {
+	var messages []string
+	for _, node := range htmlquery.Find(recordNode, ".//*[("+errorClasses+") and not(self::input or self::textarea or self::select)]") {
+		if message := strings.Join(strings.Fields(htmlquery.InnerText(node)), " "); message != "" && !containsString(messages, message) {
+			messages = append(messages, message)
+		}
+	}
+	return strings.Join(messages, ", ")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package strato
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSetDNSConfigurationRejected(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	portal.rejectPage = "txt_form_rejected.html"
+	client := newTestClient(t, portal)
+
+	err := client.SetDNSConfiguration(DNSConfig{Records: []DNSRecord{
+		{Type: "TXT", Value: "v=spf1 -all"},
+		{Type: "CNAME", Prefix: "bad prefix", Value: "example.com."},
+	}})
+	if !errors.Is(err, ErrUpdateFailed) {
+		t.Fatalf("got %v, want %v", err, ErrUpdateFailed)
+	}
+	var updateErr *UpdateError
+	if !errors.As(err, &updateErr) {
+		t.Fatalf("got %v, want an *UpdateError", err)
+	}
+	if want := "Ihre Eingaben konnten nicht übernommen werden."; updateErr.Reason != want {
+		t.Errorf("got reason %q, want %q", updateErr.Reason, want)
+	}
+	want := []FieldError{{
+		Index:   2,
+		Record:  DNSRecord{Type: "CNAME", Prefix: "bad prefix", Value: "example.com."},
+		Message: "Der Präfix enthält ungültige Zeichen.",
+	}}
+	if !reflect.DeepEqual(updateErr.Fields, want) {
+		t.Errorf("got fields %+v, want %+v", updateErr.Fields, want)
+	}
+}
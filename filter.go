@@ -0,0 +1,66 @@
+package strato
+
+import (
+	"path"
+	"strings"
+)
+
+// ACMEChallengePrefix is the prefix ACME DNS-01 challenge records are published under
+const ACMEChallengePrefix = "_acme-challenge"
+
+// RecordFilter selects DNS records. Empty fields match every record.
+type RecordFilter struct {
+	// Type matches the record type, case-insensitive
+	Type string
+	// Prefix is a glob pattern (see path.Match) the record prefix must match
+	Prefix string
+	// Value matches records whose value contains the given substring
+	Value string
+	// ACMEOnly matches only ACME challenge records
+	ACMEOnly bool
+}
+
+// IsACMEChallenge reports whether the record is an ACME DNS-01 challenge record
+func (r DNSRecord) IsACMEChallenge() bool {
+	return r.Prefix == ACMEChallengePrefix || strings.HasPrefix(r.Prefix, ACMEChallengePrefix+".")
+}
+
+// Match reports whether the record is selected by the filter
+func (f RecordFilter) Match(record DNSRecord) bool {
+	if f.Type != "" && !strings.EqualFold(f.Type, record.Type) {
+		return false
+	}
+	if f.Prefix != "" {
+		matched, err := path.Match(f.Prefix, record.Prefix)
+		if err != nil || !matched {
+			return false
+		}
+	}
+	if f.Value != "" && !strings.Contains(record.Value, f.Value) {
+		return false
+	}
+	if f.ACMEOnly && !record.IsACMEChallenge() {
+		return false
+	}
+	return true
+}
+
+// FilterRecords returns the records selected by the filter
+func FilterRecords(records []DNSRecord, filter RecordFilter) []DNSRecord {
+	var filtered []DNSRecord
+	for _, record := range records {
+		if filter.Match(record) {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+// ListDNSRecords retrieves the DNS records of the domain selected by the filter
+func (c *StratoClient) ListDNSRecords(filter RecordFilter, opts ...GetOption) ([]DNSRecord, error) {
+	config, err := c.GetDNSConfiguration(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return FilterRecords(config.Records, filter), nil
+}
@@ -0,0 +1,97 @@
+package strato
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	sessionIDPattern = regexp.MustCompile(`(sessionID=|name="sessionID" value=")[^&"']+`)
+	tokenPattern     = regexp.MustCompile(`(name="[^"]*(?:token|csrf)[^"]*" value=")[^"]*`)
+	emailPattern     = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// SanitizePage removes session IDs, form tokens and e-mail addresses from a recorded
+// portal page and replaces every occurrence of the keys of secrets with their values
+func SanitizePage(page []byte, secrets map[string]string) []byte {
+	keys := make([]string, 0, len(secrets))
+	for secret := range secrets {
+		if secret != "" {
+			keys = append(keys, secret)
+		}
+	}
+	// Longer secrets first, so a domain does not break up an e-mail identifier
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	var pairs []string
+	for _, secret := range keys {
+		pairs = append(pairs, secret, secrets[secret])
+	}
+	sanitized := strings.NewReplacer(pairs...).Replace(string(page))
+	sanitized = sessionIDPattern.ReplaceAllString(sanitized, "${1}SESSIONID")
+	sanitized = tokenPattern.ReplaceAllString(sanitized, "${1}TOKEN")
+	sanitized = emailPattern.ReplaceAllString(sanitized, "user@example.com")
+	return []byte(sanitized)
+}
+
+// RecordFixtures fetches the portal pages the parsers of the library depend on and
+// writes them sanitized to dir as <name>.html, for use as test fixtures.
+// It returns the paths of the written files.
+func (c *StratoClient) RecordFixtures(dir string) ([]string, error) {
+	pages := []struct {
+		name string
+		url  string
+	}{
+		{"login", c.api},
+		{"entry", c.portalURL("0", c.region.EntryNode)},
+		{"package", c.portalURL(c.cID, c.region.PackageNode)},
+		{"txt_form", c.portalURL(c.cID, c.region.ManageDomainsNode, "action_show_txt_records", "vhost="+c.domain)},
+		{"mailboxes", c.portalURL(c.cID, c.region.MailNode, "vhost="+url.QueryEscape(c.domain))},
+	}
+	secrets := map[string]string{
+		c.sessionID(): "SESSIONID",
+		c.password():  "PASSWORD",
+		c.identifier:  "12345678",
+		c.order:       "ORDER",
+		c.domain:      "example.com",
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, page := range pages {
+		body, err := c.fetchRaw(page.url)
+		if err != nil {
+			return paths, errors.New(page.name + ": " + err.Error())
+		}
+		path := filepath.Join(dir, page.name+".html")
+		if err := os.WriteFile(path, SanitizePage(body, secrets), 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// fetchRaw returns the unparsed body of a page
+func (c *StratoClient) fetchRaw(pageURL string) ([]byte, error) {
+	req, err := c.newRequest("GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.session.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("unexpected response status: " + resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
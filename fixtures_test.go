@@ -0,0 +1,212 @@
+package strato
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// txtFormConfig is the configuration shown by txt_form.html, in page order
+var txtFormConfig = DNSConfig{
+	DMARCType: "strato",
+	SPFType:   "none",
+	Records: []DNSRecord{
+		{Type: "TXT", Prefix: "", Value: "v=spf1 include:_spf.example.net -all"},
+		{Type: "CNAME", Prefix: "www", Value: "example.com."},
+		{Type: "TXT", Prefix: "mail._domainkey", Value: "v=DKIM1; k=rsa; p=MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEAu3+J/k2Q=="},
+		{Type: "MX", Prefix: "", Value: "10 mx.example.net."},
+	},
+	MaxRecords: 50,
+}
+
+func TestParseDNSConfigurationFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    DNSConfig
+		err     error
+	}{
+		{fixture: "txt_form.html", want: txtFormConfig},
+		{fixture: "txt_form_rejected.html", want: DNSConfig{
+			Records: []DNSRecord{
+				{Type: "TXT", Value: "v=spf1 -all"},
+				{Type: "CNAME", Prefix: "bad prefix", Value: "example.com."},
+			},
+			MaxRecords: 50,
+		}},
+		{fixture: "unknown_vhost.html", err: errTXTFormNotFound},
+		{fixture: "login.html", err: errTXTFormNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			config, err := parseDNSConfiguration(bytes.NewReader(fixture(t, test.fixture)), false)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if !reflect.DeepEqual(config, test.want) {
+				t.Errorf("got %+v, want %+v", config, test.want)
+			}
+		})
+	}
+}
+
+func TestLoginErrorFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		err     error
+		message string
+	}{
+		{"login_locked.html", ErrAccountLocked, "vorübergehend gesperrt"},
+		{"login_failed.html", ErrAuthenticationFailed, "nicht erfolgreich"},
+		{"login.html", ErrAuthenticationFailed, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			err := loginError(&http.Response{Body: io.NopCloser(bytes.NewReader(fixture(t, test.fixture)))})
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got %v, want %v", err, test.err)
+			}
+			if !strings.Contains(err.Error(), test.message) {
+				t.Errorf("error %q does not contain %q", err, test.message)
+			}
+		})
+	}
+}
+
+func TestFindCaptchaFixtures(t *testing.T) {
+	base := "https://www.strato.de/apps/CustomerService"
+	challenge, ok := findCaptcha(fixture(t, "login_captcha.html"), base)
+	if !ok {
+		t.Fatal("no CAPTCHA found")
+	}
+	want := CaptchaChallenge{
+		Field:    "login_captcha",
+		ImageURL: "https://www.strato.de/apps/CustomerService?node=captcha&sessionID=SESSIONID",
+	}
+	if !reflect.DeepEqual(challenge, want) {
+		t.Errorf("got %+v, want %+v", challenge, want)
+	}
+	if _, ok := findCaptcha(fixture(t, "login.html"), base); ok {
+		t.Error("found a CAPTCHA on the plain login page")
+	}
+}
+
+func TestDNSUpdateErrorFixtures(t *testing.T) {
+	err := dnsUpdateError(bytes.NewReader(fixture(t, "txt_form_rejected.html")), 2)
+	var updateErr *UpdateError
+	if !errors.As(err, &updateErr) {
+		t.Fatalf("got %v, want an *UpdateError", err)
+	}
+	want := &UpdateError{
+		Reason: "Ihre Eingaben konnten nicht übernommen werden.",
+		Fields: []FieldError{{
+			Index:   2,
+			Record:  DNSRecord{Type: "CNAME", Prefix: "bad prefix", Value: "example.com."},
+			Message: "Der Präfix enthält ungültige Zeichen.",
+		}},
+	}
+	if !reflect.DeepEqual(updateErr, want) {
+		t.Errorf("got %+v, want %+v", updateErr, want)
+	}
+
+	if err := dnsUpdateError(bytes.NewReader(fixture(t, "txt_form_saved.html")), 1); err != nil {
+		t.Errorf("confirmation page reported as failure: %v", err)
+	}
+}
+
+func TestResolveOrderForDomainFixture(t *testing.T) {
+	client := newTestClient(t, newTestPortal(t, RegionDE))
+	if cID := client.PackageID(); cID != "1" {
+		t.Errorf("got package ID %q, want 1", cID)
+	}
+	tests := []struct {
+		domain string
+		order  string
+	}{
+		{"example.com", "ORDER"},
+		{"example.net", "ORDER"},
+		{"www.example.com", "ORDER"},
+		{"example.org", "ORDER2"},
+		{"mail.example.org.", "ORDER2"},
+		{"example.de", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.domain, func(t *testing.T) {
+			order, err := client.ResolveOrderForDomain(test.domain)
+			if test.order == "" {
+				if err == nil {
+					t.Fatalf("got order %q, want an error", order)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order != test.order {
+				t.Errorf("got %q, want %q", order, test.order)
+			}
+		})
+	}
+}
+
+func TestUnknownVhostFixture(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal)
+	portal.setPage(txtFormNode, "unknown_vhost.html")
+	portal.setPage(RegionDE.ManageDomainsNode, "unknown_vhost.html")
+
+	_, err := client.ForDomain("example.de").GetDNSConfiguration()
+	var notFound *DomainNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("got %v, want a *DomainNotFoundError", err)
+	}
+	if want := []string{"example.com", "www.example.com"}; !reflect.DeepEqual(notFound.Available, want) {
+		t.Errorf("got available vhosts %v, want %v", notFound.Available, want)
+	}
+}
+
+func TestSanitizePage(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		secrets map[string]string
+		want    string
+	}{
+		{
+			name: "session ID in link",
+			page: `<a href="/apps/CustomerService?sessionID=a1b2c3&cID=1">`,
+			want: `<a href="/apps/CustomerService?sessionID=SESSIONID&cID=1">`,
+		},
+		{
+			name: "session ID in hidden input",
+			page: `<input type="hidden" name="sessionID" value="a1b2c3">`,
+			want: `<input type="hidden" name="sessionID" value="SESSIONID">`,
+		},
+		{
+			name: "form token",
+			page: `<input type="hidden" name="csrf_token" value="f00ba4">`,
+			want: `<input type="hidden" name="csrf_token" value="TOKEN">`,
+		},
+		{
+			name: "e-mail address",
+			page: `<td>jane.doe@mail.example.de</td>`,
+			want: `<td>user@example.com</td>`,
+		},
+		{
+			name:    "secrets, longest first",
+			page:    `<td>K-4711 shop.de www.shop.de</td>`,
+			secrets: map[string]string{"shop.de": "example.com", "www.shop.de": "www.example.org", "K-4711": "ORDER", "": "ignored"},
+			want:    `<td>ORDER example.com www.example.org</td>`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := string(SanitizePage([]byte(test.page), test.secrets)); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
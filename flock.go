@@ -0,0 +1,26 @@
+//go:build !windows && !plan9
+
+package strato
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile blocks until the caller holds an exclusive lock on file
+func lockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
+
+// tryLockFile takes an exclusive lock on file and reports false if another process holds it
+func tryLockFile(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return false, nil
+	}
+	return err == nil, err
+}
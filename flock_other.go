@@ -0,0 +1,21 @@
+//go:build plan9
+
+package strato
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile fails as file locks are not supported on this platform
+func lockFile(file *os.File) error {
+	return errors.New("file locks are not supported on this platform")
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
+
+func tryLockFile(file *os.File) (bool, error) {
+	return false, errors.New("file locks are not supported on this platform")
+}
@@ -0,0 +1,102 @@
+package strato
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// addPageSeeds adds the TXT record pages of the fixtures to the corpus of f
+func addPageSeeds(f *testing.F) {
+	for _, name := range []string{"txt_form.html", "txt_form_rejected.html", "txt_form_saved.html", "unknown_vhost.html"} {
+		f.Add(fixture(f, name), false)
+		f.Add(fixture(f, name), true)
+	}
+	f.Add([]byte(`<form id="jss_txt_record_form"><div id="jss_txt_container"><div class="txt-record-tmpl"></div></div></form>`), false)
+	f.Add([]byte(`<form id="jss_txt_record_form"><input name="dmarc_type"><input name="spf_type" checked>`), true)
+}
+
+func FuzzParseDNSConfiguration(f *testing.F) {
+	addPageSeeds(f)
+	f.Fuzz(func(t *testing.T, page []byte, lenient bool) {
+		config, err := parseDNSConfiguration(bytes.NewReader(page), lenient)
+		if err == nil && !lenient && len(config.Warnings) > 0 {
+			t.Errorf("strict parsing returned warnings: %v", config.Warnings)
+		}
+	})
+}
+
+func FuzzParseDNSConfigurationStream(f *testing.F) {
+	addPageSeeds(f)
+	f.Fuzz(func(t *testing.T, page []byte, lenient bool) {
+		config, err := parseDNSConfigurationStream(bytes.NewReader(page), lenient)
+		if err == nil && !lenient && len(config.Warnings) > 0 {
+			t.Errorf("strict parsing returned warnings: %v", config.Warnings)
+		}
+	})
+}
+
+func FuzzDNSUpdateError(f *testing.F) {
+	addPageSeeds(f)
+	f.Fuzz(func(t *testing.T, page []byte, _ bool) {
+		dnsUpdateError(bytes.NewReader(page), 3)
+	})
+}
+
+func FuzzXPathLiteral(f *testing.F) {
+	for _, seed := range []string{"example.de", "it's", `say "hi"`, `'"'`, "", "]", "')] | //*[('"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		doc := &html.Node{Type: html.DocumentNode}
+		doc.AppendChild(&html.Node{Type: html.ElementNode, Data: "div", Attr: []html.Attribute{{Key: "data-domain", Val: value}}})
+		nodes, err := htmlquery.QueryAll(doc, "//*[@data-domain="+xpathLiteral(value)+"]")
+		if err != nil {
+			t.Fatalf("xpathLiteral(%q) = %s: %v", value, xpathLiteral(value), err)
+		}
+		if len(nodes) != 1 {
+			t.Errorf("xpathLiteral(%q) = %s matched %d nodes", value, xpathLiteral(value), len(nodes))
+		}
+	})
+}
+
+func FuzzReadRecordsCSV(f *testing.F) {
+	f.Add([]byte("type,prefix,value,ttl\nTXT,,v=spf1 -all,\nCNAME,www,example.com.,3600\n"))
+	f.Add([]byte("\xef\xbb\xbfvalue;type;prefix\n\"v=DKIM1; p=abc\";TXT;mail._domainkey\n"))
+	f.Add([]byte("type,prefix\nTXT,\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		records, err := ReadRecordsCSV(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		// Whatever could be read must survive an export and import
+		var buf bytes.Buffer
+		if err := WriteRecordsCSV(&buf, records); err != nil {
+			t.Fatal(err)
+		}
+		again, err := ReadRecordsCSV(&buf)
+		if err != nil {
+			t.Fatalf("reading exported records: %v", err)
+		}
+		SortRecords(records)
+		if len(records) != len(again) || len(records) > 0 && !reflect.DeepEqual(records, again) {
+			t.Errorf("got %v after export, want %v", again, records)
+		}
+	})
+}
+
+func FuzzReadSnapshot(f *testing.F) {
+	f.Add(`{"domain":"example.com","time":"2026-01-02T03:04:05Z","config":{"dmarcType":"none","spfType":"strato","records":[{"type":"TXT","prefix":"","value":"v=spf1 -all"}]}}`)
+	f.Add(`{"domain":""}`)
+	f.Add(`[]`)
+	f.Fuzz(func(t *testing.T, data string) {
+		snapshot, err := ReadSnapshot(strings.NewReader(data))
+		if err == nil && snapshot.Domain == "" {
+			t.Error("snapshot without domain accepted")
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package strato
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GitSource is a Git repository holding the desired DNS configuration, one
+// <domain>.yaml file per domain below Path:
+//
+//	spfType: strato
+//	records:
+//	  - type: A
+//	    prefix: www
+//	    value: '{{ .PublicIPv4 }}'
+//
+// Values may contain the template expressions of RenderTemplate. They are resolved
+// after the file is parsed, so their output cannot change its structure.
+type GitSource struct {
+	URL string
+	// Branch defaults to the default branch of the remote
+	Branch string
+	// Path is the directory of the zone files within the repository
+	Path string
+	// Dir is the local working copy, created on the first Pull
+	Dir string
+	// Env lists the environment variables the zone files may read with env. Anyone
+	// who can push to the repository could otherwise publish secrets of the daemon,
+	// such as its password, in TXT records.
+	Env []string
+}
+
+// zoneFile is the content of a zone file of a GitSource
+type zoneFile struct {
+	DMARCType string `yaml:"dmarcType"`
+	SPFType   string `yaml:"spfType"`
+	Records   []struct {
+		Type   string `yaml:"type"`
+		Prefix string `yaml:"prefix"`
+		Value  string `yaml:"value"`
+	} `yaml:"records"`
+}
+
+// Pull clones or updates the working copy and returns the checked out commit
+func (s GitSource) Pull(ctx context.Context) (string, error) {
+	if _, err := os.Stat(filepath.Join(s.Dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		args := []string{"clone", "--depth", "1"}
+		if s.Branch != "" {
+			args = append(args, "--branch", s.Branch)
+		}
+		if _, err := git(ctx, "", append(args, s.URL, s.Dir)...); err != nil {
+			return "", err
+		}
+	} else {
+		ref := "HEAD"
+		if s.Branch != "" {
+			ref = s.Branch
+		}
+		if _, err := git(ctx, s.Dir, "fetch", "--depth", "1", "origin", ref); err != nil {
+			return "", err
+		}
+		// The working copy only mirrors the remote, local changes are discarded
+		if _, err := git(ctx, s.Dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+	}
+	return git(ctx, s.Dir, "rev-parse", "HEAD")
+}
+
+// Load reads the desired configuration of all domains from the working copy
+func (s GitSource) Load(data *TemplateData) (map[string]DNSConfig, error) {
+	files, err := zoneFiles(filepath.Join(s.Dir, s.Path))
+	if err != nil {
+		return nil, err
+	}
+	// An empty list allows no variable at all, unlike nil
+	return loadZoneFiles(files, data, lookupEnv(append([]string{}, s.Env...)))
+}
+
+// LoadZoneFiles reads the desired configuration of domains from path, either a single
+// <domain>.yaml file in the format of GitSource or a directory of them. Unlike those
+// of a GitSource, the values may read every environment variable.
+func LoadZoneFiles(path string, data *TemplateData) (map[string]DNSConfig, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = zoneFiles(path); err != nil {
+			return nil, err
+		}
+	}
+	return loadZoneFiles(files, data, lookupEnv(nil))
+}
+
+// zoneFiles returns the zone files in dir
+func zoneFiles(dir string) ([]string, error) {
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// loadZoneFiles parses and renders files, named after their domains
+func loadZoneFiles(files []string, data *TemplateData, env func(string) (string, error)) (map[string]DNSConfig, error) {
+	desired := map[string]DNSConfig{}
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var zone zoneFile
+		decoder := yaml.NewDecoder(bytes.NewReader(text))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&zone); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		name := filepath.Base(file)
+		render := func(value string) (string, error) {
+			return renderValue(name, value, data, env)
+		}
+		var config DNSConfig
+		if config.DMARCType, err = render(zone.DMARCType); err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", file, err)
+		}
+		if config.SPFType, err = render(zone.SPFType); err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", file, err)
+		}
+		for _, record := range zone.Records {
+			value, err := render(record.Value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render %s: %w", file, err)
+			}
+			config.Records = append(config.Records, DNSRecord{Type: record.Type, Prefix: record.Prefix, Value: value})
+		}
+		desired[strings.TrimSuffix(name, filepath.Ext(name))] = config
+	}
+	return desired, nil
+}
+
+// SyncFromGit pulls source every interval and reconciles all domains found in it,
+// recording the commit in the audit log. callback receives the commit and the report
+// of every round; a failed pull is reported with an empty report and its error.
+func (c *StratoClient) SyncFromGit(ctx context.Context, source GitSource, interval time.Duration, concurrency int, callback func(revision string, report SyncReport, err error)) error {
+	if interval <= 0 {
+		return errors.New("sync interval must be positive")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		revision, err := source.Pull(ctx)
+		if err == nil {
+			var desired map[string]DNSConfig
+			if desired, err = source.Load(NewTemplateData(ctx, nil)); err == nil {
+				clone := *c
+				clone.auditRevision = revision
+				logFor(LogForm).Debug("Synchronizing from Git", "revision", revision, "domains", len(desired))
+				callback(revision, clone.syncAll(ctx, desired, concurrency, time.Now().Add(interval)), nil)
+			}
+		}
+		if err != nil {
+			callback(revision, SyncReport{}, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// git runs a git command in dir and returns its trimmed output
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,127 @@
+package strato
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeZoneFile writes the zone file of example.com with a TXT record of value
+func writeZoneFile(t *testing.T, dir, value string) {
+	t.Helper()
+	text := "records:\n  - type: TXT\n    prefix: test\n    value: '" + value + "'\n"
+	if err := os.WriteFile(filepath.Join(dir, "example.com.yaml"), []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitSourceEnv(t *testing.T) {
+	t.Setenv("STRATO_TEST_ALLOWED", "v=allowed")
+	t.Setenv("STRATO_TEST_SECRET", "hunter2")
+	tests := []struct {
+		name  string
+		env   []string
+		value string
+		want  string
+		err   string
+	}{
+		{name: "allowed", env: []string{"STRATO_TEST_ALLOWED"}, value: `{{ env "STRATO_TEST_ALLOWED" }}`, want: "v=allowed"},
+		{name: "not allowed", env: []string{"STRATO_TEST_ALLOWED"}, value: `{{ env "STRATO_TEST_SECRET" }}`, err: "STRATO_TEST_SECRET is not allowed"},
+		{name: "no variables allowed", value: `{{ env "STRATO_TEST_ALLOWED" }}`, err: "STRATO_TEST_ALLOWED is not allowed"},
+		{name: "unset", env: []string{"STRATO_TEST_UNSET"}, value: `{{ env "STRATO_TEST_UNSET" }}`, err: "STRATO_TEST_UNSET is not set"},
+		{name: "no template", value: "v=plain", want: "v=plain"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeZoneFile(t, dir, test.value)
+			desired, err := GitSource{Dir: dir, Env: test.env}.Load(NewTemplateData(context.Background(), nil))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("got %v, want an error containing %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			records := desired["example.com"].Records
+			if len(records) != 1 || records[0].Value != test.want {
+				t.Errorf("got %+v, want the value %q", records, test.want)
+			}
+		})
+	}
+}
+
+// TestGitSourceKeepsMailSettings makes sure zone files without dmarc_type and spf_type
+// keep the settings of the portal instead of changing them in every round
+func TestGitSourceKeepsMailSettings(t *testing.T) {
+	dir := t.TempDir()
+	text := "records:\n"
+	for _, record := range append(txtFormConfig.Records, DNSRecord{Type: "TXT", Prefix: "test", Value: "v=added"}) {
+		text += fmt.Sprintf("  - type: %s\n    prefix: '%s'\n    value: '%s'\n", record.Type, record.Prefix, record.Value)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "example.com.yaml"), []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	desired, err := GitSource{Dir: dir}.Load(NewTemplateData(context.Background(), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newTestClient(t, newTestPortal(t, RegionDE), WithVerifyAfterWrite())
+
+	first := client.SyncAll(context.Background(), desired, 1)
+	if err := first.Err(); err != nil {
+		t.Fatal(err)
+	}
+	diff := first.Results[0].Diff
+	if diff.DMARCType != "" || diff.SPFType != "" || len(diff.Added) != 1 || len(diff.Removed) != 0 {
+		t.Errorf("first round: got diff %q, want only the added record", diff.String())
+	}
+	second := client.SyncAll(context.Background(), desired, 1)
+	if err := second.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := second.Results[0].Diff; !diff.Empty() {
+		t.Errorf("second round: got diff %q, want none", diff.String())
+	}
+}
+
+func TestLoadZoneFiles(t *testing.T) {
+	t.Setenv("STRATO_TEST_VALUE", "v=env")
+	dir := t.TempDir()
+	writeZoneFile(t, dir, `{{ env "STRATO_TEST_VALUE" }}`)
+	text := "spfType: none\nrecords:\n  - type: CNAME\n    prefix: www\n    value: example.net.\n"
+	if err := os.WriteFile(filepath.Join(dir, "example.net.yml"), []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	data := NewTemplateData(context.Background(), nil)
+
+	desired, err := LoadZoneFiles(dir, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(desired) != 2 {
+		t.Fatalf("got domains %v, want example.com and example.net", desired)
+	}
+	// Local zone files may read every environment variable
+	if records := desired["example.com"].Records; len(records) != 1 || records[0].Value != "v=env" {
+		t.Errorf("got records %+v of example.com, want the value of the variable", records)
+	}
+
+	desired, err = LoadZoneFiles(filepath.Join(dir, "example.net.yml"), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, ok := desired["example.net"]
+	if len(desired) != 1 || !ok || config.SPFType != "none" || len(config.Records) != 1 {
+		t.Errorf("got %+v, want the configuration of example.net only", desired)
+	}
+
+	if _, err := LoadZoneFiles(filepath.Join(dir, "missing.yaml"), data); err == nil {
+		t.Error("got no error for a missing file")
+	}
+}
@@ -4,13 +4,18 @@ go 1.22.0
 
 require (
 	github.com/antchfx/htmlquery v1.3.4
-	k8s.io/klog/v2 v2.130.1
+	github.com/libdns/libdns v1.1.1
+	github.com/oapi-codegen/runtime v1.1.2
+	golang.org/x/crypto v0.31.0
+	golang.org/x/net v0.33.0
+	golang.org/x/sys v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/antchfx/xpath v1.3.3 // indirect
-	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/apapsch/go-jsonmerge/v2 v2.0.0 // indirect
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
-	golang.org/x/net v0.33.0 // indirect
+	github.com/google/uuid v1.5.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 )
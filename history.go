@@ -0,0 +1,34 @@
+package strato
+
+// Sources of the configurations passed to a HistoryRecorder
+const (
+	// HistoryApplied marks a configuration written through a client with the recorder
+	HistoryApplied = "applied"
+	// HistoryObserved marks a configuration fetched from the portal; if it differs
+	// from the previous entry, it was changed elsewhere, e.g. in the portal
+	HistoryObserved = "observed"
+)
+
+// HistoryRecorder keeps the configurations of domains, e.g. the SQLite store of the
+// history package
+type HistoryRecorder interface {
+	// Record adds config as the current configuration of domain and reports whether
+	// it differed from the latest one
+	Record(domain string, config DNSConfig, source, actor string) (bool, error)
+}
+
+// recordHistory adds config to the history of the domain, if the client keeps one
+func (c *StratoClient) recordHistory(config DNSConfig, source string) {
+	if c.history == nil {
+		return
+	}
+	actor := ""
+	if source == HistoryApplied {
+		actor = c.auditActor
+	}
+	if changed, err := c.history.Record(c.domain, config, source, actor); err != nil {
+		logFor(LogScrape).Warn("Failed to record history", "domain", c.domain, "error", err)
+	} else if changed {
+		logFor(LogScrape).Debug("Recorded configuration in history", "domain", c.domain, "source", source)
+	}
+}
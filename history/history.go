@@ -0,0 +1,161 @@
+// Package history keeps every distinct DNS configuration of a domain in a SQLite
+// database. A Store is passed to clients with strato.WithHistory. The package is a
+// module of its own, so only programs using it depend on the SQLite driver.
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+
+	// SQLite driver without cgo, registered as "sqlite"
+	_ "modernc.org/sqlite"
+)
+
+// Entry is a configuration of a domain and when it was first seen
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Domain string    `json:"domain"`
+	// Source is strato.HistoryApplied or strato.HistoryObserved
+	Source string `json:"source"`
+	// Actor made the change, for applied configurations of clients with an actor
+	Actor  string           `json:"actor,omitempty"`
+	Config strato.DNSConfig `json:"config"`
+}
+
+// Store keeps every distinct configuration observed or applied per domain in a
+// SQLite database. Entries are only added, the database can be shared by the
+// clients of several domains and read by other processes while one writes to it.
+type Store struct {
+	db *sql.DB
+	// mu makes checking the latest entry and adding one atomic within the process
+	mu sync.Mutex
+}
+
+// historySchema creates the table of entries; config is the JSON of the DNSConfig
+const historySchema = `CREATE TABLE IF NOT EXISTS entries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	time INTEGER NOT NULL,
+	domain TEXT NOT NULL,
+	source TEXT NOT NULL,
+	actor TEXT NOT NULL DEFAULT '',
+	config TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS entries_domain_time ON entries (domain, time);`
+
+// Open opens the history database at path, creating it if it does not exist
+func Open(path string) (*Store, error) {
+	// WAL lets the history commands read while a watching process writes
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (h *Store) Close() error {
+	return h.db.Close()
+}
+
+// Record adds config as the current configuration of domain and reports whether it
+// differed from the latest entry
+func (h *Store) Record(domain string, config strato.DNSConfig, source, actor string) (bool, error) {
+	records := append([]strato.DNSRecord(nil), config.Records...)
+	strato.SortRecords(records)
+	config = strato.DNSConfig{Records: records, DMARCType: config.DMARCType, SPFType: config.SPFType}
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return false, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	tx, err := h.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	var last string
+	err = tx.QueryRow(`SELECT config FROM entries WHERE domain = ? ORDER BY time DESC, id DESC LIMIT 1`, domain).Scan(&last)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return false, err
+	default:
+		var previous strato.DNSConfig
+		if err := json.Unmarshal([]byte(last), &previous); err != nil {
+			return false, err
+		}
+		if strato.DiffConfigs(previous, config).Empty() {
+			return false, nil
+		}
+	}
+	_, err = tx.Exec(`INSERT INTO entries (time, domain, source, actor, config) VALUES (?, ?, ?, ?, ?)`,
+		time.Now().UTC().UnixNano(), domain, source, actor, string(encoded))
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Entries returns the entries of domain recorded at or after since, oldest first
+func (h *Store) Entries(domain string, since time.Time) ([]Entry, error) {
+	return h.query(`SELECT time, domain, source, actor, config FROM entries
+		WHERE domain = ? AND time >= ? ORDER BY time, id`, domain, unixNano(since))
+}
+
+// At returns the entry of domain that was current at t
+func (h *Store) At(domain string, t time.Time) (Entry, error) {
+	entries, err := h.query(`SELECT time, domain, source, actor, config FROM entries
+		WHERE domain = ? AND time <= ? ORDER BY time DESC, id DESC LIMIT 1`, domain, unixNano(t))
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(entries) == 0 {
+		return Entry{}, fmt.Errorf("no history of %s before %s", domain, t.Format(time.RFC3339))
+	}
+	return entries[0], nil
+}
+
+// query returns the entries selected by query, whose columns are those of Entry
+func (h *Store) query(query string, args ...interface{}) ([]Entry, error) {
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []Entry
+	for rows.Next() {
+		var nanos int64
+		var config string
+		var entry Entry
+		if err := rows.Scan(&nanos, &entry.Domain, &entry.Source, &entry.Actor, &config); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(config), &entry.Config); err != nil {
+			return nil, fmt.Errorf("entry of %s: %w", entry.Domain, err)
+		}
+		entry.Time = time.Unix(0, nanos).UTC()
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// unixNano returns t as stored in the database; the zero time is before every entry
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return math.MinInt64
+	}
+	return t.UnixNano()
+}
@@ -0,0 +1,144 @@
+package history
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/internal/stratotest"
+)
+
+func openTestHistory(t *testing.T) *Store {
+	t.Helper()
+	history, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { history.Close() })
+	return history
+}
+
+func TestHistoryRecord(t *testing.T) {
+	history := openTestHistory(t)
+	first := strato.DNSConfig{SPFType: strato.SPFTypeStrato, Records: []strato.DNSRecord{
+		{Type: "TXT", Value: "v=spf1 -all"},
+		{Type: "CNAME", Prefix: "www", Value: "example.com."},
+	}}
+	// The same records in another order and with another limit are the same configuration
+	reordered := strato.DNSConfig{SPFType: strato.SPFTypeStrato, MaxRecords: 100, Records: []strato.DNSRecord{first.Records[1], first.Records[0]}}
+	second := strato.DNSConfig{SPFType: strato.SPFTypeNone, Records: first.Records}
+
+	steps := []struct {
+		domain string
+		config strato.DNSConfig
+		source string
+		want   bool
+	}{
+		{"example.com", first, strato.HistoryObserved, true},
+		{"example.com", first, strato.HistoryObserved, false},
+		{"example.com", reordered, strato.HistoryApplied, false},
+		// Another domain has a history of its own
+		{"example.net", first, strato.HistoryObserved, true},
+		{"example.com", second, strato.HistoryApplied, true},
+		{"example.com", first, strato.HistoryObserved, true},
+	}
+	for i, step := range steps {
+		changed, err := history.Record(step.domain, step.config, step.source, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed != step.want {
+			t.Errorf("step %d: Record reported %t, want %t", i, changed, step.want)
+		}
+	}
+
+	entries, err := history.Entries("example.com", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSources := []string{strato.HistoryObserved, strato.HistoryApplied, strato.HistoryObserved}
+	if len(entries) != len(wantSources) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(wantSources))
+	}
+	for i, entry := range entries {
+		if entry.Source != wantSources[i] || entry.Domain != "example.com" {
+			t.Errorf("entry %d: got %s of %s, want %s of example.com", i, entry.Source, entry.Domain, wantSources[i])
+		}
+		if i > 0 && entry.Time.Before(entries[i-1].Time) {
+			t.Errorf("entry %d at %s is older than the one before", i, entry.Time)
+		}
+	}
+	if entries[1].Config.SPFType != strato.SPFTypeNone {
+		t.Errorf("got spf type %q in the applied entry, want %q", entries[1].Config.SPFType, strato.SPFTypeNone)
+	}
+
+	since, err := history.Entries("example.com", entries[1].Time)
+	if err != nil || len(since) != 2 {
+		t.Errorf("got %d entries since the second (%v), want 2", len(since), err)
+	}
+}
+
+func TestHistoryAt(t *testing.T) {
+	history := openTestHistory(t)
+	before := time.Now()
+	if _, err := history.At("example.com", before); err == nil || !strings.Contains(err.Error(), "no history") {
+		t.Errorf("got %v for an empty history, want no history", err)
+	}
+
+	first := strato.DNSConfig{Records: []strato.DNSRecord{{Type: "TXT", Value: "first"}}}
+	second := strato.DNSConfig{Records: []strato.DNSRecord{{Type: "TXT", Value: "second"}}}
+	if _, err := history.Record("example.com", first, strato.HistoryObserved, ""); err != nil {
+		t.Fatal(err)
+	}
+	between := time.Now()
+	// The entries are ordered by their time in nanoseconds
+	time.Sleep(time.Millisecond)
+	if _, err := history.Record("example.com", second, strato.HistoryApplied, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := history.At("example.com", before); err == nil {
+		t.Error("got an entry before the first one")
+	}
+	entry, err := history.At("example.com", between)
+	if err != nil || entry.Config.Records[0].Value != "first" {
+		t.Errorf("At between the entries = %v, %v, want the first entry", entry, err)
+	}
+	entry, err = history.At("example.com", time.Now())
+	if err != nil || entry.Config.Records[0].Value != "second" || entry.Actor != "alice" {
+		t.Errorf("At now = %v, %v, want the second entry by alice", entry, err)
+	}
+}
+
+func TestClientHistory(t *testing.T) {
+	history := openTestHistory(t)
+	portal := stratotest.NewPortal(t)
+	portal.SetConfig("example.com", strato.DNSConfig{Records: []strato.DNSRecord{{Type: "TXT", Value: "first"}}})
+	client := portal.Client(t, strato.WithHistory(history))
+
+	config, err := client.GetDNSConfiguration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Records = append(config.Records, strato.DNSRecord{Type: "TXT", Value: "second"})
+	if err := client.SetDNSConfiguration(config); err != nil {
+		t.Fatal(err)
+	}
+	// Fetching what was just applied adds no entry
+	if _, err := client.GetDNSConfiguration(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := history.Entries("example.com", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Source != strato.HistoryObserved || entries[1].Source != strato.HistoryApplied {
+		t.Fatalf("got entries %+v, want the observed and the applied configuration", entries)
+	}
+	if len(entries[1].Config.Records) != 2 {
+		t.Errorf("got %d records in the applied entry, want 2", len(entries[1].Config.Records))
+	}
+}
@@ -0,0 +1,251 @@
+package strato
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// FTPAccount is an (S)FTP user of the hosting package
+type FTPAccount struct {
+	Username string `json:"username"`
+	// Directory is the webspace directory the user is restricted to
+	Directory string `json:"directory"`
+	SFTP      bool   `json:"sftp"`
+}
+
+// ListFTPAccounts retrieves the (S)FTP users of the package
+func (c *StratoClient) ListFTPAccounts() ([]FTPAccount, error) {
+	doc, err := c.fetchPage(c.region.FTPNode)
+	if err != nil {
+		return nil, err
+	}
+	table := htmlquery.FindOne(doc, "//table[@id='jss_ftp_table']")
+	if table == nil {
+		return nil, errors.New("failed to find FTP account table")
+	}
+	var accounts []FTPAccount
+	for _, row := range htmlquery.Find(table, ".//tr[@data-ftp-user]") {
+		accounts = append(accounts, FTPAccount{
+			Username:  htmlquery.SelectAttr(row, "data-ftp-user"),
+			Directory: textOf(row, ".//td[contains(@class, 'directory')]"),
+			SFTP:      htmlquery.SelectAttr(row, "data-sftp") == "1",
+		})
+	}
+	return accounts, nil
+}
+
+// ResetFTPPassword sets a new password for an (S)FTP user
+func (c *StratoClient) ResetFTPPassword(username, newPassword string) error {
+	if username == "" || newPassword == "" {
+		return errors.New("username and password must not be empty")
+	}
+	form := url.Values{}
+	form.Set("ftp_user", username)
+	form.Set("passwd", newPassword)
+	form.Set("passwd_repeat", newPassword)
+	form.Set("action_change_ftp_password", "1")
+	return c.submitForm(c.region.FTPNode, form)
+}
+
+// Database is a MySQL database of the hosting package
+type Database struct {
+	Name string `json:"name"`
+	User string `json:"user"`
+	Host string `json:"host"`
+	// Size is the size as displayed by the portal (e.g. "12,5 MB")
+	Size string `json:"size"`
+}
+
+// ListDatabases retrieves the MySQL databases of the package
+func (c *StratoClient) ListDatabases() ([]Database, error) {
+	doc, err := c.fetchPage(c.region.DatabaseNode)
+	if err != nil {
+		return nil, err
+	}
+	table := htmlquery.FindOne(doc, "//table[@id='jss_database_table']")
+	if table == nil {
+		return nil, errors.New("failed to find database table")
+	}
+	var databases []Database
+	for _, row := range htmlquery.Find(table, ".//tr[@data-database]") {
+		databases = append(databases, Database{
+			Name: htmlquery.SelectAttr(row, "data-database"),
+			User: textOf(row, ".//td[contains(@class, 'user')]"),
+			Host: textOf(row, ".//td[contains(@class, 'host')]"),
+			Size: textOf(row, ".//td[contains(@class, 'size')]"),
+		})
+	}
+	return databases, nil
+}
+
+// CreateDatabase creates a new MySQL database with the given password and returns it.
+// Strato assigns database name and user itself.
+func (c *StratoClient) CreateDatabase(password string) (Database, error) {
+	if password == "" {
+		return Database{}, errors.New("database password must not be empty")
+	}
+	before, err := c.ListDatabases()
+	if err != nil {
+		return Database{}, err
+	}
+	form := url.Values{}
+	form.Set("passwd", password)
+	form.Set("passwd_repeat", password)
+	form.Set("action_create_database", "1")
+	if err := c.submitForm(c.region.DatabaseNode, form); err != nil {
+		return Database{}, err
+	}
+	after, err := c.ListDatabases()
+	if err != nil {
+		return Database{}, err
+	}
+	// The new database is the one that was not there before
+	for _, database := range after {
+		known := false
+		for _, existing := range before {
+			if existing.Name == database.Name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return database, nil
+		}
+	}
+	return Database{}, errors.New("database was not created")
+}
+
+// ResetDatabasePassword sets a new password for a MySQL database
+func (c *StratoClient) ResetDatabasePassword(name, newPassword string) error {
+	if name == "" || newPassword == "" {
+		return errors.New("database name and password must not be empty")
+	}
+	form := url.Values{}
+	form.Set("database", name)
+	form.Set("passwd", newPassword)
+	form.Set("passwd_repeat", newPassword)
+	form.Set("action_change_database_password", "1")
+	return c.submitForm(c.region.DatabaseNode, form)
+}
+
+// CronJob is a scheduled task of the hosting package
+type CronJob struct {
+	// ID is assigned by Strato and empty for jobs that are yet to be created
+	ID string `json:"id,omitempty"`
+	// Schedule is a five field cron expression, e.g. "0 3 * * *"
+	Schedule string `json:"schedule"`
+	// Script is the webspace path of the script to run
+	Script string `json:"script"`
+}
+
+// ListCronJobs retrieves the scheduled tasks of the package
+func (c *StratoClient) ListCronJobs() ([]CronJob, error) {
+	doc, err := c.fetchPage(c.region.CronNode)
+	if err != nil {
+		return nil, err
+	}
+	table := htmlquery.FindOne(doc, "//table[@id='jss_cron_table']")
+	if table == nil {
+		return nil, errors.New("failed to find cron job table")
+	}
+	var jobs []CronJob
+	for _, row := range htmlquery.Find(table, ".//tr[@data-cron-id]") {
+		jobs = append(jobs, CronJob{
+			ID:       htmlquery.SelectAttr(row, "data-cron-id"),
+			Schedule: textOf(row, ".//td[contains(@class, 'schedule')]"),
+			Script:   textOf(row, ".//td[contains(@class, 'script')]"),
+		})
+	}
+	return jobs, nil
+}
+
+// CreateCronJob schedules a new task
+func (c *StratoClient) CreateCronJob(job CronJob) error {
+	if len(strings.Fields(job.Schedule)) != 5 {
+		return errors.New("cron schedule must have five fields: " + job.Schedule)
+	}
+	if job.Script == "" {
+		return errors.New("cron job script must not be empty")
+	}
+	form := url.Values{}
+	form.Set("schedule", job.Schedule)
+	form.Set("script", job.Script)
+	form.Set("action_create_cronjob", "1")
+	return c.submitForm(c.region.CronNode, form)
+}
+
+// DeleteCronJob removes a scheduled task
+func (c *StratoClient) DeleteCronJob(id string) error {
+	if id == "" {
+		return errors.New("cron job id must not be empty")
+	}
+	form := url.Values{}
+	form.Set("cron_id", id)
+	form.Set("action_delete_cronjob", "1")
+	return c.submitForm(c.region.CronNode, form)
+}
+
+// WebspaceSettings are the hosting settings of a domain
+type WebspaceSettings struct {
+	PHPVersion string `json:"phpVersion"`
+	// Webroot is the webspace directory the domain points to
+	Webroot string `json:"webroot"`
+	// AvailablePHPVersions lists the versions offered by the portal, it is ignored by SetWebspaceSettings
+	AvailablePHPVersions []string `json:"availablePhpVersions,omitempty"`
+}
+
+// GetWebspaceSettings retrieves PHP version and webroot of a domain
+func (c *StratoClient) GetWebspaceSettings(domain string) (WebspaceSettings, error) {
+	doc, err := c.fetchPage(c.region.WebspaceNode, "vhost="+domain)
+	if err != nil {
+		return WebspaceSettings{}, err
+	}
+	form := htmlquery.FindOne(doc, "//form[@id='jss_webspace_form']")
+	if form == nil {
+		return WebspaceSettings{}, errors.New("failed to find webspace settings form")
+	}
+	settings := WebspaceSettings{
+		PHPVersion: attrOf(form, ".//select[@name='php_version']/option[@selected]", "value"),
+		Webroot:    attrOf(form, ".//input[@name='webroot']", "value"),
+	}
+	for _, option := range htmlquery.Find(form, ".//select[@name='php_version']/option") {
+		settings.AvailablePHPVersions = append(settings.AvailablePHPVersions, htmlquery.SelectAttr(option, "value"))
+	}
+	if settings.PHPVersion == "" {
+		return WebspaceSettings{}, errors.New("failed to find php_version value")
+	}
+	return settings, nil
+}
+
+// SetWebspaceSettings changes PHP version and webroot of a domain. Empty fields keep the current value.
+func (c *StratoClient) SetWebspaceSettings(domain string, settings WebspaceSettings) error {
+	current, err := c.GetWebspaceSettings(domain)
+	if err != nil {
+		return err
+	}
+	if settings.PHPVersion == "" {
+		settings.PHPVersion = current.PHPVersion
+	}
+	if settings.Webroot == "" {
+		settings.Webroot = current.Webroot
+	}
+	offered := false
+	for _, version := range current.AvailablePHPVersions {
+		if version == settings.PHPVersion {
+			offered = true
+			break
+		}
+	}
+	if !offered {
+		return errors.New("PHP version " + settings.PHPVersion + " is not offered, use one of " + strings.Join(current.AvailablePHPVersions, ", "))
+	}
+	form := url.Values{}
+	form.Set("vhost", domain)
+	form.Set("php_version", settings.PHPVersion)
+	form.Set("webroot", settings.Webroot)
+	form.Set("action_change_webspace", "1")
+	return c.submitForm(c.region.WebspaceNode, form)
+}
@@ -0,0 +1,23 @@
+package strato
+
+import (
+	"strings"
+)
+
+// normalizeIdentifier brings the identifier into the form the login page expects.
+// The portal takes customer numbers, domains and email addresses in the same field
+// and the same login flow, so only their notation is normalized. Domains are kept
+// as typed apart from case and a trailing dot, a www. label may be part of the
+// identifier.
+func normalizeIdentifier(identifier string) string {
+	identifier = strings.TrimSpace(identifier)
+	switch compact := strings.ReplaceAll(identifier, " ", ""); {
+	case strings.Contains(identifier, "@"):
+		return strings.ToLower(identifier)
+	case compact != "" && strings.Trim(compact, "0123456789") == "":
+		// Customer numbers are often copied with grouping spaces
+		return compact
+	default:
+		return strings.ToLower(strings.TrimSuffix(identifier, "."))
+	}
+}
@@ -0,0 +1,81 @@
+package strato
+
+import (
+	"bytes"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/antchfx/htmlquery"
+)
+
+func TestNormalizeIdentifier(t *testing.T) {
+	for identifier, want := range map[string]string{
+		"12345678":             "12345678",
+		" 1234 5678 ":          "12345678",
+		"Example.COM.":         "example.com",
+		"www.example.com":      "www.example.com",
+		"Admin@Example.com":    "admin@example.com",
+		"www.admin@example.de": "www.admin@example.de",
+	} {
+		if got := normalizeIdentifier(identifier); got != want {
+			t.Errorf("normalizeIdentifier(%q) = %q, want %q", identifier, got, want)
+		}
+	}
+}
+
+// TestLoginFormFixture makes sure the login page has a single field for all kinds of
+// identifiers, the one the client fills in
+func TestLoginFormFixture(t *testing.T) {
+	doc, err := htmlquery.Parse(bytes.NewReader(fixture(t, "login.html")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for _, input := range htmlquery.Find(doc, "//form[@id='jss_login_form']//input[@type='text' or @type='password' or @type='email']") {
+		fields = append(fields, htmlquery.SelectAttr(input, "name"))
+	}
+	if want := []string{RegionDE.IdentifierField, RegionDE.PasswordField}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("got fields %v, want %v", fields, want)
+	}
+	action, _, _ := strings.Cut(RegionDE.LoginAction, "=")
+	if htmlquery.FindOne(doc, "//form[@id='jss_login_form']//input[@type='submit' and @name="+xpathLiteral(action)+"]") == nil {
+		t.Errorf("got no submit button %s", action)
+	}
+}
+
+func TestLoginIdentifierKinds(t *testing.T) {
+	tests := []struct {
+		kind, identifier, want string
+	}{
+		{"customer number", "1234 5678", "12345678"},
+		{"domain", "Example.COM.", "example.com"},
+		{"email", "Admin@Example.com", "admin@example.com"},
+	}
+	var forms []url.Values
+	for _, test := range tests {
+		t.Run(test.kind, func(t *testing.T) {
+			portal := newTestPortal(t, RegionDE)
+			portal.identifier = test.want
+			_, err := NewStratoClient(portal.API(), test.identifier, testPassword, "ORDER", "example.com", WithRegion(RegionDE))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(portal.logins) != 1 {
+				t.Fatalf("got %d logins, want 1", len(portal.logins))
+			}
+			forms = append(forms, portal.logins[0])
+		})
+	}
+	// Every kind is sent the same way, only the identifier differs
+	for i, form := range forms {
+		if got := form.Get(RegionDE.IdentifierField); got != tests[i].want {
+			t.Errorf("%s: got identifier %q, want %q", tests[i].kind, got, tests[i].want)
+		}
+		form.Del(RegionDE.IdentifierField)
+		if !reflect.DeepEqual(form, forms[0]) {
+			t.Errorf("%s: got form %v, want %v like the %s", tests[i].kind, form, forms[0], tests[0].kind)
+		}
+	}
+}
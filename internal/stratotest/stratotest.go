@@ -0,0 +1,171 @@
+// Package stratotest serves a customer portal for the tests of the packages built on
+// the client, with the record form of every domain showing the configuration last
+// submitted for it.
+package stratotest
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// Portal serves the customer portal for the package of the entry.html fixture
+type Portal struct {
+	*httptest.Server
+	tb testing.TB
+
+	mu      sync.Mutex
+	configs map[string]strato.DNSConfig
+	writes  int
+	reads   int
+	// failWrites makes the portal answer that many record forms with an error
+	failWrites int
+}
+
+// NewPortal starts a portal that is closed when the test ends
+func NewPortal(tb testing.TB) *Portal {
+	p := &Portal{tb: tb, configs: map[string]strato.DNSConfig{}}
+	p.Server = httptest.NewServer(http.HandlerFunc(p.serve))
+	tb.Cleanup(p.Close)
+	return p
+}
+
+// Config returns the configuration of domain, a single record if none was submitted
+func (p *Portal) Config(domain string) strato.DNSConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.configLocked(domain)
+}
+
+func (p *Portal) configLocked(domain string) strato.DNSConfig {
+	config, ok := p.configs[domain]
+	if !ok {
+		config = strato.DNSConfig{Records: []strato.DNSRecord{{Type: "CNAME", Prefix: "www", Value: domain + "."}}}
+	}
+	return config
+}
+
+// SetConfig changes the configuration of domain, like a change made in the portal
+func (p *Portal) SetConfig(domain string, config strato.DNSConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configs[domain] = config
+}
+
+// Writes returns the number of record forms submitted
+func (p *Portal) Writes() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.writes
+}
+
+// Reads returns the number of record forms requested
+func (p *Portal) Reads() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.reads
+}
+
+// FailWrites makes the portal answer the next n record forms with an error
+func (p *Portal) FailWrites(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failWrites = n
+}
+
+// Client logs in to the portal as the owner of example.com
+func (p *Portal) Client(tb testing.TB, opts ...strato.Option) *strato.StratoClient {
+	tb.Helper()
+	client, err := strato.NewStratoClient(p.URL+"/apps/CustomerService", "12345678", "secret", "ORDER", "example.com", opts...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return client
+}
+
+func (p *Portal) serve(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && query.Has("action_change_txt_records"):
+		if p.failWrites > 0 {
+			p.failWrites--
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		config := strato.DNSConfig{DMARCType: r.PostForm.Get("dmarc_type"), SPFType: r.PostForm.Get("spf_type")}
+		types, prefixes, values := r.PostForm["type"], r.PostForm["prefix"], r.PostForm["value"]
+		for i := range types {
+			config.Records = append(config.Records, strato.DNSRecord{Type: types[i], Prefix: prefixes[i], Value: values[i]})
+		}
+		p.configs[r.PostForm.Get("vhost")] = config
+		p.writes++
+		http.Redirect(w, r, "/apps/CustomerService?sessionID=SESSIONID", http.StatusFound)
+	case r.Method == http.MethodPost:
+		http.Redirect(w, r, "/apps/CustomerService?sessionID=SESSIONID&cID=0&node="+strato.RegionDE.EntryNode, http.StatusFound)
+	case query.Get("sessionID") == "":
+		p.write(w, "login.html")
+	case query.Has("action_show_txt_records"):
+		p.reads++
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(txtFormPage(p.configLocked(query.Get("vhost"))))
+	case query.Get("node") == strato.RegionDE.EntryNode:
+		p.write(w, "entry.html")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// write answers with the fixture name of the strato package
+func (p *Portal) write(w http.ResponseWriter, name string) {
+	_, file, _, _ := runtime.Caller(0)
+	page, err := os.ReadFile(filepath.Join(filepath.Dir(file), "..", "..", "testdata", "fixtures", name))
+	if err != nil {
+		p.tb.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page)
+}
+
+// txtFormPage renders config like the record form of the portal, with the mail
+// settings only if config has them
+func txtFormPage(config strato.DNSConfig) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<!DOCTYPE html><html><body><form id="jss_txt_record_form">`)
+	for _, setting := range []struct{ name, value string }{{"dmarc_type", config.DMARCType}, {"spf_type", config.SPFType}} {
+		if setting.value == "" {
+			continue
+		}
+		for _, value := range []string{"strato", "none"} {
+			checked := ""
+			if value == setting.value {
+				checked = " checked"
+			}
+			fmt.Fprintf(&b, `<input type="radio" name="%s" value="%s"%s>`, setting.name, value, checked)
+		}
+	}
+	b.WriteString(`<div id="jss_txt_container">`)
+	for _, record := range config.Records {
+		fmt.Fprintf(&b, `<div class="txt-record-tmpl"><select name="type"><option value="%s" selected>%[1]s</option></select>`+
+			`<input name="prefix" value="%s"><textarea name="value">%s</textarea></div>`,
+			record.Type, html.EscapeString(record.Prefix), html.EscapeString(record.Value))
+	}
+	b.WriteString(`</div></form></body></html>`)
+	return b.Bytes()
+}
@@ -0,0 +1,127 @@
+package strato
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// authState is the portal session, shared by a client and the copies made of it
+// with ForDomain, WithContext and the like, so a new login reaches all of them
+type authState struct {
+	// login serializes logins after the session expired
+	login sync.Mutex
+
+	mu        sync.Mutex
+	sessionID string
+	// password is the one logins use, changed by ChangeAccountPassword
+	password string
+}
+
+// sessionID returns the ID of the current session
+func (c *StratoClient) sessionID() string {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+	return c.auth.sessionID
+}
+
+func (c *StratoClient) setSessionID(sessionID string) {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+	c.auth.sessionID = sessionID
+}
+
+// password returns the password logins use
+func (c *StratoClient) password() string {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+	return c.auth.password
+}
+
+func (c *StratoClient) setPassword(password string) {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+	c.auth.password = password
+}
+
+// relogin logs in again after the portal rejected the session with the ID expired, unless
+// another copy of the client did so in the meantime
+func (c *StratoClient) relogin(expired string) error {
+	c.auth.login.Lock()
+	defer c.auth.login.Unlock()
+	if c.sessionID() != expired {
+		return nil
+	}
+	logFor(LogAuth).Info("Session expired, logging in again")
+	return c.login()
+}
+
+// retryExpired runs fetch and, if it fails with ErrSessionExpired, logs in again and
+// runs it once more
+func (c *StratoClient) retryExpired(fetch func() error) error {
+	expired := c.sessionID()
+	err := fetch()
+	if !errors.Is(err, ErrSessionExpired) {
+		return err
+	}
+	if err := c.relogin(expired); err != nil {
+		return err
+	}
+	return fetch()
+}
+
+// Ping touches the lightweight entry page to keep the session alive. It returns
+// ErrSessionExpired if the portal redirects to the login page instead.
+func (c *StratoClient) Ping() error {
+	req, err := c.newRequest("GET", c.portalURL("0", c.region.EntryNode), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.session.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusFound:
+		return ErrSessionExpired
+	}
+	return errors.New("unexpected response status: " + resp.Status)
+}
+
+// KeepAlive pings the portal every interval until ctx is cancelled, so long-running
+// processes keep their session between operations instead of logging in again,
+// which counts towards the login rate limits of Strato. If the session expired
+// anyway, it logs in again; other errors of Ping are logged and the next ping is
+// tried. It returns when ctx is done.
+func (c *StratoClient) KeepAlive(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("keep-alive interval must be positive")
+	}
+	c = c.WithContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		expired := c.sessionID()
+		err := c.Ping()
+		switch {
+		case err == nil:
+			logFor(LogAuth).Log(ctx, LevelTrace, "Session kept alive")
+		case errors.Is(err, ErrSessionExpired):
+			if err := c.relogin(expired); err != nil {
+				logFor(LogAuth).Error("Failed to log in again after the session expired", "error", err)
+			}
+		case ctx.Err() == nil:
+			logFor(LogAuth).Warn("Failed to keep session alive", "error", err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package strato
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// loginCount returns the number of logins the portal has seen
+func (p *testPortal) loginCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.logins)
+}
+
+func TestReadAfterSessionExpired(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal)
+	// Copies of the client share the session
+	client.ForDomain("example.net").setSessionID("EXPIRED")
+	if _, err := client.GetDNSConfiguration(); err != nil {
+		t.Fatal(err)
+	}
+	if got := portal.loginCount(); got != 2 {
+		t.Errorf("got %d logins, want a second one for the expired session", got)
+	}
+	if got := client.ForDomain("example.net").sessionID(); got != testSessionID {
+		t.Errorf("copy of the client has session %q, want the new one", got)
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal)
+	// Pings fail while the entry page is missing
+	portal.mu.Lock()
+	delete(portal.pages, RegionDE.EntryNode)
+	portal.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- client.KeepAlive(ctx, 5*time.Millisecond) }()
+	time.Sleep(30 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("keep-alive stopped after a failed ping: %v", err)
+	default:
+	}
+
+	portal.setPage(RegionDE.EntryNode, "entry.html")
+	client.setSessionID("EXPIRED")
+	for deadline := time.Now().Add(5 * time.Second); portal.loginCount() < 2; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("keep-alive did not log in again after the session expired")
+		}
+	}
+	if got := client.sessionID(); got != testSessionID {
+		t.Errorf("got session %q after the login, want %q", got, testSessionID)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,60 @@
+package strato
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keychainStore keeps secrets in the macOS login keychain using the security utility
+type keychainStore struct{}
+
+func newKeyringStore() (CredentialStore, error) {
+	if _, err := exec.LookPath("security"); err != nil {
+		return nil, errors.New("security utility not found")
+	}
+	return keychainStore{}, nil
+}
+
+func (keychainStore) Get(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", CredentialService, "-a", account, "-w").Output()
+	if err != nil {
+		return "", ErrCredentialNotFound
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (keychainStore) Set(account, secret string) error {
+	if strings.ContainsAny(account, "\r\n") {
+		return errors.New("account name must not contain line breaks")
+	}
+	// In interactive mode security reads the command from stdin, so the secret does not
+	// show up in the process list. -X takes it hex encoded, which needs no quoting, and
+	// -U updates an existing item instead of failing.
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		keychainQuote(CredentialService), keychainQuote(account), hex.EncodeToString([]byte(secret))))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return err
+	}
+	// Failed commands do not change the exit status of the interactive mode
+	if message := strings.TrimSpace(string(out)); strings.Contains(message, "error") {
+		return errors.New(message)
+	}
+	return nil
+}
+
+// keychainQuote quotes s as an argument of the interactive mode of security
+func keychainQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (keychainStore) Delete(account string) error {
+	if err := exec.Command("security", "delete-generic-password", "-s", CredentialService, "-a", account).Run(); err != nil {
+		return ErrCredentialNotFound
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package strato
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// keyctlStore keeps secrets in the user keyring using the keyctl utility
+type keyctlStore struct{}
+
+func newKeyringStore() (CredentialStore, error) {
+	if _, err := exec.LookPath("keyctl"); err != nil {
+		return nil, errors.New("keyctl not found, install keyutils to use the keyring")
+	}
+	return keyctlStore{}, nil
+}
+
+func (keyctlStore) description(account string) string {
+	return CredentialService + ":" + account
+}
+
+func (s keyctlStore) search(account string) (string, error) {
+	out, err := exec.Command("keyctl", "search", "@u", "user", s.description(account)).Output()
+	if err != nil {
+		return "", ErrCredentialNotFound
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (s keyctlStore) Get(account string) (string, error) {
+	id, err := s.search(account)
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.Command("keyctl", "pipe", id).Output()
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (s keyctlStore) Set(account, secret string) error {
+	// padd reads the secret from stdin so it does not show up in the process list
+	cmd := exec.Command("keyctl", "padd", "user", s.description(account), "@u")
+	cmd.Stdin = bytes.NewBufferString(secret)
+	return cmd.Run()
+}
+
+func (s keyctlStore) Delete(account string) error {
+	id, err := s.search(account)
+	if err != nil {
+		return err
+	}
+	return exec.Command("keyctl", "unlink", id, "@u").Run()
+}
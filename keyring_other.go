@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+
+package strato
+
+import "errors"
+
+func newKeyringStore() (CredentialStore, error) {
+	return nil, errors.New("no keyring support on this platform")
+}
@@ -0,0 +1,238 @@
+// Package lego is a DNS-01 challenge provider in the shape lego expects from its
+// providers, so lego and Traefik can solve challenges for domains at Strato. It
+// follows the conventions of the providers shipped with lego: the provider name is
+// strato and it is configured through the environment variables
+//
+//	STRATO_IDENTIFIER          Strato identifier (customer number or email)
+//	STRATO_PASSWORD            Strato password
+//	STRATO_ORDER               package order number (optional, found from the domain)
+//	STRATO_REGION              portal variant: de, nl, se or uk (default de)
+//	STRATO_DOMAIN              domain whose zone holds all challenge records
+//	                           (default: the domain of each challenge)
+//	STRATO_PROPAGATION_TIMEOUT how long lego waits for the records (default 10m)
+//	STRATO_POLLING_INTERVAL    how often lego checks the records (default 15s)
+//
+// Each variable may instead be given as a file with the _FILE suffix, e.g.
+// STRATO_PASSWORD_FILE, as Traefik does for secrets. With a lego or Traefik build
+// that lists the provider, the dnsChallenge configuration is just provider: strato.
+package lego
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// Name is the name the provider is registered under in lego and Traefik
+const Name = "strato"
+
+// Environment variables read by NewDNSProviderFromEnv
+const (
+	EnvIdentifier         = "STRATO_IDENTIFIER"
+	EnvPassword           = "STRATO_PASSWORD"
+	EnvOrder              = "STRATO_ORDER"
+	EnvRegion             = "STRATO_REGION"
+	EnvDomain             = "STRATO_DOMAIN"
+	EnvPropagationTimeout = "STRATO_PROPAGATION_TIMEOUT"
+	EnvPollingInterval    = "STRATO_POLLING_INTERVAL"
+)
+
+// Config holds the settings of DNSProvider
+type Config struct {
+	Identifier string
+	Password   string
+	Order      string
+	Region     strato.Region
+	// Domain is the zone all challenge records are written to. If empty, each record
+	// goes to the domain of its challenge, which must be a (sub-)domain at Strato.
+	Domain             string
+	PropagationTimeout time.Duration
+	PollingInterval    time.Duration
+}
+
+// NewDefaultConfig returns a Config with the defaults of the environment variables
+func NewDefaultConfig() *Config {
+	return &Config{
+		Region:             strato.RegionDE,
+		PropagationTimeout: 10 * time.Minute,
+		PollingInterval:    15 * time.Second,
+	}
+}
+
+// DNSProvider implements the challenge.Provider and challenge.ProviderTimeout
+// interfaces of lego
+type DNSProvider struct {
+	config *Config
+
+	mu      sync.Mutex
+	clients map[string]*strato.StratoClient
+}
+
+// NewDNSProviderFromEnv creates a provider configured by the STRATO_* environment
+// variables
+func NewDNSProviderFromEnv() (*DNSProvider, error) {
+	config := NewDefaultConfig()
+	var err error
+	if config.Identifier, err = env(EnvIdentifier); err != nil {
+		return nil, err
+	}
+	if config.Password, err = env(EnvPassword); err != nil {
+		return nil, err
+	}
+	if config.Order, err = env(EnvOrder); err != nil {
+		return nil, err
+	}
+	if config.Domain, err = env(EnvDomain); err != nil {
+		return nil, err
+	}
+	regionName, err := env(EnvRegion)
+	if err != nil {
+		return nil, err
+	}
+	if regionName != "" {
+		if config.Region, err = strato.RegionByName(regionName); err != nil {
+			return nil, fmt.Errorf("strato: %s: %w", EnvRegion, err)
+		}
+	}
+	for variable, target := range map[string]*time.Duration{
+		EnvPropagationTimeout: &config.PropagationTimeout,
+		EnvPollingInterval:    &config.PollingInterval,
+	} {
+		value, err := env(variable)
+		if err != nil {
+			return nil, err
+		}
+		if value == "" {
+			continue
+		}
+		if *target, err = parseDuration(value); err != nil {
+			return nil, fmt.Errorf("strato: %s: %w", variable, err)
+		}
+	}
+	return NewDNSProviderConfig(config)
+}
+
+// NewDNSProvider is NewDNSProviderFromEnv under the name the provider registry of
+// lego calls
+func NewDNSProvider() (*DNSProvider, error) {
+	return NewDNSProviderFromEnv()
+}
+
+// NewDNSProviderConfig creates a provider with config
+func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
+	if config == nil {
+		return nil, errors.New("strato: the configuration of the DNS provider is nil")
+	}
+	if config.Identifier == "" || config.Password == "" {
+		return nil, fmt.Errorf("strato: %s and %s are required", EnvIdentifier, EnvPassword)
+	}
+	return &DNSProvider{config: config, clients: map[string]*strato.StratoClient{}}, nil
+}
+
+// Timeout returns how long and how often lego checks whether the records propagated
+func (d *DNSProvider) Timeout() (timeout, interval time.Duration) {
+	return d.config.PropagationTimeout, d.config.PollingInterval
+}
+
+// Present publishes the TXT record answering the challenge for domain
+func (d *DNSProvider) Present(domain, token, keyAuth string) error {
+	return d.change(domain, keyAuth, func(zone *strato.Zone, record strato.DNSRecord) bool {
+		return zone.Add(record)
+	})
+}
+
+// CleanUp removes the TXT record of the challenge for domain
+func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
+	return d.change(domain, keyAuth, func(zone *strato.Zone, record strato.DNSRecord) bool {
+		return zone.Remove(record)
+	})
+}
+
+// change applies modify to the challenge record of domain and saves the zone if
+// it changed
+func (d *DNSProvider) change(domain, keyAuth string, modify func(*strato.Zone, strato.DNSRecord) bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	zoneName, record, err := d.challengeRecord(domain, keyAuth)
+	if err != nil {
+		return err
+	}
+	client, err := d.client(zoneName)
+	if err != nil {
+		return fmt.Errorf("strato: %w", err)
+	}
+	err = client.UpdateZone(func(zone *strato.Zone) (bool, error) {
+		return modify(zone, record), nil
+	})
+	if err != nil {
+		return fmt.Errorf("strato: %w", err)
+	}
+	return nil
+}
+
+// challengeRecord returns the zone and the record of the challenge for domain, with
+// the value lego's dns01.GetChallengeInfo computes
+func (d *DNSProvider) challengeRecord(domain, keyAuth string) (string, strato.DNSRecord, error) {
+	domain = strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(domain), "*."), ".")
+	digest := sha256.Sum256([]byte(keyAuth))
+	record := strato.DNSRecord{
+		Type:   "TXT",
+		Prefix: strato.ACMEChallengePrefix,
+		Value:  base64.RawURLEncoding.EncodeToString(digest[:]),
+	}
+	zone := strings.TrimSuffix(strings.ToLower(d.config.Domain), ".")
+	switch {
+	case zone == "" || zone == domain:
+		return domain, record, nil
+	case strings.HasSuffix(domain, "."+zone):
+		record.Prefix += "." + strings.TrimSuffix(domain, "."+zone)
+		return zone, record, nil
+	}
+	return "", strato.DNSRecord{}, fmt.Errorf("strato: %s is not in the zone of %s", domain, d.config.Domain)
+}
+
+// client returns the logged in client of zone, creating it on first use
+func (d *DNSProvider) client(zone string) (*strato.StratoClient, error) {
+	if client, ok := d.clients[zone]; ok {
+		return client, nil
+	}
+	client, err := strato.NewStratoClient("", d.config.Identifier, d.config.Password, d.config.Order, zone, strato.WithRegion(d.config.Region))
+	if err != nil {
+		return nil, err
+	}
+	d.clients[zone] = client
+	return client, nil
+}
+
+// env returns the value of the environment variable name, or the content of the file
+// named by name_FILE
+func env(name string) (string, error) {
+	if value := os.Getenv(name); value != "" {
+		return value, nil
+	}
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("strato: %s_FILE: %w", name, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// parseDuration accepts Go durations and plain seconds, as lego does
+func parseDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(value)
+}
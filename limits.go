@@ -0,0 +1,77 @@
+package strato
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultRequestTimeout bounds every request to the portal, including reading the body
+	DefaultRequestTimeout = 60 * time.Second
+	// DefaultMaxResponseSize is the largest response body read from the portal
+	DefaultMaxResponseSize = 16 << 20
+)
+
+// WithContext returns a client sharing the session of c whose requests are bound
+// to ctx, so that cancelling ctx aborts them
+func (c *StratoClient) WithContext(ctx context.Context) *StratoClient {
+	clone := *c
+	clone.ctx = ctx
+	return &clone
+}
+
+// requestContext returns the context requests of the client are bound to
+func (c *StratoClient) requestContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// newRequest creates a request bound to the context of the client
+func (c *StratoClient) newRequest(method, url string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(c.requestContext(), method, url, body)
+}
+
+// limitedTransport fails reading response bodies larger than limit bytes
+type limitedTransport struct {
+	next  http.RoundTripper
+	limit int64
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > t.limit {
+		resp.Body.Close()
+		return nil, ErrResponseTooLarge
+	}
+	resp.Body = &limitedBody{body: resp.Body, remaining: t.limit}
+	return resp, nil
+}
+
+// limitedBody returns ErrResponseTooLarge once more than remaining bytes were read
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrResponseTooLarge
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
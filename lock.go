@@ -0,0 +1,362 @@
+package strato
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Locker serializes writes of several processes or replicas, see WithWriteLock
+type Locker interface {
+	// Lock blocks until the lock is held or ctx is done and returns the function releasing it
+	Lock(ctx context.Context) (unlock func() error, err error)
+}
+
+// lockPollInterval is how often a held lock is checked again
+const lockPollInterval = 2 * time.Second
+
+// lockWrites acquires the write lock of the client, if it has one, and returns the
+// function releasing it
+func (c *StratoClient) lockWrites() (func() error, error) {
+	if c.writeLock == nil {
+		return func() error { return nil }, nil
+	}
+	unlock, err := c.writeLock.Lock(c.requestContext())
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire write lock: %w", err)
+	}
+	return func() error {
+		if err := unlock(); err != nil {
+			return fmt.Errorf("failed to release write lock: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// FileLock is a Locker for processes on the same host, based on flock
+type FileLock struct {
+	Path string
+}
+
+func (l FileLock) Lock(ctx context.Context) (func() error, error) {
+	file, err := os.OpenFile(l.Path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if locked {
+			return func() error {
+				unlockFile(file)
+				return file.Close()
+			}, nil
+		}
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// LeaseLock is a Locker for replicas in a Kubernetes cluster, based on a
+// coordination.k8s.io/v1 Lease. The service account needs get, create and update
+// on leases. A holder that dies keeps the lease for Duration at most. Holders in the
+// same process share the identity, so Lock hands the lease to one of them at a time.
+type LeaseLock struct {
+	Name      string
+	Namespace string
+	// Identity tells replicas apart, e.g. the pod name
+	Identity string
+	Duration time.Duration
+	// Server is the URL of the API server and Token the bearer token used for it
+	Server string
+	Token  string
+	Client *http.Client
+
+	// held has room for the one holder of the lease in this process
+	once sync.Once
+	held chan struct{}
+}
+
+// serviceAccountDir holds the credentials Kubernetes mounts into pods
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// NewInClusterLeaseLock returns a LeaseLock using the service account of the pod,
+// in the namespace of the pod
+func NewInClusterLeaseLock(name, identity string, duration time.Duration) (*LeaseLock, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a Kubernetes cluster")
+	}
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := os.ReadFile(serviceAccountDir + "/namespace")
+	if err != nil {
+		return nil, err
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("failed to parse cluster CA certificate")
+	}
+	return &LeaseLock{
+		Name:      name,
+		Namespace: strings.TrimSpace(string(namespace)),
+		Identity:  identity,
+		Duration:  duration,
+		Server:    "https://" + net.JoinHostPort(host, port),
+		Token:     strings.TrimSpace(string(token)),
+		Client: &http.Client{
+			Timeout:   DefaultRequestTimeout,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+	}, nil
+}
+
+// lease is the part of a Lease object the lock works with
+type lease struct {
+	APIVersion string                 `json:"apiVersion"`
+	Kind       string                 `json:"kind"`
+	Metadata   map[string]interface{} `json:"metadata"`
+	Spec       leaseSpec              `json:"spec"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       *string    `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int        `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          *microTime `json:"acquireTime,omitempty"`
+	RenewTime            *microTime `json:"renewTime,omitempty"`
+}
+
+// microTime is the timestamp format of Lease objects
+type microTime struct {
+	time.Time
+}
+
+func (t microTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.UTC().Format("2006-01-02T15:04:05.000000Z07:00"))
+}
+
+// errLeaseConflict means another replica changed the lease in the meantime
+var errLeaseConflict = errors.New("lease was changed concurrently")
+
+// errLeaseLost means another replica took over the lease while it was held
+var errLeaseLost = errors.New("lease is held by another replica")
+
+func (l *LeaseLock) Lock(ctx context.Context) (func() error, error) {
+	l.once.Do(func() { l.held = make(chan struct{}, 1) })
+	select {
+	case l.held <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	for {
+		acquired, err := l.tryAcquire(ctx)
+		if err != nil && !errors.Is(err, errLeaseConflict) {
+			<-l.held
+			return nil, err
+		}
+		if acquired {
+			unlock := l.hold()
+			return func() error {
+				defer func() { <-l.held }()
+				return unlock()
+			}, nil
+		}
+		select {
+		case <-ctx.Done():
+			<-l.held
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// tryAcquire takes the lease if it is free, expired or missing. A lease held by the
+// identity of this process is left over from a holder that died, as Lock lets only
+// one holder of the process at a time get here.
+func (l *LeaseLock) tryAcquire(ctx context.Context) (bool, error) {
+	current, found, err := l.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	now := microTime{time.Now()}
+	if !found {
+		current = lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   map[string]interface{}{"name": l.Name, "namespace": l.Namespace},
+		}
+	} else if holder := current.Spec.HolderIdentity; holder != nil && *holder != "" && *holder != l.Identity {
+		expiry := time.Duration(current.Spec.LeaseDurationSeconds) * time.Second
+		if current.Spec.RenewTime != nil && time.Since(current.Spec.RenewTime.Time) < expiry {
+			logFor(LogForm).Debug("Lease held by another replica", "lease", l.Name, "holder", *holder)
+			return false, nil
+		}
+	}
+	current.Spec.HolderIdentity = &l.Identity
+	current.Spec.LeaseDurationSeconds = int(l.Duration.Seconds())
+	current.Spec.AcquireTime = &now
+	current.Spec.RenewTime = &now
+	method, url := http.MethodPut, l.collectionURL()+"/"+l.Name
+	if !found {
+		method, url = http.MethodPost, l.collectionURL()
+	}
+	if err := l.send(ctx, method, url, current); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// hold renews the lease every third of its duration until the returned function is
+// called. If the lease could not be renewed before it expired, another replica may
+// have written at the same time; the function then reports that instead of
+// releasing the lease.
+func (l *LeaseLock) hold() func() error {
+	interval := l.Duration / 3
+	if interval <= 0 {
+		interval = lockPollInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	lost := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		renewed := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := l.renew(ctx)
+			switch {
+			case err == nil:
+				renewed = time.Now()
+				continue
+			case ctx.Err() != nil:
+				return
+			case errors.Is(err, errLeaseLost) || time.Since(renewed) >= l.Duration:
+				logFor(LogForm).Error("Lost lease", "lease", l.Name, "error", err)
+				lost <- err
+				return
+			}
+			logFor(LogForm).Warn("Failed to renew lease", "lease", l.Name, "error", err)
+		}
+	}()
+	return func() error {
+		cancel()
+		<-done
+		select {
+		case err := <-lost:
+			return fmt.Errorf("lost lease %s while holding it: %w", l.Name, err)
+		default:
+		}
+		return l.release(context.Background())
+	}
+}
+
+// renew extends the lease while it is held by this replica
+func (l *LeaseLock) renew(ctx context.Context) error {
+	current, found, err := l.get(ctx)
+	if err != nil {
+		return err
+	}
+	if !found || current.Spec.HolderIdentity == nil || *current.Spec.HolderIdentity != l.Identity {
+		return errLeaseLost
+	}
+	now := microTime{time.Now()}
+	current.Spec.RenewTime = &now
+	return l.send(ctx, http.MethodPut, l.collectionURL()+"/"+l.Name, current)
+}
+
+// release gives the lease up if it is still held by this replica
+func (l *LeaseLock) release(ctx context.Context) error {
+	current, found, err := l.get(ctx)
+	if err != nil || !found {
+		return err
+	}
+	if current.Spec.HolderIdentity == nil || *current.Spec.HolderIdentity != l.Identity {
+		return nil
+	}
+	empty := ""
+	current.Spec.HolderIdentity = &empty
+	return l.send(ctx, http.MethodPut, l.collectionURL()+"/"+l.Name, current)
+}
+
+func (l *LeaseLock) collectionURL() string {
+	return strings.TrimSuffix(l.Server, "/") + "/apis/coordination.k8s.io/v1/namespaces/" + l.Namespace + "/leases"
+}
+
+func (l *LeaseLock) get(ctx context.Context) (lease, bool, error) {
+	var current lease
+	resp, err := l.do(ctx, http.MethodGet, l.collectionURL()+"/"+l.Name, nil)
+	if err != nil {
+		return current, false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return current, false, nil
+	case http.StatusOK:
+		return current, true, json.NewDecoder(resp.Body).Decode(&current)
+	}
+	return current, false, fmt.Errorf("failed to get lease %s: %s", l.Name, resp.Status)
+}
+
+// send writes the lease; resourceVersion in the metadata makes concurrent updates fail
+func (l *LeaseLock) send(ctx context.Context, method, url string, object lease) error {
+	body, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	resp, err := l.do(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusConflict:
+		return errLeaseConflict
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("failed to write lease %s: %s", l.Name, resp.Status)
+	}
+	return nil
+}
+
+func (l *LeaseLock) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+l.Token)
+	req.Header.Set("Content-Type", "application/json")
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,168 @@
+package strato
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testLeaseServer is a Kubernetes API server with a single Lease. Updates with an
+// outdated resourceVersion fail like on a real API server.
+type testLeaseServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	lease   map[string]interface{}
+	version int
+}
+
+func newTestLeaseServer(t *testing.T) *testLeaseServer {
+	s := &testLeaseServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *testLeaseServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Method == http.MethodGet {
+		if s.lease == nil {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(s.lease)
+		return
+	}
+	var lease map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&lease); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	metadata, _ := lease["metadata"].(map[string]interface{})
+	switch {
+	case r.Method == http.MethodPost && s.lease != nil:
+		http.Error(w, "exists", http.StatusConflict)
+		return
+	case r.Method == http.MethodPut && (s.lease == nil || metadata["resourceVersion"] != strconv.Itoa(s.version)):
+		http.Error(w, "conflict", http.StatusConflict)
+		return
+	}
+	s.version++
+	metadata["resourceVersion"] = strconv.Itoa(s.version)
+	s.lease = lease
+}
+
+// holder returns the holder of the lease
+func (s *testLeaseServer) holder() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lease == nil {
+		return ""
+	}
+	holder, _ := s.lease["spec"].(map[string]interface{})["holderIdentity"].(string)
+	return holder
+}
+
+func (s *testLeaseServer) lock(identity string) *LeaseLock {
+	return &LeaseLock{Name: "strato", Namespace: "default", Identity: identity, Duration: time.Minute, Server: s.URL}
+}
+
+// TestLeaseLockInProcess locks a lease from several goroutines of one replica, like
+// sync with --concurrency or parallel server requests
+func TestLeaseLockInProcess(t *testing.T) {
+	server := newTestLeaseServer(t)
+	lock := server.lock("pod-0")
+	var mu sync.Mutex
+	holders, maxHolders := 0, 0
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := lock.Lock(context.Background())
+			if err != nil {
+				errs <- err
+				return
+			}
+			mu.Lock()
+			holders++
+			maxHolders = max(maxHolders, holders)
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			holders--
+			mu.Unlock()
+			errs <- unlock()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if maxHolders != 1 {
+		t.Errorf("lease was held by %d goroutines at once", maxHolders)
+	}
+	if holder := server.holder(); holder != "" {
+		t.Errorf("lease is still held by %q", holder)
+	}
+}
+
+func TestLeaseLockOtherReplica(t *testing.T) {
+	server := newTestLeaseServer(t)
+	unlock, err := server.lock("pod-0").Lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := server.lock("pod-1").Lock(ctx); err != context.DeadlineExceeded {
+		t.Errorf("got %v while another replica holds the lease, want %v", err, context.DeadlineExceeded)
+	}
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err = server.lock("pod-1").Lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if holder := server.holder(); holder != "pod-1" {
+		t.Errorf("got holder %q, want pod-1", holder)
+	}
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLeaseLockCanceledWhileWaiting(t *testing.T) {
+	server := newTestLeaseServer(t)
+	lock := server.lock("pod-0")
+	unlock, err := lock.Lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := lock.Lock(ctx); err != context.DeadlineExceeded {
+		t.Errorf("got %v while another goroutine holds the lease, want %v", err, context.DeadlineExceeded)
+	}
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+	// The canceled holder gave its turn back
+	unlock, err = lock.Lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,141 @@
+package strato
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Components of the library that log under their own name and level
+const (
+	LogAuth   = "auth"
+	LogScrape = "scrape"
+	LogForm   = "form"
+	LogHTTP   = "http"
+)
+
+// LogComponents lists all components accepted by SetLogLevel
+var LogComponents = []string{LogAuth, LogScrape, LogForm, LogHTTP}
+
+// LevelTrace is below slog.LevelDebug and used for per-request details
+const LevelTrace = slog.LevelDebug - 4
+
+var (
+	logMu     sync.RWMutex
+	logBase   *slog.Logger
+	logLevels = map[string]slog.Level{}
+)
+
+// SetLogger sets the logger the library writes to. Without it, slog.Default() is used.
+func SetLogger(logger *slog.Logger) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	logBase = logger
+}
+
+// SetLogLevel sets the minimum level of records logged by component. Components without
+// a level of their own leave the decision to the handler of the logger.
+func SetLogLevel(component string, level slog.Level) error {
+	if !isLogComponent(component) {
+		return fmt.Errorf("unknown log component: %s", component)
+	}
+	logMu.Lock()
+	defer logMu.Unlock()
+	logLevels[component] = level
+	return nil
+}
+
+// ParseLogLevels applies a comma separated list of component=level pairs,
+// e.g. "auth=debug,http=trace"
+func ParseLogLevels(spec string) error {
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		component, name, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid log level %q: expected component=level", pair)
+		}
+		var level slog.Level
+		if strings.EqualFold(strings.TrimSpace(name), "trace") {
+			level = LevelTrace
+		} else if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+			return fmt.Errorf("invalid log level %q: %w", pair, err)
+		}
+		if err := SetLogLevel(strings.TrimSpace(component), level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isLogComponent(component string) bool {
+	for _, known := range LogComponents {
+		if component == known {
+			return true
+		}
+	}
+	return false
+}
+
+// logFor returns the logger of component
+func logFor(component string) *slog.Logger {
+	logMu.RLock()
+	base := logBase
+	logMu.RUnlock()
+	if base == nil {
+		base = slog.Default()
+	}
+	return slog.New(&componentHandler{next: base.Handler(), component: component}).With("component", component)
+}
+
+// componentHandler applies the level configured for its component before passing
+// records on to the next handler
+type componentHandler struct {
+	next      slog.Handler
+	component string
+}
+
+func (h *componentHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	logMu.RLock()
+	min, ok := logLevels[h.component]
+	logMu.RUnlock()
+	if ok {
+		return level >= min
+	}
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *componentHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.next.Handle(ctx, record)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &componentHandler{next: h.next.WithAttrs(attrs), component: h.component}
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return &componentHandler{next: h.next.WithGroup(name), component: h.component}
+}
+
+// loggingTransport logs every request to the portal with the http component.
+// Query parameters other than node are left out, as they carry the session ID.
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	logger := logFor(LogHTTP)
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		logger.Log(req.Context(), LevelTrace, "Request failed", "method", req.Method, "path", req.URL.Path, "node", req.URL.Query().Get("node"), "error", err)
+		return nil, err
+	}
+	logger.Log(req.Context(), LevelTrace, "Request", "method", req.Method, "path", req.URL.Path, "node", req.URL.Query().Get("node"), "status", resp.StatusCode, "duration", time.Since(start))
+	return resp, nil
+}
@@ -0,0 +1,123 @@
+package strato
+
+import (
+	"errors"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// Mailbox is an email address of the package
+type Mailbox struct {
+	Address string `json:"address"`
+	// Forwards lists the addresses mail is forwarded to
+	Forwards []string `json:"forwards,omitempty"`
+	// Alias is set for forward-only addresses without a mailbox of their own
+	Alias bool `json:"alias,omitempty"`
+}
+
+// ListMailboxes retrieves the email addresses of the domain
+func (c *StratoClient) ListMailboxes() ([]Mailbox, error) {
+	doc, err := c.fetchPage(c.region.MailNode, "vhost="+url.QueryEscape(c.domain))
+	if err != nil {
+		return nil, err
+	}
+	table := htmlquery.FindOne(doc, "//table[@id='jss_mailbox_table']")
+	if table == nil {
+		return nil, errors.New("failed to find mailbox table")
+	}
+	var mailboxes []Mailbox
+	for _, row := range htmlquery.Find(table, ".//tr[@data-mailbox]") {
+		mailbox := Mailbox{
+			Address: htmlquery.SelectAttr(row, "data-mailbox"),
+			Alias:   htmlquery.SelectAttr(row, "data-mailbox-type") == "alias",
+		}
+		for _, forward := range strings.Split(textOf(row, ".//td[contains(@class, 'forward')]"), ",") {
+			if forward = strings.TrimSpace(forward); forward != "" {
+				mailbox.Forwards = append(mailbox.Forwards, forward)
+			}
+		}
+		mailboxes = append(mailboxes, mailbox)
+	}
+	return mailboxes, nil
+}
+
+// ListAliases retrieves the forward-only addresses of the domain
+func (c *StratoClient) ListAliases() ([]Mailbox, error) {
+	mailboxes, err := c.ListMailboxes()
+	if err != nil {
+		return nil, err
+	}
+	var aliases []Mailbox
+	for _, mailbox := range mailboxes {
+		if mailbox.Alias {
+			aliases = append(aliases, mailbox)
+		}
+	}
+	return aliases, nil
+}
+
+// CreateMailbox creates a new email address with the given password
+func (c *StratoClient) CreateMailbox(address, password string) error {
+	if err := c.checkAddress(address); err != nil {
+		return err
+	}
+	if password == "" {
+		return errors.New("mailbox password must not be empty")
+	}
+	form := url.Values{}
+	form.Set("vhost", c.domain)
+	form.Set("mailbox", address)
+	form.Set("passwd", password)
+	form.Set("passwd_repeat", password)
+	form.Set("action_create_mailbox", "1")
+	return c.submitForm(c.region.MailNode, form)
+}
+
+// DeleteMailbox deletes an email address including all stored mail
+func (c *StratoClient) DeleteMailbox(address string) error {
+	if err := c.checkAddress(address); err != nil {
+		return err
+	}
+	form := url.Values{}
+	form.Set("vhost", c.domain)
+	form.Set("mailbox", address)
+	form.Set("action_delete_mailbox", "1")
+	return c.submitForm(c.region.MailNode, form)
+}
+
+// SetForwarding replaces the forwarding targets of an email address. No targets disable forwarding.
+func (c *StratoClient) SetForwarding(address string, targets []string) error {
+	if err := c.checkAddress(address); err != nil {
+		return err
+	}
+	form := url.Values{}
+	form.Set("vhost", c.domain)
+	form.Set("mailbox", address)
+	for _, target := range targets {
+		parsed, err := mail.ParseAddress(target)
+		if err != nil || parsed.Address != target {
+			return errors.New("invalid forwarding target: " + target)
+		}
+		if strings.EqualFold(target, address) {
+			return errors.New("cannot forward " + address + " to itself")
+		}
+		form.Add("forward", target)
+	}
+	form.Set("action_change_forwarding", "1")
+	return c.submitForm(c.region.MailNode, form)
+}
+
+// checkAddress makes sure address is an email address of the managed domain
+func (c *StratoClient) checkAddress(address string) error {
+	localPart, domain, found := strings.Cut(address, "@")
+	if !found || localPart == "" {
+		return errors.New("invalid email address: " + address)
+	}
+	if !strings.EqualFold(domain, c.domain) {
+		return errors.New("email address " + address + " does not belong to " + c.domain)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package strato
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListMailboxes(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	portal.setPage(RegionDE.MailNode, "mailboxes.html")
+	client := newTestClient(t, portal)
+
+	mailboxes, err := client.ListMailboxes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Mailbox{
+		{Address: "info@example.com"},
+		{Address: "office@example.com", Forwards: []string{"alice@example.net", "bob@example.net"}},
+		{Address: "sales@example.com", Forwards: []string{"office@example.com"}, Alias: true},
+	}
+	if !reflect.DeepEqual(mailboxes, want) {
+		t.Errorf("got %+v, want %+v", mailboxes, want)
+	}
+	if got := portal.lastRead().Get("vhost"); got != "example.com" {
+		t.Errorf("got vhost %q, want example.com", got)
+	}
+
+	aliases, err := client.ListAliases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(aliases, want[2:]) {
+		t.Errorf("got aliases %+v, want %+v", aliases, want[2:])
+	}
+}
+
+// TestMailboxForms makes sure every mail form names the mailbox by its full address,
+// like the mailbox list does
+func TestMailboxForms(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal)
+	const address = "office@example.com"
+
+	steps := []struct {
+		action string
+		run    func() error
+	}{
+		{"action_create_mailbox", func() error { return client.CreateMailbox(address, "mail-secret") }},
+		{"action_change_forwarding", func() error { return client.SetForwarding(address, []string{"alice@example.net"}) }},
+		{"action_delete_mailbox", func() error { return client.DeleteMailbox(address) }},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.action, err)
+		}
+		form := portal.lastForm()
+		if !form.Has(step.action) {
+			t.Errorf("got form %v, want %s", form, step.action)
+		}
+		if form.Get("mailbox") != address || form.Get("vhost") != "example.com" {
+			t.Errorf("%s: got mailbox %q of %q, want %s of example.com", step.action, form.Get("mailbox"), form.Get("vhost"), address)
+		}
+	}
+
+	invalid := []func() error{
+		func() error { return client.CreateMailbox("office@example.net", "mail-secret") },
+		func() error { return client.CreateMailbox(address, "") },
+		func() error { return client.SetForwarding(address, []string{address}) },
+		func() error { return client.SetForwarding(address, []string{"not an address"}) },
+		func() error { return client.DeleteMailbox("office") },
+	}
+	for i, run := range invalid {
+		if err := run(); err == nil {
+			t.Errorf("invalid call %d succeeded", i)
+		}
+	}
+	if len(portal.forms) != 3 {
+		t.Errorf("got %d forms, invalid calls submitted some", len(portal.forms))
+	}
+}
@@ -0,0 +1,82 @@
+package strato
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// MailMode selects who receives mail for a domain
+type MailMode string
+
+const (
+	// MailModeStrato delivers mail to Strato mailboxes
+	MailModeStrato MailMode = "strato"
+	// MailModeExternal delivers mail to external MX hosts
+	MailModeExternal MailMode = "external"
+	// MailModeNone announces that the domain does not receive or send mail
+	MailModeNone MailMode = "none"
+)
+
+// Values of the spf_type and dmarc_type radio buttons of the TXT record form
+const (
+	SPFTypeStrato   = "strato"
+	SPFTypeNone     = "none"
+	DMARCTypeStrato = "strato"
+	DMARCTypeNone   = "none"
+)
+
+// nullMX is the MX value of domains that accept no mail (RFC 7505)
+const nullMX = "0 ."
+
+// nullSPF is the SPF record of domains that send no mail
+const nullSPF = "v=spf1 -all"
+
+// ApplyMailMode returns a copy of config with the MX records and SPF setting of the given mode.
+// For MailModeExternal, mxHosts lists the mail servers in order of preference.
+func ApplyMailMode(config DNSConfig, mode MailMode, mxHosts []string) (DNSConfig, error) {
+	records := []DNSRecord{}
+	for _, record := range config.Records {
+		// MX records of the root domain are replaced, everything else is kept
+		if record.Type == "MX" && record.Prefix == "" {
+			continue
+		}
+		// MailModeNone replaces every SPF record of the root domain, the other modes
+		// only the one MailModeNone added, which would reject all mail
+		if record.Type == "TXT" && record.Prefix == "" && (record.Value == nullSPF || mode == MailModeNone && strings.HasPrefix(record.Value, "v=spf1")) {
+			continue
+		}
+		records = append(records, record)
+	}
+	switch mode {
+	case MailModeStrato:
+		config.SPFType = SPFTypeStrato
+	case MailModeExternal:
+		if len(mxHosts) == 0 {
+			return DNSConfig{}, errors.New("external mail mode needs at least one MX host")
+		}
+		config.SPFType = SPFTypeNone
+		for i, host := range mxHosts {
+			records = append(records, DNSRecord{Type: "MX", Value: strconv.Itoa((i+1)*10) + " " + host})
+		}
+	case MailModeNone:
+		config.SPFType = SPFTypeNone
+		records = append(records,
+			DNSRecord{Type: "MX", Value: nullMX},
+			DNSRecord{Type: "TXT", Value: nullSPF},
+		)
+	default:
+		return DNSConfig{}, errors.New("unknown mail mode: " + string(mode))
+	}
+	config.Records = records
+	return config, nil
+}
+
+// EnsureMailMode switches the domain to the given mail mode. If the MX records and
+// the SPF setting already match the mode, nothing is written and it returns false.
+func (c *StratoClient) EnsureMailMode(mode MailMode, mxHosts []string) (bool, error) {
+	diff, err := c.UpdateDNSConfiguration(func(current DNSConfig) (DNSConfig, error) {
+		return ApplyMailMode(current, mode, mxHosts)
+	})
+	return !diff.Empty(), err
+}
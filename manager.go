@@ -0,0 +1,177 @@
+package strato
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Account holds what is needed to log in to one Strato account
+type Account struct {
+	API        string `json:"api,omitempty"`
+	Identifier string `json:"identifier"`
+	Password   string `json:"password,omitempty"`
+	Order      string `json:"order,omitempty"`
+	// Domain is the domain the client of the account works on by default
+	Domain  string   `json:"domain,omitempty"`
+	Options []Option `json:"-"`
+}
+
+// AccountManager holds the clients of several Strato accounts keyed by profile
+// name. Clients log in on first use and share one rate limit, so agencies can
+// manage many customer accounts without tripping the limits of the portal.
+type AccountManager struct {
+	mu       sync.Mutex
+	accounts map[string]Account
+	clients  map[string]*StratoClient
+	limiter  *rateLimiter
+	// routes maps domains to the profile of the account holding them
+	routes map[string]string
+}
+
+// NewAccountManager returns an empty AccountManager whose clients together send at
+// most one request per interval (0 for no limit)
+func NewAccountManager(interval time.Duration) *AccountManager {
+	m := &AccountManager{
+		accounts: map[string]Account{},
+		clients:  map[string]*StratoClient{},
+	}
+	if interval > 0 {
+		m.limiter = &rateLimiter{interval: interval}
+	}
+	return m
+}
+
+// Add registers an account under profile, replacing an earlier one of the same name
+func (m *AccountManager) Add(profile string, account Account) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accounts[profile] = account
+	delete(m.clients, profile)
+	m.routes = nil
+}
+
+// LoadProfiles adds the accounts of the JSON file at path, keyed by profile name:
+//
+//	{"customer-a": {"identifier": "12345678", "domain": "example.com"}}
+//
+// The password may be left out if the caller gets it elsewhere, e.g. from a
+// credential store.
+func (m *AccountManager) LoadProfiles(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var accounts map[string]Account
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return fmt.Errorf("invalid profiles file %s: %w", path, err)
+	}
+	for profile, account := range accounts {
+		if account.Identifier == "" {
+			return fmt.Errorf("invalid profiles file %s: profile %s has no identifier", path, profile)
+		}
+		m.Add(profile, account)
+	}
+	return nil
+}
+
+// Account returns the account registered under profile
+func (m *AccountManager) Account(profile string) (Account, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	account, ok := m.accounts[profile]
+	return account, ok
+}
+
+// Profiles returns the names of all registered accounts in alphabetical order
+func (m *AccountManager) Profiles() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	profiles := make([]string, 0, len(m.accounts))
+	for profile := range m.accounts {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// Client returns the client of profile, logging in on first use
+func (m *AccountManager) Client(profile string) (*StratoClient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if client, ok := m.clients[profile]; ok {
+		return client, nil
+	}
+	account, ok := m.accounts[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown account profile: %s", profile)
+	}
+	opts := account.Options
+	if m.limiter != nil {
+		opts = append(opts[:len(opts):len(opts)], withRateLimiter(m.limiter))
+	}
+	client, err := NewStratoClient(account.API, account.Identifier, account.Password, account.Order, account.Domain, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", profile, err)
+	}
+	m.clients[profile] = client
+	return client, nil
+}
+
+// ListAllZones returns the domains of every account keyed by profile. Accounts
+// that cannot be read are left out and reported in the returned error.
+func (m *AccountManager) ListAllZones() (map[string][]string, error) {
+	zones := map[string][]string{}
+	routes := map[string]string{}
+	var errs []error
+	for _, profile := range m.Profiles() {
+		client, err := m.Client(profile)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		info, err := client.GetPackageInfo()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", profile, err))
+			continue
+		}
+		zones[profile] = info.Domains
+		for _, domain := range info.Domains {
+			routes[domain] = profile
+		}
+	}
+	m.mu.Lock()
+	m.routes = routes
+	m.mu.Unlock()
+	return zones, errors.Join(errs...)
+}
+
+// Route returns a client for domain from the account holding it. The domains of all
+// accounts are listed with ListAllZones on first use.
+func (m *AccountManager) Route(domain string) (*StratoClient, error) {
+	m.mu.Lock()
+	routes := m.routes
+	m.mu.Unlock()
+	var listErr error
+	if routes == nil {
+		_, listErr = m.ListAllZones()
+		m.mu.Lock()
+		routes = m.routes
+		m.mu.Unlock()
+	}
+	profile, ok := routes[domain]
+	if !ok && listErr != nil {
+		return nil, fmt.Errorf("no readable account holds %s: %w", domain, listErr)
+	} else if !ok {
+		return nil, fmt.Errorf("no account holds %s", domain)
+	}
+	client, err := m.Client(profile)
+	if err != nil {
+		return nil, err
+	}
+	return client.ForDomain(domain), nil
+}
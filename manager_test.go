@@ -0,0 +1,41 @@
+package strato
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	text := `{
+		"customer-b": {"identifier": "87654321", "domain": "example.net", "order": "ORDER"},
+		"customer-a": {"identifier": "12345678", "password": "secret", "domain": "example.com"}
+	}`
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	manager := NewAccountManager(0)
+	if err := manager.LoadProfiles(path); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := manager.Profiles(), []string{"customer-a", "customer-b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got profiles %v, want %v", got, want)
+	}
+	account, ok := manager.Account("customer-b")
+	if want := (Account{Identifier: "87654321", Domain: "example.net", Order: "ORDER"}); !ok || !reflect.DeepEqual(account, want) {
+		t.Errorf("got account %+v, want %+v", account, want)
+	}
+	if _, ok := manager.Account("customer-c"); ok {
+		t.Error("got an account for an unknown profile")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"customer-c": {"domain": "example.org"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.LoadProfiles(path); err == nil || !strings.Contains(err.Error(), "no identifier") {
+		t.Errorf("got %v for a profile without identifier, want an error", err)
+	}
+}
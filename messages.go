@@ -0,0 +1,156 @@
+package strato
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Language is the language of the portal pages. It follows the region by default,
+// but accounts can switch it, e.g. to English on strato.de.
+type Language string
+
+const (
+	LanguageGerman  Language = "de"
+	LanguageEnglish Language = "en"
+	LanguageDutch   Language = "nl"
+	LanguageSwedish Language = "sv"
+)
+
+// messages holds the texts of one language the client looks for on portal pages
+type messages struct {
+	// applyLabel is the caption of the submit button of the TXT record form
+	applyLabel string
+	// locked and expired are fragments of the login error banners
+	locked, expired []string
+	// quota matches the banner about the record limit, the first group is the limit
+	quota *regexp.Regexp
+}
+
+// catalog holds the messages of every language the portal is available in
+var catalog = map[Language]messages{
+	LanguageGerman: {
+		applyLabel: "Einstellung übernehmen",
+		locked:     []string{"gesperrt"},
+		expired:    []string{"abgelaufen"},
+		quota:      regexp.MustCompile(`(?i)(?:maximal|höchstens) (\d+) (?:Einträge|Records|Datensätze)`),
+	},
+	LanguageEnglish: {
+		applyLabel: "Apply setting",
+		locked:     []string{"locked"},
+		expired:    []string{"expired"},
+		quota:      regexp.MustCompile(`(?i)(?:maximum of|up to|at most) (\d+) (?:entries|records)`),
+	},
+	LanguageDutch: {
+		applyLabel: "Instelling overnemen",
+		locked:     []string{"geblokkeerd"},
+		expired:    []string{"verlopen"},
+		quota:      regexp.MustCompile(`(?i)(?:maximaal|hoogstens) (\d+) (?:vermeldingen|records)`),
+	},
+	LanguageSwedish: {
+		applyLabel: "Spara inställningar",
+		locked:     []string{"spärrat"},
+		expired:    []string{"gått ut"},
+		quota:      regexp.MustCompile(`(?i)(?:högst|maximalt) (\d+) (?:poster|records)`),
+	},
+}
+
+// languageOrder is the order the catalogs are tried in after the detected language
+var languageOrder = []Language{LanguageGerman, LanguageEnglish, LanguageDutch, LanguageSwedish}
+
+// LanguageByName returns the portal language with the given code (e.g. "en", "nl")
+func LanguageByName(name string) (Language, error) {
+	for _, language := range languageOrder {
+		if strings.EqualFold(string(language), name) {
+			return language, nil
+		}
+	}
+	return "", errors.New("unknown language: " + name)
+}
+
+// catalogsFor returns the messages of lang first, then those of the other languages,
+// so texts are recognized even if the language was detected wrongly
+func catalogsFor(lang Language) []messages {
+	catalogs := make([]messages, 0, len(languageOrder))
+	if first, ok := catalog[lang]; ok {
+		catalogs = append(catalogs, first)
+	}
+	for _, other := range languageOrder {
+		if other != lang {
+			catalogs = append(catalogs, catalog[other])
+		}
+	}
+	return catalogs
+}
+
+// pageLanguage returns the language declared by the html element of a page, e.g.
+// "en" for lang="en-GB", or "" if it declares none
+func pageLanguage(doc *html.Node) Language {
+	lang := attrOf(doc, "//html", "lang")
+	lang, _, _ = strings.Cut(strings.ToLower(lang), "-")
+	return Language(lang)
+}
+
+// applyLabel returns the caption of the submit button of the TXT record form in the
+// language set with WithLanguage, or the one of the region
+func (c *StratoClient) applyLabel() string {
+	if m, ok := catalog[c.language]; ok {
+		return m.applyLabel
+	}
+	return c.region.ApplyLabel
+}
+
+// banner returns the text of the message banner of a portal page and whether it
+// confirms a change. Only a success banner does: an error banner is a failure even if
+// it reports that part of the change was saved. Messages inside the TXT record form
+// belong to single records and are left to the form.
+func banner(doc *html.Node) (string, bool) {
+	outside := " and not(ancestor::div[@id='jss_txt_container'])"
+	if message := textOf(doc, "//*[("+errorClasses+") and not(contains(@class, 'success'))"+outside+"]"); message != "" {
+		return message, false
+	}
+	message := textOf(doc, "//*[contains(@class, 'success')"+outside+"]")
+	return message, message != ""
+}
+
+// quotaLimit returns the record limit a banner message reports, 0 if it is about
+// something else
+func quotaLimit(message string, lang Language) int {
+	for _, m := range catalogsFor(lang) {
+		if match := m.quota.FindStringSubmatch(message); match != nil {
+			limit, _ := strconv.Atoi(match[1])
+			return limit
+		}
+	}
+	return 0
+}
+
+// classifyLoginError maps the error message shown on the login page to a specific
+// error, whatever the language of the page
+func classifyLoginError(message string) error {
+	lower := strings.ToLower(message)
+	for _, m := range catalogsFor("") {
+		if containsAny(lower, m.locked) {
+			return ErrAccountLocked
+		}
+	}
+	for _, m := range catalogsFor("") {
+		if containsAny(lower, m.expired) {
+			return ErrPasswordExpired
+		}
+	}
+	return ErrAuthenticationFailed
+}
+
+// containsAny reports whether s contains one of fragments
+func containsAny(s string, fragments []string) bool {
+	for _, fragment := range fragments {
+		if strings.Contains(s, fragment) {
+			return true
+		}
+	}
+	return false
+}
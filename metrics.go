@@ -0,0 +1,130 @@
+package strato
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metrics is the state of a domain in the Prometheus text format, for the textfile
+// collector of node_exporter
+type Metrics struct {
+	Domain string
+	Config DNSConfig
+	// Annotations are used to tell when tracked ACME challenge records expire
+	Annotations map[DNSRecord]Annotation
+	// ChallengeTTL is the age at which PruneChallengeRecords removes challenge records
+	ChallengeTTL time.Duration
+	// Statuses are the sync statuses of a StatusTracker, they may cover other domains
+	Statuses []SyncStatus
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format
+func (m Metrics) WriteTo(w io.Writer) (int64, error) {
+	buffered := bufio.NewWriter(w)
+	out := &countingWriter{w: buffered}
+
+	counts := map[string]int{}
+	for _, record := range m.Config.Records {
+		counts[strings.ToUpper(record.Type)]++
+	}
+	types := make([]string, 0, len(counts))
+	for recordType := range counts {
+		types = append(types, recordType)
+	}
+	sort.Strings(types)
+	out.header("strato_dns_records", "gauge", "Number of DNS records by type")
+	for _, recordType := range types {
+		out.sample("strato_dns_records", float64(counts[recordType]), "domain", m.Domain, "type", recordType)
+	}
+
+	out.header("strato_acme_challenge_expiry_timestamp_seconds", "gauge", "Time at which a tracked ACME challenge record becomes stale and is pruned")
+	for _, record := range m.Config.Records {
+		annotation, ok := m.Annotations[record]
+		if !record.IsACMEChallenge() || !ok || annotation.Created.IsZero() {
+			continue
+		}
+		expiry := annotation.Created.Add(m.ChallengeTTL)
+		out.sample("strato_acme_challenge_expiry_timestamp_seconds", unixSeconds(expiry), "domain", m.Domain, "prefix", record.Prefix, "value", record.Value)
+	}
+
+	if len(m.Statuses) > 0 {
+		out.header("strato_sync_last_success_timestamp_seconds", "gauge", "Time of the last successful sync round, 0 if none succeeded yet")
+		for _, status := range m.Statuses {
+			out.sample("strato_sync_last_success_timestamp_seconds", unixSeconds(status.LastSuccess), "domain", status.Domain)
+		}
+		out.header("strato_sync_last_error_timestamp_seconds", "gauge", "Time of the last failed sync round, 0 if none failed")
+		for _, status := range m.Statuses {
+			out.sample("strato_sync_last_error_timestamp_seconds", unixSeconds(status.LastErrorTime), "domain", status.Domain)
+		}
+		out.header("strato_sync_consecutive_failures", "gauge", "Number of failed sync rounds since the last success")
+		for _, status := range m.Statuses {
+			out.sample("strato_sync_consecutive_failures", float64(status.ConsecutiveFailures), "domain", status.Domain)
+		}
+	}
+	if out.err != nil {
+		return out.n, out.err
+	}
+	return out.n, buffered.Flush()
+}
+
+// WriteTextfile writes the metrics to path for the node_exporter textfile collector.
+// The file is replaced atomically, so the collector never reads a partial file.
+func WriteTextfile(path string, m Metrics) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	if _, err := m.WriteTo(&b); err != nil {
+		return err
+	}
+	// The collector only reads *.prom files, so the temporary file is ignored
+	return WriteFileAtomic(path, b.Bytes(), 0o644)
+}
+
+// labelEscaper escapes label values as the text format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// countingWriter writes metric lines and keeps the first error and the bytes written
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) printf(format string, args ...interface{}) {
+	if c.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(c.w, format, args...)
+	c.n += int64(n)
+	c.err = err
+}
+
+func (c *countingWriter) header(name, kind, help string) {
+	c.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes one sample with labels given as name, value pairs
+func (c *countingWriter) sample(name string, value float64, labels ...string) {
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+`="`+labelEscaper.Replace(labels[i+1])+`"`)
+	}
+	c.printf("%s{%s} %s\n", name, strings.Join(pairs, ","), strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// unixSeconds returns t as Unix time, or 0 for the zero time
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.Unix())
+}
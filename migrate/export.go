@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// Writer is a Provider whose records can also be changed. SetRecords follows the
+// RecordSetter of libdns: records become the only records of their name and type,
+// other names and types are left alone.
+type Writer interface {
+	Provider
+	SetRecords(ctx context.Context, zone string, records []Record) error
+}
+
+// Export pushes the records of config to zone at w, so Strato can stay the source of
+// truth while another provider takes over. Only record sets that differ are written.
+// Names and types without records at Strato are not touched, so records the other
+// provider needs on its own, e.g. A records, survive. It returns the written records.
+func Export(ctx context.Context, config strato.DNSConfig, w Writer, zone string) ([]Record, error) {
+	changed, err := PlanExport(ctx, config, w, zone)
+	if err != nil || len(changed) == 0 {
+		return nil, err
+	}
+	return changed, w.SetRecords(ctx, zone, changed)
+}
+
+// PlanExport returns the records Export would write, without changing anything
+func PlanExport(ctx context.Context, config strato.DNSConfig, p Provider, zone string) ([]Record, error) {
+	existing, err := p.Records(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	current := groupRecords(existing)
+	desired := groupRecords(FromStrato(config.Records))
+	var changed []Record
+	for _, key := range sortedKeys(desired) {
+		if !sameValues(current[key], desired[key]) {
+			changed = append(changed, desired[key]...)
+		}
+	}
+	return changed, nil
+}
+
+// FromStrato converts Strato records into records of other providers
+func FromStrato(records []strato.DNSRecord) []Record {
+	converted := make([]Record, 0, len(records))
+	for _, record := range records {
+		name := strings.ToLower(record.Prefix)
+		if name == "@" {
+			name = ""
+		}
+		converted = append(converted, Record{
+			Name:  name,
+			Type:  strings.ToUpper(record.Type),
+			Value: normalizeValue(record.Type, record.Value),
+		})
+	}
+	return converted
+}
+
+// recordSet identifies the records of one name and type
+type recordSet struct {
+	name, recordType string
+}
+
+func groupRecords(records []Record) map[recordSet][]Record {
+	groups := map[recordSet][]Record{}
+	for _, record := range records {
+		key := recordSet{strings.ToLower(record.Name), strings.ToUpper(record.Type)}
+		groups[key] = append(groups[key], record)
+	}
+	return groups
+}
+
+func sortedKeys(groups map[recordSet][]Record) []recordSet {
+	keys := make([]recordSet, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].recordType < keys[j].recordType
+	})
+	return keys
+}
+
+// sameValues reports whether a and b hold the same values, ignoring order and TTLs
+func sameValues(a, b []Record) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	values := map[string]int{}
+	for _, record := range a {
+		values[strings.ToLower(record.Value)]++
+	}
+	for _, record := range b {
+		value := strings.ToLower(record.Value)
+		if values[value] == 0 {
+			return false
+		}
+		values[value]--
+	}
+	return true
+}
+
+// absoluteValue appends the trailing dot to the host name in values of CNAME, MX and
+// SRV records, as providers with zone file semantics treat names without it as
+// relative to the zone
+func absoluteValue(recordType, value string) string {
+	switch recordType {
+	case "CNAME", "MX", "SRV":
+		if !strings.HasSuffix(value, ".") {
+			return value + "."
+		}
+	}
+	return value
+}
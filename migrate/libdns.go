@@ -0,0 +1,74 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// LibDNS reads and writes zones through a libdns provider, e.g. of
+// github.com/libdns/cloudflare, so every provider libdns supports can be used
+type LibDNS struct {
+	// Name of the provider in error messages
+	Name     string
+	Provider interface {
+		libdns.RecordGetter
+		libdns.RecordSetter
+	}
+}
+
+// Records returns all DNS records of zone
+func (l *LibDNS) Records(ctx context.Context, zone string) ([]Record, error) {
+	listed, err := l.Provider.GetRecords(ctx, absoluteZone(zone))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", l.Name, err)
+	}
+	records := make([]Record, 0, len(listed))
+	for _, record := range listed {
+		rr := record.RR()
+		value := rr.Data
+		// libdns keeps TXT data unquoted, other types are in presentation format
+		if !strings.EqualFold(rr.Type, "TXT") {
+			value = normalizeValue(rr.Type, value)
+		}
+		records = append(records, Record{
+			Name:  relativeName(rr.Name, zone),
+			Type:  strings.ToUpper(rr.Type),
+			Value: value,
+			TTL:   rr.TTL,
+		})
+	}
+	return records, nil
+}
+
+// SetRecords makes records the only records of their name and type in zone
+func (l *LibDNS) SetRecords(ctx context.Context, zone string, records []Record) error {
+	converted := make([]libdns.Record, 0, len(records))
+	for _, record := range records {
+		name := record.Name
+		if name == "" {
+			name = "@"
+		}
+		rr := libdns.RR{Name: name, Type: record.Type, TTL: record.TTL, Data: absoluteValue(record.Type, record.Value)}
+		// Providers expect the typed records of libdns for the types it knows
+		parsed, err := rr.Parse()
+		if err != nil {
+			return fmt.Errorf("%s: %w", record, err)
+		}
+		converted = append(converted, parsed)
+	}
+	if _, err := l.Provider.SetRecords(ctx, absoluteZone(zone), converted); err != nil {
+		return fmt.Errorf("%s: %w", l.Name, err)
+	}
+	return nil
+}
+
+// absoluteZone appends the trailing dot libdns expects of zone names
+func absoluteZone(zone string) string {
+	if strings.HasSuffix(zone, ".") {
+		return zone
+	}
+	return zone + "."
+}
@@ -0,0 +1,153 @@
+// Package migrate moves DNS records between Strato and other DNS providers. Records
+// are read through libdns providers and converted to the subset the Strato TXT
+// record form can hold; whatever cannot be represented is reported. The providers
+// themselves are up to the caller, the strato command builds in a few.
+package migrate
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// Record is a record of another provider in zone file notation
+type Record struct {
+	// Name is relative to the zone, empty for the apex
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Value is in presentation format, e.g. "10 mx.example.com" for MX, without the
+	// trailing dot of host names and without the quotes of TXT values
+	Value string `json:"value"`
+	// TTL is zero if the provider chooses it
+	TTL time.Duration `json:"ttl,omitempty"`
+}
+
+func (r Record) String() string {
+	name := r.Name
+	if name == "" {
+		name = "@"
+	}
+	return name + " " + r.Type + " " + r.Value
+}
+
+// Provider reads the records of a zone at a DNS provider
+type Provider interface {
+	Records(ctx context.Context, zone string) ([]Record, error)
+}
+
+// SupportedTypes are the record types the Strato TXT record form accepts
+var SupportedTypes = []string{"CAA", "CNAME", "MX", "SRV", "TLSA", "TXT"}
+
+// Skipped is a record that cannot be created at Strato
+type Skipped struct {
+	Record Record `json:"record"`
+	Reason string `json:"reason"`
+}
+
+// Plan is the outcome of converting the records of a provider
+type Plan struct {
+	// Records are the Strato records to create
+	Records []strato.DNSRecord `json:"records"`
+	Skipped []Skipped          `json:"skipped"`
+}
+
+// Convert turns the records of a provider into Strato records. Records of types the
+// form does not accept, records Strato manages itself and CNAMEs at the apex are
+// skipped. TTLs are dropped, as Strato does not let customers set them.
+func Convert(records []Record) Plan {
+	var plan Plan
+	seen := map[strato.DNSRecord]bool{}
+	for _, record := range records {
+		recordType := strings.ToUpper(record.Type)
+		reason := ""
+		switch {
+		case recordType == "SOA" || (recordType == "NS" && record.Name == ""):
+			reason = "managed by Strato"
+		case recordType == "A" || recordType == "AAAA":
+			reason = "addresses are set in the domain settings of the portal, not as records"
+		case !supported(recordType):
+			reason = "type not supported by Strato"
+		case recordType == "CNAME" && record.Name == "":
+			reason = "CNAME not allowed at the apex"
+		}
+		if reason != "" {
+			plan.Skipped = append(plan.Skipped, Skipped{Record: record, Reason: reason})
+			continue
+		}
+		converted := strato.DNSRecord{Type: recordType, Prefix: record.Name, Value: record.Value}
+		if !seen[converted] {
+			seen[converted] = true
+			plan.Records = append(plan.Records, converted)
+		}
+	}
+	strato.SortRecords(plan.Records)
+	sort.SliceStable(plan.Skipped, func(i, j int) bool { return plan.Skipped[i].Record.String() < plan.Skipped[j].Record.String() })
+	return plan
+}
+
+func supported(recordType string) bool {
+	for _, supportedType := range SupportedTypes {
+		if recordType == supportedType {
+			return true
+		}
+	}
+	return false
+}
+
+// Import adds the records of plan to the DNS configuration of client. Existing
+// records are kept, so importing twice changes nothing. It returns the changes.
+func Import(client *strato.StratoClient, plan Plan) (strato.ConfigDiff, error) {
+	return client.UpdateDNSConfiguration(func(current strato.DNSConfig) (strato.DNSConfig, error) {
+		zone := strato.NewZone(current)
+		for _, record := range plan.Records {
+			zone.Add(record)
+		}
+		return zone.Config(), nil
+	})
+}
+
+// relativeName returns name relative to zone, empty for the apex
+func relativeName(name, zone string) string {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	zone = strings.TrimSuffix(strings.ToLower(zone), ".")
+	if name == zone || name == "@" || name == "" {
+		return ""
+	}
+	return strings.TrimSuffix(name, "."+zone)
+}
+
+// unquoteTXT joins the character strings of a TXT value in presentation format,
+// e.g. "\"v=DKIM1; \" \"p=...\"", into one unquoted string
+func unquoteTXT(value string) string {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, `"`) {
+		return value
+	}
+	var joined strings.Builder
+	quoted, escaped := false, false
+	for _, r := range value {
+		switch {
+		case escaped:
+			joined.WriteRune(r)
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+			joined.WriteRune(r)
+		}
+	}
+	return joined.String()
+}
+
+// normalizeValue brings a value in presentation format into the notation of Record
+func normalizeValue(recordType, value string) string {
+	if strings.EqualFold(recordType, "TXT") {
+		return unquoteTXT(value)
+	}
+	return strings.TrimSuffix(strings.TrimSpace(value), ".")
+}
@@ -0,0 +1,278 @@
+// Package octodns reads and writes zone files in the YAML format of the octoDNS
+// YamlProvider, so Strato zones can take part in octoDNS pipelines although octoDNS
+// has no Strato provider. Records use the notation of the migrate package.
+package octodns
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fl0eb/go-strato/migrate"
+	"gopkg.in/yaml.v3"
+)
+
+// field is a key of a structured value with its value
+type field struct {
+	key   string
+	value *yaml.Node
+}
+
+// Marshal writes records as octoDNS zone file. Names are sorted with the apex first,
+// names with several record types get a list of record sets.
+func Marshal(records []migrate.Record) ([]byte, error) {
+	type set struct {
+		recordType string
+		ttl        time.Duration
+		records    []migrate.Record
+	}
+	byName := map[string][]*set{}
+	for _, record := range records {
+		name := strings.ToLower(record.Name)
+		recordType := strings.ToUpper(record.Type)
+		var current *set
+		for _, s := range byName[name] {
+			if s.recordType == recordType {
+				current = s
+			}
+		}
+		if current == nil {
+			current = &set{recordType: recordType}
+			byName[name] = append(byName[name], current)
+		}
+		if record.TTL > current.ttl {
+			current.ttl = record.TTL
+		}
+		current.records = append(current.records, record)
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	root := mappingOf()
+	for _, name := range names {
+		sets := byName[name]
+		sort.Slice(sets, func(i, j int) bool { return sets[i].recordType < sets[j].recordType })
+		nodes := make([]*yaml.Node, 0, len(sets))
+		for _, s := range sets {
+			n, err := setNode(s.recordType, s.ttl, s.records)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, n)
+		}
+		if len(nodes) > 1 {
+			root.Content = append(root.Content, str(name), sequenceOf(nodes...))
+		} else {
+			root.Content = append(root.Content, str(name), nodes[0])
+		}
+	}
+	var out bytes.Buffer
+	out.WriteString("---\n")
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// setNode returns a record set, with its values sorted like octoDNS does
+func setNode(recordType string, ttl time.Duration, records []migrate.Record) (*yaml.Node, error) {
+	var fields []field
+	if ttl > 0 {
+		fields = append(fields, field{"ttl", number(strconv.Itoa(int(ttl.Seconds())))})
+	}
+	fields = append(fields, field{"type", str(recordType)})
+	sorted := append([]migrate.Record(nil), records...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Value < sorted[j].Value })
+	values := make([]*yaml.Node, 0, len(sorted))
+	for _, record := range sorted {
+		value, err := encodeValue(recordType, record.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", record, err)
+		}
+		values = append(values, value)
+	}
+	if len(values) == 1 {
+		return mappingOf(append(fields, field{"value", values[0]})...), nil
+	}
+	return mappingOf(append(fields, field{"values", sequenceOf(values...)})...), nil
+}
+
+// encodeValue converts a value in the notation of migrate.Record into an octoDNS
+// scalar or a structured value with its fields sorted by key
+func encodeValue(recordType, value string) (*yaml.Node, error) {
+	parts := strings.Fields(value)
+	switch recordType {
+	case "MX":
+		if len(parts) != 2 || !isNumber(parts[0]) {
+			return nil, fmt.Errorf("invalid MX value %q", value)
+		}
+		return mappingOf(field{"exchange", str(absolute(parts[1]))}, field{"preference", number(parts[0])}), nil
+	case "SRV":
+		if len(parts) != 4 || !isNumber(parts[0]) || !isNumber(parts[1]) || !isNumber(parts[2]) {
+			return nil, fmt.Errorf("invalid SRV value %q", value)
+		}
+		return mappingOf(
+			field{"port", number(parts[2])},
+			field{"priority", number(parts[0])},
+			field{"target", str(absolute(parts[3]))},
+			field{"weight", number(parts[1])},
+		), nil
+	case "CAA":
+		if len(parts) < 3 || !isNumber(parts[0]) {
+			return nil, fmt.Errorf("invalid CAA value %q", value)
+		}
+		caaValue := strings.Trim(strings.Join(parts[2:], " "), `"`)
+		return mappingOf(field{"flags", number(parts[0])}, field{"tag", str(parts[1])}, field{"value", str(caaValue)}), nil
+	case "TLSA":
+		if len(parts) < 4 || !isNumber(parts[0]) || !isNumber(parts[1]) || !isNumber(parts[2]) {
+			return nil, fmt.Errorf("invalid TLSA value %q", value)
+		}
+		return mappingOf(
+			field{"certificate_association_data", str(strings.Join(parts[3:], ""))},
+			field{"certificate_usage", number(parts[0])},
+			field{"matching_type", number(parts[2])},
+			field{"selector", number(parts[1])},
+		), nil
+	case "TXT", "SPF":
+		// octoDNS requires semicolons in TXT values to be escaped
+		return str(strings.ReplaceAll(value, ";", `\;`)), nil
+	case "CNAME", "ALIAS", "DNAME", "NS", "PTR":
+		return str(absolute(value)), nil
+	}
+	return str(value), nil
+}
+
+// Unmarshal reads an octoDNS zone file. Values of structured types the Strato form
+// does not know are joined in key order, so they can still be reported when skipped.
+func Unmarshal(data []byte) ([]migrate.Record, error) {
+	root, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	if !root.isMapping() {
+		return nil, fmt.Errorf("zone file must be a mapping of record names")
+	}
+	var records []migrate.Record
+	for _, name := range root.keys {
+		sets := []*node{root.mapping[name]}
+		if root.mapping[name].isSequence() {
+			sets = root.mapping[name].sequence
+		}
+		for _, set := range sets {
+			decoded, err := decodeSet(strings.ToLower(name), set)
+			if err != nil {
+				return nil, fmt.Errorf("record %q: %w", name, err)
+			}
+			records = append(records, decoded...)
+		}
+	}
+	return records, nil
+}
+
+func decodeSet(name string, set *node) ([]migrate.Record, error) {
+	if !set.isMapping() {
+		return nil, fmt.Errorf("record set must be a mapping")
+	}
+	typeNode := set.mapping["type"]
+	if typeNode == nil || typeNode.scalar == nil || *typeNode.scalar == "" {
+		return nil, fmt.Errorf("record set lacks a type")
+	}
+	recordType := strings.ToUpper(*typeNode.scalar)
+	var ttl time.Duration
+	if ttlNode := set.mapping["ttl"]; ttlNode != nil && ttlNode.scalar != nil {
+		seconds, err := strconv.Atoi(*ttlNode.scalar)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ttl %q", *ttlNode.scalar)
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+	var values []*node
+	switch {
+	case set.mapping["values"].isSequence():
+		values = set.mapping["values"].sequence
+	case set.mapping["value"] != nil:
+		values = []*node{set.mapping["value"]}
+	default:
+		return nil, fmt.Errorf("%s record set lacks a value", recordType)
+	}
+	records := make([]migrate.Record, 0, len(values))
+	for _, v := range values {
+		value, err := decodeValue(recordType, v)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, migrate.Record{Name: name, Type: recordType, Value: value, TTL: ttl})
+	}
+	return records, nil
+}
+
+// decodeValue converts an octoDNS value into the notation of migrate.Record
+func decodeValue(recordType string, v *node) (string, error) {
+	if v.scalar != nil {
+		switch recordType {
+		case "TXT", "SPF":
+			return strings.ReplaceAll(*v.scalar, `\;`, ";"), nil
+		}
+		return strings.TrimSuffix(*v.scalar, "."), nil
+	}
+	if !v.isMapping() {
+		return "", fmt.Errorf("invalid %s value", recordType)
+	}
+	get := func(keys ...string) string {
+		for _, key := range keys {
+			if n := v.mapping[key]; n != nil && n.scalar != nil {
+				return *n.scalar
+			}
+		}
+		return ""
+	}
+	var parts []string
+	switch recordType {
+	case "MX":
+		// value and priority are the keys of octoDNS before 0.9
+		parts = []string{get("preference", "priority"), strings.TrimSuffix(get("exchange", "value"), ".")}
+	case "SRV":
+		parts = []string{get("priority"), get("weight"), get("port"), strings.TrimSuffix(get("target"), ".")}
+	case "CAA":
+		parts = []string{get("flags"), get("tag"), strconv.Quote(get("value"))}
+		if parts[0] == "" {
+			parts[0] = "0"
+		}
+	case "TLSA":
+		parts = []string{get("certificate_usage"), get("selector"), get("matching_type"), get("certificate_association_data")}
+	default:
+		for _, key := range v.keys {
+			parts = append(parts, get(key))
+		}
+	}
+	for _, part := range parts {
+		if part == "" || part == `""` {
+			return "", fmt.Errorf("incomplete %s value", recordType)
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// absolute appends the trailing dot octoDNS requires for host names
+func absolute(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
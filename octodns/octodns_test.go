@@ -0,0 +1,49 @@
+package octodns
+
+import (
+	"testing"
+)
+
+func FuzzUnmarshal(f *testing.F) {
+	f.Add([]byte(`---
+'':
+  - type: MX
+    values:
+      - exchange: mx.example.net.
+        preference: 10
+  - type: TXT
+    value: v=spf1 -all
+www:
+  type: CNAME
+  value: example.com.
+`))
+	f.Add([]byte(`mail._domainkey:
+  type: TXT
+  ttl: 3600
+  value: >-
+    v=DKIM1\; k=rsa\;
+    p=MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8A
+`))
+	f.Add([]byte(`_sip._tcp:
+  type: SRV
+  values:
+    - {port: 5060, priority: 10, target: sip.example.net., weight: 5}
+anchor: &set {type: A, value: 192.0.2.1}
+alias: *set
+`))
+	f.Add([]byte("- a\n- b\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		records, err := Unmarshal(data)
+		if err != nil {
+			return
+		}
+		// Zones that could be read must survive being written and read again
+		written, err := Marshal(records)
+		if err != nil {
+			return
+		}
+		if _, err := Unmarshal(written); err != nil {
+			t.Errorf("cannot read written zone %q: %v", written, err)
+		}
+	})
+}
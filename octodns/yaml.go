@@ -0,0 +1,107 @@
+package octodns
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// node is a parsed YAML value: a scalar, a mapping or a sequence
+type node struct {
+	scalar   *string
+	keys     []string
+	mapping  map[string]*node
+	sequence []*node
+}
+
+func (n *node) isMapping() bool  { return n != nil && n.mapping != nil }
+func (n *node) isSequence() bool { return n != nil && n.sequence != nil }
+
+// parseYAML decodes a YAML document with yaml.v3, so block scalars and the folded
+// TXT values PyYAML writes for long DKIM keys are read like octoDNS reads them
+func parseYAML(data []byte) (*node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return &node{mapping: map[string]*node{}}, nil
+	}
+	return fromYAML(doc.Content[0])
+}
+
+// fromYAML converts a yaml.v3 node, following aliases. Mapping keys keep the order
+// of the document.
+func fromYAML(n *yaml.Node) (*node, error) {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return fromYAML(n.Alias)
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!null" {
+			return &node{}, nil
+		}
+		value := n.Value
+		return &node{scalar: &value}, nil
+	case yaml.SequenceNode:
+		converted := &node{sequence: []*node{}}
+		for _, item := range n.Content {
+			child, err := fromYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			converted.sequence = append(converted.sequence, child)
+		}
+		return converted, nil
+	case yaml.MappingNode:
+		converted := &node{mapping: map[string]*node{}}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: mapping keys must be scalars", key.Line)
+			}
+			if _, ok := converted.mapping[key.Value]; ok {
+				return nil, fmt.Errorf("line %d: duplicate key %q", key.Line, key.Value)
+			}
+			child, err := fromYAML(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			converted.keys = append(converted.keys, key.Value)
+			converted.mapping[key.Value] = child
+		}
+		return converted, nil
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML node", n.Line)
+}
+
+// str returns a string scalar, which yaml.v3 quotes if it would be read back as
+// something else. The booleans of YAML 1.1 are quoted too, since octoDNS reads zone
+// files with PyYAML.
+func str(s string) *yaml.Node {
+	n := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+	switch strings.ToLower(s) {
+	case "y", "n", "yes", "no", "on", "off":
+		n.Style = yaml.SingleQuotedStyle
+	}
+	return n
+}
+
+// number returns an integer scalar
+func number(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: s}
+}
+
+// mappingOf returns a mapping of fields in the given order
+func mappingOf(fields ...field) *yaml.Node {
+	m := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, f := range fields {
+		m.Content = append(m.Content, str(f.key), f.value)
+	}
+	return m
+}
+
+// sequenceOf returns a block sequence of items
+func sequenceOf(items ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: items}
+}
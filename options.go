@@ -0,0 +1,251 @@
+package strato
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Option configures optional behaviour of a StratoClient
+type Option func(*StratoClient)
+
+// WithRegion selects the country variant of the Strato portal (default: RegionDE)
+func WithRegion(region Region) Option {
+	return func(c *StratoClient) {
+		c.region = region
+	}
+}
+
+// WithPackageID uses the package with the given cID instead of looking it up by
+// order number on the entry page
+func WithPackageID(cID string) Option {
+	return func(c *StratoClient) {
+		c.cID = cID
+	}
+}
+
+// WithLoginBackoff keeps failed logins in the state file at path and refuses to log
+// in again before cooldown has passed, doubling it with every further failure, so
+// automation with wrong credentials cannot get the account locked
+func WithLoginBackoff(path string, cooldown time.Duration) Option {
+	return func(c *StratoClient) {
+		c.backoff = &loginBackoff{path: path, cooldown: cooldown}
+	}
+}
+
+// WithDriver logs in with driver instead of posting the login form directly
+func WithDriver(driver LoginDriver) Option {
+	return func(c *StratoClient) {
+		c.driver = driver
+	}
+}
+
+// WithSessionStore keeps the portal session in store after every login, so later
+// clients reuse it while the portal accepts it instead of logging in again. The
+// session is filed under the account "session:" followed by the identifier.
+func WithSessionStore(store CredentialStore) Option {
+	return func(c *StratoClient) {
+		c.sessionStore = store
+	}
+}
+
+// WithCaptchaSolver lets solver answer CAPTCHAs the login page presents. Without
+// it, such logins fail with a *CaptchaError.
+func WithCaptchaSolver(solver SolverFunc) Option {
+	return func(c *StratoClient) {
+		c.captchaSolver = solver
+	}
+}
+
+// WithVerifyAfterWrite makes SetDNSConfiguration read the configuration back after
+// writing it and return a *VerificationError if it differs from the submitted one
+func WithVerifyAfterWrite() Option {
+	return func(c *StratoClient) {
+		c.verifyAfterWrite = true
+	}
+}
+
+// WithStateDir makes SetDNSConfiguration save the previous configuration as a
+// snapshot in dir before every change, which enables Undo
+func WithStateDir(dir string) Option {
+	return func(c *StratoClient) {
+		c.stateDir = dir
+	}
+}
+
+// WithRawOrder keeps the records returned by GetDNSConfiguration in the order
+// the portal lists them instead of sorting them by type, prefix and value. Zones
+// and snapshots of the client keep that order too.
+func WithRawOrder() Option {
+	return func(c *StratoClient) {
+		c.rawOrder = true
+	}
+}
+
+// WithStreamingParser makes GetDNSConfiguration extract the records with a tokenizer
+// instead of building the DOM of the whole page, which needs a fraction of the memory
+// for large zones
+func WithStreamingParser() Option {
+	return func(c *StratoClient) {
+		c.streamingParser = true
+	}
+}
+
+// WithLenientParsing makes GetDNSConfiguration return what it could read from the
+// page, with the missing parts listed in DNSConfig.Warnings, instead of failing.
+// Some package types do not show the DMARC and SPF settings, for example.
+func WithLenientParsing() Option {
+	return func(c *StratoClient) {
+		c.lenient = true
+	}
+}
+
+// WithAnnotations keeps owner and creation time of records added through the
+// client in store, and forgets the annotations of records it removes
+func WithAnnotations(store AnnotationStore, owner string) Option {
+	return func(c *StratoClient) {
+		c.annotations = store
+		c.annotationOwner = owner
+	}
+}
+
+// WithCache lets GetDNSConfiguration serve repeated reads from memory for ttl,
+// sparing the portal on hot paths. The cache is kept per domain and shared with the
+// clients derived from this one; every write invalidates the entry of its domain.
+func WithCache(ttl time.Duration) Option {
+	return func(c *StratoClient) {
+		c.cache = newConfigCache(ttl)
+	}
+}
+
+// WithAuditLog records every DNS change and every other form submitted through the
+// client, e.g. mailbox or contact changes, together with actor and result, in logger
+func WithAuditLog(logger AuditLogger, actor string) Option {
+	return func(c *StratoClient) {
+		c.auditLog = logger
+		c.auditActor = actor
+	}
+}
+
+// WithTransport sends the requests of the client through transport instead of
+// http.DefaultTransport, e.g. a Recorder. Single operations can use another
+// transport with ContextWithTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *StratoClient) {
+		c.transport = transport
+	}
+}
+
+// WithRequestTimeout bounds every request to the portal, including reading the
+// response body (default: DefaultRequestTimeout)
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(c *StratoClient) {
+		c.session.Timeout = timeout
+	}
+}
+
+// WithMaxResponseSize limits the size of response bodies read from the portal
+// (default: DefaultMaxResponseSize). Larger responses fail with ErrResponseTooLarge.
+func WithMaxResponseSize(limit int64) Option {
+	return func(c *StratoClient) {
+		c.maxResponseSize = limit
+	}
+}
+
+// WithBaseContext binds all requests of the client, including the login, to ctx.
+// Use WithContext to bind the requests of single operations.
+func WithBaseContext(ctx context.Context) Option {
+	return func(c *StratoClient) {
+		c.ctx = ctx
+	}
+}
+
+// WithRateLimit spaces out all requests of the client, and of clients derived from it
+// with ForDomain, so that at most one request is sent per interval
+func WithRateLimit(interval time.Duration) Option {
+	return withRateLimiter(&rateLimiter{interval: interval})
+}
+
+// WithSharedRateLimit is WithRateLimit for all processes using the same file, e.g.
+// replicas of a daemon on one host working on the same account. The time of the last
+// request is kept in the file under an exclusive lock.
+func WithSharedRateLimit(path string, interval time.Duration) Option {
+	return withRateLimiter(&fileRateLimiter{path: path, interval: interval})
+}
+
+// withRateLimiter makes the client wait for limiter, which may be shared with other clients
+func withRateLimiter(limiter limiter) Option {
+	return func(c *StratoClient) {
+		c.limiter = limiter
+	}
+}
+
+// WithWriteLock holds locker while the DNS configuration is written, and with
+// UpdateZone and UpdateDNSConfiguration from the read on, so replicas sharing an
+// account neither submit the TXT record form at the same time nor overwrite each
+// other's changes
+func WithWriteLock(locker Locker) Option {
+	return func(c *StratoClient) {
+		c.writeLock = locker
+	}
+}
+
+// WithReadOnly turns all mutating calls of the client into errors wrapping ErrReadOnly,
+// so monitoring can share credentials without risking changes. With dryRun, mutating
+// calls only log what they would do and report success instead; operations reading
+// their result back may then fail to find it.
+func WithReadOnly(dryRun bool) Option {
+	return func(c *StratoClient) {
+		c.readOnly = true
+		c.dryRun = dryRun
+	}
+}
+
+// WithStatusTracker records the outcome of every Watch and SyncAll round per domain
+// in tracker, so a sync that keeps failing shows up in its Statuses
+func WithStatusTracker(tracker *StatusTracker) Option {
+	return func(c *StratoClient) {
+		c.status = tracker
+	}
+}
+
+// WithProtection protects the records matching any of filters in addition to those
+// annotated as protected, e.g. RecordFilter{Type: "TXT", Prefix: "*._domainkey"} for
+// the DKIM keys. Changes removing or modifying them fail with ErrProtectedRecord.
+func WithProtection(filters ...RecordFilter) Option {
+	return func(c *StratoClient) {
+		c.protected = append(c.protected, filters...)
+	}
+}
+
+// WithLanguage sets the language the portal pages of the account are shown in, if it
+// was changed from the default of the region. Messages are recognized in every
+// language anyway, but forms are submitted with the captions of this language.
+func WithLanguage(language Language) Option {
+	return func(c *StratoClient) {
+		c.language = language
+	}
+}
+
+// WithRecordQuota rejects configurations with more than limit records before they
+// are sent, for tariffs whose TXT record page does not state the limit
+func WithRecordQuota(limit int) Option {
+	return func(c *StratoClient) {
+		c.recordQuota = limit
+	}
+}
+
+// WithForce lets the client change and remove protected records
+func WithForce() Option {
+	return func(c *StratoClient) {
+		c.force = true
+	}
+}
+
+// WithHistory records every configuration the client fetches or writes in recorder,
+// e.g. a history.Store, which skips those equal to the latest entry of the domain
+func WithHistory(recorder HistoryRecorder) Option {
+	return func(c *StratoClient) {
+		c.history = recorder
+	}
+}
@@ -0,0 +1,56 @@
+package strato
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// PackageInfo describes the selected Strato package
+type PackageInfo struct {
+	Name  string `json:"name"`
+	Order string `json:"order"`
+	// Domains lists the domains included in the package
+	Domains []string `json:"domains"`
+	// StorageUsed and StorageQuota are the webspace figures as displayed by the portal (e.g. "1,2 GB")
+	StorageUsed   string    `json:"storageUsed"`
+	StorageQuota  string    `json:"storageQuota"`
+	ContractStart time.Time `json:"contractStart"`
+	// ContractEnd is zero for contracts that have not been cancelled
+	ContractEnd time.Time `json:"contractEnd"`
+}
+
+// GetPackageInfo retrieves name, domains, storage usage and contract dates from the package overview
+func (c *StratoClient) GetPackageInfo() (PackageInfo, error) {
+	doc, err := c.fetchPage(c.region.PackageNode)
+	if err != nil {
+		return PackageInfo{}, err
+	}
+	overview := htmlquery.FindOne(doc, "//*[@id='jss_package_overview']")
+	if overview == nil {
+		return PackageInfo{}, errors.New("failed to find package overview")
+	}
+	info := PackageInfo{
+		Name:         textOf(overview, ".//*[contains(@class, 'package-name')]"),
+		Order:        c.order,
+		StorageUsed:  textOf(overview, ".//*[contains(@class, 'storage-used')]"),
+		StorageQuota: textOf(overview, ".//*[contains(@class, 'storage-quota')]"),
+	}
+	for _, domainNode := range htmlquery.Find(overview, ".//*[@data-domain]") {
+		info.Domains = append(info.Domains, htmlquery.SelectAttr(domainNode, "data-domain"))
+	}
+	if start := textOf(overview, ".//*[contains(@class, 'contract-start')]"); start != "" {
+		if info.ContractStart, err = parsePortalDate(start); err != nil {
+			return PackageInfo{}, err
+		}
+	}
+	// Contracts without notice have no end date
+	if end := textOf(overview, ".//*[contains(@class, 'contract-end')]"); end != "" && strings.ContainsAny(end, "0123456789") {
+		if info.ContractEnd, err = parsePortalDate(end); err != nil {
+			return PackageInfo{}, err
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,151 @@
+package strato
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// portalURL builds the URL of a portal page of the package with the given cID
+func (c *StratoClient) portalURL(cID, node string, params ...string) string {
+	pageURL := c.api +
+		"?sessionID=" + c.sessionID() +
+		"&cID=" + cID +
+		"&node=" + node
+	for _, param := range params {
+		pageURL += "&" + param
+	}
+	return pageURL
+}
+
+// fetchPage loads and parses a portal page of the selected package
+func (c *StratoClient) fetchPage(node string, params ...string) (*html.Node, error) {
+	return c.fetchPackagePage(c.cID, node, params...)
+}
+
+// fetchPackagePage loads and parses a portal page of the package with the given cID,
+// logging in again if the session expired
+func (c *StratoClient) fetchPackagePage(cID, node string, params ...string) (*html.Node, error) {
+	var doc *html.Node
+	err := c.retryExpired(func() (err error) {
+		doc, err = c.fetchPackagePageOnce(cID, node, params...)
+		return err
+	})
+	return doc, err
+}
+
+func (c *StratoClient) fetchPackagePageOnce(cID, node string, params ...string) (*html.Node, error) {
+	req, err := c.newRequest("GET", c.portalURL(cID, node, params...), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.session.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusFound {
+		return nil, ErrSessionExpired
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("unexpected response status: " + resp.Status)
+	}
+	return htmlquery.Parse(resp.Body)
+}
+
+// submitForm posts a form to a portal page of the selected package.
+// Strato answers successful submissions with a redirect and shows the
+// page again, usually with an error banner, if the submission failed.
+func (c *StratoClient) submitForm(node string, form url.Values) error {
+	if proceed, err := c.checkWrite("submit " + node + " form"); !proceed {
+		return err
+	}
+	err := c.sendForm(node, form)
+	c.auditForm(form, err)
+	return err
+}
+
+// sendForm posts a form like submitForm, without recording it in the audit log
+func (c *StratoClient) sendForm(node string, form url.Values) error {
+	resp, err := c.postForm(node, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusFound { // 302
+		return nil
+	} else if resp.StatusCode == http.StatusOK { // 200
+		return formError(resp)
+	}
+	return errors.New("unexpected response status: " + resp.Status)
+}
+
+// formError builds the error for a form the portal showed again, or returns nil if
+// the page confirms the change after all
+func formError(resp *http.Response) error {
+	doc, err := htmlquery.Parse(resp.Body)
+	if err != nil {
+		return &UpdateError{}
+	}
+	message, saved := banner(doc)
+	if saved {
+		logFor(LogForm).Debug("Form shown again with a confirmation", "message", message)
+		return nil
+	}
+	return &UpdateError{Reason: message}
+}
+
+// postForm posts a form to a portal page of the selected package and returns the raw response
+func (c *StratoClient) postForm(node string, form url.Values) (*http.Response, error) {
+	if proceed, err := c.checkWrite("submit " + node + " form"); !proceed {
+		if err != nil {
+			return nil, err
+		}
+		// A dry run answers like a successful submission
+		return &http.Response{Status: "302 Found", StatusCode: http.StatusFound, Body: http.NoBody}, nil
+	}
+	form.Set("sessionID", c.sessionID())
+	form.Set("cID", c.cID)
+	form.Set("node", node)
+
+	req, err := c.newRequest("POST", c.portalURL(c.cID, node), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.session.Do(req)
+}
+
+// attrOf returns the attribute of the first node matching expr below top
+func attrOf(top *html.Node, expr, attr string) string {
+	node := htmlquery.FindOne(top, expr)
+	if node == nil {
+		return ""
+	}
+	return htmlquery.SelectAttr(node, attr)
+}
+
+// textOf returns the trimmed text of the first node matching expr below top
+func textOf(top *html.Node, expr string) string {
+	node := htmlquery.FindOne(top, expr)
+	if node == nil {
+		return ""
+	}
+	return strings.TrimSpace(htmlquery.InnerText(node))
+}
+
+// xpathLiteral quotes s as an XPath string literal. XPath 1.0 has no escape
+// sequences, so values containing both kinds of quotes are built with concat().
+func xpathLiteral(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+	return "concat('" + strings.Join(strings.Split(s, "'"), `', "'", '`) + "')"
+}
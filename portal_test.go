@@ -0,0 +1,265 @@
+package strato
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Credentials the test portal accepts
+const (
+	testIdentifier = "12345678"
+	testPassword   = "secret"
+	testSessionID  = "SESSIONID"
+)
+
+// fixture returns the recorded portal page testdata/fixtures/<name>
+func fixture(tb testing.TB, name string) []byte {
+	tb.Helper()
+	page, err := os.ReadFile(filepath.Join("testdata", "fixtures", name))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return page
+}
+
+// testPortal serves fixture pages like the customer portal of a region and keeps
+// the forms submitted to it
+type testPortal struct {
+	*httptest.Server
+	tb     testing.TB
+	region Region
+
+	mu sync.Mutex
+	// pages maps node names to the fixture served for them, or the names of
+	// action_show_* parameters for the pages of single domains. The TXT record form
+	// is served from the entry txtFormNode.
+	pages map[string]string
+	// logins are the submitted login forms, txtForms the TXT record forms,
+	// txtQueries the queries they were posted to and txtReads the queries the TXT
+	// record form was requested with
+	logins     []url.Values
+	txtForms   []url.Values
+	txtQueries []url.Values
+	txtReads   []url.Values
+	// config is shown by the TXT record form once a form was submitted
+	config *DNSConfig
+	// addresses are shown and changed by the address settings of the hosts, keyed
+	// by host name; addressForms are the submitted address settings
+	addresses    map[string]HostAddresses
+	addressForms []url.Values
+	// pageReads are the queries of the pages requested from pages, forms the
+	// submitted forms that change nothing in the portal
+	pageReads []url.Values
+	forms     []url.Values
+	// failWrites makes the portal answer that many TXT record forms with an error
+	failWrites int
+	// rejectPage, if set, is the fixture the portal shows again instead of
+	// accepting a TXT record form
+	rejectPage string
+	// identifier and password are the credentials logins need, the password is
+	// changed by the password form
+	identifier string
+	password   string
+	// requestTimes are the arrival times of all requests
+	requestTimes []time.Time
+}
+
+// txtFormNode is the key of pages for the TXT record form
+const txtFormNode = "txt"
+
+// newTestPortal starts a portal for region that lists the packages of entry.html and
+// shows txt_form.html for every domain, until a TXT record form is submitted
+func newTestPortal(tb testing.TB, region Region) *testPortal {
+	p := &testPortal{tb: tb, region: region, pages: map[string]string{
+		region.EntryNode: "entry.html",
+		txtFormNode:      "txt_form.html",
+	}, addresses: map[string]HostAddresses{}, identifier: testIdentifier, password: testPassword}
+	p.Server = httptest.NewServer(http.HandlerFunc(p.serve))
+	tb.Cleanup(p.Close)
+	return p
+}
+
+// API returns the CustomerService URL of the portal
+func (p *testPortal) API() string {
+	return p.URL + "/apps/CustomerService"
+}
+
+// setPage serves the fixture name for node
+func (p *testPortal) setPage(node, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pages[node] = name
+}
+
+// lastForm returns the form submitted last other than those of the TXT records,
+// addresses, password and login
+func (p *testPortal) lastForm() url.Values {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.forms) == 0 {
+		p.tb.Fatal("no form was submitted")
+	}
+	return p.forms[len(p.forms)-1]
+}
+
+// lastRead returns the query of the page requested last from pages
+func (p *testPortal) lastRead() url.Values {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.pageReads) == 0 {
+		p.tb.Fatal("no page was requested")
+	}
+	return p.pageReads[len(p.pageReads)-1]
+}
+
+// lastTXTForm returns the TXT record form submitted last and the query it was
+// posted to
+func (p *testPortal) lastTXTForm() (url.Values, url.Values) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.txtForms) == 0 {
+		p.tb.Fatal("no TXT record form was submitted")
+	}
+	return p.txtForms[len(p.txtForms)-1], p.txtQueries[len(p.txtQueries)-1]
+}
+
+func (p *testPortal) serve(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requestTimes = append(p.requestTimes, time.Now())
+	switch {
+	case r.Method == http.MethodPost && query.Has("action_change_txt_records") && p.failWrites > 0:
+		p.failWrites--
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	case r.Method == http.MethodPost && query.Has("action_change_txt_records") && p.rejectPage != "":
+		p.write(w, p.rejectPage)
+	case r.Method == http.MethodPost && query.Has("action_change_txt_records"):
+		p.txtForms = append(p.txtForms, r.PostForm)
+		p.txtQueries = append(p.txtQueries, query)
+		p.config = submittedConfig(r.PostForm)
+		http.Redirect(w, r, p.API()+"?sessionID="+testSessionID+"&node="+p.region.ManageDomainsNode, http.StatusFound)
+	case r.Method == http.MethodPost && r.PostForm.Has("action_change_ip_settings"):
+		p.addressForms = append(p.addressForms, r.PostForm)
+		p.addresses[r.PostForm.Get("vhost")] = HostAddresses{IPv4: r.PostForm["ipv4"], IPv6: r.PostForm["ipv6"]}
+		http.Redirect(w, r, p.API()+"?sessionID="+testSessionID+"&node="+p.region.ManageDomainsNode, http.StatusFound)
+	case r.Method == http.MethodPost && r.PostForm.Has("action_change_password"):
+		switch {
+		case query.Get("sessionID") != testSessionID:
+			http.Redirect(w, r, p.API(), http.StatusFound)
+		case r.PostForm.Get("old_passwd") != p.password:
+			http.Error(w, "wrong password", http.StatusBadRequest)
+		default:
+			p.password = r.PostForm.Get("new_passwd")
+			http.Redirect(w, r, p.API()+"?sessionID="+testSessionID+"&node="+p.region.AccountNode, http.StatusFound)
+		}
+	case r.Method == http.MethodPost && query.Get("sessionID") == testSessionID:
+		p.forms = append(p.forms, r.PostForm)
+		http.Redirect(w, r, p.API()+"?sessionID="+testSessionID+"&node="+query.Get("node"), http.StatusFound)
+	case r.Method == http.MethodPost:
+		p.logins = append(p.logins, r.PostForm)
+		if r.PostForm.Get(p.region.IdentifierField) != p.identifier || r.PostForm.Get(p.region.PasswordField) != p.password {
+			p.write(w, "login_failed.html")
+			return
+		}
+		http.Redirect(w, r, p.API()+"?sessionID="+testSessionID+"&cID=0&node="+p.region.EntryNode, http.StatusFound)
+	case query.Get("sessionID") == "":
+		p.write(w, "login.html")
+	case query.Get("sessionID") != testSessionID:
+		// The portal sends requests of expired sessions to the login page
+		http.Redirect(w, r, p.API(), http.StatusFound)
+	case query.Has("action_show_ip_settings"):
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(addressFormPage(p.addresses[query.Get("vhost")]))
+	default:
+		node := query.Get("node")
+		for key := range query {
+			if _, ok := p.pages[key]; ok && strings.HasPrefix(key, "action_show_") {
+				node = key
+			}
+		}
+		if query.Has("action_show_txt_records") {
+			node = txtFormNode
+			p.txtReads = append(p.txtReads, query)
+		}
+		if node == txtFormNode && p.config != nil {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(txtFormPage(*p.config))
+			return
+		}
+		name, ok := p.pages[node]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		p.pageReads = append(p.pageReads, query)
+		p.write(w, name)
+	}
+}
+
+// write answers with the fixture name
+func (p *testPortal) write(w http.ResponseWriter, name string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(fixture(p.tb, name))
+}
+
+// addressFormPage renders addresses like the address settings of a host
+func addressFormPage(addresses HostAddresses) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<!DOCTYPE html><html lang="de"><body><form id="jss_ip_form" method="post" action="/apps/CustomerService">`)
+	ipType := "strato"
+	if !addresses.Empty() {
+		ipType = "external"
+	}
+	for _, value := range []string{"strato", "external"} {
+		checked := ""
+		if value == ipType {
+			checked = " checked"
+		}
+		fmt.Fprintf(&b, `<label><input type="radio" name="ip_type" value="%s"%s> %s</label>`, value, checked, value)
+	}
+	for _, address := range addresses.IPv4 {
+		fmt.Fprintf(&b, `<input type="text" name="ipv4" value="%s">`, address)
+	}
+	for _, address := range addresses.IPv6 {
+		fmt.Fprintf(&b, `<input type="text" name="ipv6" value="%s">`, address)
+	}
+	b.WriteString(`<input type="submit" name="action_change_ip_settings" value="Einstellung übernehmen"></form></body></html>`)
+	return b.Bytes()
+}
+
+// submittedConfig returns the configuration of a submitted TXT record form
+func submittedConfig(form url.Values) *DNSConfig {
+	config := &DNSConfig{DMARCType: form.Get("dmarc_type"), SPFType: form.Get("spf_type")}
+	types, prefixes, values := form["type"], form["prefix"], form["value"]
+	for i := range types {
+		if i < len(prefixes) && i < len(values) {
+			config.Records = append(config.Records, DNSRecord{Type: types[i], Prefix: prefixes[i], Value: values[i]})
+		}
+	}
+	return config
+}
+
+// newTestClient logs in to the portal as the owner of example.com
+func newTestClient(tb testing.TB, p *testPortal, opts ...Option) *StratoClient {
+	tb.Helper()
+	opts = append([]Option{WithRegion(p.region)}, opts...)
+	client, err := NewStratoClient(p.API(), testIdentifier, testPassword, "ORDER", "example.com", opts...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return client
+}
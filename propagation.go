@@ -0,0 +1,213 @@
+package strato
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Checker waits until a record of domain is visible in DNS. Strato publishes
+// changes anywhere from minutes to an hour after they were saved, so integrations
+// choose how to wait by picking and composing checkers.
+type Checker interface {
+	Wait(ctx context.Context, domain string, record DNSRecord) error
+}
+
+// defaultCheckInterval is used by checkers created without an interval
+const defaultCheckInterval = 15 * time.Second
+
+// AuthoritativeChecker waits until every authoritative nameserver of the domain
+// answers with the record. That is what ACME CAs check.
+type AuthoritativeChecker struct {
+	// Interval between rounds of queries, 15 seconds by default
+	Interval time.Duration
+}
+
+// Wait polls the authoritative nameservers until all of them have the record
+func (a AuthoritativeChecker) Wait(ctx context.Context, domain string, record DNSRecord) error {
+	return poll(ctx, a.Interval, domain, record, func(name string) (bool, error) {
+		nameservers, err := authoritativeServers(ctx, domain)
+		if err != nil {
+			return false, err
+		}
+		found, err := countFound(ctx, nameservers, name, record, false)
+		return found == len(nameservers), err
+	})
+}
+
+// ResolverChecker waits until a quorum of recursive resolvers answers with the
+// record, so most clients see it once Wait returns
+type ResolverChecker struct {
+	// Resolvers default to DefaultResolvers
+	Resolvers []string
+	// Quorum is the number of resolvers that must have the record, all by default
+	Quorum int
+	// Interval between rounds of queries, 15 seconds by default
+	Interval time.Duration
+}
+
+// Wait polls the resolvers until the quorum has the record
+func (q ResolverChecker) Wait(ctx context.Context, domain string, record DNSRecord) error {
+	resolvers := q.Resolvers
+	if resolvers == nil {
+		resolvers = DefaultResolvers
+	}
+	quorum := q.Quorum
+	if quorum <= 0 || quorum > len(resolvers) {
+		quorum = len(resolvers)
+	}
+	return poll(ctx, q.Interval, domain, record, func(name string) (bool, error) {
+		found, err := countFound(ctx, resolvers, name, record, true)
+		return found >= quorum, err
+	})
+}
+
+// DelayChecker waits a fixed time without looking at DNS, for resolvers that
+// cannot be queried or as a safety margin after another checker
+type DelayChecker struct {
+	Delay time.Duration
+}
+
+// Wait sleeps for Delay
+func (d DelayChecker) Wait(ctx context.Context, domain string, record DNSRecord) error {
+	timer := time.NewTimer(d.Delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// AllOf returns a checker that runs checkers one after another
+func AllOf(checkers ...Checker) Checker {
+	return allOf(checkers)
+}
+
+type allOf []Checker
+
+func (a allOf) Wait(ctx context.Context, domain string, record DNSRecord) error {
+	for _, checker := range a {
+		if err := checker.Wait(ctx, domain, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AnyOf returns a checker that runs checkers concurrently and returns as soon as
+// one of them succeeds
+func AnyOf(checkers ...Checker) Checker {
+	return anyOf(checkers)
+}
+
+type anyOf []Checker
+
+func (a anyOf) Wait(ctx context.Context, domain string, record DNSRecord) error {
+	if len(a) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan error, len(a))
+	for _, checker := range a {
+		go func(checker Checker) {
+			results <- checker.Wait(ctx, domain, record)
+		}(checker)
+	}
+	var errs []error
+	for range a {
+		err := <-results
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// ParseChecker builds a checker from a comma separated list of the checks
+// "authoritative", "resolvers" or "resolvers:<quorum>" and "delay:<duration>",
+// which run one after another. Alternatives are separated by "|" and run
+// concurrently, e.g. "authoritative,delay:1m|delay:1h".
+func ParseChecker(spec string) (Checker, error) {
+	var alternatives []Checker
+	for _, alternative := range strings.Split(spec, "|") {
+		var sequence []Checker
+		for _, check := range strings.Split(alternative, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(check), ":")
+			switch name {
+			case "authoritative":
+				sequence = append(sequence, AuthoritativeChecker{})
+			case "resolvers":
+				quorum := 0
+				if arg != "" {
+					if _, err := fmt.Sscanf(arg, "%d", &quorum); err != nil || quorum <= 0 {
+						return nil, fmt.Errorf("invalid resolver quorum %q", arg)
+					}
+				}
+				sequence = append(sequence, ResolverChecker{Quorum: quorum})
+			case "delay":
+				delay, err := time.ParseDuration(arg)
+				if err != nil {
+					return nil, fmt.Errorf("invalid delay %q", arg)
+				}
+				sequence = append(sequence, DelayChecker{Delay: delay})
+			default:
+				return nil, fmt.Errorf("unknown propagation check %q, use authoritative, resolvers[:quorum] or delay:<duration>", check)
+			}
+		}
+		alternatives = append(alternatives, AllOf(sequence...))
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+	return AnyOf(alternatives...), nil
+}
+
+// poll calls check every interval until it reports the record as visible or ctx ends
+func poll(ctx context.Context, interval time.Duration, domain string, record DNSRecord, check func(name string) (bool, error)) error {
+	if _, ok := queryTypes[strings.ToUpper(record.Type)]; !ok {
+		return fmt.Errorf("cannot resolve %s records", record.Type)
+	}
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	name := recordName(record.Prefix, domain)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		visible, err := check(name)
+		if visible {
+			return nil
+		}
+		logFor(LogScrape).Debug("Record not visible yet", "name", name, "type", record.Type, "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("record %s did not propagate: %w", name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// countFound returns how many of servers answer the query for name with the value
+// of record. The error is the last one a server returned.
+func countFound(ctx context.Context, servers []string, name string, record DNSRecord, recursive bool) (int, error) {
+	qtype := queryTypes[strings.ToUpper(record.Type)]
+	found := 0
+	var lastErr error
+	for _, server := range servers {
+		values, _, err := queryDNS(ctx, server, name, qtype, recursive)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if containsValue(record.Type, values, record.Value) {
+			found++
+		}
+	}
+	return found, lastErr
+}
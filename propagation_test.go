@@ -0,0 +1,205 @@
+package strato
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestParseChecker(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Checker
+	}{
+		{"authoritative", allOf{AuthoritativeChecker{}}},
+		{"resolvers", allOf{ResolverChecker{}}},
+		{"resolvers:2", allOf{ResolverChecker{Quorum: 2}}},
+		{"authoritative, delay:30s", allOf{AuthoritativeChecker{}, DelayChecker{Delay: 30 * time.Second}}},
+		{"authoritative,delay:1m|delay:1h", anyOf{
+			allOf{AuthoritativeChecker{}, DelayChecker{Delay: time.Minute}},
+			allOf{DelayChecker{Delay: time.Hour}},
+		}},
+	}
+	for _, test := range tests {
+		checker, err := ParseChecker(test.spec)
+		if err != nil {
+			t.Errorf("%q: %v", test.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(checker, test.want) {
+			t.Errorf("%q: got %#v, want %#v", test.spec, checker, test.want)
+		}
+	}
+	for _, spec := range []string{"", "dns", "resolvers:0", "resolvers:many", "delay", "delay:soon", "authoritative|"} {
+		if checker, err := ParseChecker(spec); err == nil {
+			t.Errorf("%q: got %#v, want an error", spec, checker)
+		}
+	}
+}
+
+// fakeChecker returns err after delay and records that it ran
+type fakeChecker struct {
+	delay time.Duration
+	err   error
+
+	mu  *sync.Mutex
+	ran *[]string
+	id  string
+}
+
+func (f fakeChecker) Wait(ctx context.Context, domain string, record DNSRecord) error {
+	timer := time.NewTimer(f.delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+	f.mu.Lock()
+	*f.ran = append(*f.ran, f.id)
+	f.mu.Unlock()
+	return f.err
+}
+
+// fakeCheckers returns a constructor of fakeCheckers recording into ran
+func fakeCheckers() (func(id string, delay time.Duration, err error) Checker, *[]string) {
+	var mu sync.Mutex
+	var ran []string
+	return func(id string, delay time.Duration, err error) Checker {
+		return fakeChecker{delay: delay, err: err, mu: &mu, ran: &ran, id: id}
+	}, &ran
+}
+
+var errNotVisible = errors.New("not visible")
+
+func TestAllOf(t *testing.T) {
+	record := DNSRecord{Type: "TXT", Prefix: "_acme-challenge", Value: "token"}
+	checker, ran := fakeCheckers()
+	err := AllOf(checker("a", 0, nil), checker("b", 0, nil)).Wait(context.Background(), "example.com", record)
+	if err != nil || !reflect.DeepEqual(*ran, []string{"a", "b"}) {
+		t.Errorf("got %v after %v, want both checkers in order", err, *ran)
+	}
+
+	checker, ran = fakeCheckers()
+	err = AllOf(checker("a", 0, errNotVisible), checker("b", 0, nil)).Wait(context.Background(), "example.com", record)
+	if !errors.Is(err, errNotVisible) || !reflect.DeepEqual(*ran, []string{"a"}) {
+		t.Errorf("got %v after %v, want the error of the first checker", err, *ran)
+	}
+
+	if err := AllOf().Wait(context.Background(), "example.com", record); err != nil {
+		t.Errorf("no checkers: got %v", err)
+	}
+}
+
+func TestAnyOf(t *testing.T) {
+	record := DNSRecord{Type: "TXT", Prefix: "_acme-challenge", Value: "token"}
+	checker, ran := fakeCheckers()
+	start := time.Now()
+	err := AnyOf(checker("slow", time.Hour, nil), checker("fast", 0, nil)).Wait(context.Background(), "example.com", record)
+	if err != nil || !reflect.DeepEqual(*ran, []string{"fast"}) {
+		t.Errorf("got %v after %v, want the fast checker to win", err, *ran)
+	}
+	if time.Since(start) > time.Minute {
+		t.Error("waited for the slow checker")
+	}
+
+	checker, _ = fakeCheckers()
+	err = AnyOf(checker("a", 0, errNotVisible), checker("b", 10*time.Millisecond, nil)).Wait(context.Background(), "example.com", record)
+	if err != nil {
+		t.Errorf("got %v, want the failure of one alternative to be ignored", err)
+	}
+
+	checker, _ = fakeCheckers()
+	other := errors.New("timeout")
+	err = AnyOf(checker("a", 0, errNotVisible), checker("b", 0, other)).Wait(context.Background(), "example.com", record)
+	if !errors.Is(err, errNotVisible) || !errors.Is(err, other) {
+		t.Errorf("got %v, want the errors of all alternatives", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	checker, _ = fakeCheckers()
+	if err := AnyOf(checker("a", time.Hour, nil)).Wait(ctx, "example.com", record); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// startTestResolver answers TXT queries for name with values on a local UDP port
+// and returns its address
+func startTestResolver(t *testing.T, name string, values ...string) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) != 1 {
+				continue
+			}
+			question := query.Questions[0]
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true},
+				Questions: query.Questions,
+			}
+			if question.Type == dnsmessage.TypeTXT && question.Name.String() == name+"." {
+				for _, value := range values {
+					response.Answers = append(response.Answers, dnsmessage.Resource{
+						Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET, TTL: 60},
+						Body:   &dnsmessage.TXTResource{TXT: []string{value}},
+					})
+				}
+			}
+			packed, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(packed, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestResolverChecker(t *testing.T) {
+	const name = "_acme-challenge.example.com"
+	record := DNSRecord{Type: "TXT", Prefix: "_acme-challenge", Value: "token"}
+	resolvers := []string{
+		startTestResolver(t, name, "token"),
+		startTestResolver(t, name, "token", "other"),
+		startTestResolver(t, name, "stale"),
+	}
+	tests := []struct {
+		quorum  int
+		visible bool
+	}{
+		{quorum: 1, visible: true},
+		{quorum: 2, visible: true},
+		{quorum: 3, visible: false},
+		{quorum: 0, visible: false},
+	}
+	for _, test := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		checker := ResolverChecker{Resolvers: resolvers, Quorum: test.quorum, Interval: 20 * time.Millisecond}
+		err := checker.Wait(ctx, "example.com", record)
+		cancel()
+		if (err == nil) != test.visible {
+			t.Errorf("quorum %d: got %v, want visible: %v", test.quorum, err, test.visible)
+		}
+	}
+
+	if err := (ResolverChecker{Resolvers: resolvers}).Wait(context.Background(), "example.com", DNSRecord{Type: "TLSA", Value: "x"}); err == nil {
+		t.Error("got no error for a record type that cannot be resolved")
+	}
+}
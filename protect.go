@@ -0,0 +1,44 @@
+package strato
+
+// protectedRecords returns a function reporting whether a record may only be changed
+// by a forced client, either because it matches a filter set with WithProtection or
+// because it is annotated as protected
+func (c *StratoClient) protectedRecords() (func(DNSRecord) bool, error) {
+	annotations, err := c.Annotations()
+	if err != nil {
+		return nil, err
+	}
+	return func(record DNSRecord) bool {
+		if annotations[record].Protected {
+			return true
+		}
+		for _, filter := range c.protected {
+			if filter.Match(record) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// checkProtected fails with a ProtectedRecordError if diff removes protected records.
+// Modified records count as removed, their new values are not protected yet.
+func (c *StratoClient) checkProtected(diff ConfigDiff) error {
+	if c.force || (c.annotations == nil && len(c.protected) == 0) {
+		return nil
+	}
+	protected, err := c.protectedRecords()
+	if err != nil {
+		return err
+	}
+	var touched []DNSRecord
+	for _, record := range diff.Removed {
+		if protected(record) {
+			touched = append(touched, record)
+		}
+	}
+	if len(touched) > 0 {
+		return &ProtectedRecordError{Records: touched}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package strato
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// removeRecord returns an update of the DNS configuration without record
+func removeRecord(record DNSRecord) func(DNSConfig) (DNSConfig, error) {
+	return func(current DNSConfig) (DNSConfig, error) {
+		zone := NewZone(current)
+		zone.Remove(record)
+		return zone.Config(), nil
+	}
+}
+
+func TestProtectionFilters(t *testing.T) {
+	dkim := txtFormConfig.Records[2]
+	protection := WithProtection(RecordFilter{Type: "TXT", Prefix: "*._domainkey"})
+	portal := newTestPortal(t, RegionDE)
+
+	_, err := newTestClient(t, portal, protection).UpdateDNSConfiguration(removeRecord(dkim))
+	var protectedErr *ProtectedRecordError
+	if !errors.As(err, &protectedErr) || !errors.Is(err, ErrProtectedRecord) {
+		t.Fatalf("got %v, want a *ProtectedRecordError", err)
+	}
+	if want := []DNSRecord{dkim}; !reflect.DeepEqual(protectedErr.Records, want) {
+		t.Errorf("got protected records %v, want %v", protectedErr.Records, want)
+	}
+	if len(portal.txtForms) != 0 {
+		t.Fatalf("got %d submitted forms, want none", len(portal.txtForms))
+	}
+
+	// Records outside the filters can still be removed
+	if _, err := newTestClient(t, portal, protection).UpdateDNSConfiguration(removeRecord(txtFormConfig.Records[1])); err != nil {
+		t.Fatalf("got %v removing an unprotected record, want nil", err)
+	}
+
+	if _, err := newTestClient(t, portal, protection, WithForce()).UpdateDNSConfiguration(removeRecord(dkim)); err != nil {
+		t.Fatalf("got %v with force, want nil", err)
+	}
+	if NewZone(*portal.config).Contains(dkim) {
+		t.Error("the protected record was not removed with force")
+	}
+}
+
+func TestProtectedAnnotation(t *testing.T) {
+	www := txtFormConfig.Records[1]
+	store := NewFileAnnotationStore(filepath.Join(t.TempDir(), "annotations.json"))
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal, WithAnnotations(store, "alice"))
+	if err := client.Protect(www, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Modifying the record removes its old value
+	_, err := client.UpdateDNSConfiguration(func(current DNSConfig) (DNSConfig, error) {
+		zone := NewZone(current)
+		zone.Replace(DNSRecord{Type: "CNAME", Prefix: "www", Value: "example.net."}, func(record DNSRecord) bool {
+			return record == www
+		})
+		return zone.Config(), nil
+	})
+	var protectedErr *ProtectedRecordError
+	if !errors.As(err, &protectedErr) {
+		t.Fatalf("got %v, want a *ProtectedRecordError", err)
+	}
+	if want := []DNSRecord{www}; !reflect.DeepEqual(protectedErr.Records, want) {
+		t.Errorf("got protected records %v, want %v", protectedErr.Records, want)
+	}
+	if len(portal.txtForms) != 0 {
+		t.Fatalf("got %d submitted forms, want none", len(portal.txtForms))
+	}
+
+	forced := newTestClient(t, portal, WithAnnotations(store, "alice"), WithForce())
+	if _, err := forced.UpdateDNSConfiguration(removeRecord(www)); err != nil {
+		t.Fatalf("got %v with force, want nil", err)
+	}
+	annotations, err := store.List("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := annotations[www]; found {
+		t.Error("the annotation of the removed record was kept")
+	}
+}
@@ -0,0 +1,60 @@
+package strato
+
+import (
+	"strings"
+	"time"
+)
+
+// PruneOptions controls which ACME challenge records PruneChallengeRecords removes
+type PruneOptions struct {
+	// OlderThan is the minimum age of annotated records to be removed
+	OlderThan time.Duration
+	// IncludeUnannotated also removes challenge records without annotation whose
+	// value looks like a DNS-01 token, as their age is unknown
+	IncludeUnannotated bool
+	// DryRun only reports the records that would be removed
+	DryRun bool
+}
+
+// isChallengeToken reports whether value looks like a DNS-01 key authorization digest:
+// 43 characters of unpadded base64url
+func isChallengeToken(value string) bool {
+	if len(value) != 43 {
+		return false
+	}
+	return strings.Trim(value, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_") == ""
+}
+
+// PruneChallengeRecords removes stale ACME challenge records left behind by failed
+// issuance runs and returns them. Records annotated with a different owner than the
+// one configured via WithAnnotations are never touched, protected records only by
+// clients created with WithForce.
+func (c *StratoClient) PruneChallengeRecords(opts PruneOptions) ([]DNSRecord, error) {
+	annotations, err := c.Annotations()
+	if err != nil {
+		return nil, err
+	}
+	protected, err := c.protectedRecords()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var stale []DNSRecord
+	err = c.UpdateZone(func(zone *Zone) (bool, error) {
+		stale = zone.RemoveMatching(func(record DNSRecord) bool {
+			if !record.IsACMEChallenge() || (protected(record) && !c.force) {
+				return false
+			}
+			annotation, annotated := annotations[record]
+			if !annotated {
+				return opts.IncludeUnannotated && isChallengeToken(record.Value)
+			}
+			if c.annotationOwner != "" && annotation.Owner != "" && annotation.Owner != c.annotationOwner {
+				return false
+			}
+			return now.Sub(annotation.Created) >= opts.OlderThan
+		})
+		return len(stale) > 0 && !opts.DryRun, nil
+	})
+	return stale, err
+}
@@ -0,0 +1,167 @@
+package strato
+
+import (
+	"sort"
+	"strings"
+)
+
+// Publication states of a PublishedRecord
+const (
+	// PublishOK means every authoritative nameserver answers with the record
+	PublishOK = "ok"
+	// PublishMissing means no authoritative nameserver answers with the record
+	PublishMissing = "missing"
+	// PublishPartial means some authoritative nameservers lack the record
+	PublishPartial = "partial"
+	// PublishUnexpected means DNS answers with a value the portal does not list
+	PublishUnexpected = "unexpected"
+	// PublishUnknown means the nameservers could not be asked for the record
+	PublishUnknown = "unknown"
+)
+
+// PublishedRecord compares one record of the portal with the authoritative DNS answers
+type PublishedRecord struct {
+	Record DNSRecord `json:"record"`
+	Status string    `json:"status"`
+	// Servers are the nameservers lacking the record, or for PublishUnexpected
+	// the ones answering with it
+	Servers []string `json:"servers,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// PublishReport is the result of VerifyPublished
+type PublishReport struct {
+	Domain      string            `json:"domain"`
+	Nameservers []string          `json:"nameservers"`
+	Results     []PublishedRecord `json:"results"`
+}
+
+// Mismatched returns the results whose DNS answers differ from the portal
+func (r PublishReport) Mismatched() []PublishedRecord {
+	var mismatched []PublishedRecord
+	for _, result := range r.Results {
+		if result.Status != PublishOK {
+			mismatched = append(mismatched, result)
+		}
+	}
+	return mismatched
+}
+
+// VerifyPublished compares the configuration in the portal with the answers of the
+// authoritative nameservers of the domain, so records Strato accepted in the form
+// but never published are found. Only names and types that have records in the
+// portal are asked for, records generated by the SPF and DMARC settings are ignored.
+func (c *StratoClient) VerifyPublished() (PublishReport, error) {
+	config, err := c.GetDNSConfiguration(ForceRefresh())
+	if err != nil {
+		return PublishReport{}, err
+	}
+	ctx := c.requestContext()
+	nameservers, err := authoritativeServers(ctx, c.domain)
+	if err != nil {
+		return PublishReport{}, err
+	}
+	report := PublishReport{Domain: c.domain, Nameservers: nameservers}
+
+	type rrset struct{ prefix, recordType string }
+	groups := map[rrset][]DNSRecord{}
+	var order []rrset
+	for _, record := range config.Records {
+		key := rrset{strings.ToLower(record.Prefix), strings.ToUpper(record.Type)}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], record)
+	}
+
+	for _, key := range order {
+		records := groups[key]
+		qtype, ok := queryTypes[key.recordType]
+		if !ok {
+			for _, record := range records {
+				report.Results = append(report.Results, PublishedRecord{Record: record, Status: PublishUnknown, Error: "cannot resolve " + record.Type + " records"})
+			}
+			continue
+		}
+		name := recordName(key.prefix, c.domain)
+		answers := map[string][]string{}
+		errs := map[string]string{}
+		for _, server := range nameservers {
+			values, _, err := queryDNS(ctx, server, name, qtype, false)
+			if err != nil {
+				errs[server] = err.Error()
+				continue
+			}
+			answers[server] = values
+		}
+		for _, record := range records {
+			result := PublishedRecord{Record: record}
+			for _, server := range nameservers {
+				if values, ok := answers[server]; ok && !containsValue(record.Type, values, record.Value) {
+					result.Servers = append(result.Servers, server)
+				}
+			}
+			switch {
+			case len(answers) == 0:
+				result.Status = PublishUnknown
+				result.Error = errs[nameservers[0]]
+			case len(result.Servers) == len(answers):
+				result.Status = PublishMissing
+			case len(result.Servers) > 0:
+				result.Status = PublishPartial
+			default:
+				result.Status = PublishOK
+			}
+			report.Results = append(report.Results, result)
+		}
+		report.Results = append(report.Results, unexpectedRecords(config, records, answers)...)
+	}
+	logFor(LogScrape).Debug("Verified published records", "domain", c.domain, "records", len(config.Records), "mismatched", len(report.Mismatched()))
+	return report, nil
+}
+
+// unexpectedRecords returns the values nameservers answer with for the name and type
+// of records that are neither in records nor generated by the mail settings of config
+func unexpectedRecords(config DNSConfig, records []DNSRecord, answers map[string][]string) []PublishedRecord {
+	servers := map[string][]string{}
+	for server, values := range answers {
+		for _, value := range values {
+			if containsValue(records[0].Type, recordValues(records), value) || generatedRecord(config, records[0].Type, records[0].Prefix, value) {
+				continue
+			}
+			servers[value] = append(servers[value], server)
+		}
+	}
+	var unexpected []PublishedRecord
+	for value, found := range servers {
+		sort.Strings(found)
+		record := DNSRecord{Type: records[0].Type, Prefix: records[0].Prefix, Value: value}
+		unexpected = append(unexpected, PublishedRecord{Record: record, Status: PublishUnexpected, Servers: found})
+	}
+	sort.Slice(unexpected, func(i, j int) bool { return unexpected[i].Record.Value < unexpected[j].Record.Value })
+	return unexpected
+}
+
+// recordValues returns the values of records
+func recordValues(records []DNSRecord) []string {
+	values := make([]string, len(records))
+	for i, record := range records {
+		values[i] = record.Value
+	}
+	return values
+}
+
+// generatedRecord reports whether Strato publishes the value itself because of the
+// SPF or DMARC setting of config
+func generatedRecord(config DNSConfig, recordType, prefix, value string) bool {
+	if !strings.EqualFold(recordType, "TXT") {
+		return false
+	}
+	switch {
+	case prefix == "" && config.SPFType == SPFTypeStrato:
+		return strings.HasPrefix(value, "v=spf1")
+	case strings.EqualFold(prefix, "_dmarc") && config.DMARCType == DMARCTypeStrato:
+		return strings.HasPrefix(value, "v=DMARC1")
+	}
+	return false
+}
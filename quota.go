@@ -0,0 +1,28 @@
+package strato
+
+import "strconv"
+
+// maxRecords parses the record limit shown on the TXT record page, 0 if there is none
+func maxRecords(value string) int {
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// checkQuota fails with a RecordQuotaError if config has more records than allowed by
+// WithRecordQuota or, without it, the limit the portal showed for config or previous
+func (c *StratoClient) checkQuota(config, previous DNSConfig) error {
+	limit := c.recordQuota
+	if limit == 0 {
+		limit = config.MaxRecords
+	}
+	if limit == 0 {
+		limit = previous.MaxRecords
+	}
+	if limit > 0 && len(config.Records) > limit {
+		return &RecordQuotaError{Limit: limit, Records: len(config.Records)}
+	}
+	return nil
+}
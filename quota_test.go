@@ -0,0 +1,96 @@
+package strato
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQuotaBeforePost(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal)
+
+	// txt_form.html allows 50 records and shows 4
+	_, err := client.UpdateDNSConfiguration(func(current DNSConfig) (DNSConfig, error) {
+		for range 47 {
+			current.Records = append(current.Records, DNSRecord{Type: "TXT", Prefix: "_acme-challenge", Value: "token"})
+		}
+		return current, nil
+	})
+	var quotaErr *RecordQuotaError
+	if !errors.As(err, &quotaErr) || !errors.Is(err, ErrRecordQuotaExceeded) {
+		t.Fatalf("got %v, want a *RecordQuotaError", err)
+	}
+	if quotaErr.Limit != 50 || quotaErr.Records != 51 {
+		t.Errorf("got limit %d for %d records, want 50 for 51", quotaErr.Limit, quotaErr.Records)
+	}
+	if len(portal.txtForms) != 0 {
+		t.Errorf("got %d submitted forms, want none", len(portal.txtForms))
+	}
+
+	_, err = client.UpdateDNSConfiguration(func(current DNSConfig) (DNSConfig, error) {
+		for range 46 {
+			current.Records = append(current.Records, DNSRecord{Type: "TXT", Prefix: "_acme-challenge", Value: "token"})
+		}
+		return current, nil
+	})
+	if err != nil {
+		t.Errorf("got %v for 50 records, want nil", err)
+	}
+}
+
+func TestWithRecordQuota(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal, WithRecordQuota(2))
+
+	err := client.SetDNSConfiguration(DNSConfig{Records: []DNSRecord{
+		{Type: "TXT", Value: "v=spf1 -all"},
+		{Type: "CNAME", Prefix: "www", Value: "example.com."},
+		{Type: "MX", Value: "10 mx.example.net."},
+	}})
+	var quotaErr *RecordQuotaError
+	if !errors.As(err, &quotaErr) || quotaErr.Limit != 2 || quotaErr.Records != 3 {
+		t.Fatalf("got %v, want a *RecordQuotaError for 3 of 2 records", err)
+	}
+	if len(portal.txtForms) != 0 {
+		t.Errorf("got %d submitted forms, want none", len(portal.txtForms))
+	}
+}
+
+func TestSetDNSConfigurationRejectedOverQuota(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	portal.rejectPage = "txt_form_rejected.html"
+	client := newTestClient(t, portal)
+
+	// Without the limit of the page, the records are only rejected by the portal
+	config := DNSConfig{}
+	for range 51 {
+		config.Records = append(config.Records, DNSRecord{Type: "TXT", Value: "v=spf1 -all"})
+	}
+	err := client.SetDNSConfiguration(config)
+	var quotaErr *RecordQuotaError
+	if !errors.As(err, &quotaErr) || !errors.Is(err, ErrRecordQuotaExceeded) {
+		t.Fatalf("got %v, want a *RecordQuotaError", err)
+	}
+	if quotaErr.Limit != 50 || quotaErr.Records != 51 {
+		t.Errorf("got limit %d for %d records, want 50 for 51", quotaErr.Limit, quotaErr.Records)
+	}
+}
+
+func TestQuotaLimit(t *testing.T) {
+	tests := []struct {
+		message string
+		lang    Language
+		want    int
+	}{
+		{"Sie können maximal 50 Einträge anlegen.", LanguageGerman, 50},
+		{"You can create a maximum of 30 records.", LanguageEnglish, 30},
+		// The message is recognized whatever language the page claims
+		{"U kunt maximaal 20 vermeldingen aanmaken.", LanguageGerman, 20},
+		{"Ihre Eingaben konnten nicht übernommen werden.", LanguageGerman, 0},
+	}
+	for _, test := range tests {
+		if got := quotaLimit(test.message, test.lang); got != test.want {
+			t.Errorf("quotaLimit(%q) = %d, want %d", test.message, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package strato
+
+import "fmt"
+
+// checkWrite reports whether a mutating operation may go ahead. A read-only client
+// refuses it with ErrReadOnly, unless it is in dry-run mode, where the operation is
+// skipped without an error.
+func (c *StratoClient) checkWrite(operation string) (bool, error) {
+	if !c.readOnly {
+		return true, nil
+	}
+	if c.dryRun {
+		logFor(LogForm).Info("Skipping write in dry-run mode", "operation", operation, "domain", c.domain)
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: refusing to %s", ErrReadOnly, operation)
+}
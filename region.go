@@ -0,0 +1,142 @@
+package strato
+
+import (
+	"errors"
+	"strings"
+)
+
+// Region holds the country specific details of a Strato customer portal
+type Region struct {
+	Name string
+	// API is the default CustomerService URL of the portal
+	API string
+
+	// Login form field names. LoginAction is the name and caption of the submit
+	// button, separated by "=".
+	IdentifierField string
+	PasswordField   string
+	LoginAction     string
+
+	// Node names used in portal URLs
+	EntryNode         string
+	ManageDomainsNode string
+	AccountNode       string
+	MailNode          string
+	SSLNode           string
+	DomainSearchNode  string
+	PackageNode       string
+	FTPNode           string
+	DatabaseNode      string
+	CronNode          string
+	WebspaceNode      string
+	StorageNode       string
+	InvoiceNode       string
+	StatisticsNode    string
+
+	// ApplyLabel is the caption of the submit button of the TXT record form
+	ApplyLabel string
+}
+
+var (
+	RegionDE = Region{
+		Name:              "de",
+		API:               "https://www.strato.de/apps/CustomerService",
+		IdentifierField:   "identifier",
+		PasswordField:     "passwd",
+		LoginAction:       "action_customer_login.x=Login",
+		EntryNode:         "kds_CustomerEntryPage",
+		ManageDomainsNode: "ManageDomains",
+		AccountNode:       "kds_CustomerData",
+		MailNode:          "ManageMail",
+		SSLNode:           "ManageSSL",
+		DomainSearchNode:  "kds_DomainSearch",
+		PackageNode:       "kds_PackageOverview",
+		FTPNode:           "ManageFTP",
+		DatabaseNode:      "ManageDatabases",
+		CronNode:          "ManageCronjobs",
+		WebspaceNode:      "ManageWebspace",
+		StorageNode:       "kds_HiDrive",
+		InvoiceNode:       "kds_Invoices",
+		StatisticsNode:    "ManageStatistics",
+		ApplyLabel:        "Einstellung übernehmen",
+	}
+	RegionNL = Region{
+		Name:              "nl",
+		API:               "https://www.strato.nl/apps/CustomerService",
+		IdentifierField:   "identifier",
+		PasswordField:     "passwd",
+		LoginAction:       "action_customer_login.x=Inloggen",
+		EntryNode:         "kds_CustomerEntryPage",
+		ManageDomainsNode: "ManageDomains",
+		AccountNode:       "kds_CustomerData",
+		MailNode:          "ManageMail",
+		SSLNode:           "ManageSSL",
+		DomainSearchNode:  "kds_DomainSearch",
+		PackageNode:       "kds_PackageOverview",
+		FTPNode:           "ManageFTP",
+		DatabaseNode:      "ManageDatabases",
+		CronNode:          "ManageCronjobs",
+		WebspaceNode:      "ManageWebspace",
+		StorageNode:       "kds_HiDrive",
+		InvoiceNode:       "kds_Invoices",
+		StatisticsNode:    "ManageStatistics",
+		ApplyLabel:        "Instelling overnemen",
+	}
+	RegionSE = Region{
+		Name:              "se",
+		API:               "https://www.strato.se/apps/CustomerService",
+		IdentifierField:   "identifier",
+		PasswordField:     "passwd",
+		LoginAction:       "action_customer_login.x=Logga in",
+		EntryNode:         "kds_CustomerEntryPage",
+		ManageDomainsNode: "ManageDomains",
+		AccountNode:       "kds_CustomerData",
+		MailNode:          "ManageMail",
+		SSLNode:           "ManageSSL",
+		DomainSearchNode:  "kds_DomainSearch",
+		PackageNode:       "kds_PackageOverview",
+		FTPNode:           "ManageFTP",
+		DatabaseNode:      "ManageDatabases",
+		CronNode:          "ManageCronjobs",
+		WebspaceNode:      "ManageWebspace",
+		StorageNode:       "kds_HiDrive",
+		InvoiceNode:       "kds_Invoices",
+		StatisticsNode:    "ManageStatistics",
+		ApplyLabel:        "Spara inställningar",
+	}
+	RegionUK = Region{
+		Name:              "uk",
+		API:               "https://www.strato-hosting.co.uk/apps/CustomerService",
+		IdentifierField:   "identifier",
+		PasswordField:     "passwd",
+		LoginAction:       "action_customer_login.x=Login",
+		EntryNode:         "kds_CustomerEntryPage",
+		ManageDomainsNode: "ManageDomains",
+		AccountNode:       "kds_CustomerData",
+		MailNode:          "ManageMail",
+		SSLNode:           "ManageSSL",
+		DomainSearchNode:  "kds_DomainSearch",
+		PackageNode:       "kds_PackageOverview",
+		FTPNode:           "ManageFTP",
+		DatabaseNode:      "ManageDatabases",
+		CronNode:          "ManageCronjobs",
+		WebspaceNode:      "ManageWebspace",
+		StorageNode:       "kds_HiDrive",
+		InvoiceNode:       "kds_Invoices",
+		StatisticsNode:    "ManageStatistics",
+		ApplyLabel:        "Apply setting",
+	}
+)
+
+// Regions lists all known portal variants
+var Regions = []Region{RegionDE, RegionNL, RegionSE, RegionUK}
+
+// RegionByName returns the region with the given name (e.g. "de", "nl")
+func RegionByName(name string) (Region, error) {
+	for _, region := range Regions {
+		if strings.EqualFold(region.Name, name) {
+			return region, nil
+		}
+	}
+	return Region{}, errors.New("unknown region: " + name)
+}
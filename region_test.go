@@ -0,0 +1,110 @@
+package strato
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegionByName(t *testing.T) {
+	tests := []struct {
+		name   string
+		region Region
+		err    bool
+	}{
+		{name: "de", region: RegionDE},
+		{name: "NL", region: RegionNL},
+		{name: "se", region: RegionSE},
+		{name: "uk", region: RegionUK},
+		{name: "at", err: true},
+		{name: "", err: true},
+	}
+	for _, test := range tests {
+		region, err := RegionByName(test.name)
+		if (err != nil) != test.err {
+			t.Errorf("RegionByName(%q): got error %v, want error: %v", test.name, err, test.err)
+		}
+		if !reflect.DeepEqual(region, test.region) {
+			t.Errorf("RegionByName(%q) = %+v, want %+v", test.name, region, test.region)
+		}
+	}
+}
+
+// TestRegionForms logs in to the portal of every region and submits its TXT record
+// form with the field names and labels of the region
+func TestRegionForms(t *testing.T) {
+	for _, region := range Regions {
+		t.Run(region.Name, func(t *testing.T) {
+			portal := newTestPortal(t, region)
+			client := newTestClient(t, portal)
+
+			login := portal.logins[0]
+			if login.Get(region.IdentifierField) != testIdentifier || login.Get(region.PasswordField) != testPassword {
+				t.Errorf("login form %v lacks the credentials in %s and %s", login, region.IdentifierField, region.PasswordField)
+			}
+			action, label, _ := strings.Cut(region.LoginAction, "=")
+			if login.Get(action) != label {
+				t.Errorf("got login action %s=%q, want %q", action, login.Get(action), label)
+			}
+
+			if _, err := client.UpdateDNSConfiguration(func(current DNSConfig) (DNSConfig, error) {
+				current.Records = append(current.Records, acmeRecord)
+				return current, nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			form, query := portal.lastTXTForm()
+			if got := form.Get("action_change_txt_records"); got != region.ApplyLabel {
+				t.Errorf("submitted with %q, want %q", got, region.ApplyLabel)
+			}
+			if got := form.Get("node"); got != region.ManageDomainsNode {
+				t.Errorf("submitted to node %q, want %q", got, region.ManageDomainsNode)
+			}
+			if !query.Has("action_change_txt_records") {
+				t.Errorf("submitted to %v without the form action", query)
+			}
+		})
+	}
+}
+
+func TestLanguageOverridesRegionLabel(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal, WithLanguage(LanguageEnglish))
+	if err := client.SetDNSConfiguration(txtFormConfig); err != nil {
+		t.Fatal(err)
+	}
+	form, _ := portal.lastTXTForm()
+	if got, want := form.Get("action_change_txt_records"), RegionUK.ApplyLabel; got != want {
+		t.Errorf("submitted with %q, want %q", got, want)
+	}
+}
+
+// TestRegionFixtures reads the localized pages of the portals of other countries
+func TestRegionFixtures(t *testing.T) {
+	for _, region := range []Region{RegionNL, RegionSE, RegionUK} {
+		t.Run(region.Name, func(t *testing.T) {
+			portal := newTestPortal(t, region)
+			portal.setPage(txtFormNode, region.Name+"/txt_form.html")
+			client := newTestClient(t, portal)
+			config, err := client.GetDNSConfiguration()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := append([]DNSRecord(nil), txtFormConfig.Records...)
+			SortRecords(want)
+			if !reflect.DeepEqual(config.Records, want) || config.DMARCType != txtFormConfig.DMARCType || config.SPFType != txtFormConfig.SPFType {
+				t.Errorf("got %+v, want the configuration of txt_form.html", config)
+			}
+
+			page := fixture(t, region.Name+"/login_locked.html")
+			err = loginError(&http.Response{Body: io.NopCloser(bytes.NewReader(page))})
+			if !errors.Is(err, ErrAccountLocked) {
+				t.Errorf("got %v, want %v", err, ErrAccountLocked)
+			}
+		})
+	}
+}
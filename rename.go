@@ -0,0 +1,54 @@
+package strato
+
+import (
+	"errors"
+	"strings"
+)
+
+// RenamePrefix moves the records named from, and those below it, to the name to, e.g.
+// "_dmarc.old" becomes "_dmarc.new" when renaming "old" to "new". Only records of
+// recordType are moved unless it is empty. "" or "@" stand for the domain itself, whose
+// records are moved without those of subdomains. The changes are returned.
+func (z *Zone) RenamePrefix(from, to, recordType string) ConfigDiff {
+	from, to = apexPrefix(from), apexPrefix(to)
+	before := z.Config()
+	moved := z.RemoveMatching(func(record DNSRecord) bool {
+		if recordType != "" && !strings.EqualFold(record.Type, recordType) {
+			return false
+		}
+		return record.Prefix == from || (from != "" && strings.HasSuffix(record.Prefix, "."+from))
+	})
+	for _, record := range moved {
+		record.Prefix = strings.TrimSuffix(strings.TrimSuffix(record.Prefix, from), ".")
+		switch {
+		case record.Prefix == "":
+			record.Prefix = to
+		case to != "":
+			record.Prefix += "." + to
+		}
+		z.Add(record)
+	}
+	return DiffConfigs(before, z.Config())
+}
+
+// RenamePrefix renames the records like Zone.RenamePrefix in a single update of the
+// configuration and returns the changes
+func (c *StratoClient) RenamePrefix(from, to, recordType string) (ConfigDiff, error) {
+	if apexPrefix(from) == apexPrefix(to) {
+		return ConfigDiff{}, errors.New("cannot rename a prefix to itself")
+	}
+	var diff ConfigDiff
+	err := c.UpdateZone(func(zone *Zone) (bool, error) {
+		diff = zone.RenamePrefix(from, to, recordType)
+		return !diff.Empty(), nil
+	})
+	return diff, err
+}
+
+// apexPrefix maps the "@" shorthand for the domain itself to the empty prefix
+func apexPrefix(prefix string) string {
+	if prefix == "@" {
+		return ""
+	}
+	return prefix
+}
@@ -0,0 +1,83 @@
+package strato
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenamePrefix(t *testing.T) {
+	records := []DNSRecord{
+		{Type: "TXT", Prefix: "", Value: "v=spf1 -all"},
+		{Type: "MX", Prefix: "", Value: "10 mx.example.net."},
+		{Type: "TXT", Prefix: "old", Value: "v=spf1 mx -all"},
+		{Type: "TXT", Prefix: "_dmarc.old", Value: "v=DMARC1; p=none"},
+		{Type: "CNAME", Prefix: "www.old", Value: "old.example.com."},
+		{Type: "TXT", Prefix: "bold", Value: "unrelated"},
+		{Type: "TXT", Prefix: "old.other", Value: "unrelated"},
+	}
+	// renamed maps the old prefixes of the records of recordType to the new ones
+	tests := []struct {
+		name       string
+		from, to   string
+		recordType string
+		renamed    map[string]string
+	}{
+		{
+			name: "subdomain",
+			from: "old", to: "new",
+			renamed: map[string]string{"old": "new", "_dmarc.old": "_dmarc.new", "www.old": "www.new"},
+		},
+		{
+			name: "only one type",
+			from: "old", to: "new", recordType: "txt",
+			renamed: map[string]string{"old": "new", "_dmarc.old": "_dmarc.new"},
+		},
+		{
+			name: "to a deeper name",
+			from: "old", to: "a.new",
+			renamed: map[string]string{"old": "a.new", "_dmarc.old": "_dmarc.a.new", "www.old": "www.a.new"},
+		},
+		{
+			name: "domain to subdomain",
+			from: "@", to: "x",
+			renamed: map[string]string{"": "x"},
+		},
+		{
+			name: "empty prefix for the domain",
+			from: "", to: "x", recordType: "MX",
+			renamed: map[string]string{"": "x"},
+		},
+		{
+			name: "subdomain to domain",
+			from: "old", to: "@",
+			renamed: map[string]string{"old": "", "_dmarc.old": "_dmarc", "www.old": "www"},
+		},
+		{
+			name: "unknown name",
+			from: "missing", to: "new",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			zone := NewZone(DNSConfig{Records: records})
+			diff := zone.RenamePrefix(test.from, test.to, test.recordType)
+
+			want := DNSConfig{}
+			moved := 0
+			for _, record := range records {
+				prefix, ok := test.renamed[record.Prefix]
+				if ok && (test.recordType == "" || strings.EqualFold(record.Type, test.recordType)) {
+					record.Prefix = prefix
+					moved++
+				}
+				want.Records = append(want.Records, record)
+			}
+			if got := DiffConfigs(want, zone.Config()); !got.Empty() {
+				t.Errorf("got zone differing from the expected one by\n%s", got)
+			}
+			if len(diff.Removed) != moved || len(diff.Added) != moved {
+				t.Errorf("got diff\n%s\nwant %d records moved", diff, moved)
+			}
+		})
+	}
+}
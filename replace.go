@@ -0,0 +1,85 @@
+package strato
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ReplaceValues substitutes with for every occurrence of match in the values of the
+// records, only of recordType unless it is empty, and returns the changes. An
+// occurrence directly next to a letter or digit is kept, so replacing 1.2.3.4 leaves
+// 11.2.3.45 alone but changes "ip4:1.2.3.4" in an SPF record.
+func (z *Zone) ReplaceValues(match, with, recordType string) ConfigDiff {
+	before := z.Config()
+	var replaced []DNSRecord
+	z.RemoveMatching(func(record DNSRecord) bool {
+		if recordType != "" && !strings.EqualFold(record.Type, recordType) {
+			return false
+		}
+		value := replaceBounded(record.Value, match, with)
+		if value == record.Value {
+			return false
+		}
+		record.Value = value
+		replaced = append(replaced, record)
+		return true
+	})
+	for _, record := range replaced {
+		z.Add(record)
+	}
+	return DiffConfigs(before, z.Config())
+}
+
+// ReplaceValues replaces values like Zone.ReplaceValues in a single update of the
+// configuration and returns the changes
+func (c *StratoClient) ReplaceValues(match, with, recordType string) (ConfigDiff, error) {
+	if match == "" {
+		return ConfigDiff{}, errors.New("cannot replace an empty value")
+	}
+	var diff ConfigDiff
+	err := c.UpdateZone(func(zone *Zone) (bool, error) {
+		diff = zone.ReplaceValues(match, with, recordType)
+		return !diff.Empty(), nil
+	})
+	return diff, err
+}
+
+// replaceBounded replaces the occurrences of match in s that are not part of a longer
+// word or number
+func replaceBounded(s, match, with string) string {
+	if match == "" {
+		return s
+	}
+	first, _ := utf8.DecodeRuneInString(match)
+	last, _ := utf8.DecodeLastRuneInString(match)
+	var b strings.Builder
+	// written is the end of the part of s already copied to b, from where to search
+	written, from := 0, 0
+	for {
+		i := strings.Index(s[from:], match)
+		if i < 0 {
+			b.WriteString(s[written:])
+			return b.String()
+		}
+		i += from
+		end := i + len(match)
+		// The neighbours are looked up in s, not in what is left of it, so a skipped
+		// occurrence still counts as the neighbour of the next one
+		before, _ := utf8.DecodeLastRuneInString(s[:i])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if (isWordRune(first) && isWordRune(before)) || (isWordRune(last) && isWordRune(after)) {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			from = i + size
+			continue
+		}
+		b.WriteString(s[written:i])
+		b.WriteString(with)
+		written, from = end, end
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,47 @@
+package strato
+
+import "testing"
+
+func TestReplaceBounded(t *testing.T) {
+	tests := []struct {
+		s, match, with string
+		want           string
+	}{
+		{"1.2.3.4", "1.2.3.4", "5.6.7.8", "5.6.7.8"},
+		{"v=spf1 ip4:1.2.3.4 -all", "1.2.3.4", "5.6.7.8", "v=spf1 ip4:5.6.7.8 -all"},
+		{"11.2.3.4", "1.2.3.4", "5.6.7.8", "11.2.3.4"},
+		{"1.2.3.45", "1.2.3.4", "5.6.7.8", "1.2.3.45"},
+		{"11.2.3.4 1.2.3.4", "1.2.3.4", "5.6.7.8", "11.2.3.4 5.6.7.8"},
+		{"1.2.3.4,1.2.3.4", "1.2.3.4", "5.6.7.8", "5.6.7.8,5.6.7.8"},
+		{"10 mail.example.com.", "example.com", "example.net", "10 mail.example.net."},
+		{"10 mail.myexample.com.", "example.com", "example.net", "10 mail.myexample.com."},
+		{"include:_spf.example.com", "_spf.example.com", "_spf.example.net", "include:_spf.example.net"},
+		// A match starting or ending with punctuation has no boundary on that side
+		{"a.example.com", ".example.com", ".example.net", "a.example.net"},
+		{"2001:db8::1", "2001:db8::", "2001:db9::", "2001:db9::1"},
+		{"grüße.example", "grüße", "gruesse", "gruesse.example"},
+		{"begrüße", "grüße", "gruesse", "begrüße"},
+		{"unchanged", "", "x", "unchanged"},
+		{"aaa", "aa", "b", "aaa"},
+	}
+	for _, test := range tests {
+		if got := replaceBounded(test.s, test.match, test.with); got != test.want {
+			t.Errorf("replaceBounded(%q, %q, %q) = %q, want %q", test.s, test.match, test.with, got, test.want)
+		}
+	}
+}
+
+func TestReplaceValues(t *testing.T) {
+	zone := NewZone(DNSConfig{Records: []DNSRecord{
+		{Type: "TXT", Prefix: "", Value: "v=spf1 ip4:192.0.2.1 -all"},
+		{Type: "TXT", Prefix: "legacy", Value: "v=spf1 ip4:192.0.2.10 -all"},
+		{Type: "CNAME", Prefix: "www", Value: "192.0.2.1.example.net."},
+	}})
+	diff := zone.ReplaceValues("192.0.2.1", "198.51.100.1", "txt")
+	if len(diff.Removed) != 1 || len(diff.Added) != 1 || diff.Added[0].Value != "v=spf1 ip4:198.51.100.1 -all" {
+		t.Errorf("got diff\n%s\nwant only the SPF record of the domain changed", diff)
+	}
+	if diff := zone.ReplaceValues("192.0.2.1", "198.51.100.1", "txt"); !diff.Empty() {
+		t.Errorf("repeated replacement changed\n%s", diff)
+	}
+}
@@ -0,0 +1,335 @@
+package strato
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DefaultResolvers are the public resolvers ResolveEffective asks besides the
+// authoritative nameservers of the domain
+var DefaultResolvers = []string{"1.1.1.1", "8.8.8.8", "9.9.9.9"}
+
+// dnsQueryTimeout bounds a single query to a nameserver
+const dnsQueryTimeout = 5 * time.Second
+
+// Propagation states of an EffectiveRecord
+const (
+	// PropagationPublished means every nameserver and resolver answers with the record
+	PropagationPublished = "published"
+	// PropagationPending means the authoritative nameservers have the record, but
+	// resolvers still answer from their cache
+	PropagationPending = "propagating"
+	// PropagationMissing means the authoritative nameservers do not have the record
+	PropagationMissing = "missing"
+	// PropagationUnknown means no nameserver could be asked
+	PropagationUnknown = "unknown"
+)
+
+// ResolverAnswer is what one nameserver answered for the name and type of a record
+type ResolverAnswer struct {
+	Server        string `json:"server"`
+	Authoritative bool   `json:"authoritative"`
+	// Values are the answers in the notation of DNSRecord.Value
+	Values []string      `json:"values,omitempty"`
+	TTL    time.Duration `json:"ttl"`
+	// Found reports whether the value of the record is among Values
+	Found bool   `json:"found"`
+	Error string `json:"error,omitempty"`
+}
+
+// EffectiveRecord is a record as it is seen in DNS. Strato does not let customers set
+// TTLs, so TTL is the only way to learn how long changes take to propagate.
+type EffectiveRecord struct {
+	Record DNSRecord `json:"record"`
+	// Name is the fully qualified name of the record
+	Name string `json:"name"`
+	// TTL is handed out by the authoritative nameservers, zero if they lack the record
+	TTL     time.Duration    `json:"ttl"`
+	Answers []ResolverAnswer `json:"answers"`
+}
+
+// Propagation summarizes the answers as one of the Propagation* states
+func (e EffectiveRecord) Propagation() string {
+	state := PropagationUnknown
+	for _, answer := range e.Answers {
+		if answer.Error != "" {
+			continue
+		}
+		switch {
+		case answer.Authoritative && !answer.Found:
+			return PropagationMissing
+		case !answer.Found:
+			state = PropagationPending
+		case state == PropagationUnknown:
+			state = PropagationPublished
+		}
+	}
+	return state
+}
+
+// WithResolvers replaces DefaultResolvers for ResolveEffective. Servers are given as
+// host or host:port.
+func WithResolvers(servers ...string) Option {
+	return func(c *StratoClient) {
+		c.resolvers = servers
+	}
+}
+
+// ResolveEffective asks the authoritative nameservers of the domain and public
+// resolvers for record and reports its TTL and how far it has propagated
+func (c *StratoClient) ResolveEffective(record DNSRecord) (EffectiveRecord, error) {
+	ctx := c.requestContext()
+	qtype, ok := queryTypes[strings.ToUpper(record.Type)]
+	if !ok {
+		return EffectiveRecord{}, fmt.Errorf("cannot resolve %s records", record.Type)
+	}
+	nameservers, err := authoritativeServers(ctx, c.domain)
+	if err != nil {
+		return EffectiveRecord{}, err
+	}
+	resolvers := c.resolvers
+	if resolvers == nil {
+		resolvers = DefaultResolvers
+	}
+	effective := EffectiveRecord{Record: record, Name: recordName(record.Prefix, c.domain)}
+	ask := func(server string, authoritative bool) {
+		answer := ResolverAnswer{Server: server, Authoritative: authoritative}
+		values, ttl, err := queryDNS(ctx, server, effective.Name, qtype, !authoritative)
+		if err != nil {
+			answer.Error = err.Error()
+		} else {
+			answer.Values = values
+			answer.TTL = ttl
+			answer.Found = containsValue(record.Type, values, record.Value)
+		}
+		if authoritative && answer.Found && answer.TTL > effective.TTL {
+			effective.TTL = answer.TTL
+		}
+		effective.Answers = append(effective.Answers, answer)
+	}
+	for _, server := range nameservers {
+		ask(server, true)
+	}
+	for _, server := range resolvers {
+		ask(server, false)
+	}
+	logFor(LogScrape).Debug("Resolved record", "name", effective.Name, "type", record.Type, "propagation", effective.Propagation())
+	return effective, nil
+}
+
+// queryTypes maps the record types Strato manages to their DNS query types
+var queryTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"SRV":   dnsmessage.TypeSRV,
+	"TXT":   dnsmessage.TypeTXT,
+}
+
+// recordName returns the fully qualified name of a record with prefix below domain
+func recordName(prefix, domain string) string {
+	domain = strings.TrimSuffix(domain, ".")
+	if prefix == "" || prefix == "@" {
+		return domain + "."
+	}
+	return prefix + "." + domain + "."
+}
+
+// authoritativeServers returns the nameservers of the zone containing domain, walking
+// up the labels until a name with NS records is found
+func authoritativeServers(ctx context.Context, domain string) ([]string, error) {
+	name := strings.TrimSuffix(domain, ".")
+	for strings.Contains(name, ".") {
+		records, err := net.DefaultResolver.LookupNS(ctx, name)
+		if err == nil && len(records) > 0 {
+			servers := make([]string, 0, len(records))
+			for _, record := range records {
+				servers = append(servers, strings.TrimSuffix(record.Host, "."))
+			}
+			return servers, nil
+		}
+		var dnsErr *net.DNSError
+		if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+			return nil, fmt.Errorf("failed to look up nameservers of %s: %w", name, err)
+		}
+		_, name, _ = strings.Cut(name, ".")
+	}
+	return nil, fmt.Errorf("no nameservers found for %s", domain)
+}
+
+// queryDNS sends a query for name and qtype to server and returns the answers of that
+// type in the notation of DNSRecord.Value along with their smallest TTL. Responses
+// truncated over UDP are repeated over TCP.
+func queryDNS(ctx context.Context, server, name string, qtype dnsmessage.Type, recursive bool) ([]string, time.Duration, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	ctx, cancel := context.WithTimeout(ctx, dnsQueryTimeout)
+	defer cancel()
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	id := uint16(rand.Uint32())
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: recursive})
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		return nil, 0, err
+	}
+	if err := builder.Question(dnsmessage.Question{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, 0, err
+	}
+	if err := builder.StartAdditionals(); err != nil {
+		return nil, 0, err
+	}
+	// EDNS0 lets long TXT records such as DKIM keys fit into one UDP response
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, 0, err
+	}
+	if err := builder.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, 0, err
+	}
+	query, err := builder.Finish()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response, err := exchangeDNS(ctx, "udp", server, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(response); err != nil {
+		return nil, 0, fmt.Errorf("invalid response from %s: %w", server, err)
+	}
+	if msg.Truncated {
+		if response, err = exchangeDNS(ctx, "tcp", server, query); err != nil {
+			return nil, 0, err
+		}
+		if err := msg.Unpack(response); err != nil {
+			return nil, 0, fmt.Errorf("invalid response from %s: %w", server, err)
+		}
+	}
+	if msg.ID != id {
+		return nil, 0, fmt.Errorf("response from %s does not match the query", server)
+	}
+	if msg.RCode != dnsmessage.RCodeSuccess && msg.RCode != dnsmessage.RCodeNameError {
+		return nil, 0, fmt.Errorf("%s answered %s", server, msg.RCode)
+	}
+
+	var values []string
+	var ttl uint32
+	for _, resource := range msg.Answers {
+		if resource.Header.Type != qtype {
+			continue
+		}
+		value, ok := resourceValue(resource.Body)
+		if !ok {
+			continue
+		}
+		if len(values) == 0 || resource.Header.TTL < ttl {
+			ttl = resource.Header.TTL
+		}
+		values = append(values, value)
+	}
+	return values, time.Duration(ttl) * time.Second, nil
+}
+
+// exchangeDNS sends query to server over network and returns the raw response
+func exchangeDNS(ctx context.Context, network, server string, query []byte) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		response := make([]byte, 4096)
+		n, err := conn.Read(response)
+		if err != nil {
+			return nil, err
+		}
+		return response[:n], nil
+	}
+	// Over TCP every message is prefixed with its length
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	if _, err := conn.Write(append(framed, query...)); err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	response := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// resourceValue formats an answer like the value of a DNSRecord
+func resourceValue(body dnsmessage.ResourceBody) (string, bool) {
+	switch body := body.(type) {
+	case *dnsmessage.AResource:
+		return netip.AddrFrom4(body.A).String(), true
+	case *dnsmessage.AAAAResource:
+		return netip.AddrFrom16(body.AAAA).String(), true
+	case *dnsmessage.CNAMEResource:
+		return strings.TrimSuffix(body.CNAME.String(), "."), true
+	case *dnsmessage.MXResource:
+		return strconv.Itoa(int(body.Pref)) + " " + strings.TrimSuffix(body.MX.String(), "."), true
+	case *dnsmessage.SRVResource:
+		return fmt.Sprintf("%d %d %d %s", body.Priority, body.Weight, body.Port, strings.TrimSuffix(body.Target.String(), ".")), true
+	case *dnsmessage.TXTResource:
+		return strings.Join(body.TXT, ""), true
+	}
+	return "", false
+}
+
+// containsValue reports whether value, as entered in the portal, is among the
+// resolved values of a record of recordType
+func containsValue(recordType string, values []string, value string) bool {
+	want := normalizeValue(recordType, value)
+	for _, found := range values {
+		if normalizeValue(recordType, found) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeValue removes the differences in notation between the portal and DNS answers
+func normalizeValue(recordType, value string) string {
+	value = strings.TrimSpace(value)
+	switch strings.ToUpper(recordType) {
+	case "TXT":
+		if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+			value = value[1 : len(value)-1]
+		}
+		return value
+	case "A", "AAAA":
+		if addr, err := netip.ParseAddr(value); err == nil {
+			return addr.Unmap().String()
+		}
+	}
+	return strings.ToLower(strings.TrimSuffix(strings.Join(strings.Fields(value), " "), "."))
+}
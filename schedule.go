@@ -0,0 +1,270 @@
+package strato
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// States of a ScheduledChange
+const (
+	SchedulePending  = "pending"
+	ScheduleApplied  = "applied"
+	ScheduleFailed   = "failed"
+	ScheduleCanceled = "canceled"
+)
+
+// ScheduledChange is a change to the records of a domain that is due at a given time,
+// e.g. an MX switch in a maintenance window. It is kept as the records to add and
+// remove rather than a whole configuration, so changes made to the domain between
+// scheduling and applying are preserved.
+type ScheduledChange struct {
+	ID     string    `json:"id"`
+	Domain string    `json:"domain"`
+	At     time.Time `json:"at"`
+	// Source describes where the change came from, e.g. the applied file
+	Source string      `json:"source,omitempty"`
+	Add    []DNSRecord `json:"add,omitempty"`
+	Remove []DNSRecord `json:"remove,omitempty"`
+	// DMARCType and SPFType are set if the change switches the setting
+	DMARCType string `json:"dmarcType,omitempty"`
+	SPFType   string `json:"spfType,omitempty"`
+
+	Status string `json:"status"`
+	// Done is when the change was applied, failed or canceled
+	Done time.Time `json:"done,omitempty"`
+	// Error is the last error of applying the change. A pending change with an error
+	// is retried.
+	Error    string `json:"error,omitempty"`
+	Attempts int    `json:"attempts,omitempty"`
+}
+
+// A change that cannot be applied is retried at the next check, until it failed
+// scheduleAttempts times or is more than scheduleRetryWindow overdue
+const (
+	scheduleAttempts    = 5
+	scheduleRetryWindow = time.Hour
+)
+
+// ScheduleChange returns a pending change turning current into desired at the given time
+func ScheduleChange(domain string, at time.Time, current, desired DNSConfig) ScheduledChange {
+	diff := DiffConfigs(current, desired)
+	change := ScheduledChange{Domain: domain, At: at, Add: diff.Added, Remove: diff.Removed, Status: SchedulePending}
+	if current.DMARCType != desired.DMARCType {
+		change.DMARCType = desired.DMARCType
+	}
+	if current.SPFType != desired.SPFType {
+		change.SPFType = desired.SPFType
+	}
+	return change
+}
+
+// Diff returns the change in the form DiffConfigs reports
+func (s ScheduledChange) Diff() ConfigDiff {
+	diff := ConfigDiff{Added: s.Add, Removed: s.Remove}
+	if s.DMARCType != "" {
+		diff.DMARCType = "-> " + s.DMARCType
+	}
+	if s.SPFType != "" {
+		diff.SPFType = "-> " + s.SPFType
+	}
+	return diff
+}
+
+// apply makes the change to zone and reports whether the zone changed
+func (s ScheduledChange) apply(zone *Zone) bool {
+	changed := false
+	for _, record := range s.Remove {
+		changed = zone.Remove(record) || changed
+	}
+	for _, record := range s.Add {
+		changed = zone.Add(record) || changed
+	}
+	if s.DMARCType != "" && s.DMARCType != zone.DMARCType {
+		zone.DMARCType = s.DMARCType
+		changed = true
+	}
+	if s.SPFType != "" && s.SPFType != zone.SPFType {
+		zone.SPFType = s.SPFType
+		changed = true
+	}
+	return changed
+}
+
+// Schedule keeps scheduled changes in a JSON file. A lock file next to it is held
+// while the file is read and replaced, so changes can be scheduled from the CLI while a daemon applies
+// them, and replicas sharing the file apply every change only once.
+type Schedule struct {
+	path string
+}
+
+// NewSchedule returns the schedule kept at path, which is created by the first Add
+func NewSchedule(path string) *Schedule {
+	return &Schedule{path: path}
+}
+
+// Add stores change as pending and returns it with its ID
+func (s *Schedule) Add(change ScheduledChange) (ScheduledChange, error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return change, err
+	}
+	change.ID = hex.EncodeToString(id)
+	change.Status = SchedulePending
+	err := s.update(func(changes []ScheduledChange) ([]ScheduledChange, error) {
+		return append(changes, change), nil
+	})
+	return change, err
+}
+
+// Changes returns all changes, ordered by the time they are due
+func (s *Schedule) Changes() ([]ScheduledChange, error) {
+	var all []ScheduledChange
+	err := s.update(func(changes []ScheduledChange) ([]ScheduledChange, error) {
+		all = changes
+		return nil, nil
+	})
+	sort.SliceStable(all, func(i, j int) bool { return all[i].At.Before(all[j].At) })
+	return all, err
+}
+
+// Cancel marks the pending change with id as canceled
+func (s *Schedule) Cancel(id string) error {
+	return s.update(func(changes []ScheduledChange) ([]ScheduledChange, error) {
+		for i := range changes {
+			if changes[i].ID != id {
+				continue
+			}
+			if changes[i].Status != SchedulePending {
+				return nil, fmt.Errorf("change %s is already %s", id, changes[i].Status)
+			}
+			changes[i].Status = ScheduleCanceled
+			changes[i].Done = time.Now().UTC()
+			return changes, nil
+		}
+		return nil, fmt.Errorf("no scheduled change %s", id)
+	})
+}
+
+// update calls fn with the changes in the file while holding the lock of the file
+// and writes back what fn returns, unless that is nil. The lock is taken on a file
+// next to the schedule, which is replaced atomically, so a failed write leaves the
+// pending changes in place.
+func (s *Schedule) update(fn func([]ScheduledChange) ([]ScheduledChange, error)) error {
+	file, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := lockFile(file); err != nil {
+		return err
+	}
+	defer unlockFile(file)
+
+	content, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	var changes []ScheduledChange
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &changes); err != nil {
+			return fmt.Errorf("invalid schedule file %s: %w", s.path, err)
+		}
+	}
+	changes, err = fn(changes)
+	if err != nil || changes == nil {
+		return err
+	}
+	data, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(s.path, data, 0o600)
+}
+
+// ApplyDue applies the pending changes of schedule that are due at now, using the
+// session of c for the domains of other changes, and returns the ones that were
+// applied or failed. A change that could not be applied stays pending and is tried
+// again by the next call, unless the error cannot go away by itself, it failed
+// scheduleAttempts times or it is more than scheduleRetryWindow overdue.
+// The schedule stays locked until all due changes are applied.
+func (c *StratoClient) ApplyDue(schedule *Schedule, now time.Time) ([]ScheduledChange, error) {
+	var done []ScheduledChange
+	updated := false
+	err := schedule.update(func(changes []ScheduledChange) ([]ScheduledChange, error) {
+		for i := range changes {
+			change := &changes[i]
+			if change.Status != SchedulePending || change.At.After(now) {
+				continue
+			}
+			change.Attempts++
+			if err := c.ForDomain(change.Domain).applyScheduled(*change); err != nil {
+				change.Error = err.Error()
+				if !permanentScheduleError(err) && change.Attempts < scheduleAttempts && now.Sub(change.At) < scheduleRetryWindow {
+					logFor(LogForm).Warn("Failed to apply scheduled change, retrying", "domain", change.Domain, "id", change.ID, "attempt", change.Attempts, "error", err)
+					updated = true
+					continue
+				}
+				change.Status = ScheduleFailed
+			} else {
+				change.Status = ScheduleApplied
+				change.Error = ""
+			}
+			change.Done = time.Now().UTC()
+			done = append(done, *change)
+		}
+		if len(done) == 0 && !updated {
+			return nil, nil
+		}
+		return changes, nil
+	})
+	return done, err
+}
+
+// permanentScheduleError reports whether retrying a change that failed with err is
+// pointless because the error needs someone to act
+func permanentScheduleError(err error) bool {
+	return errors.Is(err, ErrReadOnly) || errors.Is(err, ErrProtectedRecord) ||
+		errors.Is(err, ErrRecordQuotaExceeded) || errors.Is(err, ErrDomainNotFound)
+}
+
+func (c *StratoClient) applyScheduled(change ScheduledChange) error {
+	return c.UpdateZone(func(zone *Zone) (bool, error) {
+		if !change.apply(zone) {
+			logFor(LogForm).Info("Scheduled change already in place", "domain", change.Domain, "id", change.ID)
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
+// RunSchedule checks schedule every interval and applies the changes that are due,
+// calling callback with each applied or failed change. It blocks until ctx is cancelled.
+func (c *StratoClient) RunSchedule(ctx context.Context, schedule *Schedule, interval time.Duration, callback func(ScheduledChange)) error {
+	if interval <= 0 {
+		return errors.New("schedule interval must be positive")
+	}
+	c = c.WithContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		done, err := c.ApplyDue(schedule, time.Now())
+		if err != nil {
+			logFor(LogForm).Error("Failed to read schedule", "path", schedule.path, "error", err)
+		}
+		for _, change := range done {
+			callback(change)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
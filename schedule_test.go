@@ -0,0 +1,199 @@
+package strato
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mxSwitch is a scheduled change replacing the MX record of example.com
+func mxSwitch(at time.Time) ScheduledChange {
+	current := DNSConfig{Records: []DNSRecord{{Type: "MX", Prefix: "", Value: "10 old.example.net."}}}
+	desired := DNSConfig{Records: []DNSRecord{{Type: "MX", Prefix: "", Value: "10 new.example.net."}}}
+	return ScheduleChange("example.com", at, current, desired)
+}
+
+func newTestSchedule(t *testing.T) *Schedule {
+	return NewSchedule(filepath.Join(t.TempDir(), "schedule.json"))
+}
+
+func TestScheduleAdd(t *testing.T) {
+	schedule := newTestSchedule(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	later, err := schedule.Add(mxSwitch(now.Add(2 * time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sooner, err := schedule.Add(mxSwitch(now.Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if later.ID == "" || later.ID == sooner.ID || later.Status != SchedulePending {
+		t.Errorf("got changes %+v and %+v, want pending changes with distinct IDs", later, sooner)
+	}
+
+	changes, err := NewSchedule(schedule.path).Changes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].ID != sooner.ID || changes[1].ID != later.ID {
+		t.Fatalf("got %+v, want both changes ordered by time", changes)
+	}
+	if diff := changes[0].Diff(); len(diff.Added) != 1 || len(diff.Removed) != 1 {
+		t.Errorf("got diff %v, want the MX record replaced", diff)
+	}
+}
+
+func TestScheduleConcurrentAdd(t *testing.T) {
+	schedule := newTestSchedule(t)
+	const n = 20
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Every change goes through a schedule of its own, like separate processes
+			if _, err := NewSchedule(schedule.path).Add(mxSwitch(time.Now().Add(time.Hour))); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	changes, err := schedule.Changes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != n {
+		t.Errorf("got %d changes, want %d", len(changes), n)
+	}
+	leftovers, _ := filepath.Glob(schedule.path + ".tmp*")
+	if len(leftovers) != 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+}
+
+func TestScheduleCancel(t *testing.T) {
+	schedule := newTestSchedule(t)
+	change, err := schedule.Add(mxSwitch(time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schedule.Cancel(change.ID); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := schedule.Changes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes[0].Status != ScheduleCanceled || changes[0].Done.IsZero() {
+		t.Errorf("got %+v, want a canceled change", changes[0])
+	}
+	if err := schedule.Cancel(change.ID); err == nil {
+		t.Error("canceled a change twice")
+	}
+	if err := schedule.Cancel("unknown"); err == nil {
+		t.Error("canceled an unknown change")
+	}
+}
+
+func TestApplyDue(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal)
+	schedule := newTestSchedule(t)
+	now := time.Now()
+	due, err := schedule.Add(mxSwitch(now.Add(-time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := schedule.Add(mxSwitch(now.Add(time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := client.ApplyDue(schedule, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || done[0].ID != due.ID || done[0].Status != ScheduleApplied {
+		t.Fatalf("got %+v, want the due change applied", done)
+	}
+	form, _ := portal.lastTXTForm()
+	if !NewZone(*submittedConfig(form)).Contains(DNSRecord{Type: "MX", Value: "10 new.example.net."}) {
+		t.Errorf("submitted %v, want the new MX record", form["value"])
+	}
+
+	done, err = client.ApplyDue(schedule, now)
+	if err != nil || len(done) != 0 {
+		t.Errorf("got %+v, %v, want an applied change not to be applied again", done, err)
+	}
+}
+
+func TestApplyDueRetry(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	client := newTestClient(t, portal)
+	schedule := newTestSchedule(t)
+	at := time.Now().Add(-time.Minute)
+	change, err := schedule.Add(mxSwitch(at))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	portal.mu.Lock()
+	portal.failWrites = 1
+	portal.mu.Unlock()
+	done, err := client.ApplyDue(schedule, at)
+	if err != nil || len(done) != 0 {
+		t.Fatalf("got %+v, %v, want the failed change to be kept for a retry", done, err)
+	}
+	changes, _ := schedule.Changes()
+	if changes[0].Status != SchedulePending || changes[0].Error == "" || changes[0].Attempts != 1 {
+		t.Fatalf("got %+v, want a pending change with the error", changes[0])
+	}
+
+	done, err = client.ApplyDue(schedule, at)
+	if err != nil || len(done) != 1 || done[0].Status != ScheduleApplied || done[0].Error != "" {
+		t.Fatalf("got %+v, %v, want the change applied on the retry", done, err)
+	}
+	if done[0].ID != change.ID || done[0].Attempts != 2 {
+		t.Errorf("got %+v, want the second attempt of %s", done[0], change.ID)
+	}
+}
+
+func TestApplyDueGivesUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		now      time.Duration
+		attempts int
+		opts     []Option
+	}{
+		{name: "attempts", failures: scheduleAttempts, attempts: scheduleAttempts},
+		{name: "overdue", failures: 1, now: scheduleRetryWindow, attempts: 1},
+		{name: "permanent error", opts: []Option{WithReadOnly(false)}, attempts: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			portal := newTestPortal(t, RegionDE)
+			client := newTestClient(t, portal, test.opts...)
+			schedule := newTestSchedule(t)
+			at := time.Now().Add(-time.Minute)
+			if _, err := schedule.Add(mxSwitch(at)); err != nil {
+				t.Fatal(err)
+			}
+			portal.mu.Lock()
+			portal.failWrites = test.failures
+			portal.mu.Unlock()
+
+			var done []ScheduledChange
+			for i := 0; i < scheduleAttempts && len(done) == 0; i++ {
+				var err error
+				if done, err = client.ApplyDue(schedule, at.Add(test.now)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(done) != 1 || done[0].Status != ScheduleFailed || done[0].Error == "" || done[0].Attempts != test.attempts {
+				t.Errorf("got %+v, want a change failed after %d attempts", done, test.attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+// Package apiclient is a client of the REST API of package server, generated from
+// its OpenAPI document with oapi-codegen. Requests are authenticated with a request
+// editor that sets the bearer token:
+//
+//	client, err := apiclient.NewClientWithResponses("http://localhost:8080",
+//		apiclient.WithRequestEditorFn(func(ctx context.Context, req *http.Request) error {
+//			req.Header.Set("Authorization", "Bearer "+token)
+//			return nil
+//		}))
+//	response, err := client.GetConfigWithResponse(ctx, "example.de")
+package apiclient
+
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.5.0 -config oapi-codegen.yaml ../openapi.yaml
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// Scope is a permission granted to a token
+type Scope string
+
+const (
+	// ScopeRead allows reading configurations and proposals
+	ScopeRead Scope = "read"
+	// ScopeWrite allows changing configurations, or proposing changes with WithApproval
+	ScopeWrite Scope = "write"
+	// ScopeApprove allows approving and rejecting proposals of other users
+	ScopeApprove Scope = "approve"
+)
+
+// Token grants access to the API. Only the SHA-256 hash of the secret is stored.
+type Token struct {
+	// Name identifies the user of the token, e.g. in the audit log
+	Name   string  `json:"name"`
+	SHA256 string  `json:"sha256"`
+	Scopes []Scope `json:"scopes"`
+	// Domains restricts the token to domains matching these patterns, e.g.
+	// *.example.de for all subdomains of example.de; empty allows all domains
+	Domains []string `json:"domains,omitempty"`
+}
+
+// HashToken returns the value of Token.SHA256 for secret
+func HashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseTokens reads a JSON list of tokens
+func ParseTokens(data []byte) ([]Token, error) {
+	var tokens []Token
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		if token.Name == "" || len(token.SHA256) != sha256.Size*2 {
+			return nil, fmt.Errorf("token %q needs a name and a hex encoded sha256", token.Name)
+		}
+		for _, scope := range token.Scopes {
+			if scope != ScopeRead && scope != ScopeWrite && scope != ScopeApprove {
+				return nil, fmt.Errorf("token %s has unknown scope %q", token.Name, scope)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// LoadTokens reads a JSON list of tokens from a file
+func LoadTokens(path string) ([]Token, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTokens(data)
+}
+
+// WithTokens requires every request to carry one of tokens as bearer token and
+// limits it to the scopes and domains of the token. The name of the token is used
+// as user, whatever WithIdentify or WithTrustedProxy say.
+func WithTokens(tokens []Token) Option {
+	return func(s *Server) {
+		s.tokens = tokens
+	}
+}
+
+// user returns the user of a request: the name of the token that authenticated it,
+// or without tokens the user determined by WithIdentify
+func (s *Server) user(r *http.Request) string {
+	if token, ok := r.Context().Value(tokenKey{}).(*Token); ok {
+		return token.Name
+	}
+	return s.identify(r)
+}
+
+// tokenKey is the context key of the token of a request
+type tokenKey struct{}
+
+var (
+	errUnauthenticated = errors.New("missing or unknown API token")
+	errForbidden       = errors.New("token does not allow this request")
+)
+
+// authenticate finds the token of the request; without configured tokens every
+// request is allowed
+func (s *Server) authenticate(r *http.Request) (*http.Request, error) {
+	if s.tokens == nil {
+		return r, nil
+	}
+	secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		// Browsers opening the dashboard send the token as basic auth password
+		_, secret, ok = r.BasicAuth()
+	}
+	if !ok || secret == "" {
+		return r, errUnauthenticated
+	}
+	hash := []byte(HashToken(secret))
+	for i := range s.tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(strings.ToLower(s.tokens[i].SHA256))) == 1 {
+			return r.WithContext(context.WithValue(r.Context(), tokenKey{}, &s.tokens[i])), nil
+		}
+	}
+	return r, errUnauthenticated
+}
+
+// authorize checks that the token of the request has scope for domain; an empty
+// domain only checks the scope
+func (s *Server) authorize(r *http.Request, scope Scope, domain string) error {
+	if s.tokens == nil {
+		return nil
+	}
+	token, ok := r.Context().Value(tokenKey{}).(*Token)
+	if !ok {
+		return errUnauthenticated
+	}
+	granted := false
+	for _, tokenScope := range token.Scopes {
+		granted = granted || tokenScope == scope
+	}
+	if !granted || (domain != "" && !token.allows(domain)) {
+		return errForbidden
+	}
+	return nil
+}
+
+// allows reports whether the token may access domain
+func (t *Token) allows(domain string) bool {
+	if len(t.Domains) == 0 {
+		return true
+	}
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for _, pattern := range t.Domains {
+		if matched, _ := path.Match(strings.ToLower(pattern), domain); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,274 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/internal/stratotest"
+)
+
+// testTokens are the tokens of the test server; every secret is the name followed by
+// "-secret"
+var testTokens = []Token{
+	// alice changes example.com only
+	{Name: "alice", SHA256: HashToken("alice-secret"), Scopes: []Scope{ScopeRead, ScopeWrite}, Domains: []string{"example.com"}},
+	// bob reviews the changes of all domains
+	{Name: "bob", SHA256: HashToken("bob-secret"), Scopes: []Scope{ScopeRead, ScopeApprove}},
+	// carol does everything; her hash is stored in upper case
+	{Name: "carol", SHA256: strings.ToUpper(HashToken("carol-secret")), Scopes: []Scope{ScopeRead, ScopeWrite, ScopeApprove}},
+	// dave reads the subdomains of example.net
+	{Name: "dave", SHA256: HashToken("dave-secret"), Scopes: []Scope{ScopeRead}, Domains: []string{"*.example.net"}},
+	// erin reviews the changes of example.org only
+	{Name: "erin", SHA256: HashToken("erin-secret"), Scopes: []Scope{ScopeRead, ScopeApprove}, Domains: []string{"example.org"}},
+}
+
+// newTestServer returns a server with testTokens working on a test portal
+func newTestServer(t *testing.T, opts ...Option) (*Server, *stratotest.Portal) {
+	portal := stratotest.NewPortal(t)
+	return New(portal.Client(t), append([]Option{WithTokens(testTokens)}, opts...)...), portal
+}
+
+// request sends a request with the token of user, none if user is empty
+func request(s *Server, user, method, target, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if user != "" {
+		r.Header.Set("Authorization", "Bearer "+user+"-secret")
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestAuthentication(t *testing.T) {
+	s, _ := newTestServer(t)
+	tests := []struct {
+		name          string
+		authorization string
+		path          string
+		status        int
+		challenge     string
+	}{
+		{name: "missing token", path: "/v1/domains/example.com/config", status: http.StatusUnauthorized, challenge: "Bearer"},
+		{name: "unknown token", authorization: "Bearer mallory-secret", path: "/v1/domains/example.com/config", status: http.StatusUnauthorized, challenge: "Bearer"},
+		{name: "empty token", authorization: "Bearer ", path: "/v1/domains/example.com/config", status: http.StatusUnauthorized, challenge: "Bearer"},
+		{name: "hash as token", authorization: "Bearer " + HashToken("alice-secret"), path: "/v1/domains/example.com/config", status: http.StatusUnauthorized, challenge: "Bearer"},
+		{name: "bearer token", authorization: "Bearer alice-secret", path: "/v1/domains/example.com/config", status: http.StatusOK},
+		{name: "upper case hash", authorization: "Bearer carol-secret", path: "/v1/domains/example.com/config", status: http.StatusOK},
+		{name: "dashboard without token", path: "/ui", status: http.StatusUnauthorized, challenge: `Basic realm="go-strato"`},
+		{name: "dashboard with basic auth", authorization: "Basic " + basicAuth("alice", "alice-secret"), path: "/ui", status: http.StatusOK},
+		{name: "basic auth with unknown password", authorization: "Basic " + basicAuth("alice", "bob"), path: "/ui", status: http.StatusUnauthorized, challenge: `Basic realm="go-strato"`},
+		{name: "OpenAPI document", path: "/v1/openapi.yaml", status: http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.authorization != "" {
+				r.Header.Set("Authorization", test.authorization)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+			if w.Code != test.status {
+				t.Errorf("got status %d, want %d: %s", w.Code, test.status, w.Body)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != test.challenge {
+				t.Errorf("got challenge %q, want %q", got, test.challenge)
+			}
+		})
+	}
+}
+
+// basicAuth encodes the credentials of a basic Authorization header
+func basicAuth(user, password string) string {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth(user, password)
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Basic ")
+}
+
+func TestAuthorization(t *testing.T) {
+	s, portal := newTestServer(t)
+	config := `{"records":[{"type":"TXT","prefix":"_acme-challenge","value":"token"}]}`
+	tests := []struct {
+		name   string
+		user   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "read own domain", user: "alice", method: http.MethodGet, path: "/v1/domains/example.com/config", status: http.StatusOK},
+		{name: "read other domain", user: "alice", method: http.MethodGet, path: "/v1/domains/example.net/config", status: http.StatusForbidden},
+		{name: "read domain with different case", user: "alice", method: http.MethodGet, path: "/v1/domains/EXAMPLE.com./config", status: http.StatusOK},
+		{name: "write own domain", user: "alice", method: http.MethodPut, path: "/v1/domains/example.com/config", body: config, status: http.StatusOK},
+		{name: "write other domain", user: "alice", method: http.MethodPut, path: "/v1/domains/example.net/config", body: config, status: http.StatusForbidden},
+		{name: "write without scope", user: "bob", method: http.MethodPut, path: "/v1/domains/example.com/config", body: config, status: http.StatusForbidden},
+		{name: "read matching pattern", user: "dave", method: http.MethodGet, path: "/v1/domains/www.example.net/config", status: http.StatusOK},
+		{name: "read domain of pattern", user: "dave", method: http.MethodGet, path: "/v1/domains/example.net/config", status: http.StatusForbidden},
+		{name: "dashboard of other domain", user: "alice", method: http.MethodGet, path: "/ui/domains/example.net", status: http.StatusForbidden},
+		{name: "refresh other domain", user: "alice", method: http.MethodPost, path: "/ui/domains/example.net/sync", status: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := request(s, test.user, test.method, test.path, test.body)
+			if w.Code != test.status {
+				t.Errorf("got status %d, want %d: %s", w.Code, test.status, w.Body)
+			}
+		})
+	}
+	if records := portal.Config("example.net").Records; len(records) != 1 {
+		t.Errorf("forbidden write changed example.net to %v", records)
+	}
+}
+
+func TestTokenAllows(t *testing.T) {
+	tests := []struct {
+		domains []string
+		domain  string
+		allowed bool
+	}{
+		{nil, "example.com", true},
+		{[]string{"example.com"}, "example.com", true},
+		{[]string{"example.com"}, "Example.COM.", true},
+		{[]string{"example.com"}, "www.example.com", false},
+		{[]string{"example.com"}, "example.com.evil.org", false},
+		{[]string{"*.example.de"}, "a.example.de", true},
+		{[]string{"*.example.de"}, "example.de", false},
+		{[]string{"*.example.de"}, "a.b.example.de", true},
+		{[]string{"*.EXAMPLE.de"}, "a.example.de", true},
+		{[]string{"example.org", "*.example.de"}, "example.org", true},
+		{[]string{"[invalid"}, "[invalid", false},
+	}
+	for _, test := range tests {
+		token := Token{Domains: test.domains}
+		if got := token.allows(test.domain); got != test.allowed {
+			t.Errorf("token for %v allows %q: %v, want %v", test.domains, test.domain, got, test.allowed)
+		}
+	}
+}
+
+func TestParseTokens(t *testing.T) {
+	hash := HashToken("secret")
+	tests := []struct {
+		name string
+		data string
+		err  bool
+	}{
+		{name: "valid", data: `[{"name":"ci","sha256":"` + hash + `","scopes":["read","write","approve"],"domains":["*.example.de"]}]`},
+		{name: "no name", data: `[{"sha256":"` + hash + `","scopes":["read"]}]`, err: true},
+		{name: "short hash", data: `[{"name":"ci","sha256":"abc","scopes":["read"]}]`, err: true},
+		{name: "secret instead of hash", data: `[{"name":"ci","sha256":"secret","scopes":["read"]}]`, err: true},
+		{name: "unknown scope", data: `[{"name":"ci","sha256":"` + hash + `","scopes":["admin"]}]`, err: true},
+		{name: "not a list", data: `{"name":"ci"}`, err: true},
+	}
+	for _, test := range tests {
+		if _, err := ParseTokens([]byte(test.data)); (err != nil) != test.err {
+			t.Errorf("%s: got error %v, want error: %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestStatusFiltering(t *testing.T) {
+	tracker, err := strato.NewStatusTracker("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.Record("example.com", nil, time.Time{})
+	tracker.Record("example.net", errors.New("failed"), time.Time{})
+	s, _ := newTestServer(t, WithStatus(tracker))
+
+	tests := map[string][]string{
+		"alice": {"example.com"},
+		"bob":   {"example.com", "example.net"},
+		"dave":  {},
+	}
+	for user, want := range tests {
+		w := request(s, user, http.MethodGet, "/v1/status", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d: %s", user, w.Code, w.Body)
+		}
+		var statuses []strato.SyncStatus
+		if err := json.Unmarshal(w.Body.Bytes(), &statuses); err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, status := range statuses {
+			got = append(got, status.Domain)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: got statuses of %v, want %v", user, got, want)
+		}
+	}
+}
+
+func TestEventsFiltering(t *testing.T) {
+	s, _ := newTestServer(t)
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v1/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Authorization", "Bearer alice-secret")
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %s", resp.Status)
+	}
+
+	diff := strato.ConfigDiff{Added: []strato.DNSRecord{{Type: "TXT", Prefix: "a", Value: "b"}}}
+	for _, domain := range []string{"example.net", "www.example.com", "example.com"} {
+		s.publish(Event{Time: time.Now(), Domain: domain, Source: "watch", Diff: diff}, nil)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Domain != "example.com" {
+			t.Errorf("got event of %s", event.Domain)
+		}
+		return
+	}
+	t.Fatalf("stream ended without an event: %v", scanner.Err())
+}
+
+func TestEventsOfOtherDomain(t *testing.T) {
+	s, _ := newTestServer(t)
+	w := request(s, "alice", http.MethodGet, "/v1/events?domain=example.net", "")
+	if w.Code != http.StatusForbidden {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestDashboardFiltering(t *testing.T) {
+	s, _ := newTestServer(t)
+	for _, domain := range []string{"example.com", "example.net"} {
+		config := strato.DNSConfig{Records: []strato.DNSRecord{{Type: "TXT", Prefix: "owner", Value: "owner-of-" + domain}}}
+		s.publish(Event{Time: time.Now(), Domain: domain, Source: "api", Diff: strato.ConfigDiff{Added: config.Records}}, &config)
+	}
+	w := request(s, "alice", http.MethodGet, "/ui", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "example.com") || strings.Contains(body, "example.net") {
+		t.Errorf("dashboard of alice does not show example.com only:\n%s", body)
+	}
+}
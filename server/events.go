@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// Event is a change of the configuration of a domain
+type Event struct {
+	Time   time.Time `json:"time"`
+	Domain string    `json:"domain"`
+	// Source is "watch" for changes found by polling, "api" for changes made through the server
+	Source string            `json:"source"`
+	Diff   strato.ConfigDiff `json:"diff"`
+	// Error is set if polling the domain failed
+	Error string `json:"error,omitempty"`
+}
+
+// eventBuffer is how many events a subscriber may fall behind before events are dropped
+const eventBuffer = 64
+
+// broker fans events out to the subscribers of /v1/events
+type broker struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func (b *broker) subscribe() chan Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers == nil {
+		b.subscribers = map[chan Event]struct{}{}
+	}
+	events := make(chan Event, eventBuffer)
+	b.subscribers[events] = struct{}{}
+	return events
+}
+
+func (b *broker) unsubscribe(events chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, events)
+}
+
+// publish hands event to all subscribers; slow subscribers miss it rather than
+// holding up the others
+func (b *broker) publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// publish records event for the dashboard and sends it to the subscribers of /v1/events
+func (s *Server) publish(event Event, config *strato.DNSConfig) {
+	s.record(event, config)
+	s.events.publish(event)
+}
+
+// Watch polls the configuration of domains every interval and publishes changes
+// as events until ctx is done
+func (s *Server) Watch(ctx context.Context, domains []string, interval time.Duration) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(domains))
+	for i, domain := range domains {
+		wg.Add(1)
+		go func(i int, domain string) {
+			defer wg.Done()
+			err := s.client.ForDomain(domain).Watch(ctx, interval, func(event strato.WatchEvent) {
+				published := Event{Time: event.Time, Domain: domain, Source: "watch", Diff: event.Diff}
+				if event.Err != nil {
+					published.Error = event.Err.Error()
+					s.publish(published, nil)
+					return
+				}
+				s.publish(published, &event.Config)
+			})
+			if !errors.Is(err, context.Canceled) {
+				errs[i] = fmt.Errorf("%s: %w", domain, err)
+			}
+		}(i, domain)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// streamEvents sends events as server-sent events until the client disconnects.
+// ?domain= limits the stream to one domain.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	domain := r.URL.Query().Get("domain")
+	if err := s.authorize(r, ScopeRead, domain); err != nil {
+		writeError(w, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorBody{Error: "streaming is not supported"})
+		return
+	}
+	events := s.events.subscribe()
+	defer s.events.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	// Comments keep proxies from closing idle connections
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event := <-events:
+			if (domain != "" && event.Domain != domain) || s.authorize(r, ScopeRead, event.Domain) != nil {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: change\ndata: %s\n\n", data)
+		}
+		flusher.Flush()
+	}
+}
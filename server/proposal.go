@@ -0,0 +1,187 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// ProposalStatus is the state of a proposal
+type ProposalStatus string
+
+const (
+	StatusPending  ProposalStatus = "pending"
+	StatusApplied  ProposalStatus = "applied"
+	StatusRejected ProposalStatus = "rejected"
+	StatusFailed   ProposalStatus = "failed"
+)
+
+// Proposal is a queued change waiting for approval. Proposals are kept in memory
+// only and are lost when the server restarts.
+type Proposal struct {
+	ID       string            `json:"id"`
+	Domain   string            `json:"domain"`
+	Diff     strato.ConfigDiff `json:"diff"`
+	Desired  strato.DNSConfig  `json:"desired"`
+	Proposer string            `json:"proposer"`
+	Created  time.Time         `json:"created"`
+	Status   ProposalStatus    `json:"status"`
+	// Reviewer approved or rejected the proposal
+	Reviewer string     `json:"reviewer,omitempty"`
+	Reviewed *time.Time `json:"reviewed,omitempty"`
+	Error    string     `json:"error,omitempty"`
+
+	// base is the configuration the diff was computed against
+	base strato.DNSConfig
+	// applying is set while an approval is being applied
+	applying bool
+}
+
+var (
+	errProposalNotFound   = errors.New("proposal not found")
+	errProposalNotPending = errors.New("proposal is not pending")
+	errSelfApproval       = errors.New("proposals have to be reviewed by another authenticated user")
+	// errStaleProposal means the zone changed since the proposal was made
+	errStaleProposal = errors.New("configuration changed since the proposal was made, propose again")
+)
+
+// propose queues a change
+func (s *Server) propose(r *http.Request, domain string, base, desired strato.DNSConfig, diff strato.ConfigDiff) (Proposal, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Proposal{}, err
+	}
+	proposal := &Proposal{
+		ID:       hex.EncodeToString(id),
+		Domain:   domain,
+		Diff:     diff,
+		Desired:  desired,
+		Proposer: s.user(r),
+		Created:  time.Now().UTC(),
+		Status:   StatusPending,
+		base:     base,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.proposals[proposal.ID] = proposal
+	return *proposal, nil
+}
+
+func (s *Server) listProposals(w http.ResponseWriter, r *http.Request) {
+	if err := s.authorize(r, ScopeRead, ""); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.mu.Lock()
+	proposals := make([]Proposal, 0, len(s.proposals))
+	for _, proposal := range s.proposals {
+		if s.authorize(r, ScopeRead, proposal.Domain) != nil {
+			continue
+		}
+		if status := r.URL.Query().Get("status"); status == "" || string(proposal.Status) == status {
+			proposals = append(proposals, *proposal)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(proposals, func(i, j int) bool { return proposals[i].Created.Before(proposals[j].Created) })
+	writeJSON(w, http.StatusOK, proposals)
+}
+
+func (s *Server) getProposal(w http.ResponseWriter, r *http.Request) {
+	if err := s.authorize(r, ScopeRead, ""); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.mu.Lock()
+	proposal, ok := s.proposals[r.PathValue("id")]
+	var copied Proposal
+	if ok {
+		copied = *proposal
+	}
+	s.mu.Unlock()
+	// Proposals of domains the token may not read are not found either, so their IDs
+	// cannot be probed
+	if !ok || s.authorize(r, ScopeRead, copied.Domain) != nil {
+		writeError(w, errProposalNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, copied)
+}
+
+// review moves a pending proposal to the reviewing user, who has to differ from the
+// proposer. With apply, the proposal is marked as being applied until finishReview.
+func (s *Server) review(r *http.Request, apply bool) (*Proposal, error) {
+	if err := s.authorize(r, ScopeApprove, ""); err != nil {
+		return nil, err
+	}
+	reviewer := s.user(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	proposal, ok := s.proposals[r.PathValue("id")]
+	switch {
+	case !ok || s.authorize(r, ScopeApprove, proposal.Domain) != nil:
+		return nil, errProposalNotFound
+	case proposal.Status != StatusPending || proposal.applying:
+		return nil, errProposalNotPending
+	case reviewer == "" || reviewer == proposal.Proposer:
+		return nil, errSelfApproval
+	}
+	proposal.Reviewer = reviewer
+	now := time.Now().UTC()
+	proposal.Reviewed = &now
+	proposal.applying = apply
+	return proposal, nil
+}
+
+func (s *Server) approveProposal(w http.ResponseWriter, r *http.Request) {
+	proposal, err := s.review(r, true)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// The proposal stays pending while it is applied, so it can be approved again if
+	// applying fails for reasons other than a stale base
+	_, err = s.clientFor(r, proposal.Domain).UpdateDNSConfiguration(func(current strato.DNSConfig) (strato.DNSConfig, error) {
+		if !strato.DiffConfigs(proposal.base, current).Empty() {
+			return current, errStaleProposal
+		}
+		return proposal.Desired, nil
+	})
+	s.mu.Lock()
+	proposal.applying = false
+	switch {
+	case err == nil:
+		proposal.Status = StatusApplied
+		s.publish(Event{Time: time.Now(), Domain: proposal.Domain, Source: "api", Diff: proposal.Diff}, &proposal.Desired)
+	case errors.Is(err, errStaleProposal):
+		proposal.Status = StatusFailed
+		proposal.Error = err.Error()
+	default:
+		proposal.Error = err.Error()
+	}
+	result := *proposal
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) rejectProposal(w http.ResponseWriter, r *http.Request) {
+	proposal, err := s.review(r, false)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.mu.Lock()
+	proposal.Status = StatusRejected
+	result := *proposal
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/internal/stratotest"
+)
+
+// proposedConfig is the configuration the tests propose
+const proposedConfig = `{"records":[{"type":"TXT","prefix":"_acme-challenge","value":"token"}]}`
+
+// propose puts proposedConfig for example.com as user and returns the proposal
+func propose(t *testing.T, s *Server, user string) Proposal {
+	t.Helper()
+	w := request(s, user, http.MethodPut, "/v1/domains/example.com/config", proposedConfig)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusAccepted, w.Body)
+	}
+	var proposal Proposal
+	if err := json.Unmarshal(w.Body.Bytes(), &proposal); err != nil {
+		t.Fatal(err)
+	}
+	if proposal.Status != StatusPending || proposal.Proposer != user {
+		t.Fatalf("got proposal %+v, want a pending proposal of %s", proposal, user)
+	}
+	return proposal
+}
+
+// review approves or rejects the proposal as user and checks the status of the response
+func review(t *testing.T, s *Server, user, action, id string, status int) Proposal {
+	t.Helper()
+	w := request(s, user, http.MethodPost, "/v1/proposals/"+id+"/"+action, "")
+	if w.Code != status {
+		t.Fatalf("%s %s as %s: got status %d, want %d: %s", action, id, user, w.Code, status, w.Body)
+	}
+	var proposal Proposal
+	json.Unmarshal(w.Body.Bytes(), &proposal)
+	return proposal
+}
+
+// applied reports whether the portal has the proposed configuration for example.com
+func applied(portal *stratotest.Portal) bool {
+	records := portal.Config("example.com").Records
+	return len(records) == 1 && records[0].Prefix == "_acme-challenge"
+}
+
+func TestFourEyes(t *testing.T) {
+	s, portal := newTestServer(t, WithApproval())
+	proposal := propose(t, s, "alice")
+	if applied(portal) {
+		t.Fatal("proposal was applied before it was approved")
+	}
+	if len(proposal.Diff.Added) != 1 || len(proposal.Diff.Removed) != 1 {
+		t.Errorf("got diff %v, want the proposed record to replace the current one", proposal.Diff)
+	}
+
+	review(t, s, "alice", "approve", proposal.ID, http.StatusForbidden)
+	proposal = review(t, s, "bob", "approve", proposal.ID, http.StatusOK)
+	if proposal.Status != StatusApplied || proposal.Reviewer != "bob" || proposal.Reviewed == nil {
+		t.Errorf("got %+v, want a proposal applied by bob", proposal)
+	}
+	if !applied(portal) {
+		t.Error("approved proposal was not applied")
+	}
+	review(t, s, "bob", "approve", proposal.ID, http.StatusUnprocessableEntity)
+	review(t, s, "bob", "reject", proposal.ID, http.StatusUnprocessableEntity)
+}
+
+func TestSelfApproval(t *testing.T) {
+	s, portal := newTestServer(t, WithApproval())
+	proposal := propose(t, s, "carol")
+	review(t, s, "carol", "approve", proposal.ID, http.StatusUnprocessableEntity)
+	review(t, s, "carol", "reject", proposal.ID, http.StatusUnprocessableEntity)
+	if applied(portal) {
+		t.Error("proposal approved by its proposer was applied")
+	}
+	review(t, s, "bob", "approve", proposal.ID, http.StatusOK)
+}
+
+func TestAnonymousReviewer(t *testing.T) {
+	portal := stratotest.NewPortal(t)
+	s := New(portal.Client(t), WithApproval())
+	proposal := propose(t, s, "")
+	review(t, s, "", "approve", proposal.ID, http.StatusUnprocessableEntity)
+	if applied(portal) {
+		t.Error("proposal approved without a reviewer was applied")
+	}
+
+	// Behind an authenticating proxy, the users are told apart by their names
+	s = New(portal.Client(t), WithApproval(), WithTrustedProxy())
+	proxied := func(user, method, target, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("X-Remote-User", user)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+	w := proxied("alice", http.MethodPut, "/v1/domains/example.com/config", proposedConfig)
+	if err := json.Unmarshal(w.Body.Bytes(), &proposal); err != nil || proposal.Proposer != "alice" {
+		t.Fatalf("got proposal %s, want one of alice", w.Body)
+	}
+	if w := proxied("alice", http.MethodPost, "/v1/proposals/"+proposal.ID+"/approve", ""); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("self-approval behind the proxy got status %d", w.Code)
+	}
+	if w := proxied("bob", http.MethodPost, "/v1/proposals/"+proposal.ID+"/approve", ""); w.Code != http.StatusOK {
+		t.Errorf("approval behind the proxy got status %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestStaleProposal(t *testing.T) {
+	s, portal := newTestServer(t, WithApproval())
+	proposal := propose(t, s, "alice")
+	changed := strato.DNSConfig{Records: []strato.DNSRecord{{Type: "CNAME", Prefix: "www", Value: "elsewhere.example.net."}}}
+	portal.SetConfig("example.com", changed)
+
+	review(t, s, "bob", "approve", proposal.ID, http.StatusConflict)
+	if records := portal.Config("example.com").Records; len(records) != 1 || records[0] != changed.Records[0] {
+		t.Errorf("stale proposal overwrote the change made in the meantime: %v", records)
+	}
+	w := request(s, "bob", http.MethodGet, "/v1/proposals/"+proposal.ID, "")
+	json.Unmarshal(w.Body.Bytes(), &proposal)
+	if proposal.Status != StatusFailed || proposal.Error == "" {
+		t.Errorf("got %+v, want a failed proposal", proposal)
+	}
+	review(t, s, "bob", "approve", proposal.ID, http.StatusUnprocessableEntity)
+}
+
+func TestRetryAfterFailure(t *testing.T) {
+	s, portal := newTestServer(t, WithApproval())
+	proposal := propose(t, s, "alice")
+	portal.FailWrites(1)
+
+	review(t, s, "bob", "approve", proposal.ID, http.StatusBadGateway)
+	w := request(s, "bob", http.MethodGet, "/v1/proposals/"+proposal.ID, "")
+	json.Unmarshal(w.Body.Bytes(), &proposal)
+	if proposal.Status != StatusPending || proposal.Error == "" {
+		t.Errorf("got %+v, want a pending proposal with the error", proposal)
+	}
+
+	proposal = review(t, s, "bob", "approve", proposal.ID, http.StatusOK)
+	if proposal.Status != StatusApplied || !applied(portal) {
+		t.Errorf("got %+v, want the proposal applied on the second approval", proposal)
+	}
+}
+
+func TestRejectProposal(t *testing.T) {
+	s, portal := newTestServer(t, WithApproval())
+	proposal := propose(t, s, "alice")
+	proposal = review(t, s, "bob", "reject", proposal.ID, http.StatusOK)
+	if proposal.Status != StatusRejected || proposal.Reviewer != "bob" {
+		t.Errorf("got %+v, want a proposal rejected by bob", proposal)
+	}
+	review(t, s, "bob", "approve", proposal.ID, http.StatusUnprocessableEntity)
+	if applied(portal) {
+		t.Error("rejected proposal was applied")
+	}
+}
+
+// TestProposalVisibility makes sure tokens for other domains cannot tell proposals
+// from unknown IDs
+func TestProposalVisibility(t *testing.T) {
+	s, portal := newTestServer(t, WithApproval())
+	proposal := propose(t, s, "alice")
+
+	for _, id := range []string{proposal.ID, "0123456789abcdef"} {
+		if w := request(s, "dave", http.MethodGet, "/v1/proposals/"+id, ""); w.Code != http.StatusNotFound {
+			t.Errorf("dave got status %d for proposal %s, want %d", w.Code, id, http.StatusNotFound)
+		}
+		review(t, s, "erin", "approve", id, http.StatusNotFound)
+		review(t, s, "erin", "reject", id, http.StatusNotFound)
+	}
+	if applied(portal) {
+		t.Error("proposal was applied by a reviewer of another domain")
+	}
+	if w := request(s, "alice", http.MethodGet, "/v1/proposals/"+proposal.ID, ""); w.Code != http.StatusOK {
+		t.Errorf("alice got status %d for her proposal", w.Code)
+	}
+
+	w := request(s, "dave", http.MethodGet, "/v1/proposals", "")
+	if strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("dave got proposals %s", w.Body)
+	}
+	w = request(s, "bob", http.MethodGet, "/v1/proposals?status=pending", "")
+	var proposals []Proposal
+	if err := json.Unmarshal(w.Body.Bytes(), &proposals); err != nil || len(proposals) != 1 || proposals[0].ID != proposal.ID {
+		t.Errorf("bob got pending proposals %s", w.Body)
+	}
+}
+
+func TestTokenOverridesProxyUser(t *testing.T) {
+	s, portal := newTestServer(t, WithApproval(), WithTrustedProxy())
+	// carol claims to be someone else for the proposal and for the approval
+	send := func(user, method, target, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer carol-secret")
+		r.Header.Set("X-Remote-User", user)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+	var proposal Proposal
+	w := send("mallory", http.MethodPut, "/v1/domains/example.com/config", proposedConfig)
+	if err := json.Unmarshal(w.Body.Bytes(), &proposal); err != nil || proposal.Proposer != "carol" {
+		t.Fatalf("got proposal %s, want one of carol", w.Body)
+	}
+	if w := send("trent", http.MethodPost, "/v1/proposals/"+proposal.ID+"/approve", ""); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("self-approval under another proxy user got status %d: %s", w.Code, w.Body)
+	}
+	if applied(portal) {
+		t.Error("proposal approved by its proposer was applied")
+	}
+}
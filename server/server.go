@@ -0,0 +1,254 @@
+// Package server exposes the DNS configuration of the domains of a package over a
+// REST API. Changes can be queued as proposals that a second user has to approve.
+//
+// The API is described by the OpenAPI document served at /v1/openapi.yaml; package
+// apiclient is generated from it for Go programs. With curl:
+//
+//	curl -H "Authorization: Bearer $TOKEN" http://localhost:8080/v1/domains/example.de/config
+//	curl -X PUT -H "Authorization: Bearer $TOKEN" --data @config.json http://localhost:8080/v1/domains/example.de/config
+//	curl -X POST -H "Authorization: Bearer $REVIEWER_TOKEN" http://localhost:8080/v1/proposals/$ID/approve
+package server
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// OpenAPI is the OpenAPI 3 document of the API
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// Server is an http.Handler serving the API below /v1
+type Server struct {
+	client          *strato.StratoClient
+	requireApproval bool
+	identify        func(*http.Request) string
+	tokens          []Token
+	mux             *http.ServeMux
+	events          broker
+	status          *strato.StatusTracker
+
+	stateMu sync.Mutex
+	zones   map[string]*zoneState
+	recent  []Event
+
+	mu        sync.Mutex
+	proposals map[string]*Proposal
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithApproval queues changes as proposals instead of applying them right away
+func WithApproval() Option {
+	return func(s *Server) {
+		s.requireApproval = true
+	}
+}
+
+// WithIdentify sets how the user of a request is determined if no token of
+// WithTokens authenticated it. Without a user, proposals cannot be approved, as the
+// server cannot tell the reviewer from the proposer.
+func WithIdentify(identify func(*http.Request) string) Option {
+	return func(s *Server) {
+		s.identify = identify
+	}
+}
+
+// WithTrustedProxy takes the user from the X-Remote-User header. Only use it behind
+// an authenticating reverse proxy that sets the header and strips it from client
+// requests, as anyone else can claim any name with it.
+func WithTrustedProxy() Option {
+	return WithIdentify(func(r *http.Request) string {
+		return r.Header.Get("X-Remote-User")
+	})
+}
+
+// WithStatus serves the statuses of tracker on /v1/status. Pass the tracker the client
+// was created with, so the rounds of Watch show up.
+func WithStatus(tracker *strato.StatusTracker) Option {
+	return func(s *Server) {
+		s.status = tracker
+	}
+}
+
+// New returns a server working on the package of client
+func New(client *strato.StratoClient, opts ...Option) *Server {
+	s := &Server{
+		client:    client,
+		identify:  func(*http.Request) string { return "" },
+		mux:       http.NewServeMux(),
+		proposals: map[string]*Proposal{},
+		zones:     map[string]*zoneState{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux.HandleFunc("GET /v1/domains/{domain}/config", s.getConfig)
+	s.mux.HandleFunc("PUT /v1/domains/{domain}/config", s.putConfig)
+	s.mux.HandleFunc("GET /v1/events", s.streamEvents)
+	s.mux.HandleFunc("GET /v1/status", s.getStatus)
+	s.mux.HandleFunc("GET /ui", s.uiDashboard)
+	s.mux.HandleFunc("GET /ui/domains/{domain}", s.uiDomain)
+	s.mux.HandleFunc("POST /ui/domains/{domain}/sync", s.uiSync)
+	s.mux.HandleFunc("GET /v1/proposals", s.listProposals)
+	s.mux.HandleFunc("GET /v1/proposals/{id}", s.getProposal)
+	s.mux.HandleFunc("POST /v1/proposals/{id}/approve", s.approveProposal)
+	s.mux.HandleFunc("POST /v1/proposals/{id}/reject", s.rejectProposal)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && r.URL.Path == "/v1/openapi.yaml" {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(OpenAPI)
+		return
+	}
+	r, err := s.authenticate(r)
+	if err != nil {
+		if strings.HasPrefix(r.URL.Path, "/ui") {
+			w.Header().Set("WWW-Authenticate", `Basic realm="go-strato"`)
+		}
+		writeError(w, err)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// clientFor returns a client for the domain of the request, acting as the user of the request
+func (s *Server) clientFor(r *http.Request, domain string) *strato.StratoClient {
+	return s.client.ForDomain(domain).ForActor(s.user(r)).WithContext(r.Context())
+}
+
+func (s *Server) getConfig(w http.ResponseWriter, r *http.Request) {
+	if err := s.authorize(r, ScopeRead, r.PathValue("domain")); err != nil {
+		writeError(w, err)
+		return
+	}
+	config, err := s.clientFor(r, r.PathValue("domain")).GetDNSConfiguration()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, config)
+}
+
+func (s *Server) getStatus(w http.ResponseWriter, r *http.Request) {
+	if err := s.authorize(r, ScopeRead, ""); err != nil {
+		writeError(w, err)
+		return
+	}
+	statuses := []strato.SyncStatus{}
+	if s.status != nil {
+		for _, status := range s.status.Statuses() {
+			if s.authorize(r, ScopeRead, status.Domain) == nil {
+				statuses = append(statuses, status)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// ChangeResult is the response of an applied change
+type ChangeResult struct {
+	Domain  string            `json:"domain"`
+	Diff    strato.ConfigDiff `json:"diff"`
+	Changed bool              `json:"changed"`
+}
+
+func (s *Server) putConfig(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	if err := s.authorize(r, ScopeWrite, domain); err != nil {
+		writeError(w, err)
+		return
+	}
+	var desired strato.DNSConfig
+	if err := json.NewDecoder(r.Body).Decode(&desired); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: "invalid configuration: " + err.Error()})
+		return
+	}
+	client := s.clientFor(r, domain)
+	if s.requireApproval {
+		current, err := client.GetDNSConfiguration(strato.ForceRefresh())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		desired = strato.KeepMailSettings(current, desired)
+		if diff := strato.DiffConfigs(current, desired); !diff.Empty() {
+			proposal, err := s.propose(r, domain, current, desired, diff)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusAccepted, proposal)
+			return
+		}
+		writeJSON(w, http.StatusOK, ChangeResult{Domain: domain})
+		return
+	}
+	diff, err := client.UpdateDNSConfiguration(func(current strato.DNSConfig) (strato.DNSConfig, error) {
+		desired = strato.KeepMailSettings(current, desired)
+		return desired, nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	result := ChangeResult{Domain: domain, Diff: diff, Changed: !diff.Empty()}
+	if result.Changed {
+		s.publish(Event{Time: time.Now(), Domain: domain, Source: "api", Diff: diff}, &desired)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// errorBody is the response of a failed request
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Debug("Failed to write response", "error", err)
+	}
+}
+
+// writeError answers with the status matching err
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	switch {
+	case errors.Is(err, strato.ErrDomainNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, strato.ErrReadOnly):
+		status = http.StatusForbidden
+	case errors.Is(err, strato.ErrVerificationFailed), errors.Is(err, strato.ErrRecordQuotaExceeded),
+		errors.Is(err, strato.ErrProtectedRecord), errors.Is(err, errStaleProposal):
+		status = http.StatusConflict
+	case errors.Is(err, errUnauthenticated):
+		if w.Header().Get("WWW-Authenticate") == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		status = http.StatusUnauthorized
+	case errors.Is(err, errForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, errProposalNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, errProposalNotPending), errors.Is(err, errSelfApproval):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, context.Canceled):
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, errorBody{Error: err.Error()})
+}
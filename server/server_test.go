@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/internal/stratotest"
+)
+
+func TestConfigCache(t *testing.T) {
+	portal := stratotest.NewPortal(t)
+	s := New(portal.Client(t, strato.WithCache(time.Hour)), WithTokens(testTokens))
+	for range 2 {
+		if w := request(s, "alice", http.MethodGet, "/v1/domains/example.com/config", ""); w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+		}
+	}
+	if portal.Reads() != 1 {
+		t.Errorf("got %d reads for two requests, want 1", portal.Reads())
+	}
+
+	desired := strato.DNSConfig{Records: []strato.DNSRecord{{Type: "TXT", Prefix: "_acme-challenge", Value: "token"}}}
+	body, err := json.Marshal(desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := request(s, "alice", http.MethodPut, "/v1/domains/example.com/config", string(body)); w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	w := request(s, "alice", http.MethodGet, "/v1/domains/example.com/config", "")
+	var config strato.DNSConfig
+	if err := json.Unmarshal(w.Body.Bytes(), &config); err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Records) != 1 || config.Records[0] != desired.Records[0] {
+		t.Errorf("got records %v after the change, want %v", config.Records, desired.Records)
+	}
+}
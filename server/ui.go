@@ -0,0 +1,168 @@
+package server
+
+import (
+	"embed"
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/fl0eb/go-strato"
+)
+
+//go:embed ui/*.html
+var uiFiles embed.FS
+
+var uiTemplates = template.Must(template.ParseFS(uiFiles, "ui/*.html"))
+
+// recentEvents is how many events the dashboard shows
+const recentEvents = 50
+
+// zoneState is what the server knows about a domain from watching and refreshing it
+type zoneState struct {
+	Domain    string
+	Config    strato.DNSConfig
+	Refreshed time.Time
+	Error     string
+}
+
+// record remembers event for the dashboard and, if config is set, the configuration
+// the domain had at that time
+func (s *Server) record(event Event, config *strato.DNSConfig) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if event.Source != "" && (!event.Diff.Empty() || event.Error != "") {
+		s.recent = append(s.recent, event)
+		if len(s.recent) > recentEvents {
+			s.recent = s.recent[len(s.recent)-recentEvents:]
+		}
+	}
+	zone := s.zones[event.Domain]
+	if zone == nil {
+		zone = &zoneState{Domain: event.Domain}
+		s.zones[event.Domain] = zone
+	}
+	zone.Error = event.Error
+	if config != nil {
+		zone.Config = *config
+		zone.Refreshed = event.Time
+	}
+}
+
+// refresh fetches the configuration of domain and publishes the changes since it was last seen
+func (s *Server) refresh(r *http.Request, domain string) {
+	config, err := s.clientFor(r, domain).GetDNSConfiguration(strato.ForceRefresh())
+	event := Event{Time: time.Now(), Domain: domain, Source: "watch"}
+	if err != nil {
+		event.Error = err.Error()
+		s.record(event, nil)
+		s.events.publish(event)
+		return
+	}
+	s.stateMu.Lock()
+	previous, known := s.zones[domain]
+	if known && !previous.Refreshed.IsZero() {
+		event.Diff = strato.DiffConfigs(previous.Config, config)
+	}
+	s.stateMu.Unlock()
+	s.record(event, &config)
+	if !event.Diff.Empty() {
+		s.events.publish(event)
+	}
+}
+
+// uiPage is the data of the dashboard templates
+type uiPage struct {
+	Title   string
+	Domain  string
+	Error   string
+	Zones   []zoneState
+	Zone    *zoneState
+	Changes []Event
+}
+
+// snapshot copies the zones the token of r may read and the recent changes, newest first
+func (s *Server) snapshot(r *http.Request) ([]zoneState, []Event) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	var zones []zoneState
+	for _, zone := range s.zones {
+		if s.authorize(r, ScopeRead, zone.Domain) == nil {
+			zones = append(zones, *zone)
+		}
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Domain < zones[j].Domain })
+	var changes []Event
+	for i := len(s.recent) - 1; i >= 0; i-- {
+		if s.authorize(r, ScopeRead, s.recent[i].Domain) == nil {
+			changes = append(changes, s.recent[i])
+		}
+	}
+	return zones, changes
+}
+
+func (s *Server) uiDashboard(w http.ResponseWriter, r *http.Request) {
+	if err := s.authorize(r, ScopeRead, ""); err != nil {
+		writeError(w, err)
+		return
+	}
+	page := uiPage{Title: "Zones"}
+	// Domains of the package that were not watched or refreshed yet are listed too
+	vhosts, err := s.client.WithContext(r.Context()).ListVhosts()
+	if err != nil {
+		page.Error = "Failed to list domains: " + err.Error()
+	}
+	s.stateMu.Lock()
+	for _, vhost := range vhosts {
+		if s.zones[vhost] == nil {
+			s.zones[vhost] = &zoneState{Domain: vhost}
+		}
+	}
+	s.stateMu.Unlock()
+	page.Zones, page.Changes = s.snapshot(r)
+	s.render(w, "dashboard", page)
+}
+
+func (s *Server) uiDomain(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	if err := s.authorize(r, ScopeRead, domain); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.stateMu.Lock()
+	zone, known := s.zones[domain]
+	s.stateMu.Unlock()
+	if !known || zone.Refreshed.IsZero() {
+		s.refresh(r, domain)
+	}
+	page := uiPage{Title: domain, Domain: domain}
+	zones, changes := s.snapshot(r)
+	for i := range zones {
+		if zones[i].Domain == domain {
+			page.Zone = &zones[i]
+		}
+	}
+	for _, change := range changes {
+		if change.Domain == domain {
+			page.Changes = append(page.Changes, change)
+		}
+	}
+	s.render(w, "domain", page)
+}
+
+func (s *Server) uiSync(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	if err := s.authorize(r, ScopeRead, domain); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.refresh(r, domain)
+	http.Redirect(w, r, "/ui/domains/"+domain, http.StatusSeeOther)
+}
+
+func (s *Server) render(w http.ResponseWriter, name string, page uiPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := uiTemplates.ExecuteTemplate(w, name, page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,67 @@
+package strato
+
+import (
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileRateLimiter is a rateLimiter whose state is shared through a locked file
+type fileRateLimiter struct {
+	path     string
+	interval time.Duration
+}
+
+// wait reserves the next slot in the file and blocks until it is reached or ctx is done
+func (l *fileRateLimiter) wait(ctx context.Context) error {
+	next, err := l.reserve()
+	if err != nil {
+		return err
+	}
+	if wait := time.Until(next); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
+
+// reserve returns the time the caller may send its request at and stores it as the
+// last request time. The lock is only held while reading and writing the file.
+func (l *fileRateLimiter) reserve() (time.Time, error) {
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer file.Close()
+	if err := lockFile(file); err != nil {
+		return time.Time{}, err
+	}
+	defer unlockFile(file)
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := time.Now()
+	// An empty or unreadable file means no request was sent yet
+	if last, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64); err == nil {
+		if slot := time.Unix(0, last).Add(l.interval); slot.After(next) {
+			next = slot
+		}
+	}
+	if err := file.Truncate(0); err != nil {
+		return time.Time{}, err
+	}
+	if _, err := file.WriteAt([]byte(strconv.FormatInt(next.UnixNano(), 10)), 0); err != nil {
+		return time.Time{}, err
+	}
+	return next, nil
+}
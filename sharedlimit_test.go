@@ -0,0 +1,55 @@
+package strato
+
+import (
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSharedRateLimit(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	path := filepath.Join(t.TempDir(), "ratelimit")
+	const interval = 50 * time.Millisecond
+	// Two clients with limiters of their own, like two processes sharing the file
+	clients := []*StratoClient{
+		newTestClient(t, portal, WithSharedRateLimit(path, interval)),
+		newTestClient(t, portal, WithSharedRateLimit(path, interval)),
+	}
+
+	portal.mu.Lock()
+	portal.requestTimes = nil
+	portal.mu.Unlock()
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 3 {
+				if _, err := client.GetDNSConfiguration(ForceRefresh()); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	portal.mu.Lock()
+	times := slices.Clone(portal.requestTimes)
+	portal.mu.Unlock()
+	if len(times) < 6 {
+		t.Fatalf("got %d requests, want at least 6", len(times))
+	}
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+	// Requests leave at their slot, but may take different times to arrive
+	const tolerance = 15 * time.Millisecond
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < interval-tolerance {
+			t.Errorf("request %d arrived %s after the one before, want at least %s", i, gap, interval)
+		}
+	}
+	if total := times[len(times)-1].Sub(times[0]); total < time.Duration(len(times)-1)*interval-tolerance {
+		t.Errorf("%d requests took %s, want at least %s", len(times), total, time.Duration(len(times)-1)*interval)
+	}
+}
@@ -0,0 +1,131 @@
+package strato
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot is a point in time copy of the DNS configuration of a domain
+type Snapshot struct {
+	Domain string    `json:"domain"`
+	Time   time.Time `json:"time"`
+	Config DNSConfig `json:"config"`
+
+	// rawOrder makes WriteSnapshot keep the records in the order of the portal
+	rawOrder bool
+}
+
+// snapshotTimeFormat is used in snapshot file names, it sorts chronologically
+const snapshotTimeFormat = "20060102T150405.000Z"
+
+// TakeSnapshot fetches the current DNS configuration as a Snapshot
+func (c *StratoClient) TakeSnapshot() (Snapshot, error) {
+	config, err := c.GetDNSConfiguration(ForceRefresh())
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Domain: c.domain, Time: time.Now().UTC(), Config: config, rawOrder: c.rawOrder}, nil
+}
+
+// WriteSnapshot encodes the snapshot as indented JSON. Records are written in
+// canonical order so snapshots of the same configuration are byte-identical,
+// unless the snapshot was taken by a client created WithRawOrder.
+func WriteSnapshot(w io.Writer, snapshot Snapshot) error {
+	if !snapshot.rawOrder {
+		records := append([]DNSRecord(nil), snapshot.Config.Records...)
+		SortRecords(records)
+		snapshot.Config.Records = records
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+// ReadSnapshot decodes a snapshot written by WriteSnapshot
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return Snapshot{}, err
+	}
+	if snapshot.Domain == "" {
+		return Snapshot{}, errors.New("snapshot has no domain")
+	}
+	return snapshot, nil
+}
+
+// SaveSnapshot writes the snapshot to dir as <domain>-<timestamp>.json and returns the file path
+func SaveSnapshot(dir string, snapshot Snapshot) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	name := strings.ReplaceAll(snapshot.Domain, "/", "_") + "-" + snapshot.Time.UTC().Format(snapshotTimeFormat) + ".json"
+	path := filepath.Join(dir, name)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if err := WriteSnapshot(file, snapshot); err != nil {
+		file.Close()
+		return "", err
+	}
+	return path, file.Close()
+}
+
+// LoadSnapshot reads a snapshot file
+func LoadSnapshot(path string) (Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer file.Close()
+	return ReadSnapshot(file)
+}
+
+// LatestSnapshot returns the most recent snapshot of the domain stored in dir and its file path
+func LatestSnapshot(dir, domain string) (Snapshot, string, error) {
+	pattern := filepath.Join(dir, strings.ReplaceAll(domain, "/", "_")+"-*.json")
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return Snapshot{}, "", err
+	}
+	if len(paths) == 0 {
+		return Snapshot{}, "", errors.New("no snapshot found for " + domain)
+	}
+	// The timestamp in the file name sorts chronologically
+	sort.Strings(paths)
+	path := paths[len(paths)-1]
+	snapshot, err := LoadSnapshot(path)
+	if err != nil {
+		return Snapshot{}, "", err
+	}
+	return snapshot, path, nil
+}
+
+// Undo re-applies the most recent pre-change snapshot from the state directory
+// and removes it, so repeated calls step further back in history.
+func (c *StratoClient) Undo() (Snapshot, error) {
+	if c.stateDir == "" {
+		return Snapshot{}, errors.New("undo requires a state directory")
+	}
+	snapshot, path, err := LatestSnapshot(c.stateDir, c.domain)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if proceed, err := c.checkWrite("undo"); !proceed {
+		return snapshot, err
+	}
+	// The configuration being undone is not saved as a snapshot, otherwise the
+	// next undo would restore it again instead of stepping further back
+	clone := *c
+	clone.stateDir = ""
+	if err := clone.SetDNSConfiguration(snapshot.Config); err != nil {
+		return Snapshot{}, err
+	}
+	return snapshot, os.Remove(path)
+}
@@ -0,0 +1,297 @@
+// Package spf builds SPF records, checks them against the DNS lookup limit of
+// RFC 7208 and flattens includes into address ranges.
+package spf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/fl0eb/go-strato"
+)
+
+// MaxLookups is the number of DNS lookups an SPF evaluation may cause (RFC 7208 4.6.4)
+const MaxLookups = 10
+
+// ErrTooManyLookups is returned by Validate if a record exceeds MaxLookups
+var ErrTooManyLookups = errors.New("spf record exceeds the DNS lookup limit")
+
+// Qualifier is the result of the all mechanism
+type Qualifier string
+
+const (
+	Pass     Qualifier = "+"
+	Fail     Qualifier = "-"
+	SoftFail Qualifier = "~"
+	Neutral  Qualifier = "?"
+)
+
+// Record is an SPF policy made of include, ip4, ip6 and all directives
+type Record struct {
+	Includes []string
+	IP4      []netip.Prefix
+	IP6      []netip.Prefix
+	// All is the qualifier of the final all mechanism; empty leaves it out
+	All Qualifier
+}
+
+// Resolver looks up the DNS records needed to evaluate includes. *net.Resolver implements it.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// ParsePrefix parses an address or address range as used by ip4 and ip6 directives
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// String formats the record as TXT value
+func (r Record) String() string {
+	terms := []string{"v=spf1"}
+	for _, prefix := range r.IP4 {
+		terms = append(terms, "ip4:"+formatPrefix(prefix))
+	}
+	for _, prefix := range r.IP6 {
+		terms = append(terms, "ip6:"+formatPrefix(prefix))
+	}
+	for _, include := range r.Includes {
+		terms = append(terms, "include:"+include)
+	}
+	if r.All != "" {
+		terms = append(terms, string(r.All)+"all")
+	}
+	return strings.Join(terms, " ")
+}
+
+func formatPrefix(prefix netip.Prefix) string {
+	if prefix.IsSingleIP() {
+		return prefix.Addr().String()
+	}
+	return prefix.String()
+}
+
+// Lookups counts the DNS lookups an evaluation of the record causes, following includes
+func (r Record) Lookups(ctx context.Context, resolver Resolver) (int, error) {
+	e := &expansion{resolver: resolver, seen: map[string]bool{}}
+	for _, include := range r.Includes {
+		e.lookups++
+		if err := e.expand(ctx, include); err != nil {
+			return 0, err
+		}
+	}
+	return e.lookups, nil
+}
+
+// Validate checks the record against the lookup limit
+func (r Record) Validate(ctx context.Context, resolver Resolver) error {
+	if r.All != "" && r.All != Pass && r.All != Fail && r.All != SoftFail && r.All != Neutral {
+		return fmt.Errorf("invalid all qualifier: %q", r.All)
+	}
+	for _, prefix := range r.IP4 {
+		if !prefix.Addr().Is4() {
+			return fmt.Errorf("ip4 directive with IPv6 range: %s", prefix)
+		}
+	}
+	for _, prefix := range r.IP6 {
+		if !prefix.Addr().Is6() || prefix.Addr().Is4In6() {
+			return fmt.Errorf("ip6 directive with IPv4 range: %s", prefix)
+		}
+	}
+	lookups, err := r.Lookups(ctx, resolver)
+	if err != nil {
+		return err
+	}
+	if lookups > MaxLookups {
+		return fmt.Errorf("%w: %d of %d", ErrTooManyLookups, lookups, MaxLookups)
+	}
+	return nil
+}
+
+// Flatten replaces the includes of the record by the address ranges they resolve to,
+// so the record needs no lookups at all. Only the pass mechanisms of included records
+// are taken over; records using exists, ptr or macros cannot be flattened. The result
+// is a snapshot and has to be refreshed when the included providers change addresses.
+func (r Record) Flatten(ctx context.Context, resolver Resolver) (Record, error) {
+	e := &expansion{resolver: resolver, seen: map[string]bool{}, flatten: true}
+	for _, include := range r.Includes {
+		if err := e.expand(ctx, include); err != nil {
+			return Record{}, err
+		}
+	}
+	flat := Record{All: r.All}
+	seen := map[netip.Prefix]bool{}
+	for _, prefix := range append(append(append([]netip.Prefix{}, r.IP4...), r.IP6...), e.prefixes...) {
+		prefix = prefix.Masked()
+		if seen[prefix] {
+			continue
+		}
+		seen[prefix] = true
+		if prefix.Addr().Is4() {
+			flat.IP4 = append(flat.IP4, prefix)
+		} else {
+			flat.IP6 = append(flat.IP6, prefix)
+		}
+	}
+	return flat, nil
+}
+
+// expansion walks included SPF records, counting lookups and collecting address ranges
+type expansion struct {
+	resolver Resolver
+	seen     map[string]bool
+	flatten  bool
+	lookups  int
+	prefixes []netip.Prefix
+}
+
+func (e *expansion) expand(ctx context.Context, domain string) error {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if e.seen[domain] {
+		return fmt.Errorf("spf include loop at %s", domain)
+	}
+	e.seen[domain] = true
+	defer delete(e.seen, domain)
+
+	value, err := e.lookupSPF(ctx, domain)
+	if err != nil {
+		return err
+	}
+	for _, term := range strings.Fields(value)[1:] {
+		qualifier := Pass
+		if strings.ContainsAny(term[:1], "+-~?") {
+			qualifier, term = Qualifier(term[:1]), term[1:]
+		}
+		name, arg, _ := strings.Cut(term, ":")
+		if strings.HasPrefix(name, "redirect=") {
+			name, arg = "redirect", strings.TrimPrefix(name, "redirect=")
+		} else if strings.Contains(name, "=") {
+			// Other modifiers like exp= do not affect the result
+			continue
+		}
+		if strings.Contains(arg, "%{") {
+			return fmt.Errorf("spf record of %s uses macros: %s", domain, term)
+		}
+		name = strings.ToLower(name)
+		switch name {
+		case "include", "redirect", "a", "mx", "ptr", "exists":
+			e.lookups++
+		}
+		if e.lookups > 10*MaxLookups {
+			return fmt.Errorf("spf record of %s causes too many lookups", domain)
+		}
+		switch name {
+		case "include", "redirect":
+			if err := e.expand(ctx, arg); err != nil {
+				return err
+			}
+			continue
+		case "all":
+			// The all of an included record does not affect the including one
+			continue
+		}
+		if !e.flatten || qualifier != Pass {
+			continue
+		}
+		switch name {
+		case "ip4", "ip6":
+			prefix, err := ParsePrefix(arg)
+			if err != nil {
+				return fmt.Errorf("spf record of %s: %w", domain, err)
+			}
+			e.prefixes = append(e.prefixes, prefix)
+		case "a", "mx":
+			if strings.Contains(arg, "/") {
+				return fmt.Errorf("cannot flatten %s of %s: CIDR lengths are not supported", term, domain)
+			}
+			if arg == "" {
+				arg = domain
+			}
+			if err := e.resolveHosts(ctx, name, arg); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("cannot flatten %s of %s", term, domain)
+		}
+	}
+	return nil
+}
+
+// lookupSPF returns the SPF record published for domain
+func (e *expansion) lookupSPF(ctx context.Context, domain string) (string, error) {
+	values, err := e.resolver.LookupTXT(ctx, domain)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up spf record of %s: %w", domain, err)
+	}
+	var found []string
+	for _, value := range values {
+		if value == "v=spf1" || strings.HasPrefix(strings.ToLower(value), "v=spf1 ") {
+			found = append(found, value)
+		}
+	}
+	if len(found) != 1 {
+		return "", fmt.Errorf("expected one spf record for %s, found %d", domain, len(found))
+	}
+	return found[0], nil
+}
+
+// resolveHosts adds the addresses of the a or mx mechanism for host
+func (e *expansion) resolveHosts(ctx context.Context, mechanism, host string) error {
+	hosts := []string{host}
+	if mechanism == "mx" {
+		mxs, err := e.resolver.LookupMX(ctx, host)
+		if err != nil {
+			return fmt.Errorf("failed to look up mx of %s: %w", host, err)
+		}
+		hosts = hosts[:0]
+		for _, mx := range mxs {
+			hosts = append(hosts, mx.Host)
+		}
+	}
+	for _, host := range hosts {
+		addrs, err := e.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return fmt.Errorf("failed to look up addresses of %s: %w", host, err)
+		}
+		for _, ipAddr := range addrs {
+			if addr, ok := netip.AddrFromSlice(ipAddr.IP); ok {
+				addr = addr.Unmap()
+				e.prefixes = append(e.prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			}
+		}
+	}
+	return nil
+}
+
+// Apply checks record, resolving its mechanisms with resolver, and publishes it as the
+// SPF record of the root domain of the client. Any earlier v=spf1 record of the root
+// domain goes, and so does the one Strato adds itself, since a second SPF record
+// makes every check fail. Nothing is written if the record is already the only one.
+func Apply(ctx context.Context, client *strato.StratoClient, record Record, resolver Resolver) (bool, error) {
+	if err := record.Validate(ctx, resolver); err != nil {
+		return false, err
+	}
+	changed := false
+	err := client.WithContext(ctx).UpdateZone(func(zone *strato.Zone) (bool, error) {
+		changed = zone.Replace(strato.DNSRecord{Type: "TXT", Value: record.String()}, func(existing strato.DNSRecord) bool {
+			return strings.HasPrefix(existing.Value, "v=spf1")
+		})
+		if zone.SPFType != "" && zone.SPFType != strato.SPFTypeNone {
+			zone.SPFType = strato.SPFTypeNone
+			changed = true
+		}
+		return changed, nil
+	})
+	return changed, err
+}
@@ -0,0 +1,201 @@
+package spf
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/fl0eb/go-strato"
+	"github.com/fl0eb/go-strato/internal/stratotest"
+)
+
+// fakeResolver answers from maps and with a not found error for other names
+type fakeResolver struct {
+	txt   map[string][]string
+	addrs map[string][]string
+	mx    map[string][]string
+}
+
+func notFound(name string) error {
+	return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	values, ok := r.txt[name]
+	if !ok {
+		return nil, notFound(name)
+	}
+	return values, nil
+}
+
+func (r fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addrs, ok := r.addrs[host]
+	if !ok {
+		return nil, notFound(host)
+	}
+	var ipAddrs []net.IPAddr
+	for _, addr := range addrs {
+		ipAddrs = append(ipAddrs, net.IPAddr{IP: net.ParseIP(addr)})
+	}
+	return ipAddrs, nil
+}
+
+func (r fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	hosts, ok := r.mx[name]
+	if !ok {
+		return nil, notFound(name)
+	}
+	var mxs []*net.MX
+	for _, host := range hosts {
+		mxs = append(mxs, &net.MX{Host: host, Pref: 10})
+	}
+	return mxs, nil
+}
+
+// resolver publishes the SPF records of two mail providers
+var resolver = fakeResolver{
+	txt: map[string][]string{
+		"_spf.provider.test":  {"google-site-verification=abc", "v=spf1 include:_spf2.provider.test a mx ip4:192.0.2.0/24 ip6:2001:db8::/32 ~ip4:198.51.100.1 ?a:other.test -all"},
+		"_spf2.provider.test": {"v=spf1 redirect=_spf3.provider.test"},
+		"_spf3.provider.test": {"v=spf1 ip4:203.0.113.0/28 exp=explain.provider.test -all"},
+		"loop.test":           {"v=spf1 include:loop2.test -all"},
+		"loop2.test":          {"v=spf1 redirect=LOOP.test."},
+		"macro.test":          {"v=spf1 exists:%{i}._spf.macro.test -all"},
+		"ptr.test":            {"v=spf1 ptr -all"},
+		"double.test":         {"v=spf1 -all", "v=spf1 +all"},
+	},
+	addrs: map[string][]string{
+		"_spf.provider.test": {"203.0.113.20"},
+		"mail.provider.test": {"2001:db8:1::25", "::ffff:203.0.113.25"},
+	},
+	mx: map[string][]string{
+		"_spf.provider.test": {"mail.provider.test"},
+	},
+}
+
+func TestLookups(t *testing.T) {
+	tests := []struct {
+		includes []string
+		want     int
+		err      string
+	}{
+		{nil, 0, ""},
+		// include _spf, include _spf2, redirect _spf3, a, mx and the neutral a
+		{[]string{"_spf.provider.test"}, 6, ""},
+		{[]string{"_spf3.provider.test", "_spf3.provider.test"}, 2, ""},
+		{[]string{"loop.test"}, 0, "loop"},
+		{[]string{"macro.test"}, 0, "macros"},
+		{[]string{"double.test"}, 0, "expected one spf record"},
+		{[]string{"missing.test"}, 0, "failed to look up"},
+	}
+	for _, test := range tests {
+		got, err := Record{Includes: test.includes}.Lookups(context.Background(), resolver)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Lookups of %v: got error %v, want %q", test.includes, err, test.err)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("Lookups of %v = %d, %v, want %d", test.includes, got, err, test.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tooMany := Record{All: Fail}
+	for range 3 {
+		tooMany.Includes = append(tooMany.Includes, "_spf.provider.test")
+	}
+	if err := tooMany.Validate(context.Background(), resolver); !errors.Is(err, ErrTooManyLookups) {
+		t.Errorf("got %v for 18 lookups, want ErrTooManyLookups", err)
+	}
+	tests := []struct {
+		record Record
+		valid  bool
+	}{
+		{Record{Includes: []string{"_spf.provider.test"}, All: SoftFail}, true},
+		{Record{Includes: []string{"_spf.provider.test", "_spf.provider.test"}}, false},
+		{Record{IP4: []netip.Prefix{netip.MustParsePrefix("192.0.2.1/32")}, All: "x"}, false},
+		{Record{IP4: []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")}}, false},
+		{Record{IP6: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}}, false},
+		{Record{IP6: []netip.Prefix{netip.MustParsePrefix("::ffff:192.0.2.1/128")}}, false},
+	}
+	for _, test := range tests {
+		if err := test.record.Validate(context.Background(), resolver); (err == nil) != test.valid {
+			t.Errorf("Validate(%s) = %v, want valid %t", test.record, err, test.valid)
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	record := Record{
+		Includes: []string{"_spf.provider.test"},
+		IP4:      []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24"), netip.MustParsePrefix("192.0.2.7/32")},
+		All:      Fail,
+	}
+	flat, err := record.Flatten(context.Background(), resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The softfail and neutral mechanisms of the provider are left out, the range
+	// given twice is kept once
+	want := "v=spf1 ip4:192.0.2.0/24 ip4:192.0.2.7 ip4:203.0.113.0/28 ip4:203.0.113.20 ip4:203.0.113.25 " +
+		"ip6:2001:db8:1::25 ip6:2001:db8::/32 -all"
+	if flat.String() != want {
+		t.Errorf("got %s\nwant %s", flat, want)
+	}
+	if lookups, err := flat.Lookups(context.Background(), resolver); err != nil || lookups != 0 {
+		t.Errorf("flattened record needs %d lookups (%v), want 0", lookups, err)
+	}
+
+	for _, include := range []string{"macro.test", "ptr.test", "loop.test"} {
+		if _, err := (Record{Includes: []string{include}}).Flatten(context.Background(), resolver); err == nil {
+			t.Errorf("flattened include:%s, want an error", include)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	portal := stratotest.NewPortal(t)
+	portal.SetConfig("example.com", strato.DNSConfig{SPFType: strato.SPFTypeStrato, Records: []strato.DNSRecord{
+		{Type: "TXT", Value: "v=spf1 include:old.test -all"},
+		{Type: "TXT", Value: "google-site-verification=abc"},
+		{Type: "TXT", Prefix: "mail", Value: "v=spf1 a -all"},
+	}})
+	client := portal.Client(t)
+	record := Record{Includes: []string{"_spf3.provider.test"}, All: SoftFail}
+
+	changed, err := Apply(context.Background(), client, record, resolver)
+	if err != nil || !changed {
+		t.Fatalf("Apply = %t, %v, want a change", changed, err)
+	}
+	config := portal.Config("example.com")
+	want := []strato.DNSRecord{
+		{Type: "TXT", Value: "google-site-verification=abc"},
+		{Type: "TXT", Prefix: "mail", Value: "v=spf1 a -all"},
+		{Type: "TXT", Value: "v=spf1 include:_spf3.provider.test ~all"},
+	}
+	if config.SPFType != strato.SPFTypeNone {
+		t.Errorf("got spf type %q, want %q", config.SPFType, strato.SPFTypeNone)
+	}
+	if len(config.Records) != len(want) {
+		t.Fatalf("got records %v, want %v", config.Records, want)
+	}
+	for _, record := range want {
+		if !strato.NewZone(config).Contains(record) {
+			t.Errorf("record %v is missing in %v", record, config.Records)
+		}
+	}
+
+	writes := portal.Writes()
+	if changed, err := Apply(context.Background(), client, record, resolver); err != nil || changed {
+		t.Errorf("repeated Apply = %t, %v, want no change", changed, err)
+	}
+	if portal.Writes() != writes {
+		t.Error("repeated Apply wrote the zone")
+	}
+}
@@ -0,0 +1,67 @@
+package strato
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// TrafficStats holds the traffic of a domain in one month
+type TrafficStats struct {
+	Domain string `json:"domain"`
+	// Month is the first day of the month the figures belong to
+	Month    time.Time    `json:"month"`
+	Bytes    int64        `json:"bytes"`
+	Requests int64        `json:"requests"`
+	Days     []DailyUsage `json:"days"`
+}
+
+// DailyUsage is the traffic of a single day
+type DailyUsage struct {
+	Date     time.Time `json:"date"`
+	Bytes    int64     `json:"bytes"`
+	Requests int64     `json:"requests"`
+}
+
+// GetTrafficStats retrieves bandwidth and request counts of a domain for the month containing month
+func (c *StratoClient) GetTrafficStats(domain string, month time.Time) (TrafficStats, error) {
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	doc, err := c.fetchPage(c.region.StatisticsNode, "vhost="+domain, "month="+month.Format("2006-01"))
+	if err != nil {
+		return TrafficStats{}, err
+	}
+	table := htmlquery.FindOne(doc, "//table[@id='jss_traffic_table']")
+	if table == nil {
+		return TrafficStats{}, errors.New("failed to find traffic table")
+	}
+	stats := TrafficStats{Domain: domain, Month: month}
+	for _, row := range htmlquery.Find(table, ".//tr[@data-day]") {
+		day, err := time.Parse(time.DateOnly, htmlquery.SelectAttr(row, "data-day"))
+		if err != nil {
+			return TrafficStats{}, err
+		}
+		usage := DailyUsage{Date: day}
+		if usage.Bytes, err = parseCount(htmlquery.SelectAttr(row, "data-bytes")); err != nil {
+			return TrafficStats{}, err
+		}
+		if usage.Requests, err = parseCount(htmlquery.SelectAttr(row, "data-requests")); err != nil {
+			return TrafficStats{}, err
+		}
+		stats.Bytes += usage.Bytes
+		stats.Requests += usage.Requests
+		stats.Days = append(stats.Days, usage)
+	}
+	return stats, nil
+}
+
+// parseCount parses an integer that may contain thousands separators of any portal language
+func parseCount(value string) (int64, error) {
+	value = strings.NewReplacer(".", "", ",", "", " ", "", "\u00a0", "").Replace(value)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
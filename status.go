@@ -0,0 +1,127 @@
+package strato
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SyncStatus is the health of the synchronization of one domain
+type SyncStatus struct {
+	Domain      string    `json:"domain"`
+	LastSuccess time.Time `json:"lastSuccess"`
+	// LastError is the error of the most recent failed round, kept after later successes
+	LastError     string    `json:"lastError,omitempty"`
+	LastErrorTime time.Time `json:"lastErrorTime"`
+	// ConsecutiveFailures counts the failed rounds since the last success
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+	// NextRetry is when the next round is due, zero for one-shot runs
+	NextRetry time.Time `json:"nextRetry"`
+}
+
+// Failing reports whether the last round of the domain failed
+func (s SyncStatus) Failing() bool {
+	return s.ConsecutiveFailures > 0
+}
+
+// StatusTracker keeps the SyncStatus of every domain. If it has a path, every change
+// is written to that file, so the status command can read it from another process.
+type StatusTracker struct {
+	path string
+
+	mu       sync.Mutex
+	statuses map[string]SyncStatus
+}
+
+// NewStatusTracker returns a tracker persisting to path, continuing with the
+// statuses already in the file. An empty path keeps the statuses in memory only.
+func NewStatusTracker(path string) (*StatusTracker, error) {
+	t := &StatusTracker{path: path, statuses: map[string]SyncStatus{}}
+	if path == "" {
+		return t, nil
+	}
+	statuses, err := LoadSyncStatus(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		t.statuses[status.Domain] = status
+	}
+	return t, nil
+}
+
+// Record stores the outcome of a round for domain; next is when the following round is due
+func (t *StatusTracker) Record(domain string, err error, next time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status := t.statuses[domain]
+	status.Domain = domain
+	status.NextRetry = next
+	if err != nil {
+		status.LastError = err.Error()
+		status.LastErrorTime = time.Now().UTC()
+		status.ConsecutiveFailures++
+	} else {
+		status.LastSuccess = time.Now().UTC()
+		status.ConsecutiveFailures = 0
+	}
+	t.statuses[domain] = status
+	if t.path == "" {
+		return
+	}
+	if err := t.save(); err != nil {
+		logFor(LogForm).Warn("Failed to write sync status", "path", t.path, "error", err)
+	}
+}
+
+// Statuses returns the status of every domain, sorted by domain
+func (t *StatusTracker) Statuses() []SyncStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	statuses := make([]SyncStatus, 0, len(t.statuses))
+	for _, status := range t.statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Domain < statuses[j].Domain })
+	return statuses
+}
+
+func (t *StatusTracker) save() error {
+	data, err := json.MarshalIndent(t.statuses, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o700); err != nil {
+		return err
+	}
+	return WriteFileAtomic(t.path, data, 0o600)
+}
+
+// LoadSyncStatus reads the statuses a StatusTracker wrote to path, sorted by domain.
+// A missing file yields no statuses.
+func LoadSyncStatus(path string) ([]SyncStatus, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	statuses := map[string]SyncStatus{}
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil, fmt.Errorf("invalid sync status file %s: %w", path, err)
+	}
+	t := &StatusTracker{statuses: statuses}
+	return t.Statuses(), nil
+}
+
+// recordStatus passes the outcome of a round to the tracker set with WithStatusTracker
+func (c *StratoClient) recordStatus(domain string, err error, next time.Time) {
+	if c.status != nil {
+		c.status.Record(domain, err, next)
+	}
+}
@@ -0,0 +1,77 @@
+package strato
+
+import (
+	"errors"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// StorageClient accesses the hiDrive storage product of an account through the
+// authenticated session of a StratoClient
+type StorageClient struct {
+	client *StratoClient
+	cID    string
+}
+
+// StorageQuota is the used and total storage space as displayed by the portal (e.g. "120 GB")
+type StorageQuota struct {
+	Used  string `json:"used"`
+	Total string `json:"total"`
+}
+
+// ShareLink is a public link to a file or folder on hiDrive
+type ShareLink struct {
+	URL  string `json:"url"`
+	Path string `json:"path"`
+	// Expiry is zero for links that do not expire
+	Expiry time.Time `json:"expiry"`
+}
+
+// Storage returns a client for the hiDrive package with the given order number,
+// reusing the session of c so no additional login is needed
+func (c *StratoClient) Storage(order string) (*StorageClient, error) {
+	cID, err := c.packageID(order)
+	if err != nil {
+		return nil, err
+	}
+	return &StorageClient{client: c, cID: cID}, nil
+}
+
+// Quota retrieves the storage usage of the hiDrive package
+func (s *StorageClient) Quota() (StorageQuota, error) {
+	doc, err := s.client.fetchPackagePage(s.cID, s.client.region.StorageNode)
+	if err != nil {
+		return StorageQuota{}, err
+	}
+	quotaNode := htmlquery.FindOne(doc, "//*[@id='jss_storage_quota']")
+	if quotaNode == nil {
+		return StorageQuota{}, errors.New("failed to find storage quota")
+	}
+	return StorageQuota{
+		Used:  textOf(quotaNode, ".//*[contains(@class, 'used')]"),
+		Total: textOf(quotaNode, ".//*[contains(@class, 'total')]"),
+	}, nil
+}
+
+// ListShareLinks retrieves the public share links of the hiDrive package
+func (s *StorageClient) ListShareLinks() ([]ShareLink, error) {
+	doc, err := s.client.fetchPackagePage(s.cID, s.client.region.StorageNode, "action_show_shares")
+	if err != nil {
+		return nil, err
+	}
+	var links []ShareLink
+	for _, row := range htmlquery.Find(doc, "//table[@id='jss_share_table']//tr[@data-share-url]") {
+		link := ShareLink{
+			URL:  htmlquery.SelectAttr(row, "data-share-url"),
+			Path: textOf(row, ".//td[contains(@class, 'path')]"),
+		}
+		if expiry := textOf(row, ".//td[contains(@class, 'expiry')]"); expiry != "" {
+			if link.Expiry, err = parsePortalDate(expiry); err != nil {
+				return nil, err
+			}
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
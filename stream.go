@@ -0,0 +1,168 @@
+package strato
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// parseDNSConfigurationStream extracts the configuration from the TXT record page
+// token by token. It accepts the same markup as parseDNSConfiguration.
+func parseDNSConfigurationStream(r io.Reader, lenient bool) (DNSConfig, error) {
+	var (
+		config                   DNSConfig
+		foundForm, done          bool
+		hasDMARC, hasSPF         bool
+		checkedDMARC, checkedSPF bool
+		inForm                   bool
+		// depth of open div elements inside the form, and the depths of the
+		// record container and the current record (0 if outside)
+		depth, containerDepth, recordDepth int
+		record                             DNSRecord
+		hasType, hasValue                  bool
+		recordCount                        int
+		inSelect, inTextarea               bool
+		value                              strings.Builder
+	)
+
+	tokenizer := html.NewTokenizer(r)
+	for !done {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return DNSConfig{}, err
+			}
+			done = true
+		case html.TextToken:
+			if inTextarea {
+				value.Write(tokenizer.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			if !inForm {
+				if token.Data == "form" && attr(token, "id") == "jss_txt_record_form" {
+					inForm, foundForm = true, true
+				}
+				continue
+			}
+			switch token.Data {
+			case "div":
+				if tokenType == html.SelfClosingTagToken {
+					continue
+				}
+				depth++
+				if containerDepth == 0 && attr(token, "id") == "jss_txt_container" {
+					containerDepth = depth
+					config.MaxRecords = maxRecords(attr(token, "data-max-records"))
+				} else if containerDepth > 0 && recordDepth == 0 && depth == containerDepth+1 &&
+					strings.Contains(attr(token, "class"), "txt-record-tmpl") {
+					recordDepth = depth
+					record, hasType, hasValue = DNSRecord{}, false, false
+				}
+			case "input":
+				_, checked := lookupAttr(token, "checked")
+				switch name := attr(token, "name"); {
+				case name == "dmarc_type":
+					hasDMARC = true
+					if checked && !checkedDMARC {
+						config.DMARCType, checkedDMARC = attr(token, "value"), true
+					}
+				case name == "spf_type":
+					hasSPF = true
+					if checked && !checkedSPF {
+						config.SPFType, checkedSPF = attr(token, "value"), true
+					}
+				case name == "prefix" && recordDepth > 0:
+					record.Prefix = attr(token, "value")
+				}
+			case "select":
+				inSelect = recordDepth > 0 && attr(token, "name") == "type"
+			case "option":
+				if _, selected := lookupAttr(token, "selected"); inSelect && selected && !hasType {
+					record.Type = attr(token, "value")
+					hasType = true
+				}
+			case "textarea":
+				if recordDepth > 0 && attr(token, "name") == "value" && !hasValue {
+					inTextarea = true
+					value.Reset()
+				}
+			}
+		case html.EndTagToken:
+			if !inForm {
+				continue
+			}
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "form":
+				done = true
+			case "select":
+				inSelect = false
+			case "textarea":
+				if inTextarea {
+					record.Value = value.String()
+					inTextarea, hasValue = false, true
+				}
+			case "div":
+				if depth == recordDepth && recordDepth > 0 {
+					recordCount++
+					if hasType && hasValue {
+						config.Records = append(config.Records, record)
+					} else if lenient {
+						config.Warnings = append(config.Warnings, fmt.Sprintf("skipped record %d without type or value", recordCount))
+					}
+					recordDepth = 0
+				}
+				if depth == containerDepth {
+					containerDepth = 0
+				}
+				if depth > 0 {
+					depth--
+				}
+			}
+		}
+	}
+
+	if !foundForm {
+		return DNSConfig{}, errTXTFormNotFound
+	}
+	for _, check := range []struct {
+		present, checked bool
+		value, name      string
+	}{
+		{hasDMARC, checkedDMARC, config.DMARCType, "dmarc_type"},
+		{hasSPF, checkedSPF, config.SPFType, "spf_type"},
+	} {
+		var message string
+		if check.present && !check.checked {
+			message = "failed to find " + check.name + " element"
+		} else if check.present && check.value == "" {
+			message = "failed to find " + check.name + " value"
+		}
+		if message != "" {
+			if err := problem(&config, lenient, message); err != nil {
+				return DNSConfig{}, err
+			}
+		}
+	}
+	return config, nil
+}
+
+// attr returns the value of the attribute key of token
+func attr(token html.Token, key string) string {
+	value, _ := lookupAttr(token, key)
+	return value
+}
+
+// lookupAttr returns the value of the attribute key of token and whether it is present
+func lookupAttr(token html.Token, key string) (string, bool) {
+	for _, a := range token.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,84 @@
+package strato
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// streamParityPages are pages both parsers must read alike, besides the fixtures
+var streamParityPages = map[string]string{
+	"empty form": `<form id="jss_txt_record_form"></form>`,
+	"record without value": `<form id="jss_txt_record_form"><div id="jss_txt_container">
+		<div class="txt-record-tmpl"><select name="type"><option value="TXT" selected>TXT</option></select><input name="prefix" value="a"></div>
+		<div class="txt-record-tmpl"><select name="type"><option value="TXT" selected>TXT</option></select><textarea name="value">v</textarea></div>
+	</div></form>`,
+	"record without selected type": `<form id="jss_txt_record_form"><div id="jss_txt_container">
+		<div class="txt-record-tmpl"><select name="type"><option value="TXT">TXT</option></select><textarea name="value">v</textarea></div>
+	</div></form>`,
+	"nested markup in record": `<form id="jss_txt_record_form"><div id="jss_txt_container">
+		<div class="txt-record-tmpl row"><div class="col"><div><select name="type"><option value="CNAME" selected>CNAME</option></select></div></div>
+		<div class="col"><input name="prefix" value="www"><textarea name="value">example.com.</textarea></div></div>
+	</div></form>`,
+	"entities in value": `<form id="jss_txt_record_form"><div id="jss_txt_container">
+		<div class="txt-record-tmpl"><select name="type"><option value="TXT" selected>TXT</option></select><input name="prefix" value="a&amp;b"><textarea name="value">&quot;x&quot; &lt;y&gt;</textarea></div>
+	</div></form>`,
+	"unchecked mail settings": `<form id="jss_txt_record_form"><input type="radio" name="dmarc_type" value="strato"><input type="radio" name="spf_type" value="none" checked></form>`,
+	"empty mail setting":      `<form id="jss_txt_record_form"><input type="radio" name="dmarc_type" value="" checked></form>`,
+	"no form":                 `<html><body><div id="jss_txt_container"></div></body></html>`,
+}
+
+func TestStreamParserParity(t *testing.T) {
+	pages := map[string][]byte{}
+	for _, name := range []string{"txt_form.html", "txt_form_rejected.html", "txt_form_saved.html", "unknown_vhost.html", "login.html"} {
+		pages[name] = fixture(t, name)
+	}
+	for name, page := range streamParityPages {
+		pages[name] = []byte(page)
+	}
+	for _, n := range benchmarkSizes {
+		pages[fmt.Sprintf("%d records", n)] = txtFormPage(largeConfig(n))
+	}
+	for name, page := range pages {
+		for _, lenient := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/lenient=%v", name, lenient), func(t *testing.T) {
+				want, wantErr := parseDNSConfiguration(bytes.NewReader(page), lenient)
+				got, err := parseDNSConfigurationStream(bytes.NewReader(page), lenient)
+				if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+					t.Fatalf("got error %v, htmlquery parser returned %v", err, wantErr)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("got %+v, htmlquery parser returned %+v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestTXTFormPage(t *testing.T) {
+	config := largeConfig(20)
+	config.MaxRecords = 1000
+	got, err := parseDNSConfiguration(bytes.NewReader(txtFormPage(config)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, config) {
+		t.Errorf("got %+v, want %+v", got, config)
+	}
+}
+
+func BenchmarkParseDNSConfigurationStream(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		page := txtFormPage(largeConfig(n))
+		b.Run(fmt.Sprintf("records=%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(page)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := parseDNSConfigurationStream(bytes.NewReader(page), false); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package strato
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SyncResult is the outcome of reconciling one domain
+type SyncResult struct {
+	Domain string `json:"domain"`
+	// Diff holds the changes that were applied (or had to be applied, if Err is set).
+	// For CheckDrift it holds the differences found.
+	Diff    ConfigDiff `json:"diff"`
+	Changed bool       `json:"changed"`
+	Err     error      `json:"-"`
+}
+
+// SyncReport collects the results of SyncAll per domain
+type SyncReport struct {
+	Results []SyncResult `json:"results"`
+}
+
+// Failed returns the results of domains that could not be reconciled
+func (r SyncReport) Failed() []SyncResult {
+	var failed []SyncResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err joins the errors of all failed domains, or returns nil if every domain was reconciled
+func (r SyncReport) Err() error {
+	var errs []error
+	for _, result := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s: %w", result.Domain, result.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// ForDomain returns a client for another domain of the same package that shares the
+// authenticated session and the read cache of c
+func (c *StratoClient) ForDomain(domain string) *StratoClient {
+	clone := *c
+	clone.domain = domain
+	return &clone
+}
+
+// SyncAll reconciles the DNS configuration of several domains of the package towards
+// desired, working on up to concurrency domains at the same time. Requests of all
+// workers share the rate limit configured with WithRateLimit. Failures of single
+// domains do not stop the others; they are collected in the returned report.
+func (c *StratoClient) SyncAll(ctx context.Context, desired map[string]DNSConfig, concurrency int) SyncReport {
+	return c.syncAll(ctx, desired, concurrency, time.Time{})
+}
+
+// syncAll runs SyncAll and records the result of every domain with the next round due at next
+func (c *StratoClient) syncAll(ctx context.Context, desired map[string]DNSConfig, concurrency int, next time.Time) SyncReport {
+	report := c.forEachDomain(ctx, desired, concurrency, (*StratoClient).syncDomain)
+	for _, result := range report.Results {
+		c.recordStatus(result.Domain, result.Err, next)
+	}
+	return report
+}
+
+// CheckDrift compares the DNS configuration of several domains with desired without
+// changing anything. Results with a non-empty Diff are returned by Drifted.
+func (c *StratoClient) CheckDrift(ctx context.Context, desired map[string]DNSConfig, concurrency int) SyncReport {
+	return c.forEachDomain(ctx, desired, concurrency, (*StratoClient).driftDomain)
+}
+
+// Drifted returns the results of domains whose configuration differs from the desired one
+func (r SyncReport) Drifted() []SyncResult {
+	var drifted []SyncResult
+	for _, result := range r.Results {
+		if result.Err == nil && !result.Diff.Empty() {
+			drifted = append(drifted, result)
+		}
+	}
+	return drifted
+}
+
+// forEachDomain runs fn for the domains of desired on up to concurrency workers
+func (c *StratoClient) forEachDomain(ctx context.Context, desired map[string]DNSConfig, concurrency int, fn func(*StratoClient, context.Context, DNSConfig) SyncResult) SyncReport {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	domains := make([]string, 0, len(desired))
+	for domain := range desired {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	results := make([]SyncResult, len(domains))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, domain := range domains {
+		select {
+		case <-ctx.Done():
+			results[i] = SyncResult{Domain: domain, Err: ctx.Err()}
+			continue
+		case semaphore <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(i int, domain string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			results[i] = fn(c.ForDomain(domain), ctx, desired[domain])
+		}(i, domain)
+	}
+	wg.Wait()
+	return SyncReport{Results: results}
+}
+
+// syncDomain reconciles the domain of c towards the desired configuration
+func (c *StratoClient) syncDomain(ctx context.Context, desired DNSConfig) SyncResult {
+	c = c.WithContext(ctx)
+	result := SyncResult{Domain: c.domain}
+	if result.Err = ctx.Err(); result.Err != nil {
+		return result
+	}
+	result.Diff, result.Err = c.UpdateDNSConfiguration(func(current DNSConfig) (DNSConfig, error) {
+		return KeepMailSettings(current, desired), ctx.Err()
+	})
+	switch {
+	case result.Err != nil:
+	case result.Diff.Empty():
+		logFor(LogForm).Debug("Domain is in sync", "domain", c.domain)
+	default:
+		result.Changed = true
+		logFor(LogForm).Info("Domain synchronized", "domain", c.domain, "diff", result.Diff.String())
+	}
+	return result
+}
+
+// driftDomain compares the domain of c with the desired configuration
+func (c *StratoClient) driftDomain(ctx context.Context, desired DNSConfig) SyncResult {
+	c = c.WithContext(ctx)
+	result := SyncResult{Domain: c.domain}
+	if result.Err = ctx.Err(); result.Err != nil {
+		return result
+	}
+	current, err := c.GetDNSConfiguration(ForceRefresh())
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Diff = DiffConfigs(current, KeepMailSettings(current, desired))
+	return result
+}
+
+// rateLimiter spaces out requests so that at most one is sent per interval
+type rateLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	last     time.Time
+}
+
+// wait blocks until the next request may be sent or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	wait := time.Until(l.last.Add(l.interval))
+	if wait < 0 {
+		wait = 0
+	}
+	l.last = time.Now().Add(wait)
+	l.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
+
+// limiter delays requests to the portal
+type limiter interface {
+	wait(ctx context.Context) error
+}
+
+// rateLimitedTransport waits for its limiter before every request
+type rateLimitedTransport struct {
+	next    http.RoundTripper
+	limiter limiter
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,146 @@
+package strato
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// TemplateData is available as "." in apply file templates. The public addresses
+// are only detected if the template uses them.
+type TemplateData struct {
+	ctx       context.Context
+	detectors map[IPFamily]IPDetector
+	detected  map[IPFamily]netip.Addr
+}
+
+// NewTemplateData returns template data detecting public addresses with detectors
+// (default: DefaultDetectors)
+func NewTemplateData(ctx context.Context, detectors map[IPFamily]IPDetector) *TemplateData {
+	if detectors == nil {
+		detectors = DefaultDetectors()
+	}
+	return &TemplateData{ctx: ctx, detectors: detectors, detected: map[IPFamily]netip.Addr{}}
+}
+
+func (d *TemplateData) publicIP(family IPFamily) (string, error) {
+	if addr, ok := d.detected[family]; ok {
+		return addr.String(), nil
+	}
+	detector, ok := d.detectors[family]
+	if !ok {
+		return "", fmt.Errorf("no detector for %s", family)
+	}
+	addr, err := detector.Detect(d.ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to detect public %s address: %w", family, err)
+	}
+	d.detected[family] = addr
+	return addr.String(), nil
+}
+
+// PublicIPv4 returns the public IPv4 address of this host
+func (d *TemplateData) PublicIPv4() (string, error) {
+	return d.publicIP(IPv4)
+}
+
+// PublicIPv6 returns the public IPv6 address of this host
+func (d *TemplateData) PublicIPv6() (string, error) {
+	return d.publicIP(IPv6)
+}
+
+// RenderTemplate resolves the Go template expressions of an apply file, e.g.
+// {{ .PublicIPv4 }} or {{ env "DKIM_KEY" }}. Referencing an unset variable with env
+// is an error, so a file cannot silently publish empty values. The output of every
+// expression is escaped for a JSON string, so a quote or backslash in a value cannot
+// break the document or add fields to it.
+func RenderTemplate(name string, text []byte, data *TemplateData) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"env":        lookupEnv(nil),
+		"jsonEscape": jsonEscape,
+	}).Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tmpl.Templates() {
+		escapeActions(t.Tree.Root)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// renderValue resolves the template expressions of a single value that was already
+// parsed, reading environment variables with env
+func renderValue(name, value string, data *TemplateData, env func(string) (string, error)) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+	tmpl, err := template.New(name).Funcs(template.FuncMap{"env": env}).Parse(value)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// lookupEnv returns the env template function. Unless allowed is nil, it only reads
+// the variables in allowed.
+func lookupEnv(allowed []string) func(string) (string, error) {
+	return func(key string) (string, error) {
+		if allowed != nil && !containsString(allowed, key) {
+			return "", fmt.Errorf("environment variable %s is not allowed in templates", key)
+		}
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", key)
+		}
+		return value, nil
+	}
+}
+
+// jsonEscape returns the text of value as the content of a JSON string
+func jsonEscape(value interface{}) string {
+	quoted, _ := json.Marshal(fmt.Sprint(value))
+	return string(quoted[1 : len(quoted)-1])
+}
+
+// escapeActions pipes the output of every action below node through jsonEscape, the
+// way html/template escapes for HTML. Conditions and variable declarations are left
+// alone, as they print nothing.
+func escapeActions(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeActions(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) == 0 {
+			escape := parse.NewIdentifier("jsonEscape").SetPos(n.Pos)
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{escape}})
+		}
+	case *parse.IfNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.RangeNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.WithNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	}
+}
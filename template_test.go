@@ -0,0 +1,60 @@
+package strato
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplateEscapes(t *testing.T) {
+	values := []string{
+		`plain`,
+		`say "hi"`,
+		`C:\keys\dkim`,
+		`", "prefix": "evil`,
+		`line one` + "\n" + `line two`,
+		`\"}]}`,
+	}
+	for _, value := range values {
+		t.Setenv("STRATO_TEST_VALUE", value)
+		text := `{"records": [{"type": "TXT", "prefix": "test", "value": "{{ env "STRATO_TEST_VALUE" }}"}]}`
+		out, err := RenderTemplate("test.json", []byte(text), NewTemplateData(context.Background(), nil))
+		if err != nil {
+			t.Fatalf("%q: %v", value, err)
+		}
+		var config DNSConfig
+		if err := json.Unmarshal(out, &config); err != nil {
+			t.Fatalf("%q broke the document: %v\n%s", value, err, out)
+		}
+		if len(config.Records) != 1 || config.Records[0].Value != value || config.Records[0].Prefix != "test" {
+			t.Errorf("%q: got %+v", value, config.Records)
+		}
+	}
+}
+
+func TestRenderTemplateControlFlow(t *testing.T) {
+	t.Setenv("STRATO_TEST_VALUE", `a"b`)
+	text := `{{ $v := env "STRATO_TEST_VALUE" }}{{ if $v }}"{{ $v }}"{{ else }}"none"{{ end }}` +
+		`{{ range $i := 2 }} "{{ $i }}"{{ end }}` +
+		`{{ with .PublicIPv4 }} "{{ . }}"{{ end }}`
+	data := NewTemplateData(context.Background(), map[IPFamily]IPDetector{
+		IPv4: detectorFunc(func() (netip.Addr, error) { return netip.MustParseAddr("192.0.2.1"), nil }),
+	})
+	out, err := RenderTemplate("test", []byte(text), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"a\"b" "0" "1" "192.0.2.1"`; string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+}
+
+func TestRenderTemplateUnsetVariable(t *testing.T) {
+	text := `{"value": "{{ env "STRATO_TEST_UNSET" }}"}`
+	_, err := RenderTemplate("test.json", []byte(text), NewTemplateData(context.Background(), nil))
+	if err == nil || !strings.Contains(err.Error(), "STRATO_TEST_UNSET is not set") {
+		t.Errorf("got %v, want an error for the unset variable", err)
+	}
+}
@@ -0,0 +1,29 @@
+package strato
+
+import (
+	"context"
+	"net/http"
+)
+
+type transportKey struct{}
+
+// ContextWithTransport returns a copy of ctx that sends the requests of clients bound
+// to it with WithContext through transport instead of the transport of the client.
+// Server modes can use it to attach per-tenant proxies or instrumentation to single
+// operations; session cookies, logging, rate and size limits still apply.
+func ContextWithTransport(ctx context.Context, transport http.RoundTripper) context.Context {
+	return context.WithValue(ctx, transportKey{}, transport)
+}
+
+// contextTransport sends requests through the transport found in their context,
+// or through next
+type contextTransport struct {
+	next http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if transport, ok := req.Context().Value(transportKey{}).(http.RoundTripper); ok && transport != nil {
+		return transport.RoundTrip(req)
+	}
+	return t.next.RoundTrip(req)
+}
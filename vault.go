@@ -0,0 +1,191 @@
+package strato
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// errVaultForbidden is returned when Vault rejects the token, e.g. because it expired
+var errVaultForbidden = errors.New("vault: permission denied")
+
+// Credentials are the secrets needed to log in to the Strato portal
+type Credentials struct {
+	Identifier string
+	Password   string
+	// TOTPSeed is the seed of the second factor, if the account has one. Logins do
+	// not use it yet.
+	TOTPSeed string
+}
+
+// CredentialProvider supplies login credentials, e.g. from a secrets manager
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// VaultProvider reads credentials from a HashiCorp Vault KV version 2 secret.
+// The secret is expected to hold the keys identifier, password and optionally
+// totp_seed, and ddns_password for DynDNS updates.
+type VaultProvider struct {
+	// Address of the Vault server, e.g. https://vault.example.com:8200
+	Address string
+	// Token used to authenticate against Vault
+	Token string
+	// Mount is the path of the KV engine (default: secret)
+	Mount string
+	// Path of the secret below the mount
+	Path string
+
+	client *http.Client
+}
+
+// NewVaultProviderFromEnv creates a VaultProvider for path using the standard
+// VAULT_ADDR and VAULT_TOKEN environment variables
+func NewVaultProviderFromEnv(mount, path string) (*VaultProvider, error) {
+	provider := &VaultProvider{
+		Address: os.Getenv("VAULT_ADDR"),
+		Token:   os.Getenv("VAULT_TOKEN"),
+		Mount:   mount,
+		Path:    path,
+	}
+	if provider.Address == "" || provider.Token == "" {
+		return nil, errors.New("VAULT_ADDR and VAULT_TOKEN must be set")
+	}
+	return provider, nil
+}
+
+// do sends an authenticated request to the Vault API and decodes the JSON response into out
+func (v *VaultProvider) do(ctx context.Context, method, path string, out interface{}) error {
+	if v.client == nil {
+		v.client = &http.Client{Timeout: 30 * time.Second}
+	}
+	var body io.Reader
+	if method == "POST" {
+		body = strings.NewReader("{}")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(v.Address, "/")+"/v1/"+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusForbidden {
+		return errVaultForbidden
+	} else if resp.StatusCode != http.StatusOK {
+		return errors.New("vault: unexpected response status: " + resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// Secret reads all keys of the secret at Path
+func (v *VaultProvider) Secret(ctx context.Context) (map[string]string, error) {
+	mount := v.Mount
+	if mount == "" {
+		mount = "secret"
+	}
+	var secret struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := v.do(ctx, "GET", mount+"/data/"+strings.TrimPrefix(v.Path, "/"), &secret); err != nil {
+		return nil, err
+	}
+	return secret.Data.Data, nil
+}
+
+// Credentials reads the current credentials from Vault
+func (v *VaultProvider) Credentials(ctx context.Context) (Credentials, error) {
+	data, err := v.Secret(ctx)
+	if err != nil {
+		return Credentials{}, err
+	}
+	credentials := Credentials{
+		Identifier: data["identifier"],
+		Password:   data["password"],
+		TOTPSeed:   data["totp_seed"],
+	}
+	if credentials.Identifier == "" || credentials.Password == "" {
+		return Credentials{}, fmt.Errorf("vault secret %s lacks identifier or password", v.Path)
+	}
+	return credentials, nil
+}
+
+// DDNSPassword reads the DynDNS password from the ddns_password key of the secret
+func (v *VaultProvider) DDNSPassword(ctx context.Context) (string, error) {
+	data, err := v.Secret(ctx)
+	if err != nil {
+		return "", err
+	}
+	if data["ddns_password"] == "" {
+		return "", fmt.Errorf("vault secret %s lacks ddns_password", v.Path)
+	}
+	return data["ddns_password"], nil
+}
+
+// vaultRetryDelay is the delay before the first retry of a failed token renewal,
+// doubled with every further failure up to the renewal interval
+const vaultRetryDelay = 5 * time.Second
+
+// RenewToken keeps the Vault token alive by renewing it every interval until ctx is cancelled,
+// so long-running processes never need a long-lived token on disk. Failed renewals are
+// logged and retried with backoff; it only gives up once Vault rejects the token or
+// its lease ran out.
+func (v *VaultProvider) RenewToken(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("renewal interval must be positive")
+	}
+	var expires time.Time
+	var retry time.Duration
+	delay := interval
+	for {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		var renewal struct {
+			Auth struct {
+				LeaseDuration int `json:"lease_duration"`
+			} `json:"auth"`
+		}
+		err := v.do(ctx, "POST", "auth/token/renew-self", &renewal)
+		switch {
+		case err == nil:
+			lease := time.Duration(renewal.Auth.LeaseDuration) * time.Second
+			// Tokens without lease, like root tokens, never expire
+			expires = time.Time{}
+			if lease > 0 {
+				expires = time.Now().Add(lease)
+			}
+			retry, delay = 0, interval
+			logFor(LogAuth).Debug("Renewed vault token", "leaseDuration", lease)
+			continue
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, errVaultForbidden):
+			return fmt.Errorf("vault token is no longer valid: %w", err)
+		case !expires.IsZero() && time.Now().After(expires):
+			return fmt.Errorf("vault token expired: %w", err)
+		}
+		retry = min(max(2*retry, vaultRetryDelay), interval)
+		delay = retry
+		if half := time.Until(expires) / 2; !expires.IsZero() && half < delay {
+			// Try again before the lease runs out
+			delay = max(half, min(time.Second, interval))
+		}
+		logFor(LogAuth).Warn("Failed to renew vault token, retrying", "error", err, "retryIn", delay)
+	}
+}
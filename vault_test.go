@@ -0,0 +1,64 @@
+package strato
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestVaultCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/strato" || r.Header.Get("X-Vault-Token") != "token" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"identifier":"12345678","password":"secret","totp_seed":"SEED","ddns_password":"dyndns"}}}`))
+	}))
+	defer server.Close()
+	provider := &VaultProvider{Address: server.URL, Token: "token", Path: "strato"}
+
+	credentials, err := provider.Credentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Credentials{Identifier: "12345678", Password: "secret", TOTPSeed: "SEED"}); credentials != want {
+		t.Errorf("got %+v, want %+v", credentials, want)
+	}
+	if password, err := provider.DDNSPassword(context.Background()); err != nil || password != "dyndns" {
+		t.Errorf("got DynDNS password %q and %v, want %q", password, err, "dyndns")
+	}
+}
+
+// TestVaultRenewTokenRetries makes sure a failed renewal does not stop renewing, and
+// that renewing stops once Vault rejects the token
+func TestVaultRenewTokenRetries(t *testing.T) {
+	var renewals atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch renewals.Add(1) {
+		case 1:
+			w.Write([]byte(`{"auth":{"lease_duration":3600}}`))
+		case 2, 3:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case 4:
+			w.Write([]byte(`{"auth":{"lease_duration":3600}}`))
+		default:
+			http.Error(w, "permission denied", http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+	provider := &VaultProvider{Address: server.URL, Token: "token", Path: "strato"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := provider.RenewToken(ctx, 5*time.Millisecond)
+	if !errors.Is(err, errVaultForbidden) {
+		t.Errorf("got %v, want %v", err, errVaultForbidden)
+	}
+	if got := renewals.Load(); got != 5 {
+		t.Errorf("got %d renewals, want 5", got)
+	}
+}
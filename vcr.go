@@ -0,0 +1,167 @@
+package strato
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// CassetteMode selects whether a Recorder records or replays interactions
+type CassetteMode int
+
+const (
+	// ModeRecord sends requests to the portal and records them
+	ModeRecord CassetteMode = iota
+	// ModeReplay answers requests from the cassette without network access
+	ModeReplay
+)
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	RequestBody  string      `json:"requestBody,omitempty"`
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header"`
+	ResponseBody string      `json:"responseBody"`
+}
+
+// Cassette is a sequence of recorded interactions
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is a RoundTripper that records interactions with the portal to a cassette
+// file, or replays them from it, so flows like login, get, set and verify can be run
+// offline. Secrets are scrubbed with SanitizePage before anything is written.
+// Install it with WithTransport.
+type Recorder struct {
+	path     string
+	mode     CassetteMode
+	next     http.RoundTripper
+	secrets  map[string]string
+	mu       sync.Mutex
+	cassette Cassette
+	position int
+}
+
+// NewRecorder returns a Recorder for the cassette at path. In ModeReplay the cassette is
+// loaded immediately; in ModeRecord requests are sent through next (default:
+// http.DefaultTransport) and Save writes the cassette. Every key of secrets is replaced
+// by its value in recorded URLs, bodies and headers; in replay the same replacement
+// is applied to requests before they are matched.
+func NewRecorder(path string, mode CassetteMode, next http.RoundTripper, secrets map[string]string) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	scrubbed := make(map[string]string, 2*len(secrets))
+	for secret, replacement := range secrets {
+		scrubbed[secret] = replacement
+		scrubbed[url.QueryEscape(secret)] = replacement
+	}
+	r := &Recorder{path: path, mode: mode, next: next, secrets: scrubbed}
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+		}
+	}
+	return r, nil
+}
+
+func (r *Recorder) scrub(s string) string {
+	return string(SanitizePage([]byte(s), r.secrets))
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		if requestBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+	if r.mode == ModeReplay {
+		return r.replay(req, requestBody)
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	header := http.Header{}
+	for key, values := range resp.Header {
+		for _, value := range values {
+			header.Add(key, r.scrub(value))
+		}
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Method:       req.Method,
+		URL:          r.scrub(req.URL.String()),
+		RequestBody:  r.scrub(string(requestBody)),
+		Status:       resp.StatusCode,
+		Header:       header,
+		ResponseBody: r.scrub(string(responseBody)),
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay answers req with the next interaction of the cassette
+func (r *Recorder) replay(req *http.Request, requestBody []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.position >= len(r.cassette.Interactions) {
+		return nil, fmt.Errorf("cassette %s exhausted at %s %s", r.path, req.Method, req.URL.Path)
+	}
+	interaction := r.cassette.Interactions[r.position]
+	if interaction.Method != req.Method || interaction.URL != r.scrub(req.URL.String()) {
+		return nil, fmt.Errorf("cassette %s: request %d is %s %s, got %s %s", r.path, r.position,
+			interaction.Method, interaction.URL, req.Method, r.scrub(req.URL.String()))
+	}
+	r.position++
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(interaction.ResponseBody))),
+		ContentLength: int64(len(interaction.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+// Save writes the recorded interactions to the cassette file
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return errors.New("only recording cassettes can be saved")
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o600)
+}
@@ -0,0 +1,124 @@
+package strato
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var recordCassettes = flag.Bool("record", false, "record the cassettes in testdata/cassettes against the test portal before replaying them")
+
+// cassetteAPI is the portal URL the test portal is recorded as
+const cassetteAPI = "https://www.strato.de/apps/CustomerService"
+
+// cassetteSecrets scrub the password and the address of the test portal from
+// recorded cassettes
+func cassetteSecrets(p *testPortal) map[string]string {
+	return map[string]string{
+		p.URL:        "https://www.strato.de",
+		testPassword: "PASSWORD",
+	}
+}
+
+// acmeRecord is the record the cassette flow adds
+var acmeRecord = DNSRecord{Type: "TXT", Prefix: "_acme-challenge", Value: "gfj9Xq-Rc5HX7cdUHSmIzvIW7uaqPZ6bAhcOBUmM0Ac"}
+
+// runCassetteFlow logs in, reads the configuration, adds acmeRecord and verifies the
+// write, with the requests sent through transport
+func runCassetteFlow(t *testing.T, api string, transport *Recorder) *StratoClient {
+	t.Helper()
+	client, err := NewStratoClient(api, testIdentifier, testPassword, "ORDER", "example.com",
+		WithTransport(transport), WithVerifyAfterWrite())
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := client.GetDNSConfiguration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := txtFormConfig
+	want.Records = append([]DNSRecord(nil), want.Records...)
+	SortRecords(want.Records)
+	if !reflect.DeepEqual(config, want) {
+		t.Fatalf("got %+v, want %+v", config, want)
+	}
+	config.Records = append(config.Records, acmeRecord)
+	if err := client.SetDNSConfiguration(config); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestCassetteLoginGetSetVerify(t *testing.T) {
+	path := filepath.Join("testdata", "cassettes", "login_get_set.json")
+	if *recordCassettes {
+		portal := newTestPortal(t, RegionDE)
+		recorder, err := NewRecorder(path, ModeRecord, nil, cassetteSecrets(portal))
+		if err != nil {
+			t.Fatal(err)
+		}
+		runCassetteFlow(t, portal.API(), recorder)
+		if err := recorder.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recorder, err := NewRecorder(path, ModeReplay, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := runCassetteFlow(t, cassetteAPI, recorder)
+	if _, err := client.GetDNSConfiguration(); err == nil || !strings.Contains(err.Error(), "exhausted") {
+		t.Errorf("got %v after the end of the cassette, want it to be exhausted", err)
+	}
+	if err := recorder.Save(); err == nil {
+		t.Error("replaying cassette was saved")
+	}
+}
+
+func TestRecorderScrubsSecrets(t *testing.T) {
+	portal := newTestPortal(t, RegionDE)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, err := NewRecorder(path, ModeRecord, nil, cassetteSecrets(portal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runCassetteFlow(t, portal.API(), recorder)
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	cassette, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{testPassword, portal.URL} {
+		if strings.Contains(string(cassette), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+	if !strings.Contains(string(cassette), "passwd=PASSWORD") {
+		t.Error("cassette lacks the scrubbed login form")
+	}
+
+	// The scrubbed cassette replays the flow against the recorded portal URL
+	replay, err := NewRecorder(path, ModeReplay, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runCassetteFlow(t, cassetteAPI, replay)
+}
+
+func TestRecorderReplayMismatch(t *testing.T) {
+	recorder, err := NewRecorder(filepath.Join("testdata", "cassettes", "login_get_set.json"), ModeReplay, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewStratoClient("https://www.strato.nl/apps/CustomerService", testIdentifier, testPassword, "ORDER", "example.com",
+		WithTransport(recorder))
+	if err == nil || !strings.Contains(err.Error(), "request 0 is GET "+cassetteAPI) {
+		t.Errorf("got %v, want a mismatch with the first request of the cassette", err)
+	}
+}
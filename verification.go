@@ -0,0 +1,71 @@
+package strato
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// VerificationProvider describes the TXT record a service checks to verify domain ownership
+type VerificationProvider struct {
+	// Prefix is the record name below the domain, empty for the domain itself
+	Prefix string
+	// ValuePrefix is put in front of the token issued by the service
+	ValuePrefix string
+}
+
+// VerificationProviders lists the domain verification records of common services
+var VerificationProviders = map[string]VerificationProvider{
+	"adobe":     {ValuePrefix: "adobe-idp-site-verification="},
+	"apple":     {ValuePrefix: "apple-domain-verification="},
+	"atlassian": {ValuePrefix: "atlassian-domain-verification="},
+	"docusign":  {ValuePrefix: "docusign="},
+	"dropbox":   {ValuePrefix: "dropbox-domain-verification="},
+	"facebook":  {ValuePrefix: "facebook-domain-verification="},
+	"google":    {ValuePrefix: "google-site-verification="},
+	"hibp":      {ValuePrefix: "have-i-been-pwned-verification="},
+	"microsoft": {ValuePrefix: "MS="},
+	"openai":    {ValuePrefix: "openai-domain-verification="},
+	"yandex":    {ValuePrefix: "yandex-verification: "},
+	"zoom":      {ValuePrefix: "ZOOM_verify_"},
+}
+
+// VerificationProviderNames returns the names of VerificationProviders in order
+func VerificationProviderNames() []string {
+	names := make([]string, 0, len(VerificationProviders))
+	for name := range VerificationProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// VerificationRecord returns the TXT record verifying the domain for provider. token
+// may be given with or without the value prefix, as services show it either way.
+func VerificationRecord(provider, token string) (DNSRecord, error) {
+	p, ok := VerificationProviders[strings.ToLower(provider)]
+	if !ok {
+		return DNSRecord{}, errors.New("unknown verification provider " + provider + ", use one of " + strings.Join(VerificationProviderNames(), ", "))
+	}
+	token = strings.Trim(strings.TrimSpace(token), `"`)
+	token = strings.TrimPrefix(token, p.ValuePrefix)
+	if token == "" || strings.ContainsAny(token, " \t\"") {
+		return DNSRecord{}, errors.New("invalid verification token: " + token)
+	}
+	return DNSRecord{Type: "TXT", Prefix: p.Prefix, Value: p.ValuePrefix + token}, nil
+}
+
+// AddVerificationRecord adds the verification record of provider and reports whether
+// it was missing. Earlier tokens of the provider are kept, as services accept any of them.
+func (c *StratoClient) AddVerificationRecord(provider, token string) (bool, error) {
+	record, err := VerificationRecord(provider, token)
+	if err != nil {
+		return false, err
+	}
+	added := false
+	err = c.UpdateZone(func(zone *Zone) (bool, error) {
+		added = zone.Add(record)
+		return added, nil
+	})
+	return added, err
+}
@@ -0,0 +1,42 @@
+package strato
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// errTXTFormNotFound is returned by the parsers if the page has no TXT record form,
+// which is what Strato shows for unknown vhosts
+var errTXTFormNotFound = fmt.Errorf("%w: failed to find form element", ErrUnexpectedPage)
+
+// ListVhosts returns the domains and subdomains of the package
+func (c *StratoClient) ListVhosts() ([]string, error) {
+	doc, err := c.fetchPage(c.region.ManageDomainsNode)
+	if err != nil {
+		return nil, err
+	}
+	var vhosts []string
+	for _, node := range htmlquery.Find(doc, "//*[@data-vhost]") {
+		if vhost := htmlquery.SelectAttr(node, "data-vhost"); vhost != "" {
+			vhosts = append(vhosts, vhost)
+		}
+	}
+	return vhosts, nil
+}
+
+// vhostError turns a missing TXT form into a *DomainNotFoundError if the domain is
+// not among the vhosts of the package, or returns err otherwise
+func (c *StratoClient) vhostError(err error) error {
+	vhosts, listErr := c.ListVhosts()
+	if listErr != nil || len(vhosts) == 0 {
+		return err
+	}
+	for _, vhost := range vhosts {
+		if strings.EqualFold(vhost, c.domain) {
+			return err
+		}
+	}
+	return &DomainNotFoundError{Domain: c.domain, Available: vhosts}
+}
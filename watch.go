@@ -0,0 +1,56 @@
+package strato
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WatchEvent is emitted by Watch whenever the configuration changed or could not be fetched
+type WatchEvent struct {
+	Time time.Time
+	// Config is the configuration fetched at Time
+	Config DNSConfig
+	// Diff holds the changes since the previous snapshot
+	Diff ConfigDiff
+	// Err is set if fetching the configuration failed, Config and Diff are empty then
+	Err error
+}
+
+// Watch polls the DNS configuration every interval and calls callback with the changes
+// compared to the previous snapshot. It blocks until ctx is cancelled.
+func (c *StratoClient) Watch(ctx context.Context, interval time.Duration, callback func(WatchEvent)) error {
+	if interval <= 0 {
+		return errors.New("watch interval must be positive")
+	}
+	c = c.WithContext(ctx)
+	last, err := c.GetDNSConfiguration(ForceRefresh())
+	c.recordStatus(c.domain, err, time.Now().Add(interval))
+	if err != nil {
+		return err
+	}
+	logFor(LogScrape).Debug("Watching DNS configuration", "domain", c.domain, "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			config, err := c.GetDNSConfiguration(ForceRefresh())
+			c.recordStatus(c.domain, err, now.Add(interval))
+			if err != nil {
+				callback(WatchEvent{Time: now, Err: err})
+				continue
+			}
+			diff := DiffConfigs(last, config)
+			if diff.Empty() {
+				logFor(LogScrape).Log(ctx, LevelTrace, "No changes detected", "domain", c.domain)
+				continue
+			}
+			last = config
+			callback(WatchEvent{Time: now, Config: config, Diff: diff})
+		}
+	}
+}
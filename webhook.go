@@ -0,0 +1,101 @@
+package strato
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WebhookFormat selects the payload layout expected by the receiving service
+type WebhookFormat string
+
+const (
+	// WebhookGeneric posts the Notification as JSON
+	WebhookGeneric WebhookFormat = "generic"
+	// WebhookSlack posts a Slack incoming webhook message
+	WebhookSlack WebhookFormat = "slack"
+	// WebhookDiscord posts a Discord webhook message
+	WebhookDiscord WebhookFormat = "discord"
+)
+
+// Notification describes a change of records or a failed operation
+type Notification struct {
+	Time   time.Time  `json:"timestamp"`
+	Domain string     `json:"domain"`
+	Event  string     `json:"event"`
+	Diff   ConfigDiff `json:"diff"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// Summary renders the notification as a short human readable message
+func (n Notification) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", n.Domain, n.Event)
+	if n.Error != "" {
+		fmt.Fprintf(&b, ": %s", n.Error)
+	}
+	if !n.Diff.Empty() {
+		fmt.Fprintf(&b, "\n```\n%s\n```", n.Diff)
+	}
+	return b.String()
+}
+
+// Webhook posts notifications to an HTTP endpoint
+type Webhook struct {
+	URL    string
+	Format WebhookFormat
+
+	client *http.Client
+}
+
+// NewWebhook returns a Webhook posting to url in the given format
+func NewWebhook(url string, format WebhookFormat) (*Webhook, error) {
+	switch format {
+	case "":
+		format = WebhookGeneric
+	case WebhookGeneric, WebhookSlack, WebhookDiscord:
+	default:
+		return nil, fmt.Errorf("unsupported webhook format: %s", format)
+	}
+	return &Webhook{URL: url, Format: format, client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// Notify posts the notification to the webhook
+func (w *Webhook) Notify(ctx context.Context, n Notification) error {
+	var payload interface{}
+	switch w.Format {
+	case WebhookSlack:
+		payload = map[string]string{"text": n.Summary()}
+	case WebhookDiscord:
+		payload = map[string]string{"content": n.Summary()}
+	default:
+		payload = n
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("webhook: unexpected response status: " + resp.Status)
+	}
+	return nil
+}
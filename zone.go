@@ -0,0 +1,184 @@
+package strato
+
+import (
+	"sort"
+	"strings"
+)
+
+// Zone wraps a DNSConfig with indexed lookups and mutation helpers.
+// Records are kept unique and in a deterministic order (type, prefix, value),
+// unless the zone was fetched by a client created WithRawOrder.
+type Zone struct {
+	DMARCType string
+	SPFType   string
+	// MaxRecords is the record limit of the tariff, if the configuration had one
+	MaxRecords int
+
+	// rawOrder keeps the records in the order of the portal, new ones last
+	rawOrder bool
+
+	records  []DNSRecord
+	index    map[DNSRecord]bool
+	byType   map[string][]DNSRecord
+	byPrefix map[string][]DNSRecord
+}
+
+// NewZone builds a Zone from a configuration, dropping duplicate records
+func NewZone(config DNSConfig) *Zone {
+	return newZone(config, false)
+}
+
+// newZone builds a Zone like NewZone that keeps the order of config if rawOrder is set
+func newZone(config DNSConfig, rawOrder bool) *Zone {
+	zone := &Zone{DMARCType: config.DMARCType, SPFType: config.SPFType, MaxRecords: config.MaxRecords, rawOrder: rawOrder}
+	zone.records = append(zone.records, config.Records...)
+	zone.reindex()
+	return zone
+}
+
+// lessRecord orders records by type, prefix and value
+func lessRecord(a, b DNSRecord) bool {
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+	if a.Prefix != b.Prefix {
+		return a.Prefix < b.Prefix
+	}
+	return a.Value < b.Value
+}
+
+// SortRecords sorts records in canonical order: by type, prefix and value
+func SortRecords(records []DNSRecord) {
+	sort.SliceStable(records, func(i, j int) bool { return lessRecord(records[i], records[j]) })
+}
+
+// reindex sorts the records, removes duplicates and rebuilds the lookup maps
+func (z *Zone) reindex() {
+	if !z.rawOrder {
+		SortRecords(z.records)
+	}
+	z.index = make(map[DNSRecord]bool, len(z.records))
+	z.byType = map[string][]DNSRecord{}
+	z.byPrefix = map[string][]DNSRecord{}
+	unique := z.records[:0]
+	for _, record := range z.records {
+		if z.index[record] {
+			continue
+		}
+		z.index[record] = true
+		recordType := strings.ToUpper(record.Type)
+		z.byType[recordType] = append(z.byType[recordType], record)
+		z.byPrefix[record.Prefix] = append(z.byPrefix[record.Prefix], record)
+		unique = append(unique, record)
+	}
+	z.records = unique
+}
+
+// Records returns all records in the order of the zone
+func (z *Zone) Records() []DNSRecord {
+	return append([]DNSRecord(nil), z.records...)
+}
+
+// Len returns the number of records
+func (z *Zone) Len() int {
+	return len(z.records)
+}
+
+// Contains reports whether the zone holds the record
+func (z *Zone) Contains(record DNSRecord) bool {
+	return z.index[record]
+}
+
+// ByType returns the records of a type, case-insensitive
+func (z *Zone) ByType(recordType string) []DNSRecord {
+	return append([]DNSRecord(nil), z.byType[strings.ToUpper(recordType)]...)
+}
+
+// ByPrefix returns the records with exactly the given prefix
+func (z *Zone) ByPrefix(prefix string) []DNSRecord {
+	return append([]DNSRecord(nil), z.byPrefix[prefix]...)
+}
+
+// Find returns the records selected by the filter
+func (z *Zone) Find(filter RecordFilter) []DNSRecord {
+	return FilterRecords(z.records, filter)
+}
+
+// Add inserts a record and reports whether it was missing
+func (z *Zone) Add(record DNSRecord) bool {
+	if z.index[record] {
+		return false
+	}
+	z.records = append(z.records, record)
+	z.reindex()
+	return true
+}
+
+// Remove deletes a record and reports whether it was present
+func (z *Zone) Remove(record DNSRecord) bool {
+	if !z.index[record] {
+		return false
+	}
+	return len(z.RemoveMatching(func(r DNSRecord) bool { return r == record })) > 0
+}
+
+// RemoveMatching deletes all records for which match returns true and returns them
+func (z *Zone) RemoveMatching(match func(DNSRecord) bool) []DNSRecord {
+	var kept, removed []DNSRecord
+	for _, record := range z.records {
+		if match(record) {
+			removed = append(removed, record)
+		} else {
+			kept = append(kept, record)
+		}
+	}
+	if len(removed) > 0 {
+		z.records = kept
+		z.reindex()
+	}
+	return removed
+}
+
+// Replace adds record in place of the records of the same type and prefix for which
+// replaces returns true, or of all of them if replaces is nil, and reports whether the
+// zone changed
+func (z *Zone) Replace(record DNSRecord, replaces func(DNSRecord) bool) bool {
+	replaced := z.RemoveMatching(func(r DNSRecord) bool {
+		return r.Type == record.Type && r.Prefix == record.Prefix && r != record && (replaces == nil || replaces(r))
+	})
+	return z.Add(record) || len(replaced) > 0
+}
+
+// Config converts the zone back into a DNSConfig for SetDNSConfiguration
+func (z *Zone) Config() DNSConfig {
+	return DNSConfig{DMARCType: z.DMARCType, SPFType: z.SPFType, Records: z.Records(), MaxRecords: z.MaxRecords}
+}
+
+// GetZone retrieves the DNS configuration of the domain as a Zone
+func (c *StratoClient) GetZone(opts ...GetOption) (*Zone, error) {
+	config, err := c.GetDNSConfiguration(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newZone(config, c.rawOrder), nil
+}
+
+// SetZone replaces the DNS configuration of the domain with the zone
+func (c *StratoClient) SetZone(zone *Zone) error {
+	return c.SetDNSConfiguration(zone.Config())
+}
+
+// UpdateZone fetches the zone, lets update change it and writes it back if update
+// reports a change. Like UpdateDNSConfiguration, it holds the write lock from the
+// read to the write.
+func (c *StratoClient) UpdateZone(update func(zone *Zone) (bool, error)) error {
+	_, err := c.UpdateDNSConfiguration(func(current DNSConfig) (DNSConfig, error) {
+		zone := newZone(current, c.rawOrder)
+		changed, err := update(zone)
+		if err != nil || !changed {
+			return current, err
+		}
+		return zone.Config(), nil
+	})
+	return err
+}